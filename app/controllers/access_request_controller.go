@@ -0,0 +1,177 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"golang_starter_kit_2025/app/helpers"
+	"golang_starter_kit_2025/app/models"
+	"golang_starter_kit_2025/app/requests"
+	"golang_starter_kit_2025/app/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"gorm.io/gorm"
+)
+
+type AccessRequestController struct {
+	service services.AccessRequestService
+}
+
+func NewAccessRequestController(service services.AccessRequestService) *AccessRequestController {
+	return &AccessRequestController{service: service}
+}
+
+// @Summary		Request Temporary Role
+// @Description	API untuk meminta akses Role sementara dengan justifikasi dan durasi
+// @Tags			Access Request
+// @Accept			json
+// @Produce		json
+// @Param			body	body		requests.AccessRequestCreate	true	"Access request"
+// @Success		201		{object}	helpers.ResponseParams[models.AccessRequest]{item=models.AccessRequest}
+// @Router			/access-requests [post]
+func (c *AccessRequestController) Create(ctx *gin.Context) {
+	var req requests.AccessRequestCreate
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		var verr validator.ValidationErrors
+		if errors.As(err, &verr) {
+			helpers.ResponseError(ctx, &helpers.ResponseParams[any]{
+				Errors:    helpers.ValidationError(verr),
+				Message:   "Parameter tidak valid",
+				Reference: "ERROR-4",
+			}, http.StatusBadRequest)
+			return
+		}
+		helpers.ResponseError(ctx, &helpers.ResponseParams[any]{
+			Errors:    map[string]string{"error": err.Error()},
+			Message:   "Parameter tidak valid",
+			Reference: "ERROR-4",
+		}, http.StatusBadRequest)
+		return
+	}
+
+	request, err := c.service.Create(ctx.GetUint("user_id"), req.RoleID, req.Justification, req.DurationMinutes)
+	if err != nil {
+		helpers.ResponseError(ctx, &helpers.ResponseParams[any]{
+			Errors:    map[string]string{"error": err.Error()},
+			Message:   "Gagal membuat permintaan akses",
+			Reference: "ERROR-3",
+		}, accessRequestErrorStatus(err))
+		return
+	}
+
+	helpers.ResponseSuccess(ctx, &helpers.ResponseParams[models.AccessRequest]{Item: &request}, http.StatusCreated)
+}
+
+// @Summary		My Access Requests
+// @Description	API untuk melihat permintaan akses milik user yang sedang login
+// @Tags			Access Request
+// @Accept			json
+// @Produce		json
+// @Success		200	{object}	helpers.ResponseParams[models.AccessRequest]{data=[]models.AccessRequest}
+// @Router			/access-requests/mine [get]
+func (c *AccessRequestController) Mine(ctx *gin.Context) {
+	requests, err := c.service.GetByUser(ctx.GetUint("user_id"))
+	if err != nil {
+		helpers.ResponseError(ctx, &helpers.ResponseParams[any]{
+			Errors:    map[string]string{"error": err.Error()},
+			Message:   "Gagal mendapatkan permintaan akses",
+			Reference: "ERROR-3",
+		}, http.StatusInternalServerError)
+		return
+	}
+
+	helpers.ResponseSuccess(ctx, &helpers.ResponseParams[models.AccessRequest]{Data: &requests}, http.StatusOK)
+}
+
+// @Summary		List Access Requests
+// @Description	API untuk approver melihat permintaan akses
+// @Tags			Access Request
+// @Accept			json
+// @Produce		json
+// @Param			status	query		string	false	"Status (pending, approved, denied)"
+// @Success		200		{object}	helpers.ResponseParams[models.AccessRequest]{data=[]models.AccessRequest}
+// @Router			/access-requests [get]
+func (c *AccessRequestController) List(ctx *gin.Context) {
+	requests, err := c.service.GetAll(ctx.Query("status"))
+	if err != nil {
+		helpers.ResponseError(ctx, &helpers.ResponseParams[any]{
+			Errors:    map[string]string{"error": err.Error()},
+			Message:   "Gagal mendapatkan permintaan akses",
+			Reference: "ERROR-3",
+		}, http.StatusInternalServerError)
+		return
+	}
+
+	helpers.ResponseSuccess(ctx, &helpers.ResponseParams[models.AccessRequest]{Data: &requests}, http.StatusOK)
+}
+
+// @Summary		Approve Access Request
+// @Description	API untuk menyetujui permintaan akses dan memberikan Role selama durasi yang diminta
+// @Tags			Access Request
+// @Accept			json
+// @Produce		json
+// @Param			id		path		string							true	"Access Request ID"
+// @Param			body	body		requests.AccessRequestReview	false	"Review note"
+// @Success		200		{object}	helpers.ResponseParams[models.AccessRequest]{item=models.AccessRequest}
+// @Router			/access-requests/{id}/approve [post]
+func (c *AccessRequestController) Approve(ctx *gin.Context) {
+	c.review(ctx, c.service.Approve)
+}
+
+// @Summary		Deny Access Request
+// @Description	API untuk menolak permintaan akses
+// @Tags			Access Request
+// @Accept			json
+// @Produce		json
+// @Param			id		path		string							true	"Access Request ID"
+// @Param			body	body		requests.AccessRequestReview	false	"Review note"
+// @Success		200		{object}	helpers.ResponseParams[models.AccessRequest]{item=models.AccessRequest}
+// @Router			/access-requests/{id}/deny [post]
+func (c *AccessRequestController) Deny(ctx *gin.Context) {
+	c.review(ctx, c.service.Deny)
+}
+
+func (c *AccessRequestController) review(ctx *gin.Context, decide func(id string, reviewerId uint, note string) (models.AccessRequest, error)) {
+	var req requests.AccessRequestReview
+	if ctx.Request.ContentLength > 0 {
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			helpers.ResponseError(ctx, &helpers.ResponseParams[any]{
+				Errors:    map[string]string{"error": err.Error()},
+				Message:   "Parameter tidak valid",
+				Reference: "ERROR-4",
+			}, http.StatusBadRequest)
+			return
+		}
+	}
+
+	request, err := decide(ctx.Param("id"), ctx.GetUint("user_id"), req.Note)
+	if err != nil {
+		helpers.ResponseError(ctx, &helpers.ResponseParams[any]{
+			Errors:    map[string]string{"error": err.Error()},
+			Message:   "Gagal memproses permintaan akses",
+			Reference: "ERROR-3",
+		}, accessRequestErrorStatus(err))
+		return
+	}
+
+	helpers.ResponseSuccess(ctx, &helpers.ResponseParams[models.AccessRequest]{Item: &request}, http.StatusOK)
+}
+
+func accessRequestErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrAccessRequestSelfReview):
+		return http.StatusForbidden
+	case errors.Is(err, services.ErrAccessRequestNotPending),
+		errors.Is(err, services.ErrRoleAlreadyGranted),
+		errors.Is(err, services.ErrRoleGrantScheduled):
+		return http.StatusConflict
+	case errors.Is(err, services.ErrAccessRequestDuration),
+		errors.Is(err, services.ErrInvalidRoleIds):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -1,18 +1,32 @@
 package controllers
 
 import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
 	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
 	"time"
 
+	"golang_starter_kit_2025/app/encryption"
 	"golang_starter_kit_2025/app/helpers"
+	"golang_starter_kit_2025/app/imagevariant"
 	"golang_starter_kit_2025/app/services"
+	"golang_starter_kit_2025/app/signedurl"
+	"golang_starter_kit_2025/app/storage"
 
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v5"
 )
 
 type FileController struct {
-	jwtService services.JwtService
+	fileService services.FileService
 }
 
 func NewFileController() *FileController {
@@ -20,85 +34,199 @@ func NewFileController() *FileController {
 }
 
 // @Summary		Serve file
-// @Description	Serve file
+// @Description	Serve file lewat URL bertanda tangan, atau dengan token untuk file yang boleh dibaca pengguna (pemilik atau pemegang permission). Varian gambar hanya dilayani lewat URL bertanda tangan yang mencakup parameternya. Akses ke file sensitif dicatat.
 // @Tags			File
 // @Accept			json
 // @Produce		jpeg
-// @Param			signature	query		string	false	"Signature"
+// @Security		BearerAuth
 // @Param			key			path		string	true	"File key"
-// @Param			path		path		string	true	"File path"
+// @Param			filename	path		string	true	"File name"
+// @Param			expires		query		int		false	"Waktu kadaluarsa (unix)"
+// @Param			bind		query		string	false	"Pengikatan URL (ip, user)"
+// @Param			width		query		int		false	"Lebar maksimum varian gambar"
+// @Param			height		query		int		false	"Tinggi maksimum varian gambar"
+// @Param			fit			query		string	false	"contain (default) atau cover"
+// @Param			crop		query		string	false	"Potongan x,y,lebar,tinggi sebelum diperkecil"
+// @Param			format		query		string	false	"jpeg atau png"
+// @Param			quality		query		int		false	"Kualitas JPEG 1-100"
+// @Param			signature	query		string	false	"Signature"
 // @Success		200			{string}	string	"File"
+// @Success		304			{string}	string	"Tidak berubah (If-None-Match)"
+// @Failure		400			{object}	map[string]string	"Parameter varian tidak valid"
+// @Failure		401			{object}	map[string]string	"Membutuhkan URL bertanda tangan atau token"
+// @Failure		403			{object}	map[string]string	"Signature tidak valid, kadaluarsa, tidak punya akses, atau varian tanpa signature"
+// @Failure		404			{object}	map[string]string	"File not found"
+// @Failure		415			{object}	map[string]string	"File tidak bisa dibuat varian"
 // @Router			/file/{key}/{filename} [get]
 func (controller FileController) ServeFile(ctx *gin.Context) {
-	key := ctx.Param("key")
-	filename := ctx.Param("filename")
-	signature := ctx.Query("signature")
+	path, ok := controller.authorize(ctx, false)
+	if !ok {
+		return
+	}
 
-	if key == "" || filename == "" || signature == "" {
-		helpers.ResponseError(ctx, &helpers.ResponseParams[any]{
-			Message:   "File not found",
-			Reference: "ERROR-7",
-		}, 404)
+	cacheControl := "private, no-cache"
+	if ctx.GetBool("signed_url") {
+		// the signature has been checked by OptionalSignedURLMiddleware, so
+		// the expiry is genuine and the response may be cached until then
+		maxAge := int64(0)
+		if expires, err := strconv.ParseInt(ctx.Query(signedurl.ParamExpires), 10, 64); err == nil {
+			maxAge = max(0, expires-time.Now().Unix())
+		}
+		cacheControl = fmt.Sprintf("private, max-age=%d", maxAge)
+	}
+	controller.serve(ctx, path, cacheControl)
+}
+
+// @Summary		Serve file without authentication
+// @Description	Serve file publik tanpa autentikasi. File dengan hak akses lain tidak ditemukan lewat route ini.
+// @Tags			File
+// @Accept			json
+// @Produce		jpeg
+// @Param			key			path		string				true	"File key"
+// @Param			filename	path		string				true	"File name"
+// @Success		200			{file}		string				"File"
+// @Failure		404			{object}	map[string]string	"File not found"
+// @Router			/file/public/{key}/{filename} [get]
+func (controller FileController) ServePublicFile(ctx *gin.Context) {
+	path, ok := controller.authorize(ctx, true)
+	if !ok {
 		return
 	}
+	controller.serve(ctx, path, "public, max-age=86400")
+}
 
-	var jwtKey = []byte(helpers.GetEnv("APP_KEY", "your_secret_key"))
-	token, err := jwt.Parse(signature, func(t *jwt.Token) (interface{}, error) {
-		return jwtKey, nil
+// authorize confines the requested path to the storage root and checks the
+// access policy of the file. It answers the request itself when access is
+// refused.
+func (controller FileController) authorize(ctx *gin.Context, public bool) (string, bool) {
+	path, err := storage.JoinPath(ctx.Param("key"), ctx.Param("filename"))
+	if err != nil {
+		controller.fileError(ctx, err)
+		return "", false
+	}
+
+	// the log records the variant, never the signature of the URL
+	variant, _ := imagevariant.Parse(ctx.Request.URL.Query())
+	err = controller.fileService.Authorize(path, services.FileRequest{
+		UserID:    ctx.GetUint("user_id"),
+		Signed:    ctx.GetBool("signed_url"),
+		Public:    public,
+		IP:        ctx.ClientIP(),
+		UserAgent: ctx.Request.UserAgent(),
+		Variant:   variant.Values().Encode(),
 	})
+	if err != nil {
+		controller.fileError(ctx, err)
+		return "", false
+	}
+	return path, true
+}
+
+// serve streams a stored file, decrypting it on the fly, or an image variant
+// when the query asks for one. Variants are only rendered for signed URLs,
+// whose signature covers the variant parameters: every new combination costs
+// a decode and a cached object, so they cannot be left to the client. Stored
+// files never change, so the ETag only depends on the path and the variant
+// and a conditional request is answered with 304 before anything is read or
+// rendered. A file that fails its integrity check before anything is sent is
+// answered with 422; a later failure aborts the response.
+func (controller FileController) serve(ctx *gin.Context, path string, cacheControl string) {
+	variant, err := imagevariant.Parse(ctx.Request.URL.Query())
 	if err != nil {
 		helpers.ResponseError(ctx, &helpers.ResponseParams[any]{
-			Message:   "File not found",
-			Reference: "ERROR-8",
-		}, 400)
+			Errors:    map[string]string{"error": err.Error()},
+			Message:   "Parameter tidak valid",
+			Reference: "ERROR-4",
+		}, http.StatusBadRequest)
 		return
 	}
-
-	tokenClaims, ok := token.Claims.(jwt.MapClaims)
-	if !ok || !token.Valid {
+	if !variant.IsZero() && !ctx.GetBool("signed_url") {
 		helpers.ResponseError(ctx, &helpers.ResponseParams[any]{
-			Message:   "File not found",
+			Message:   "Varian gambar hanya tersedia lewat URL bertanda tangan",
 			Reference: "ERROR-8",
-		}, 400)
+		}, http.StatusForbidden)
 		return
 	}
 
-	expiredAt := int64(tokenClaims["expired_at"].(float64))
-	if expiredAt < time.Now().Unix() {
-		helpers.ResponseError(ctx, &helpers.ResponseParams[any]{
-			Message:   "File not found",
-			Reference: "ERROR-9",
-		}, 400)
+	sum := sha256.Sum256([]byte(path + "?" + variant.Values().Encode()))
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+	cacheHeaders := func() {
+		ctx.Header("ETag", etag)
+		ctx.Header("Cache-Control", cacheControl)
+	}
+	if etagMatches(ctx.GetHeader("If-None-Match"), etag) {
+		cacheHeaders()
+		ctx.Status(http.StatusNotModified)
 		return
 	}
 
-	ctx.File("storage/" + key + "/" + filename)
-}
+	if !variant.IsZero() {
+		content, contentType, err := controller.fileService.Variant(path, variant)
+		if err != nil {
+			controller.fileError(ctx, err)
+			return
+		}
+		cacheHeaders()
+		ctx.Data(http.StatusOK, contentType, content)
+		return
+	}
 
-// @Summary		Serve file without authentication
-// @Description	Serve file directly without JWT authentication
-// @Tags			File
-// @Accept			json
-// @Produce		jpeg
-// @Param			key			path		string				true	"File key"
-// @Param			filename	path		string				true	"File name"
-// @Success		200			{file}		string				"File"
-// @Failure		404			{object}	map[string]string	"File not found"
-// @Router			/file/public/{key}/{filename} [get]
-func (controller FileController) ServePublicFile(ctx *gin.Context) {
-	key := ctx.Param("key")
-	filename := ctx.Param("filename")
+	reader, size, err := controller.fileService.Open(path)
+	if err != nil {
+		controller.fileError(ctx, err)
+		return
+	}
+	defer reader.Close()
 
-	// Validasi parameter
-	if key == "" || filename == "" {
+	// authenticate the first chunk before committing to a response
+	buffered := bufio.NewReader(reader)
+	if _, err := buffered.Peek(1); err != nil && err != io.EOF {
 		helpers.ResponseError(ctx, &helpers.ResponseParams[any]{
-			Message:   "File not found",
-			Reference: "ERROR-7",
-		}, http.StatusNotFound)
+			Message:   "File rusak",
+			Reference: "ERROR-10",
+		}, http.StatusUnprocessableEntity)
 		return
 	}
 
-	// Menyajikan file tanpa autentikasi
-	filePath := "storage/" + key + "/" + filename
-	ctx.File(filePath)
+	contentType := mime.TypeByExtension(filepath.Ext(path))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	cacheHeaders()
+	ctx.DataFromReader(http.StatusOK, size, contentType, buffered, nil)
+}
+
+func (controller FileController) fileError(ctx *gin.Context, err error) {
+	code := http.StatusInternalServerError
+	message := "File not found"
+	reference := "ERROR-7"
+	switch {
+	case errors.Is(err, fs.ErrNotExist), errors.Is(err, storage.ErrInvalidPath), errors.Is(err, services.ErrFileNotFound):
+		code = http.StatusNotFound
+	case errors.Is(err, services.ErrFileUnauthenticated):
+		code, message, reference = http.StatusUnauthorized, "Membutuhkan URL bertanda tangan atau token", "ERROR-1"
+	case errors.Is(err, services.ErrFileForbidden):
+		code, message, reference = http.StatusForbidden, "Tidak punya akses ke file ini", "ERROR-8"
+	case errors.Is(err, encryption.ErrTampered), errors.Is(err, encryption.ErrMalformed):
+		code = http.StatusUnprocessableEntity
+	case errors.Is(err, imagevariant.ErrInvalidOptions):
+		code, message, reference = http.StatusBadRequest, "Parameter tidak valid", "ERROR-4"
+	case errors.Is(err, imagevariant.ErrUnsupported):
+		code, message, reference = http.StatusUnsupportedMediaType, "File tidak bisa dibuat varian", "ERROR-4"
+	}
+	helpers.ResponseError(ctx, &helpers.ResponseParams[any]{
+		Message:   message,
+		Reference: reference,
+	}, code)
+}
+
+// etagMatches reports whether an If-None-Match header lists etag
+func etagMatches(header string, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == etag || candidate == "*" {
+			return true
+		}
+	}
+	return false
 }
@@ -0,0 +1,274 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"golang_starter_kit_2025/app/helpers"
+	"golang_starter_kit_2025/app/models"
+	"golang_starter_kit_2025/app/requests"
+	"golang_starter_kit_2025/app/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+type GroupController struct {
+	service services.GroupService
+}
+
+func NewGroupController(service services.GroupService) *GroupController {
+	return &GroupController{service: service}
+}
+
+// @Summary		Get All Groups
+// @Description	API untuk mendapatkan semua Group
+// @Tags			Group
+// @Accept			json
+// @Produce		json
+// @Success		200	{object}	helpers.ResponseParams[models.UserGroup]{data=[]models.UserGroup}
+// @Router			/groups [get]
+func (c *GroupController) List(ctx *gin.Context) {
+	groups, err := c.service.GetAll()
+	if err != nil {
+		helpers.ResponseError(ctx, &helpers.ResponseParams[any]{
+			Errors:    map[string]string{"error": err.Error()},
+			Message:   "Gagal mendapatkan daftar Group",
+			Reference: "ERROR-3",
+		}, http.StatusInternalServerError)
+		return
+	}
+
+	helpers.ResponseSuccess(ctx, &helpers.ResponseParams[models.UserGroup]{Data: &groups}, http.StatusOK)
+}
+
+// @Summary		Get Group
+// @Description	API untuk mendapatkan Group beserta Role-nya
+// @Tags			Group
+// @Accept			json
+// @Produce		json
+// @Param			id	path		string	true	"Group ID"
+// @Success		200	{object}	helpers.ResponseParams[models.UserGroup]{item=models.UserGroup}
+// @Router			/groups/{id} [get]
+func (c *GroupController) Get(ctx *gin.Context) {
+	group, err := c.service.Find(ctx.Param("id"))
+	if err != nil {
+		helpers.ResponseError(ctx, &helpers.ResponseParams[any]{
+			Errors:    map[string]string{"error": err.Error()},
+			Message:   "Group tidak ditemukan",
+			Reference: "ERROR-3",
+		}, assignmentErrorStatus(err))
+		return
+	}
+
+	helpers.ResponseSuccess(ctx, &helpers.ResponseParams[models.UserGroup]{Item: &group}, http.StatusOK)
+}
+
+// @Summary		Create/Update Group
+// @Description	API untuk mengupdate atau membuat Group
+// @Tags			Group
+// @Accept			json
+// @Produce		json
+// @Param			group	body		requests.GroupRequestPut	true	"Group Data"
+// @Success		200		{object}	helpers.ResponseParams[models.UserGroup]{item=models.UserGroup}
+// @Router			/groups [put]
+func (c *GroupController) Put(ctx *gin.Context) {
+	var req requests.GroupRequestPut
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		var verr validator.ValidationErrors
+		if errors.As(err, &verr) {
+			helpers.ResponseError(ctx, &helpers.ResponseParams[any]{
+				Errors:    helpers.ValidationError(verr),
+				Message:   "Parameter tidak valid",
+				Reference: "ERROR-4",
+			}, http.StatusBadRequest)
+			return
+		}
+		helpers.ResponseError(ctx, &helpers.ResponseParams[any]{
+			Errors:    map[string]string{"error": err.Error()},
+			Message:   "Gagal membuat Group",
+			Reference: "ERROR-3",
+		}, http.StatusBadRequest)
+		return
+	}
+
+	group, err := c.service.Put(models.UserGroup{ID: req.ID, Name: req.Name, Description: req.Description})
+	if err != nil {
+		helpers.ResponseError(ctx, &helpers.ResponseParams[any]{
+			Errors:    map[string]string{"error": err.Error()},
+			Message:   "Gagal membuat Group",
+			Reference: "ERROR-3",
+		}, http.StatusBadRequest)
+		return
+	}
+
+	helpers.ResponseSuccess(ctx, &helpers.ResponseParams[models.UserGroup]{Item: &group}, http.StatusOK)
+}
+
+// @Summary		Delete Group
+// @Description	API untuk menghapus Group
+// @Tags			Group
+// @Accept			json
+// @Produce		json
+// @Param			id	path		string	true	"Group ID"
+// @Success		200	{object}	helpers.ResponseParams[any]{}
+// @Router			/groups/{id} [delete]
+func (c *GroupController) Delete(ctx *gin.Context) {
+	if err := c.service.Delete(ctx.Param("id")); err != nil {
+		helpers.ResponseError(ctx, &helpers.ResponseParams[any]{
+			Errors:    map[string]string{"error": err.Error()},
+			Message:   "Gagal menghapus Group",
+			Reference: "ERROR-3",
+		}, assignmentErrorStatus(err))
+		return
+	}
+
+	helpers.ResponseSuccess(ctx, &helpers.ResponseParams[any]{Message: "Group deleted"}, http.StatusOK)
+}
+
+// @Summary		Get Group Members
+// @Description	API untuk mendapatkan anggota Group
+// @Tags			Group
+// @Accept			json
+// @Produce		json
+// @Param			id	path		string	true	"Group ID"
+// @Success		200	{object}	helpers.ResponseParams[models.User]{data=[]models.User}
+// @Router			/groups/{id}/members [get]
+func (c *GroupController) GetMembers(ctx *gin.Context) {
+	users, err := c.service.GetMembers(ctx.Param("id"))
+	if err != nil {
+		helpers.ResponseError(ctx, &helpers.ResponseParams[any]{
+			Errors:    map[string]string{"error": err.Error()},
+			Message:   "Gagal mendapatkan anggota Group",
+			Reference: "ERROR-3",
+		}, http.StatusInternalServerError)
+		return
+	}
+
+	helpers.ResponseSuccess(ctx, &helpers.ResponseParams[models.User]{Data: &users}, http.StatusOK)
+}
+
+// @Summary		Update Group Members
+// @Description	API untuk menambah dan mengeluarkan anggota Group
+// @Tags			Group
+// @Accept			json
+// @Produce		json
+// @Param			id		path		string								true	"Group ID"
+// @Param			body	body		requests.GroupRequestUpdateMembers	true	"User IDs to add and remove"
+// @Success		200		{object}	helpers.ResponseParams[any]
+// @Router			/groups/{id}/members [patch]
+func (c *GroupController) UpdateMembers(ctx *gin.Context) {
+	var req requests.GroupRequestUpdateMembers
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		helpers.ResponseError(ctx, &helpers.ResponseParams[any]{
+			Errors:    map[string]string{"error": err.Error()},
+			Message:   "Parameter tidak valid",
+			Reference: "ERROR-4",
+		}, http.StatusBadRequest)
+		return
+	}
+
+	if err := c.service.UpdateMembers(ctx.Param("id"), req.Add, req.Remove); err != nil {
+		helpers.ResponseError(ctx, &helpers.ResponseParams[any]{
+			Errors:    map[string]string{"error": err.Error()},
+			Message:   "Gagal mengubah anggota Group",
+			Reference: "ERROR-3",
+		}, groupErrorStatus(err))
+		return
+	}
+
+	helpers.ResponseSuccess(ctx, &helpers.ResponseParams[any]{Message: "Anggota Group berhasil diubah"}, http.StatusOK)
+}
+
+// @Summary		Get Group Roles
+// @Description	API untuk mendapatkan Role yang dimiliki Group
+// @Tags			Group
+// @Accept			json
+// @Produce		json
+// @Param			id	path		string	true	"Group ID"
+// @Success		200	{object}	helpers.ResponseParams[models.Role]{data=[]models.Role}
+// @Router			/groups/{id}/roles [get]
+func (c *GroupController) GetRoles(ctx *gin.Context) {
+	roles, err := c.service.GetRoles(ctx.Param("id"))
+	if err != nil {
+		helpers.ResponseError(ctx, &helpers.ResponseParams[any]{
+			Errors:    map[string]string{"error": err.Error()},
+			Message:   "Gagal mendapatkan Role Group",
+			Reference: "ERROR-3",
+		}, http.StatusInternalServerError)
+		return
+	}
+
+	helpers.ResponseSuccess(ctx, &helpers.ResponseParams[models.Role]{Data: &roles}, http.StatusOK)
+}
+
+// @Summary		Replace Group Roles
+// @Description	API untuk mengganti seluruh Role yang dimiliki Group
+// @Tags			Group
+// @Accept			json
+// @Produce		json
+// @Param			id		path		string							true	"Group ID"
+// @Param			body	body		requests.GroupRequestAssignRoles	true	"Role IDs"
+// @Success		200		{object}	helpers.ResponseParams[any]
+// @Router			/groups/{id}/roles [post]
+func (c *GroupController) AssignRoles(ctx *gin.Context) {
+	var req requests.GroupRequestAssignRoles
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		helpers.ResponseError(ctx, &helpers.ResponseParams[any]{
+			Errors:    map[string]string{"error": err.Error()},
+			Message:   "Parameter tidak valid",
+			Reference: "ERROR-4",
+		}, http.StatusBadRequest)
+		return
+	}
+
+	if err := c.service.AssignRoles(ctx.Param("id"), req.Roles); err != nil {
+		helpers.ResponseError(ctx, &helpers.ResponseParams[any]{
+			Errors:    map[string]string{"error": err.Error()},
+			Message:   "Gagal mengatur Role Group",
+			Reference: "ERROR-3",
+		}, groupErrorStatus(err))
+		return
+	}
+
+	helpers.ResponseSuccess(ctx, &helpers.ResponseParams[any]{Message: "Role Group berhasil diatur"}, http.StatusOK)
+}
+
+// @Summary		Update Group Roles
+// @Description	API untuk menambah dan mencabut Role tertentu dari Group
+// @Tags			Group
+// @Accept			json
+// @Produce		json
+// @Param			id		path		string							true	"Group ID"
+// @Param			body	body		requests.GroupRequestUpdateRoles	true	"Role IDs to add and remove"
+// @Success		200		{object}	helpers.ResponseParams[any]
+// @Router			/groups/{id}/roles [patch]
+func (c *GroupController) UpdateRoles(ctx *gin.Context) {
+	var req requests.GroupRequestUpdateRoles
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		helpers.ResponseError(ctx, &helpers.ResponseParams[any]{
+			Errors:    map[string]string{"error": err.Error()},
+			Message:   "Parameter tidak valid",
+			Reference: "ERROR-4",
+		}, http.StatusBadRequest)
+		return
+	}
+
+	if err := c.service.UpdateRoles(ctx.Param("id"), req.Add, req.Remove); err != nil {
+		helpers.ResponseError(ctx, &helpers.ResponseParams[any]{
+			Errors:    map[string]string{"error": err.Error()},
+			Message:   "Gagal mengubah Role Group",
+			Reference: "ERROR-3",
+		}, groupErrorStatus(err))
+		return
+	}
+
+	helpers.ResponseSuccess(ctx, &helpers.ResponseParams[any]{Message: "Role Group berhasil diubah"}, http.StatusOK)
+}
+
+func groupErrorStatus(err error) int {
+	if errors.Is(err, services.ErrInvalidUserIds) {
+		return http.StatusBadRequest
+	}
+	return assignmentErrorStatus(err)
+}
@@ -0,0 +1,405 @@
+package controllers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"golang_starter_kit_2025/app/filescan"
+	"golang_starter_kit_2025/app/helpers"
+	"golang_starter_kit_2025/app/models"
+	"golang_starter_kit_2025/app/nik"
+	"golang_starter_kit_2025/app/requests"
+	"golang_starter_kit_2025/app/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"gorm.io/gorm"
+)
+
+type KycController struct {
+	service       services.KycService
+	uploadService services.UploadService
+}
+
+func NewKycController(service services.KycService) *KycController {
+	return &KycController{service: service}
+}
+
+// @Summary		Create KYC Submission
+// @Description	API untuk memulai pengajuan KYC baru dalam status draft
+// @Tags			KYC
+// @Accept			json
+// @Produce		json
+// @Success		201	{object}	helpers.ResponseParams[models.KycSubmission]{item=models.KycSubmission}
+// @Router			/kyc [post]
+func (c *KycController) Create(ctx *gin.Context) {
+	submission, err := c.service.Create(ctx.GetUint("user_id"))
+	if err != nil {
+		helpers.ResponseError(ctx, &helpers.ResponseParams[any]{
+			Errors:    map[string]string{"error": err.Error()},
+			Message:   "Gagal membuat pengajuan KYC",
+			Reference: "ERROR-3",
+		}, kycErrorStatus(err))
+		return
+	}
+
+	helpers.ResponseSuccess(ctx, &helpers.ResponseParams[models.KycSubmission]{Item: &submission}, http.StatusCreated)
+}
+
+// @Summary		My KYC Submission
+// @Description	API untuk melihat status, dokumen dan riwayat pengajuan KYC terakhir milik user yang sedang login
+// @Tags			KYC
+// @Accept			json
+// @Produce		json
+// @Success		200	{object}	helpers.ResponseParams[models.KycSubmission]{item=models.KycSubmission}
+// @Router			/kyc/mine [get]
+func (c *KycController) Mine(ctx *gin.Context) {
+	submission, err := c.service.GetMine(ctx.GetUint("user_id"))
+	if err != nil {
+		helpers.ResponseError(ctx, &helpers.ResponseParams[any]{
+			Errors:    map[string]string{"error": err.Error()},
+			Message:   "Pengajuan KYC tidak ditemukan",
+			Reference: "ERROR-3",
+		}, kycErrorStatus(err))
+		return
+	}
+
+	helpers.ResponseSuccess(ctx, &helpers.ResponseParams[models.KycSubmission]{Item: &submission}, http.StatusOK)
+}
+
+// @Summary		Update My KYC Data
+// @Description	API untuk mengisi data pribadi pengajuan KYC yang masih draft atau diminta diajukan ulang
+// @Tags			KYC
+// @Accept			json
+// @Produce		json
+// @Param			body	body		requests.KycRequestPut	true	"Data pribadi"
+// @Success		200		{object}	helpers.ResponseParams[models.KycSubmission]{item=models.KycSubmission}
+// @Router			/kyc/mine [put]
+func (c *KycController) UpdateMine(ctx *gin.Context) {
+	var req requests.KycRequestPut
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		var verr validator.ValidationErrors
+		if errors.As(err, &verr) {
+			errs := helpers.ValidationError(verr)
+			if _, parseErr := nik.Parse(req.NIK); errs["NIK"] == "nik" && parseErr != nil {
+				errs["NIK"] = parseErr.Error()
+			}
+			helpers.ResponseError(ctx, &helpers.ResponseParams[any]{
+				Errors:    errs,
+				Message:   "Parameter tidak valid",
+				Reference: "ERROR-4",
+			}, http.StatusBadRequest)
+			return
+		}
+		helpers.ResponseError(ctx, &helpers.ResponseParams[any]{
+			Errors:    map[string]string{"error": err.Error()},
+			Message:   "Parameter tidak valid",
+			Reference: "ERROR-4",
+		}, http.StatusBadRequest)
+		return
+	}
+
+	data := models.KycSubmission{
+		NIK:        req.NIK,
+		FullName:   strings.TrimSpace(req.FullName),
+		BirthPlace: strings.TrimSpace(req.BirthPlace),
+		Gender:     req.Gender,
+		Address:    strings.TrimSpace(req.Address),
+		Phone:      req.Phone,
+	}
+	if req.BirthDate != "" {
+		birthDate, _ := time.Parse("2006-01-02", req.BirthDate)
+		data.BirthDate = &birthDate
+	}
+
+	submission, err := c.service.UpdateMine(ctx.GetUint("user_id"), data)
+	if err != nil {
+		helpers.ResponseError(ctx, &helpers.ResponseParams[any]{
+			Errors:    map[string]string{"error": err.Error()},
+			Message:   "Gagal memperbarui pengajuan KYC",
+			Reference: "ERROR-3",
+		}, kycErrorStatus(err))
+		return
+	}
+
+	helpers.ResponseSuccess(ctx, &helpers.ResponseParams[models.KycSubmission]{Item: &submission}, http.StatusOK)
+}
+
+// @Summary		Upload KYC Document
+// @Description	API untuk mengunggah foto KTP atau selfie, sebagai JSON base64, upload_id dari upload resumable (/uploads) atau multipart form dengan field file. Hasil pemeriksaan kualitas gambar dikembalikan agar foto dapat diambil ulang.
+// @Tags			KYC
+// @Accept			json,mpfd
+// @Produce		json
+// @Param			body	body		requests.KycRequestUploadDocument	true	"Dokumen"
+// @Success		201		{object}	helpers.ResponseParams[models.KycDocument]{item=models.KycDocument}
+// @Router			/kyc/mine/documents [post]
+func (c *KycController) UploadDocument(ctx *gin.Context) {
+	var req requests.KycRequestUploadDocument
+	if err := ctx.ShouldBind(&req); err != nil {
+		var verr validator.ValidationErrors
+		if errors.As(err, &verr) {
+			helpers.ResponseError(ctx, &helpers.ResponseParams[any]{
+				Errors:    helpers.ValidationError(verr),
+				Message:   "Parameter tidak valid",
+				Reference: "ERROR-4",
+			}, http.StatusBadRequest)
+			return
+		}
+		helpers.ResponseError(ctx, &helpers.ResponseParams[any]{
+			Errors:    map[string]string{"error": err.Error()},
+			Message:   "Parameter tidak valid",
+			Reference: "ERROR-4",
+		}, http.StatusBadRequest)
+		return
+	}
+
+	var input services.UploadInput
+	var err error
+	switch {
+	case req.UploadID != "":
+		input, err = c.uploadService.Input(ctx.GetUint("user_id"), req.UploadID, models.UploadPurposeKycDocument)
+	case strings.HasPrefix(ctx.ContentType(), "multipart/"):
+		input, err = readFormFile(ctx, "file", services.UploadPurposes[models.UploadPurposeKycDocument].MaxSize)
+	case req.File == "":
+		err = errors.New("file is required")
+	default:
+		input.Content, input.DeclaredType, err = helpers.DecodeDataURI(req.File)
+	}
+	if err != nil {
+		status := http.StatusBadRequest
+		if req.UploadID != "" {
+			status = uploadErrorStatus(err)
+		}
+		helpers.ResponseError(ctx, &helpers.ResponseParams[any]{
+			Errors:    map[string]string{"file": err.Error()},
+			Message:   "File tidak valid",
+			Reference: "ERROR-4",
+		}, status)
+		return
+	}
+
+	document, err := c.service.UploadDocument(ctx.GetUint("user_id"), models.KycDocumentType(req.Type), input)
+	if err != nil {
+		helpers.ResponseError(ctx, &helpers.ResponseParams[any]{
+			Errors:    map[string]string{"error": err.Error()},
+			Message:   "Gagal mengunggah dokumen KYC",
+			Reference: "ERROR-3",
+		}, kycErrorStatus(err))
+		return
+	}
+
+	// the upload has been stored as the document
+	if req.UploadID != "" {
+		c.uploadService.Terminate(ctx.GetUint("user_id"), req.UploadID)
+	}
+
+	helpers.ResponseSuccess(ctx, &helpers.ResponseParams[models.KycDocument]{Item: &document}, http.StatusCreated)
+}
+
+// @Summary		Submit My KYC
+// @Description	API untuk mengajukan KYC untuk ditinjau setelah data pribadi dan dokumen lengkap
+// @Tags			KYC
+// @Accept			json
+// @Produce		json
+// @Success		200	{object}	helpers.ResponseParams[models.KycSubmission]{item=models.KycSubmission}
+// @Router			/kyc/mine/submit [post]
+func (c *KycController) Submit(ctx *gin.Context) {
+	submission, err := c.service.Submit(ctx.GetUint("user_id"))
+	if err != nil {
+		helpers.ResponseError(ctx, &helpers.ResponseParams[any]{
+			Errors:    map[string]string{"error": err.Error()},
+			Message:   "Gagal mengajukan KYC",
+			Reference: "ERROR-3",
+		}, kycErrorStatus(err))
+		return
+	}
+
+	helpers.ResponseSuccess(ctx, &helpers.ResponseParams[models.KycSubmission]{Item: &submission}, http.StatusOK)
+}
+
+// @Summary		List KYC Submissions
+// @Description	API untuk reviewer melihat pengajuan KYC
+// @Tags			KYC
+// @Accept			json
+// @Produce		json
+// @Param			status	query		string	false	"Status (draft, submitted, in_review, approved, rejected, resubmission_required)"
+// @Success		200		{object}	helpers.ResponseParams[models.KycSubmission]{data=[]models.KycSubmission}
+// @Router			/kyc [get]
+func (c *KycController) List(ctx *gin.Context) {
+	submissions, err := c.service.GetAll(ctx.Query("status"))
+	if err != nil {
+		helpers.ResponseError(ctx, &helpers.ResponseParams[any]{
+			Errors:    map[string]string{"error": err.Error()},
+			Message:   "Gagal mendapatkan pengajuan KYC",
+			Reference: "ERROR-3",
+		}, http.StatusInternalServerError)
+		return
+	}
+
+	helpers.ResponseSuccess(ctx, &helpers.ResponseParams[models.KycSubmission]{Data: &submissions}, http.StatusOK)
+}
+
+// @Summary		Get KYC Submission
+// @Description	API untuk reviewer melihat detail, dokumen dan riwayat pengajuan KYC
+// @Tags			KYC
+// @Accept			json
+// @Produce		json
+// @Param			id	path		string	true	"KYC Submission ID"
+// @Success		200	{object}	helpers.ResponseParams[models.KycSubmission]{item=models.KycSubmission}
+// @Router			/kyc/{id} [get]
+func (c *KycController) Get(ctx *gin.Context) {
+	submission, err := c.service.Find(ctx.Param("id"))
+	if err != nil {
+		helpers.ResponseError(ctx, &helpers.ResponseParams[any]{
+			Errors:    map[string]string{"error": err.Error()},
+			Message:   "Pengajuan KYC tidak ditemukan",
+			Reference: "ERROR-3",
+		}, kycErrorStatus(err))
+		return
+	}
+
+	helpers.ResponseSuccess(ctx, &helpers.ResponseParams[models.KycSubmission]{Item: &submission}, http.StatusOK)
+}
+
+// @Summary		Start KYC Review
+// @Description	API untuk reviewer mengambil pengajuan KYC yang sudah diajukan untuk ditinjau
+// @Tags			KYC
+// @Accept			json
+// @Produce		json
+// @Param			id	path		string	true	"KYC Submission ID"
+// @Success		200	{object}	helpers.ResponseParams[models.KycSubmission]{item=models.KycSubmission}
+// @Router			/kyc/{id}/review [post]
+func (c *KycController) StartReview(ctx *gin.Context) {
+	submission, err := c.service.StartReview(ctx.Param("id"), ctx.GetUint("user_id"))
+	if err != nil {
+		helpers.ResponseError(ctx, &helpers.ResponseParams[any]{
+			Errors:    map[string]string{"error": err.Error()},
+			Message:   "Gagal memulai peninjauan KYC",
+			Reference: "ERROR-3",
+		}, kycErrorStatus(err))
+		return
+	}
+
+	helpers.ResponseSuccess(ctx, &helpers.ResponseParams[models.KycSubmission]{Item: &submission}, http.StatusOK)
+}
+
+// @Summary		Approve KYC
+// @Description	API untuk menyetujui pengajuan KYC yang sedang ditinjau
+// @Tags			KYC
+// @Accept			json
+// @Produce		json
+// @Param			id		path		string						true	"KYC Submission ID"
+// @Param			body	body		requests.KycRequestDecision	false	"Komentar reviewer"
+// @Success		200		{object}	helpers.ResponseParams[models.KycSubmission]{item=models.KycSubmission}
+// @Router			/kyc/{id}/approve [post]
+func (c *KycController) Approve(ctx *gin.Context) {
+	c.decide(ctx, models.KycStatusApproved)
+}
+
+// @Summary		Reject KYC
+// @Description	API untuk menolak pengajuan KYC yang sedang ditinjau, dengan kode alasan
+// @Tags			KYC
+// @Accept			json
+// @Produce		json
+// @Param			id		path		string						true	"KYC Submission ID"
+// @Param			body	body		requests.KycRequestDecision	true	"Kode alasan dan komentar reviewer"
+// @Success		200		{object}	helpers.ResponseParams[models.KycSubmission]{item=models.KycSubmission}
+// @Router			/kyc/{id}/reject [post]
+func (c *KycController) Reject(ctx *gin.Context) {
+	c.decide(ctx, models.KycStatusRejected)
+}
+
+// @Summary		Request KYC Resubmission
+// @Description	API untuk meminta pemohon memperbaiki dan mengajukan ulang KYC, dengan kode alasan
+// @Tags			KYC
+// @Accept			json
+// @Produce		json
+// @Param			id		path		string						true	"KYC Submission ID"
+// @Param			body	body		requests.KycRequestDecision	true	"Kode alasan dan komentar reviewer"
+// @Success		200		{object}	helpers.ResponseParams[models.KycSubmission]{item=models.KycSubmission}
+// @Router			/kyc/{id}/request-resubmission [post]
+func (c *KycController) RequestResubmission(ctx *gin.Context) {
+	c.decide(ctx, models.KycStatusResubmissionRequired)
+}
+
+func (c *KycController) decide(ctx *gin.Context, status models.KycStatus) {
+	var req requests.KycRequestDecision
+	if ctx.Request.ContentLength > 0 {
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			helpers.ResponseError(ctx, &helpers.ResponseParams[any]{
+				Errors:    map[string]string{"error": err.Error()},
+				Message:   "Parameter tidak valid",
+				Reference: "ERROR-4",
+			}, http.StatusBadRequest)
+			return
+		}
+	}
+
+	submission, err := c.service.Decide(ctx.Param("id"), ctx.GetUint("user_id"), status, models.KycReasonCode(req.ReasonCode), req.Comment)
+	if err != nil {
+		helpers.ResponseError(ctx, &helpers.ResponseParams[any]{
+			Errors:    map[string]string{"error": err.Error()},
+			Message:   "Gagal memproses pengajuan KYC",
+			Reference: "ERROR-3",
+		}, kycErrorStatus(err))
+		return
+	}
+
+	helpers.ResponseSuccess(ctx, &helpers.ResponseParams[models.KycSubmission]{Item: &submission}, http.StatusOK)
+}
+
+func kycErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrKycSelfReview):
+		return http.StatusForbidden
+	case errors.Is(err, services.ErrKycSubmissionExists),
+		errors.Is(err, services.ErrKycNotEditable),
+		errors.Is(err, services.ErrKycInvalidTransition),
+		errors.Is(err, services.ErrNIKAlreadyRegistered):
+		return http.StatusConflict
+	case errors.Is(err, services.ErrKycIncomplete),
+		errors.Is(err, services.ErrKycReasonRequired),
+		errors.Is(err, services.ErrKycNIKMismatch),
+		errors.Is(err, services.ErrKycInvalidNIK),
+		errors.Is(err, services.ErrKycInvalidDocument),
+		errors.Is(err, services.ErrKycDocumentQuality):
+		return http.StatusBadRequest
+	case errors.Is(err, services.ErrUploadRejected):
+		return http.StatusUnprocessableEntity
+	case errors.Is(err, filescan.ErrUnavailable):
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// readFormFile reads an uploaded multipart file for the upload pipeline. At
+// most one byte more than limit is read, which is enough for the pipeline to
+// refuse the file as too large.
+func readFormFile(ctx *gin.Context, key string, limit int64) (services.UploadInput, error) {
+	header, err := ctx.FormFile(key)
+	if err != nil {
+		return services.UploadInput{}, err
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		return services.UploadInput{}, err
+	}
+	defer file.Close()
+
+	content, err := io.ReadAll(io.LimitReader(file, limit+1))
+	if err != nil {
+		return services.UploadInput{}, err
+	}
+	return services.UploadInput{
+		Content:      content,
+		Name:         header.Filename,
+		DeclaredType: header.Header.Get("Content-Type"),
+	}, nil
+}
@@ -0,0 +1,91 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"golang_starter_kit_2025/app/helpers"
+	"golang_starter_kit_2025/app/models"
+	"golang_starter_kit_2025/app/requests"
+	"golang_starter_kit_2025/app/responses"
+	"golang_starter_kit_2025/app/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+type KycFlagController struct {
+	service services.KycFlagService
+}
+
+func NewKycFlagController(service services.KycFlagService) *KycFlagController {
+	return &KycFlagController{service: service}
+}
+
+// @Summary		KYC Duplicate Clusters
+// @Description	API untuk reviewer melihat kelompok pemohon yang kemungkinan orang yang sama, berdasarkan NIK, telepon, email, nama dan tanggal lahir, serta foto dokumen
+// @Tags			KYC
+// @Accept			json
+// @Produce		json
+// @Param			status	query		string	false	"Status flag (open, confirmed). Default semua kecuali dismissed"
+// @Success		200		{object}	helpers.ResponseParams[responses.KycDuplicateCluster]{data=[]responses.KycDuplicateCluster}
+// @Router			/kyc/duplicates [get]
+func (c *KycFlagController) Clusters(ctx *gin.Context) {
+	clusters, err := c.service.Clusters(ctx.Query("status"))
+	if err != nil {
+		helpers.ResponseError(ctx, &helpers.ResponseParams[any]{
+			Errors:    map[string]string{"error": err.Error()},
+			Message:   "Gagal mendapatkan duplikasi KYC",
+			Reference: "ERROR-3",
+		}, http.StatusInternalServerError)
+		return
+	}
+
+	helpers.ResponseSuccess(ctx, &helpers.ResponseParams[responses.KycDuplicateCluster]{Data: &clusters}, http.StatusOK)
+}
+
+// @Summary		Resolve KYC Duplicate Flag
+// @Description	API untuk reviewer mengonfirmasi atau mengabaikan flag duplikasi. Flag yang diabaikan tidak dimunculkan lagi saat pengajuan ulang.
+// @Tags			KYC
+// @Accept			json
+// @Produce		json
+// @Param			id		path		string							true	"KYC Flag ID"
+// @Param			body	body		requests.KycRequestResolveFlag	true	"Keputusan reviewer"
+// @Success		200		{object}	helpers.ResponseParams[models.KycFlag]{item=models.KycFlag}
+// @Router			/kyc/flags/{id}/resolve [post]
+func (c *KycFlagController) Resolve(ctx *gin.Context) {
+	var req requests.KycRequestResolveFlag
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		var verr validator.ValidationErrors
+		if errors.As(err, &verr) {
+			helpers.ResponseError(ctx, &helpers.ResponseParams[any]{
+				Errors:    helpers.ValidationError(verr),
+				Message:   "Parameter tidak valid",
+				Reference: "ERROR-4",
+			}, http.StatusBadRequest)
+			return
+		}
+		helpers.ResponseError(ctx, &helpers.ResponseParams[any]{
+			Errors:    map[string]string{"error": err.Error()},
+			Message:   "Parameter tidak valid",
+			Reference: "ERROR-4",
+		}, http.StatusBadRequest)
+		return
+	}
+
+	flag, err := c.service.Resolve(ctx.Param("id"), ctx.GetUint("user_id"), models.KycFlagStatus(req.Status), req.Note)
+	if err != nil {
+		status := kycErrorStatus(err)
+		if errors.Is(err, services.ErrKycFlagInvalidStatus) {
+			status = http.StatusBadRequest
+		}
+		helpers.ResponseError(ctx, &helpers.ResponseParams[any]{
+			Errors:    map[string]string{"error": err.Error()},
+			Message:   "Gagal memproses flag duplikasi",
+			Reference: "ERROR-3",
+		}, status)
+		return
+	}
+
+	helpers.ResponseSuccess(ctx, &helpers.ResponseParams[models.KycFlag]{Item: &flag}, http.StatusOK)
+}
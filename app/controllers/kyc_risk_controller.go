@@ -0,0 +1,62 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"golang_starter_kit_2025/app/helpers"
+	"golang_starter_kit_2025/app/risk"
+	"golang_starter_kit_2025/app/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type KycRiskController struct {
+	service services.KycRiskService
+}
+
+func NewKycRiskController(service services.KycRiskService) *KycRiskController {
+	return &KycRiskController{service: service}
+}
+
+// @Summary		KYC Risk Rules
+// @Description	API untuk melihat aturan penilaian risiko KYC yang sedang dipakai
+// @Tags			KYC
+// @Accept			json
+// @Produce		json
+// @Success		200	{object}	helpers.ResponseParams[risk.RuleSet]{item=risk.RuleSet}
+// @Router			/kyc/risk/rules [get]
+func (c *KycRiskController) Rules(ctx *gin.Context) {
+	helpers.ResponseSuccess(ctx, &helpers.ResponseParams[risk.RuleSet]{Item: c.service.Rules()}, http.StatusOK)
+}
+
+// @Summary		Rescore KYC Risk
+// @Description	API untuk memuat ulang file aturan risiko dan menilai ulang semua pengajuan KYC yang menunggu keputusan di background. Penilaian lama tetap disimpan.
+// @Tags			KYC
+// @Accept			json
+// @Produce		json
+// @Success		202	{object}	helpers.ResponseParams[services.KycRescoreReport]{item=services.KycRescoreReport}
+// @Router			/kyc/risk/rescore [post]
+func (c *KycRiskController) Rescore(ctx *gin.Context) {
+	report, err := c.service.StartRescore()
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, risk.ErrInvalidRules):
+			status = http.StatusUnprocessableEntity
+		case errors.Is(err, services.ErrKycRescoreRunning):
+			status = http.StatusConflict
+		}
+		helpers.ResponseError(ctx, &helpers.ResponseParams[any]{
+			Errors:    map[string]string{"error": err.Error()},
+			Message:   "Gagal menilai ulang risiko KYC",
+			Reference: "ERROR-3",
+		}, status)
+		return
+	}
+
+	helpers.ResponseSuccess(ctx, &helpers.ResponseParams[services.KycRescoreReport]{
+		Item:    &report,
+		Message: "Penilaian ulang risiko KYC dijalankan di background",
+	}, http.StatusAccepted)
+}
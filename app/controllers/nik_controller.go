@@ -0,0 +1,68 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"golang_starter_kit_2025/app/helpers"
+	"golang_starter_kit_2025/app/nik"
+	"golang_starter_kit_2025/app/requests"
+	"golang_starter_kit_2025/app/responses"
+	"golang_starter_kit_2025/app/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+type NIKController struct {
+	service services.NIKService
+}
+
+func NewNIKController(service services.NIKService) *NIKController {
+	return &NIKController{service: service}
+}
+
+// @Summary		Check NIK
+// @Description	API untuk memvalidasi NIK, menguraikan wilayah, tanggal lahir dan jenis kelamin, serta memeriksa apakah NIK sudah terdaftar
+// @Tags			NIK
+// @Accept			json
+// @Produce		json
+// @Param			body	body		requests.CheckNIKRequest	true	"NIK"
+// @Success		200		{object}	helpers.ResponseParams[responses.CheckNIKResult]{item=responses.CheckNIKResult}
+// @Router			/nik/check [post]
+func (c *NIKController) Check(ctx *gin.Context) {
+	var req requests.CheckNIKRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		var verr validator.ValidationErrors
+		if errors.As(err, &verr) {
+			errs := helpers.ValidationError(verr)
+			if _, parseErr := nik.Parse(req.NIK); errs["NIK"] == "nik" && parseErr != nil {
+				errs["NIK"] = parseErr.Error()
+			}
+			helpers.ResponseError(ctx, &helpers.ResponseParams[any]{
+				Errors:    errs,
+				Message:   "NIK tidak valid",
+				Reference: "ERROR-4",
+			}, http.StatusBadRequest)
+			return
+		}
+		helpers.ResponseError(ctx, &helpers.ResponseParams[any]{
+			Errors:    map[string]string{"error": err.Error()},
+			Message:   "Parameter tidak valid",
+			Reference: "ERROR-4",
+		}, http.StatusBadRequest)
+		return
+	}
+
+	result, err := c.service.Check(req.NIK)
+	if err != nil {
+		helpers.ResponseError(ctx, &helpers.ResponseParams[any]{
+			Errors:    map[string]string{"error": err.Error()},
+			Message:   "Gagal memeriksa NIK",
+			Reference: "ERROR-3",
+		}, http.StatusInternalServerError)
+		return
+	}
+
+	helpers.ResponseSuccess(ctx, &helpers.ResponseParams[responses.CheckNIKResult]{Item: &result}, http.StatusOK)
+}
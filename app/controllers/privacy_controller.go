@@ -0,0 +1,157 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"golang_starter_kit_2025/app/helpers"
+	"golang_starter_kit_2025/app/models"
+	"golang_starter_kit_2025/app/requests"
+	"golang_starter_kit_2025/app/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"gorm.io/gorm"
+)
+
+type PrivacyController struct {
+	service          services.PrivacyService
+	retentionService services.RetentionService
+}
+
+func NewPrivacyController(service services.PrivacyService, retentionService services.RetentionService) *PrivacyController {
+	return &PrivacyController{service: service, retentionService: retentionService}
+}
+
+// @Summary		Export My Data
+// @Description	API untuk mengunduh semua data pribadi user yang sedang login beserta foto dokumen KYC dalam satu arsip ZIP
+// @Tags			Privacy
+// @Produce		application/zip
+// @Success		200	{file}	file	"Arsip ZIP"
+// @Router			/privacy/export [get]
+func (c *PrivacyController) ExportMine(ctx *gin.Context) {
+	c.export(ctx, fmt.Sprint(ctx.GetUint("user_id")))
+}
+
+// @Summary		Export User Data
+// @Description	API untuk mengunduh semua data pribadi seorang user dalam satu arsip ZIP, untuk menjawab permintaan akses data
+// @Tags			Privacy
+// @Produce		application/zip
+// @Param			id	path	string	true	"User ID"
+// @Success		200	{file}	file	"Arsip ZIP"
+// @Router			/privacy/users/{id}/export [get]
+func (c *PrivacyController) ExportUser(ctx *gin.Context) {
+	c.export(ctx, ctx.Param("id"))
+}
+
+// export collects the data before writing anything, so lookup failures are
+// still answered with JSON; a failure while streaming aborts the response
+func (c *PrivacyController) export(ctx *gin.Context, userId string) {
+	data, err := c.service.Collect(userId)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			status = http.StatusNotFound
+		}
+		helpers.ResponseError(ctx, &helpers.ResponseParams[any]{
+			Errors:    map[string]string{"error": err.Error()},
+			Message:   "Gagal mengekspor data pribadi",
+			Reference: "ERROR-3",
+		}, status)
+		return
+	}
+
+	ctx.Header("Content-Type", "application/zip")
+	ctx.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s-%s.zip"`, data.User.Reference, data.GeneratedAt.Format("20060102150405")))
+	ctx.Status(http.StatusOK)
+	if err := c.service.WriteArchive(ctx.Writer, data); err != nil {
+		ctx.Error(err)
+		ctx.Abort()
+	}
+}
+
+// @Summary		Erase User Data
+// @Description	API untuk menganonimkan data pribadi seorang user dan pengajuan KYC-nya serta menghapus foto dokumennya. Hanya referensi dan hasil yang wajib disimpan yang tetap ada.
+// @Tags			Privacy
+// @Accept			json
+// @Produce		json
+// @Param			id		path		string						true	"User ID"
+// @Param			body	body		requests.PrivacyRequestErase	true	"Alasan penghapusan"
+// @Success		200		{object}	helpers.ResponseParams[models.ErasureRecord]{item=models.ErasureRecord}
+// @Router			/privacy/users/{id}/erase [post]
+func (c *PrivacyController) Erase(ctx *gin.Context) {
+	var req requests.PrivacyRequestErase
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		var verr validator.ValidationErrors
+		if errors.As(err, &verr) {
+			helpers.ResponseError(ctx, &helpers.ResponseParams[any]{
+				Errors:    helpers.ValidationError(verr),
+				Message:   "Parameter tidak valid",
+				Reference: "ERROR-4",
+			}, http.StatusBadRequest)
+			return
+		}
+		helpers.ResponseError(ctx, &helpers.ResponseParams[any]{
+			Errors:    map[string]string{"error": err.Error()},
+			Message:   "Parameter tidak valid",
+			Reference: "ERROR-4",
+		}, http.StatusBadRequest)
+		return
+	}
+
+	actorId := ctx.GetUint("user_id")
+	record, err := c.service.Erase(ctx.Param("id"), &actorId, req.Reason)
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			status = http.StatusNotFound
+		case errors.Is(err, services.ErrCannotEraseOwnData):
+			status = http.StatusForbidden
+		case errors.Is(err, services.ErrAlreadyErased):
+			status = http.StatusConflict
+		}
+		helpers.ResponseError(ctx, &helpers.ResponseParams[any]{
+			Errors:    map[string]string{"error": err.Error()},
+			Message:   "Gagal menghapus data pribadi",
+			Reference: "ERROR-3",
+		}, status)
+		return
+	}
+
+	helpers.ResponseSuccess(ctx, &helpers.ResponseParams[models.ErasureRecord]{Item: &record}, http.StatusOK)
+}
+
+// @Summary		List Erasures
+// @Description	API untuk melihat catatan penghapusan data pribadi yang sudah dilakukan
+// @Tags			Privacy
+// @Accept			json
+// @Produce		json
+// @Success		200	{object}	helpers.ResponseParams[models.ErasureRecord]{data=[]models.ErasureRecord}
+// @Router			/privacy/erasures [get]
+func (c *PrivacyController) Erasures(ctx *gin.Context) {
+	records, err := c.service.ErasureRecords()
+	if err != nil {
+		helpers.ResponseError(ctx, &helpers.ResponseParams[any]{
+			Errors:    map[string]string{"error": err.Error()},
+			Message:   "Gagal mendapatkan catatan penghapusan data",
+			Reference: "ERROR-3",
+		}, http.StatusInternalServerError)
+		return
+	}
+
+	helpers.ResponseSuccess(ctx, &helpers.ResponseParams[models.ErasureRecord]{Data: &records}, http.StatusOK)
+}
+
+// @Summary		Retention Policies
+// @Description	API untuk melihat berapa lama setiap kategori data disimpan. Nilai 0 berarti disimpan selamanya.
+// @Tags			Privacy
+// @Accept			json
+// @Produce		json
+// @Success		200	{object}	helpers.ResponseParams[services.RetentionPolicy]{data=[]services.RetentionPolicy}
+// @Router			/privacy/retention [get]
+func (c *PrivacyController) Retention(ctx *gin.Context) {
+	policies := c.retentionService.Policies()
+	helpers.ResponseSuccess(ctx, &helpers.ResponseParams[services.RetentionPolicy]{Data: &policies}, http.StatusOK)
+}
@@ -6,10 +6,13 @@ import (
 
 	"golang_starter_kit_2025/app/helpers"
 	"golang_starter_kit_2025/app/models"
+	"golang_starter_kit_2025/app/requests"
+	"golang_starter_kit_2025/app/responses"
 	"golang_starter_kit_2025/app/services"
 
 	"github.com/gin-gonic/gin"
 	"github.com/go-playground/validator/v10"
+	"gorm.io/gorm"
 )
 
 type RoleController struct {
@@ -108,6 +111,15 @@ type AssignPermissionsRequest struct {
 	Permissions []uint `json:"permissions"`
 }
 
+// @Summary		Replace Role Permissions
+// @Description	API untuk mengganti seluruh Permission milik Role dalam satu transaksi
+// @Tags			Role
+// @Accept			json
+// @Produce		json
+// @Param			id		path		string						true	"Role ID"
+// @Param			body	body		AssignPermissionsRequest	true	"Permission IDs"
+// @Success		200		{object}	helpers.ResponseParams[any]
+// @Router			/roles/{id}/permissions [post]
 func (c *RoleController) AssignPermissions(ctx *gin.Context) {
 	var req AssignPermissionsRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
@@ -118,13 +130,45 @@ func (c *RoleController) AssignPermissions(ctx *gin.Context) {
 	roleId := ctx.Param("id")
 	err := c.service.AssignPermissionsToRole(roleId, req.Permissions)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		ctx.JSON(assignmentErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
 	ctx.JSON(http.StatusOK, gin.H{"message": "Permissions assigned to role"})
 }
 
+// @Summary		Update Role Permissions
+// @Description	API untuk menambah dan mencabut Permission tertentu dari Role tanpa mengubah Permission lainnya
+// @Tags			Role
+// @Accept			json
+// @Produce		json
+// @Param			id		path		string									true	"Role ID"
+// @Param			body	body		requests.RoleRequestUpdatePermissions	true	"Permission IDs to add and remove"
+// @Success		200		{object}	helpers.ResponseParams[any]
+// @Router			/roles/{id}/permissions [patch]
+func (c *RoleController) UpdatePermissions(ctx *gin.Context) {
+	var req requests.RoleRequestUpdatePermissions
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		helpers.ResponseError(ctx, &helpers.ResponseParams[any]{
+			Errors:    map[string]string{"error": err.Error()},
+			Message:   "Parameter tidak valid",
+			Reference: "ERROR-4",
+		}, http.StatusBadRequest)
+		return
+	}
+
+	if err := c.service.UpdateRolePermissions(ctx.Param("id"), req.Add, req.Remove); err != nil {
+		helpers.ResponseError(ctx, &helpers.ResponseParams[any]{
+			Errors:    map[string]string{"error": err.Error()},
+			Message:   "Gagal mengubah Permission Role",
+			Reference: "ERROR-3",
+		}, assignmentErrorStatus(err))
+		return
+	}
+
+	helpers.ResponseSuccess(ctx, &helpers.ResponseParams[any]{Message: "Permission Role berhasil diubah"}, http.StatusOK)
+}
+
 func (c *RoleController) GetPermissions(ctx *gin.Context) {
 	roleId := ctx.Param("id")
 	permissions, err := c.service.GetPermissionsByRoleId(roleId)
@@ -134,3 +178,69 @@ func (c *RoleController) GetPermissions(ctx *gin.Context) {
 	}
 	ctx.JSON(http.StatusOK, permissions)
 }
+
+// @Summary		Get Role Permission Matrix
+// @Description	API untuk mendapatkan matriks Role × Permission
+// @Tags			Role
+// @Accept			json
+// @Produce		json
+// @Success		200	{object}	helpers.ResponseParams[responses.PermissionMatrix]{item=responses.PermissionMatrix}
+// @Router			/roles/matrix [get]
+func (c *RoleController) GetMatrix(ctx *gin.Context) {
+	matrix, err := c.service.GetPermissionMatrix()
+	if err != nil {
+		helpers.ResponseError(ctx, &helpers.ResponseParams[any]{
+			Errors:    map[string]string{"error": err.Error()},
+			Message:   "Gagal mendapatkan matriks Permission",
+			Reference: "ERROR-3",
+		}, http.StatusInternalServerError)
+		return
+	}
+
+	helpers.ResponseSuccess(ctx, &helpers.ResponseParams[responses.PermissionMatrix]{Item: matrix}, http.StatusOK)
+}
+
+// @Summary		Update Role Permission Matrix
+// @Description	API untuk mengganti Permission beberapa Role sekaligus dalam satu transaksi. Role yang tidak dikirim tidak diubah.
+// @Tags			Role
+// @Accept			json
+// @Produce		json
+// @Param			body	body		requests.RoleRequestPermissionMatrix	true	"Permission IDs per Role ID"
+// @Success		200		{object}	helpers.ResponseParams[responses.PermissionMatrix]{item=responses.PermissionMatrix}
+// @Router			/roles/matrix [put]
+func (c *RoleController) PutMatrix(ctx *gin.Context) {
+	var req requests.RoleRequestPermissionMatrix
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		helpers.ResponseError(ctx, &helpers.ResponseParams[any]{
+			Errors:    map[string]string{"error": err.Error()},
+			Message:   "Parameter tidak valid",
+			Reference: "ERROR-4",
+		}, http.StatusBadRequest)
+		return
+	}
+
+	if err := c.service.UpdatePermissionMatrix(req.Grants); err != nil {
+		helpers.ResponseError(ctx, &helpers.ResponseParams[any]{
+			Errors:    map[string]string{"error": err.Error()},
+			Message:   "Gagal mengubah matriks Permission",
+			Reference: "ERROR-3",
+		}, assignmentErrorStatus(err))
+		return
+	}
+
+	c.GetMatrix(ctx)
+}
+
+// assignmentErrorStatus maps role/permission assignment errors to an HTTP status
+func assignmentErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrInvalidPermissionIds),
+		errors.Is(err, services.ErrInvalidRoleIds),
+		errors.Is(err, services.ErrConflictingIds):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
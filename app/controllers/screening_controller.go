@@ -0,0 +1,133 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"golang_starter_kit_2025/app/helpers"
+	"golang_starter_kit_2025/app/models"
+	"golang_starter_kit_2025/app/requests"
+	"golang_starter_kit_2025/app/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+type ScreeningController struct {
+	service services.ScreeningService
+}
+
+func NewScreeningController(service services.ScreeningService) *ScreeningController {
+	return &ScreeningController{service: service}
+}
+
+// @Summary		List Watchlists
+// @Description	API untuk melihat daftar sanksi/PEP yang sudah diimpor beserta versi aktifnya
+// @Tags			Screening
+// @Accept			json
+// @Produce		json
+// @Success		200	{object}	helpers.ResponseParams[models.Watchlist]{data=[]models.Watchlist}
+// @Router			/screening/watchlists [get]
+func (c *ScreeningController) Watchlists(ctx *gin.Context) {
+	lists, err := c.service.Watchlists()
+	if err != nil {
+		helpers.ResponseError(ctx, &helpers.ResponseParams[any]{
+			Errors:    map[string]string{"error": err.Error()},
+			Message:   "Gagal mendapatkan watchlist",
+			Reference: "ERROR-3",
+		}, http.StatusInternalServerError)
+		return
+	}
+
+	helpers.ResponseSuccess(ctx, &helpers.ResponseParams[models.Watchlist]{Data: &lists}, http.StatusOK)
+}
+
+// @Summary		List Screening Hits
+// @Description	API untuk melihat kecocokan screening terakhir setiap user terhadap watchlist
+// @Tags			Screening
+// @Accept			json
+// @Produce		json
+// @Param			status	query		string	false	"Status (open, confirmed, cleared). Default open"
+// @Success		200		{object}	helpers.ResponseParams[models.ScreeningHit]{data=[]models.ScreeningHit}
+// @Router			/screening/hits [get]
+func (c *ScreeningController) Hits(ctx *gin.Context) {
+	hits, err := c.service.Hits(ctx.Query("status"))
+	if err != nil {
+		helpers.ResponseError(ctx, &helpers.ResponseParams[any]{
+			Errors:    map[string]string{"error": err.Error()},
+			Message:   "Gagal mendapatkan hasil screening",
+			Reference: "ERROR-3",
+		}, http.StatusInternalServerError)
+		return
+	}
+
+	helpers.ResponseSuccess(ctx, &helpers.ResponseParams[models.ScreeningHit]{Data: &hits}, http.StatusOK)
+}
+
+// @Summary		User Screening Results
+// @Description	API untuk melihat riwayat screening seorang user beserta kecocokannya
+// @Tags			Screening
+// @Accept			json
+// @Produce		json
+// @Param			id	path		string	true	"User ID"
+// @Success		200	{object}	helpers.ResponseParams[models.ScreeningResult]{data=[]models.ScreeningResult}
+// @Router			/screening/users/{id} [get]
+func (c *ScreeningController) UserResults(ctx *gin.Context) {
+	results, err := c.service.Results(ctx.Param("id"))
+	if err != nil {
+		helpers.ResponseError(ctx, &helpers.ResponseParams[any]{
+			Errors:    map[string]string{"error": err.Error()},
+			Message:   "Gagal mendapatkan hasil screening",
+			Reference: "ERROR-3",
+		}, http.StatusInternalServerError)
+		return
+	}
+
+	helpers.ResponseSuccess(ctx, &helpers.ResponseParams[models.ScreeningResult]{Data: &results}, http.StatusOK)
+}
+
+// @Summary		Resolve Screening Hit
+// @Description	API untuk mengonfirmasi atau menghapus kecocokan screening. Keputusan berlaku juga pada screening ulang berikutnya.
+// @Tags			Screening
+// @Accept			json
+// @Produce		json
+// @Param			id		path		string								true	"Screening Hit ID"
+// @Param			body	body		requests.ScreeningRequestResolveHit	true	"Keputusan reviewer"
+// @Success		200		{object}	helpers.ResponseParams[models.ScreeningHit]{item=models.ScreeningHit}
+// @Router			/screening/hits/{id}/resolve [post]
+func (c *ScreeningController) ResolveHit(ctx *gin.Context) {
+	var req requests.ScreeningRequestResolveHit
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		var verr validator.ValidationErrors
+		if errors.As(err, &verr) {
+			helpers.ResponseError(ctx, &helpers.ResponseParams[any]{
+				Errors:    helpers.ValidationError(verr),
+				Message:   "Parameter tidak valid",
+				Reference: "ERROR-4",
+			}, http.StatusBadRequest)
+			return
+		}
+		helpers.ResponseError(ctx, &helpers.ResponseParams[any]{
+			Errors:    map[string]string{"error": err.Error()},
+			Message:   "Parameter tidak valid",
+			Reference: "ERROR-4",
+		}, http.StatusBadRequest)
+		return
+	}
+
+	hit, err := c.service.ResolveHit(ctx.Param("id"), ctx.GetUint("user_id"), models.ScreeningHitStatus(req.Status), req.Note)
+	if err != nil {
+		status := kycErrorStatus(err)
+		if errors.Is(err, services.ErrScreeningHitInvalidStatus) {
+			status = http.StatusBadRequest
+		}
+		helpers.ResponseError(ctx, &helpers.ResponseParams[any]{
+			Errors:    map[string]string{"error": err.Error()},
+			Message:   "Gagal memproses hasil screening",
+			Reference: "ERROR-3",
+		}, status)
+		return
+	}
+
+	helpers.ResponseSuccess(ctx, &helpers.ResponseParams[models.ScreeningHit]{Item: &hit}, http.StatusOK)
+}
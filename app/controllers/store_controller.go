@@ -0,0 +1,422 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"golang_starter_kit_2025/app/helpers"
+	"golang_starter_kit_2025/app/models"
+	"golang_starter_kit_2025/app/requests"
+	"golang_starter_kit_2025/app/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+type StoreController struct {
+	service       services.StoreService
+	uploadService services.UploadService
+}
+
+func NewStoreController(service services.StoreService) *StoreController {
+	return &StoreController{service: service}
+}
+
+// @Summary		Create Store
+// @Description	API untuk mendaftarkan toko milik user yang sedang login. Verifikasi usaha (KYB) dimulai dalam status draft.
+// @Tags			Store
+// @Accept			json
+// @Produce		json
+// @Param			body	body		requests.StoreRequestPut	true	"Data toko"
+// @Success		201		{object}	helpers.ResponseParams[models.Store]{item=models.Store}
+// @Router			/stores [post]
+func (c *StoreController) Create(ctx *gin.Context) {
+	data, ok := bindStore(ctx)
+	if !ok {
+		return
+	}
+
+	store, err := c.service.Create(ctx.GetUint("user_id"), data)
+	if err != nil {
+		helpers.ResponseError(ctx, &helpers.ResponseParams[any]{
+			Errors:    map[string]string{"error": err.Error()},
+			Message:   "Gagal membuat toko",
+			Reference: "ERROR-3",
+		}, storeErrorStatus(err))
+		return
+	}
+
+	helpers.ResponseSuccess(ctx, &helpers.ResponseParams[models.Store]{Item: &store}, http.StatusCreated)
+}
+
+// @Summary		My Stores
+// @Description	API untuk melihat toko milik user yang sedang login
+// @Tags			Store
+// @Accept			json
+// @Produce		json
+// @Success		200	{object}	helpers.ResponseParams[models.Store]{data=[]models.Store}
+// @Router			/stores/mine [get]
+func (c *StoreController) Mine(ctx *gin.Context) {
+	stores, err := c.service.GetMine(ctx.GetUint("user_id"))
+	if err != nil {
+		helpers.ResponseError(ctx, &helpers.ResponseParams[any]{
+			Errors:    map[string]string{"error": err.Error()},
+			Message:   "Gagal mendapatkan toko",
+			Reference: "ERROR-3",
+		}, http.StatusInternalServerError)
+		return
+	}
+
+	helpers.ResponseSuccess(ctx, &helpers.ResponseParams[models.Store]{Data: &stores}, http.StatusOK)
+}
+
+// @Summary		Get My Store
+// @Description	API untuk melihat detail, dokumen dan riwayat verifikasi toko milik user yang sedang login
+// @Tags			Store
+// @Accept			json
+// @Produce		json
+// @Param			id	path		string	true	"Store ID"
+// @Success		200	{object}	helpers.ResponseParams[models.Store]{item=models.Store}
+// @Router			/stores/mine/{id} [get]
+func (c *StoreController) GetMine(ctx *gin.Context) {
+	store, err := c.service.FindMine(ctx.GetUint("user_id"), ctx.Param("id"))
+	if err != nil {
+		helpers.ResponseError(ctx, &helpers.ResponseParams[any]{
+			Errors:    map[string]string{"error": err.Error()},
+			Message:   "Toko tidak ditemukan",
+			Reference: "ERROR-3",
+		}, storeErrorStatus(err))
+		return
+	}
+
+	helpers.ResponseSuccess(ctx, &helpers.ResponseParams[models.Store]{Item: &store}, http.StatusOK)
+}
+
+// @Summary		Update My Store
+// @Description	API untuk mengubah data toko yang masih draft atau diminta diajukan ulang
+// @Tags			Store
+// @Accept			json
+// @Produce		json
+// @Param			id		path		string						true	"Store ID"
+// @Param			body	body		requests.StoreRequestPut	true	"Data toko"
+// @Success		200		{object}	helpers.ResponseParams[models.Store]{item=models.Store}
+// @Router			/stores/mine/{id} [put]
+func (c *StoreController) UpdateMine(ctx *gin.Context) {
+	data, ok := bindStore(ctx)
+	if !ok {
+		return
+	}
+
+	store, err := c.service.UpdateMine(ctx.GetUint("user_id"), ctx.Param("id"), data)
+	if err != nil {
+		helpers.ResponseError(ctx, &helpers.ResponseParams[any]{
+			Errors:    map[string]string{"error": err.Error()},
+			Message:   "Gagal memperbarui toko",
+			Reference: "ERROR-3",
+		}, storeErrorStatus(err))
+		return
+	}
+
+	helpers.ResponseSuccess(ctx, &helpers.ResponseParams[models.Store]{Item: &store}, http.StatusOK)
+}
+
+// @Summary		Delete My Store
+// @Description	API untuk menghapus toko milik user yang sedang login, kecuali saat verifikasinya sedang menunggu atau dalam peninjauan
+// @Tags			Store
+// @Accept			json
+// @Produce		json
+// @Param			id	path		string	true	"Store ID"
+// @Success		200	{object}	helpers.ResponseParams[any]
+// @Router			/stores/mine/{id} [delete]
+func (c *StoreController) DeleteMine(ctx *gin.Context) {
+	if err := c.service.DeleteMine(ctx.GetUint("user_id"), ctx.Param("id")); err != nil {
+		helpers.ResponseError(ctx, &helpers.ResponseParams[any]{
+			Errors:    map[string]string{"error": err.Error()},
+			Message:   "Gagal menghapus toko",
+			Reference: "ERROR-3",
+		}, storeErrorStatus(err))
+		return
+	}
+
+	helpers.ResponseSuccess(ctx, &helpers.ResponseParams[any]{Message: "Toko berhasil dihapus"}, http.StatusOK)
+}
+
+// @Summary		Upload Store Document
+// @Description	API untuk mengunggah dokumen usaha (NIB/izin usaha, NPWP, akta pendirian atau foto tempat usaha) berupa gambar atau PDF, sebagai JSON base64, upload_id dari upload resumable (/uploads) atau multipart form dengan field file
+// @Tags			Store
+// @Accept			json,mpfd
+// @Produce		json
+// @Param			id		path		string								true	"Store ID"
+// @Param			body	body		requests.StoreRequestUploadDocument	true	"Dokumen"
+// @Success		201		{object}	helpers.ResponseParams[models.StoreDocument]{item=models.StoreDocument}
+// @Router			/stores/mine/{id}/documents [post]
+func (c *StoreController) UploadDocument(ctx *gin.Context) {
+	var req requests.StoreRequestUploadDocument
+	if err := ctx.ShouldBind(&req); err != nil {
+		var verr validator.ValidationErrors
+		if errors.As(err, &verr) {
+			helpers.ResponseError(ctx, &helpers.ResponseParams[any]{
+				Errors:    helpers.ValidationError(verr),
+				Message:   "Parameter tidak valid",
+				Reference: "ERROR-4",
+			}, http.StatusBadRequest)
+			return
+		}
+		helpers.ResponseError(ctx, &helpers.ResponseParams[any]{
+			Errors:    map[string]string{"error": err.Error()},
+			Message:   "Parameter tidak valid",
+			Reference: "ERROR-4",
+		}, http.StatusBadRequest)
+		return
+	}
+
+	var input services.UploadInput
+	var err error
+	switch {
+	case req.UploadID != "":
+		input, err = c.uploadService.Input(ctx.GetUint("user_id"), req.UploadID, models.UploadPurposeStoreDocument)
+	case strings.HasPrefix(ctx.ContentType(), "multipart/"):
+		input, err = readFormFile(ctx, "file", services.UploadPurposes[models.UploadPurposeStoreDocument].MaxSize)
+	case req.File == "":
+		err = errors.New("file is required")
+	default:
+		input.Content, input.DeclaredType, err = helpers.DecodeDataURI(req.File)
+	}
+	if err != nil {
+		status := http.StatusBadRequest
+		if req.UploadID != "" {
+			status = uploadErrorStatus(err)
+		}
+		helpers.ResponseError(ctx, &helpers.ResponseParams[any]{
+			Errors:    map[string]string{"file": err.Error()},
+			Message:   "File tidak valid",
+			Reference: "ERROR-4",
+		}, status)
+		return
+	}
+
+	document, err := c.service.UploadDocument(ctx.GetUint("user_id"), ctx.Param("id"), models.StoreDocumentType(req.Type), input)
+	if err != nil {
+		helpers.ResponseError(ctx, &helpers.ResponseParams[any]{
+			Errors:    map[string]string{"error": err.Error()},
+			Message:   "Gagal mengunggah dokumen toko",
+			Reference: "ERROR-3",
+		}, storeErrorStatus(err))
+		return
+	}
+
+	// the upload has been stored as the document
+	if req.UploadID != "" {
+		c.uploadService.Terminate(ctx.GetUint("user_id"), req.UploadID)
+	}
+
+	helpers.ResponseSuccess(ctx, &helpers.ResponseParams[models.StoreDocument]{Item: &document}, http.StatusCreated)
+}
+
+// @Summary		Submit Store Verification
+// @Description	API untuk mengajukan verifikasi usaha (KYB) setelah data dan dokumen toko lengkap. Pemilik toko harus sudah lolos KYC.
+// @Tags			Store
+// @Accept			json
+// @Produce		json
+// @Param			id	path		string	true	"Store ID"
+// @Success		200	{object}	helpers.ResponseParams[models.Store]{item=models.Store}
+// @Router			/stores/mine/{id}/submit [post]
+func (c *StoreController) Submit(ctx *gin.Context) {
+	store, err := c.service.Submit(ctx.GetUint("user_id"), ctx.Param("id"))
+	if err != nil {
+		helpers.ResponseError(ctx, &helpers.ResponseParams[any]{
+			Errors:    map[string]string{"error": err.Error()},
+			Message:   "Gagal mengajukan verifikasi toko",
+			Reference: "ERROR-3",
+		}, storeErrorStatus(err))
+		return
+	}
+
+	helpers.ResponseSuccess(ctx, &helpers.ResponseParams[models.Store]{Item: &store}, http.StatusOK)
+}
+
+// @Summary		List Stores
+// @Description	API untuk reviewer melihat toko dan status verifikasinya
+// @Tags			Store
+// @Accept			json
+// @Produce		json
+// @Param			status	query		string	false	"Status (draft, submitted, in_review, approved, rejected, resubmission_required)"
+// @Success		200		{object}	helpers.ResponseParams[models.Store]{data=[]models.Store}
+// @Router			/stores [get]
+func (c *StoreController) List(ctx *gin.Context) {
+	stores, err := c.service.GetAll(ctx.Query("status"))
+	if err != nil {
+		helpers.ResponseError(ctx, &helpers.ResponseParams[any]{
+			Errors:    map[string]string{"error": err.Error()},
+			Message:   "Gagal mendapatkan toko",
+			Reference: "ERROR-3",
+		}, http.StatusInternalServerError)
+		return
+	}
+
+	helpers.ResponseSuccess(ctx, &helpers.ResponseParams[models.Store]{Data: &stores}, http.StatusOK)
+}
+
+// @Summary		Get Store
+// @Description	API untuk reviewer melihat detail, dokumen dan riwayat verifikasi toko
+// @Tags			Store
+// @Accept			json
+// @Produce		json
+// @Param			id	path		string	true	"Store ID"
+// @Success		200	{object}	helpers.ResponseParams[models.Store]{item=models.Store}
+// @Router			/stores/{id} [get]
+func (c *StoreController) Get(ctx *gin.Context) {
+	store, err := c.service.Find(ctx.Param("id"))
+	if err != nil {
+		helpers.ResponseError(ctx, &helpers.ResponseParams[any]{
+			Errors:    map[string]string{"error": err.Error()},
+			Message:   "Toko tidak ditemukan",
+			Reference: "ERROR-3",
+		}, storeErrorStatus(err))
+		return
+	}
+
+	helpers.ResponseSuccess(ctx, &helpers.ResponseParams[models.Store]{Item: &store}, http.StatusOK)
+}
+
+// @Summary		Start Store Review
+// @Description	API untuk reviewer mengambil verifikasi toko yang sudah diajukan untuk ditinjau
+// @Tags			Store
+// @Accept			json
+// @Produce		json
+// @Param			id	path		string	true	"Store ID"
+// @Success		200	{object}	helpers.ResponseParams[models.Store]{item=models.Store}
+// @Router			/stores/{id}/review [post]
+func (c *StoreController) StartReview(ctx *gin.Context) {
+	store, err := c.service.StartReview(ctx.Param("id"), ctx.GetUint("user_id"))
+	if err != nil {
+		helpers.ResponseError(ctx, &helpers.ResponseParams[any]{
+			Errors:    map[string]string{"error": err.Error()},
+			Message:   "Gagal memulai peninjauan toko",
+			Reference: "ERROR-3",
+		}, storeErrorStatus(err))
+		return
+	}
+
+	helpers.ResponseSuccess(ctx, &helpers.ResponseParams[models.Store]{Item: &store}, http.StatusOK)
+}
+
+// @Summary		Approve Store
+// @Description	API untuk menyetujui verifikasi toko yang sedang ditinjau
+// @Tags			Store
+// @Accept			json
+// @Produce		json
+// @Param			id		path		string						true	"Store ID"
+// @Param			body	body		requests.KycRequestDecision	false	"Komentar reviewer"
+// @Success		200		{object}	helpers.ResponseParams[models.Store]{item=models.Store}
+// @Router			/stores/{id}/approve [post]
+func (c *StoreController) Approve(ctx *gin.Context) {
+	c.decide(ctx, models.KycStatusApproved)
+}
+
+// @Summary		Reject Store
+// @Description	API untuk menolak verifikasi toko yang sedang ditinjau, dengan kode alasan
+// @Tags			Store
+// @Accept			json
+// @Produce		json
+// @Param			id		path		string						true	"Store ID"
+// @Param			body	body		requests.KycRequestDecision	true	"Kode alasan dan komentar reviewer"
+// @Success		200		{object}	helpers.ResponseParams[models.Store]{item=models.Store}
+// @Router			/stores/{id}/reject [post]
+func (c *StoreController) Reject(ctx *gin.Context) {
+	c.decide(ctx, models.KycStatusRejected)
+}
+
+// @Summary		Request Store Resubmission
+// @Description	API untuk meminta pemilik toko memperbaiki dan mengajukan ulang verifikasi usaha, dengan kode alasan
+// @Tags			Store
+// @Accept			json
+// @Produce		json
+// @Param			id		path		string						true	"Store ID"
+// @Param			body	body		requests.KycRequestDecision	true	"Kode alasan dan komentar reviewer"
+// @Success		200		{object}	helpers.ResponseParams[models.Store]{item=models.Store}
+// @Router			/stores/{id}/request-resubmission [post]
+func (c *StoreController) RequestResubmission(ctx *gin.Context) {
+	c.decide(ctx, models.KycStatusResubmissionRequired)
+}
+
+func (c *StoreController) decide(ctx *gin.Context, status models.KycStatus) {
+	var req requests.KycRequestDecision
+	if ctx.Request.ContentLength > 0 {
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			helpers.ResponseError(ctx, &helpers.ResponseParams[any]{
+				Errors:    map[string]string{"error": err.Error()},
+				Message:   "Parameter tidak valid",
+				Reference: "ERROR-4",
+			}, http.StatusBadRequest)
+			return
+		}
+	}
+
+	store, err := c.service.Decide(ctx.Param("id"), ctx.GetUint("user_id"), status, models.KycReasonCode(req.ReasonCode), req.Comment)
+	if err != nil {
+		helpers.ResponseError(ctx, &helpers.ResponseParams[any]{
+			Errors:    map[string]string{"error": err.Error()},
+			Message:   "Gagal memproses verifikasi toko",
+			Reference: "ERROR-3",
+		}, storeErrorStatus(err))
+		return
+	}
+
+	helpers.ResponseSuccess(ctx, &helpers.ResponseParams[models.Store]{Item: &store}, http.StatusOK)
+}
+
+// bindStore reads the store data of a create or update request, answering
+// invalid input itself
+func bindStore(ctx *gin.Context) (models.Store, bool) {
+	var req requests.StoreRequestPut
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		var verr validator.ValidationErrors
+		if errors.As(err, &verr) {
+			helpers.ResponseError(ctx, &helpers.ResponseParams[any]{
+				Errors:    helpers.ValidationError(verr),
+				Message:   "Parameter tidak valid",
+				Reference: "ERROR-4",
+			}, http.StatusBadRequest)
+			return models.Store{}, false
+		}
+		helpers.ResponseError(ctx, &helpers.ResponseParams[any]{
+			Errors:    map[string]string{"error": err.Error()},
+			Message:   "Parameter tidak valid",
+			Reference: "ERROR-4",
+		}, http.StatusBadRequest)
+		return models.Store{}, false
+	}
+
+	return models.Store{
+		Name:         strings.TrimSpace(req.Name),
+		LegalName:    strings.TrimSpace(req.LegalName),
+		BusinessType: models.StoreBusinessType(req.BusinessType),
+		NIB:          req.NIB,
+		NPWP:         req.NPWP,
+		Phone:        req.Phone,
+		Address:      strings.TrimSpace(req.Address),
+		City:         strings.TrimSpace(req.City),
+		State:        strings.TrimSpace(req.State),
+		Country:      strings.TrimSpace(req.Country),
+		Zip:          strings.TrimSpace(req.Zip),
+	}, true
+}
+
+func storeErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrKybSelfReview):
+		return http.StatusForbidden
+	case errors.Is(err, services.ErrStoreNotEditable),
+		errors.Is(err, services.ErrStoreUnderReview),
+		errors.Is(err, services.ErrKybInvalidTransition):
+		return http.StatusConflict
+	case errors.Is(err, services.ErrKybIncomplete),
+		errors.Is(err, services.ErrKybInvalidIdentifier),
+		errors.Is(err, services.ErrKybOwnerNotVerified):
+		return http.StatusBadRequest
+	default:
+		return kycErrorStatus(err)
+	}
+}
@@ -0,0 +1,251 @@
+package controllers
+
+import (
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"golang_starter_kit_2025/app/helpers"
+	"golang_starter_kit_2025/app/models"
+	"golang_starter_kit_2025/app/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// tusVersion is the version of the tus resumable upload protocol implemented
+// by UploadController, see https://tus.io/protocols/resumable-upload
+const tusVersion = "1.0.0"
+
+type UploadController struct {
+	service services.UploadService
+}
+
+func NewUploadController(service services.UploadService) *UploadController {
+	return &UploadController{service: service}
+}
+
+// @Summary		Upload Capabilities
+// @Description	API untuk mengetahui versi, ekstensi dan ukuran maksimum protokol upload tus
+// @Tags			Upload
+// @Success		204
+// @Header			204	{string}	Tus-Version		"Versi tus yang didukung"
+// @Header			204	{string}	Tus-Extension	"Ekstensi tus yang didukung"
+// @Header			204	{integer}	Tus-Max-Size	"Ukuran upload maksimum"
+// @Router			/uploads [options]
+func (c *UploadController) Options(ctx *gin.Context) {
+	ctx.Header("Tus-Resumable", tusVersion)
+	ctx.Header("Tus-Version", tusVersion)
+	ctx.Header("Tus-Extension", "creation,termination,expiration")
+	ctx.Header("Tus-Max-Size", strconv.FormatInt(services.UploadMaxSize(), 10))
+	ctx.Status(http.StatusNoContent)
+}
+
+// @Summary		Create Upload
+// @Description	API untuk memulai upload resumable (tus). Upload-Metadata wajib berisi purpose (kyc_document atau store_document) dan boleh berisi filename serta filetype.
+// @Tags			Upload
+// @Param			Tus-Resumable	header	string	true	"1.0.0"
+// @Param			Upload-Length	header	integer	true	"Ukuran file dalam byte"
+// @Param			Upload-Metadata	header	string	true	"purpose a3ljX2RvY3VtZW50,filename a3RwLmpwZw=="
+// @Success		201
+// @Header			201	{string}	Location		"URL upload"
+// @Header			201	{string}	Upload-Expires	"Waktu kadaluarsa upload"
+// @Router			/uploads [post]
+func (c *UploadController) Create(ctx *gin.Context) {
+	if !c.checkVersion(ctx) {
+		return
+	}
+
+	length, err := strconv.ParseInt(ctx.GetHeader("Upload-Length"), 10, 64)
+	if err != nil || length < 0 {
+		c.error(ctx, errors.New("Upload-Length header is required"), http.StatusBadRequest)
+		return
+	}
+	metadata, err := parseUploadMetadata(ctx.GetHeader("Upload-Metadata"))
+	if err != nil {
+		c.error(ctx, err, http.StatusBadRequest)
+		return
+	}
+	purpose := models.UploadPurpose(metadata["purpose"])
+	delete(metadata, "purpose")
+
+	upload, err := c.service.Create(ctx.GetUint("user_id"), purpose, length, metadata)
+	if err != nil {
+		c.error(ctx, err, uploadErrorStatus(err))
+		return
+	}
+
+	ctx.Header("Location", strings.TrimSuffix(ctx.Request.URL.Path, "/")+"/"+upload.Reference)
+	ctx.Header("Upload-Expires", upload.ExpiresAt.UTC().Format(http.TimeFormat))
+	ctx.Status(http.StatusCreated)
+}
+
+// @Summary		Upload Offset
+// @Description	API untuk mengetahui jumlah byte yang sudah diterima, agar upload yang terputus bisa dilanjutkan
+// @Tags			Upload
+// @Param			id				path	string	true	"Upload ID"
+// @Param			Tus-Resumable	header	string	true	"1.0.0"
+// @Success		200
+// @Header			200	{integer}	Upload-Offset	"Jumlah byte yang sudah diterima"
+// @Header			200	{integer}	Upload-Length	"Ukuran file dalam byte"
+// @Router			/uploads/{id} [head]
+func (c *UploadController) Head(ctx *gin.Context) {
+	if !c.checkVersion(ctx) {
+		return
+	}
+
+	upload, err := c.service.Find(ctx.GetUint("user_id"), ctx.Param("id"))
+	if err != nil {
+		ctx.Status(uploadErrorStatus(err))
+		return
+	}
+
+	ctx.Header("Cache-Control", "no-store")
+	ctx.Header("Upload-Offset", strconv.FormatInt(upload.Offset, 10))
+	ctx.Header("Upload-Length", strconv.FormatInt(upload.Length, 10))
+	ctx.Header("Upload-Expires", upload.ExpiresAt.UTC().Format(http.TimeFormat))
+	ctx.Header("Upload-Metadata", encodeUploadMetadata(upload))
+	ctx.Status(http.StatusOK)
+}
+
+// @Summary		Upload Chunk
+// @Description	API untuk mengirim potongan file mulai dari Upload-Offset. Bila koneksi terputus, byte yang sudah diterima tetap disimpan.
+// @Tags			Upload
+// @Accept			application/offset+octet-stream
+// @Param			id				path	string	true	"Upload ID"
+// @Param			Tus-Resumable	header	string	true	"1.0.0"
+// @Param			Upload-Offset	header	integer	true	"Offset potongan"
+// @Success		204
+// @Header			204	{integer}	Upload-Offset	"Jumlah byte yang sudah diterima"
+// @Router			/uploads/{id} [patch]
+func (c *UploadController) Patch(ctx *gin.Context) {
+	if !c.checkVersion(ctx) {
+		return
+	}
+	if ctx.ContentType() != "application/offset+octet-stream" {
+		c.error(ctx, errors.New("Content-Type must be application/offset+octet-stream"), http.StatusUnsupportedMediaType)
+		return
+	}
+	offset, err := strconv.ParseInt(ctx.GetHeader("Upload-Offset"), 10, 64)
+	if err != nil || offset < 0 {
+		c.error(ctx, errors.New("Upload-Offset header is required"), http.StatusBadRequest)
+		return
+	}
+
+	upload, err := c.service.WriteChunk(ctx.GetUint("user_id"), ctx.Param("id"), offset, ctx.Request.Body)
+	if err != nil {
+		c.error(ctx, err, uploadErrorStatus(err))
+		return
+	}
+
+	ctx.Header("Upload-Offset", strconv.FormatInt(upload.Offset, 10))
+	ctx.Header("Upload-Expires", upload.ExpiresAt.UTC().Format(http.TimeFormat))
+	ctx.Status(http.StatusNoContent)
+}
+
+// @Summary		Get Upload
+// @Description	API untuk melihat status upload, termasuk tipe file yang terdeteksi setelah upload selesai
+// @Tags			Upload
+// @Produce		json
+// @Param			id	path		string	true	"Upload ID"
+// @Success		200	{object}	helpers.ResponseParams[models.Upload]{item=models.Upload}
+// @Router			/uploads/{id} [get]
+func (c *UploadController) Get(ctx *gin.Context) {
+	upload, err := c.service.Find(ctx.GetUint("user_id"), ctx.Param("id"))
+	if err != nil {
+		helpers.ResponseError(ctx, &helpers.ResponseParams[any]{
+			Errors:    map[string]string{"error": err.Error()},
+			Message:   "Upload tidak ditemukan",
+			Reference: "ERROR-3",
+		}, uploadErrorStatus(err))
+		return
+	}
+
+	helpers.ResponseSuccess(ctx, &helpers.ResponseParams[models.Upload]{Item: &upload}, http.StatusOK)
+}
+
+// @Summary		Terminate Upload
+// @Description	API untuk membatalkan upload dan menghapus semua byte yang sudah diterima
+// @Tags			Upload
+// @Param			id				path	string	true	"Upload ID"
+// @Param			Tus-Resumable	header	string	true	"1.0.0"
+// @Success		204
+// @Router			/uploads/{id} [delete]
+func (c *UploadController) Terminate(ctx *gin.Context) {
+	if !c.checkVersion(ctx) {
+		return
+	}
+
+	if err := c.service.Terminate(ctx.GetUint("user_id"), ctx.Param("id")); err != nil {
+		c.error(ctx, err, uploadErrorStatus(err))
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
+
+// checkVersion answers every tus request with the protocol version and
+// refuses clients speaking another version
+func (c *UploadController) checkVersion(ctx *gin.Context) bool {
+	ctx.Header("Tus-Resumable", tusVersion)
+	if ctx.GetHeader("Tus-Resumable") != tusVersion {
+		ctx.Header("Tus-Version", tusVersion)
+		c.error(ctx, errors.New("unsupported tus version"), http.StatusPreconditionFailed)
+		return false
+	}
+	return true
+}
+
+func (c *UploadController) error(ctx *gin.Context, err error, status int) {
+	helpers.ResponseError(ctx, &helpers.ResponseParams[any]{
+		Errors:    map[string]string{"error": err.Error()},
+		Message:   "Upload gagal",
+		Reference: "ERROR-4",
+	}, status)
+}
+
+// parseUploadMetadata decodes the Upload-Metadata header: comma separated
+// pairs of a key and an optional base64 value
+func parseUploadMetadata(header string) (map[string]string, error) {
+	metadata := make(map[string]string)
+	for _, pair := range strings.Split(header, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		key, encoded, _ := strings.Cut(pair, " ")
+		value, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, errors.New("Upload-Metadata values must be base64 encoded")
+		}
+		metadata[key] = string(value)
+	}
+	return metadata, nil
+}
+
+func encodeUploadMetadata(upload models.Upload) string {
+	pairs := []string{"purpose " + base64.StdEncoding.EncodeToString([]byte(upload.Purpose))}
+	for key, value := range upload.Metadata {
+		pairs = append(pairs, key+" "+base64.StdEncoding.EncodeToString([]byte(value)))
+	}
+	return strings.Join(pairs, ",")
+}
+
+func uploadErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrUploadNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrUploadExpired):
+		return http.StatusGone
+	case errors.Is(err, services.ErrUploadTooLarge):
+		return http.StatusRequestEntityTooLarge
+	case errors.Is(err, services.ErrUploadOffsetMismatch),
+		errors.Is(err, services.ErrUploadIncomplete):
+		return http.StatusConflict
+	case errors.Is(err, services.ErrUploadUnknownPurpose),
+		errors.Is(err, services.ErrUploadWrongPurpose):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
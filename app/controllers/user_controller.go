@@ -1,12 +1,15 @@
 package controllers
 
 import (
+	"errors"
 	"net/http"
 
 	"golang_starter_kit_2025/app/models"
+	"golang_starter_kit_2025/app/requests"
 	"golang_starter_kit_2025/app/services"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 type UserController struct {
@@ -92,6 +95,14 @@ type AssignRolesRequest struct {
 	Roles []uint `json:"roles"`
 }
 
+// @Summary	Replace user roles
+// @Tags		users
+// @Accept		json
+// @Produce	json
+// @Param		id		path		string				true	"User ID"
+// @Param		body	body		AssignRolesRequest	true	"Role IDs"
+// @Success	200		{object}	map[string]string
+// @Router		/users/{id}/roles [post]
 func (c *UserController) AssignRoles(ctx *gin.Context) {
 	var req AssignRolesRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
@@ -102,18 +113,144 @@ func (c *UserController) AssignRoles(ctx *gin.Context) {
 	userId := ctx.Param("id")
 	err := c.service.AssignRolesToUser(userId, req.Roles)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		ctx.JSON(assignmentErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
 	ctx.JSON(http.StatusOK, gin.H{"message": "Roles assigned to user"})
 }
+
+// @Summary	Add and remove user roles
+// @Description	Added roles may be limited to a window with starts_at/expires_at
+// @Tags		users
+// @Accept		json
+// @Produce	json
+// @Param		id		path		string							true	"User ID"
+// @Param		body	body		requests.UserRequestUpdateRoles	true	"Role IDs to add and remove"
+// @Success	200		{object}	map[string]string
+// @Router		/users/{id}/roles [patch]
+func (c *UserController) UpdateRoles(ctx *gin.Context) {
+	var req requests.UserRequestUpdateRoles
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if req.StartsAt != nil && req.ExpiresAt != nil && !req.ExpiresAt.After(*req.StartsAt) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "expires_at must be after starts_at"})
+		return
+	}
+
+	if err := c.service.UpdateUserRoles(ctx.Param("id"), req.Add, req.Remove, req.StartsAt, req.ExpiresAt); err != nil {
+		ctx.JSON(assignmentErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "User roles updated"})
+}
+
+// @Summary	Show user roles
+// @Description	Roles of a user, marked as direct and/or inherited from groups
+// @Tags		users
+// @Accept		json
+// @Produce	json
+// @Param		id	path		string	true	"User ID"
+// @Success	200	{array}		responses.UserRole
+// @Router		/users/{id}/roles [get]
 func (c *UserController) GetRoles(ctx *gin.Context) {
 	userId := ctx.Param("id")
 	roles, err := c.service.GetRolesByUserId(userId)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		ctx.JSON(assignmentErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 	ctx.JSON(http.StatusOK, roles)
 }
+
+// @Summary	Suspend a user account
+// @Tags		users
+// @Accept		json
+// @Produce	json
+// @Param		id		path		string							true	"User ID"
+// @Param		body	body		requests.UserRequestChangeStatus	true	"Reason"
+// @Success	200		{object}	models.User
+// @Router		/users/{id}/suspend [post]
+func (c *UserController) Suspend(ctx *gin.Context) {
+	c.changeStatus(ctx, models.UserStatusSuspended)
+}
+
+// @Summary	Lock a user account
+// @Tags		users
+// @Accept		json
+// @Produce	json
+// @Param		id		path		string							true	"User ID"
+// @Param		body	body		requests.UserRequestChangeStatus	true	"Reason"
+// @Success	200		{object}	models.User
+// @Router		/users/{id}/lock [post]
+func (c *UserController) Lock(ctx *gin.Context) {
+	c.changeStatus(ctx, models.UserStatusLocked)
+}
+
+// @Summary	Reactivate a user account
+// @Tags		users
+// @Accept		json
+// @Produce	json
+// @Param		id		path		string							true	"User ID"
+// @Param		body	body		requests.UserRequestChangeStatus	true	"Reason"
+// @Success	200		{object}	models.User
+// @Router		/users/{id}/reactivate [post]
+func (c *UserController) Reactivate(ctx *gin.Context) {
+	c.changeStatus(ctx, models.UserStatusActive)
+}
+
+// @Summary	Close a user account
+// @Tags		users
+// @Accept		json
+// @Produce	json
+// @Param		id		path		string							true	"User ID"
+// @Param		body	body		requests.UserRequestChangeStatus	true	"Reason"
+// @Success	200		{object}	models.User
+// @Router		/users/{id}/close [post]
+func (c *UserController) Close(ctx *gin.Context) {
+	c.changeStatus(ctx, models.UserStatusClosed)
+}
+
+// @Summary	Show the status history of a user account
+// @Tags		users
+// @Accept		json
+// @Produce	json
+// @Param		id	path		string	true	"User ID"
+// @Success	200	{array}		models.UserStatusHistory
+// @Router		/users/{id}/status-history [get]
+func (c *UserController) StatusHistory(ctx *gin.Context) {
+	history, err := c.service.GetStatusHistory(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, history)
+}
+
+func (c *UserController) changeStatus(ctx *gin.Context, status models.UserStatus) {
+	var req requests.UserRequestChangeStatus
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := c.service.ChangeStatus(ctx.Param("id"), status, req.Reason, ctx.GetUint("user_id"))
+	if err != nil {
+		code := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			code = http.StatusNotFound
+		case errors.Is(err, services.ErrInvalidStatusTransition):
+			code = http.StatusConflict
+		case errors.Is(err, services.ErrCannotChangeOwnStatus):
+			code = http.StatusForbidden
+		}
+		ctx.JSON(code, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, user)
+}
@@ -0,0 +1,13 @@
+package database_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestDatabaseSuite(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Database Test Suite")
+}
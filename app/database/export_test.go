@@ -0,0 +1,21 @@
+package database
+
+import "golang_starter_kit_2025/database"
+
+// MigrateAll runs the pending migrations of conn under the migration lock
+func MigrateAll(conn *database.Connection) error {
+	return withMigrationLock(conn, func(m *migrator) error {
+		if err := m.ensureMigrationsTable(); err != nil {
+			return err
+		}
+		return m.migrateAll()
+	})
+}
+
+// SplitMigrationName exposes splitMigrationName to the tests
+var SplitMigrationName = splitMigrationName
+
+// MigrationFiles returns the migration files conn would run
+func MigrationFiles(conn *database.Connection) (map[string]string, error) {
+	return (&migrator{conn: conn, db: conn.DB}).migrationFiles()
+}
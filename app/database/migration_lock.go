@@ -0,0 +1,194 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"strings"
+	"time"
+
+	"golang_starter_kit_2025/app/helpers"
+	"golang_starter_kit_2025/config"
+	"golang_starter_kit_2025/database"
+
+	"gorm.io/gorm"
+)
+
+// migrationLockName identifies the lock held while migrations run
+const migrationLockName = "golang_starter_kit_migrations"
+
+// migrationLockPoll is how often a waiting instance retries the lock
+const migrationLockPoll = 500 * time.Millisecond
+
+var ErrMigrationLocked = errors.New("migrasi sedang dijalankan oleh instance lain")
+
+// MigrationError tells which statement of a migration failed
+type MigrationError struct {
+	Migration string
+	// Statement is the position of the failed statement, starting at 1
+	Statement int
+	Total     int
+	SQL       string
+	// RolledBack is set when the statements before the failed one were
+	// rolled back together with it
+	RolledBack bool
+	Err        error
+}
+
+func (e *MigrationError) Error() string {
+	state := "seluruh migrasi di-rollback"
+	if !e.RolledBack {
+		state = "belum ada statement yang dijalankan"
+		if e.Statement > 1 {
+			state = fmt.Sprintf("statement 1-%d sudah dijalankan dan tidak bisa di-rollback, perbaiki database secara manual", e.Statement-1)
+		}
+	}
+	return fmt.Sprintf("migrasi %s gagal pada statement %d dari %d: %v\n%s\n(%s)", e.Migration, e.Statement, e.Total, e.Err, e.SQL, state)
+}
+
+func (e *MigrationError) Unwrap() error {
+	return e.Err
+}
+
+// migrator runs migrations on the one connection of the pool that holds the
+// migration lock
+type migrator struct {
+	conn *database.Connection
+	db   *gorm.DB
+}
+
+// withMigrationLock runs fn while holding a lock on the database, so that
+// instances started together do not run the same migrations twice; the
+// others wait up to MIGRATION_LOCK_TIMEOUT_SECONDS and then find nothing left
+// to do. Advisory locks belong to a database session, so fn gets a migrator
+// bound to the session that took the lock.
+func withMigrationLock(conn *database.Connection, fn func(m *migrator) error) (err error) {
+	ctx := context.Background()
+	sqlConn, err := conn.SqlDB.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("gagal membuka koneksi migrasi: %v", err)
+	}
+	defer sqlConn.Close()
+
+	db := conn.DB.Session(&gorm.Session{NewDB: true, Context: ctx})
+	db.Statement.ConnPool = sqlConn
+	m := &migrator{conn: conn, db: db}
+
+	timeout := time.Duration(helpers.GetEnvInt("MIGRATION_LOCK_TIMEOUT_SECONDS", 300)) * time.Second
+	if err := m.lock(timeout); err != nil {
+		return err
+	}
+	defer func() {
+		if unlockErr := m.unlock(); unlockErr != nil && err == nil {
+			err = fmt.Errorf("gagal melepas lock migrasi: %v", unlockErr)
+		}
+	}()
+	return fn(m)
+}
+
+func (m *migrator) lock(timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	waiting := false
+	for {
+		locked, err := m.tryLock()
+		if err != nil {
+			return fmt.Errorf("gagal mengambil lock migrasi: %v", err)
+		}
+		if locked {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("%w (menunggu %s)", ErrMigrationLocked, timeout)
+		}
+		if !waiting {
+			fmt.Println("Menunggu migrasi instance lain selesai...")
+			waiting = true
+		}
+		time.Sleep(migrationLockPoll)
+	}
+}
+
+func (m *migrator) tryLock() (bool, error) {
+	switch m.conn.GetType() {
+	case config.PostgreSQL:
+		var locked bool
+		err := m.db.Raw("SELECT pg_try_advisory_lock(?)", migrationLockKey()).Scan(&locked).Error
+		return locked, err
+	case config.MySQL:
+		// GET_LOCK names are server wide, so the name includes the database
+		var locked sql.NullInt64
+		err := m.db.Raw("SELECT GET_LOCK(CONCAT(?, DATABASE()), 0)", migrationLockName+":").Scan(&locked).Error
+		return locked.Valid && locked.Int64 == 1, err
+	case config.SQLServer:
+		var result int
+		err := m.db.Raw(`DECLARE @result INT;
+			EXEC @result = sp_getapplock @Resource = ?, @LockMode = 'Exclusive', @LockOwner = 'Session', @LockTimeout = 0;
+			SELECT @result`, migrationLockName).Scan(&result).Error
+		return result >= 0, err
+	default:
+		// a SQLite database is a local file; every migration runs in a
+		// transaction, which holds the write lock of the file
+		return true, nil
+	}
+}
+
+func (m *migrator) unlock() error {
+	switch m.conn.GetType() {
+	case config.PostgreSQL:
+		return m.db.Exec("SELECT pg_advisory_unlock(?)", migrationLockKey()).Error
+	case config.MySQL:
+		return m.db.Exec("SELECT RELEASE_LOCK(CONCAT(?, DATABASE()))", migrationLockName+":").Error
+	case config.SQLServer:
+		return m.db.Exec("EXEC sp_releaseapplock @Resource = ?, @LockOwner = 'Session'", migrationLockName).Error
+	default:
+		return nil
+	}
+}
+
+// migrationLockKey is migrationLockName as a PostgreSQL advisory lock key
+func migrationLockKey() int64 {
+	hash := fnv.New64a()
+	hash.Write([]byte(migrationLockName))
+	return int64(hash.Sum64())
+}
+
+// transactionalDDL reports whether schema changes can be rolled back. MySQL
+// commits implicitly after every DDL statement.
+func (m *migrator) transactionalDDL() bool {
+	switch m.conn.GetType() {
+	case config.PostgreSQL, config.SQLite, config.SQLServer:
+		return true
+	default:
+		return false
+	}
+}
+
+// apply runs the statements of a migration and then record, which updates
+// the migrations table. Where DDL is transactional both run in one
+// transaction, so a migration that fails halfway leaves nothing behind and is
+// not recorded.
+func (m *migrator) apply(name string, statements []string, record func(tx *gorm.DB) error) error {
+	transactional := m.transactionalDDL()
+	run := func(tx *gorm.DB) error {
+		for i, statement := range statements {
+			if err := tx.Exec(statement).Error; err != nil {
+				return &MigrationError{
+					Migration:  name,
+					Statement:  i + 1,
+					Total:      len(statements),
+					SQL:        strings.TrimSpace(statement),
+					RolledBack: transactional,
+					Err:        err,
+				}
+			}
+		}
+		return record(tx)
+	}
+
+	if transactional {
+		return m.db.Transaction(run)
+	}
+	return run(m.db)
+}
@@ -3,27 +3,44 @@ package database
 import (
 	"fmt"
 	"os"
+	"path/filepath"
 	"sort"
 	"strings"
 
+	"golang_starter_kit_2025/app/database/sqlsplit"
+	"golang_starter_kit_2025/config"
+	"golang_starter_kit_2025/database"
 	"golang_starter_kit_2025/facades"
-)
 
-const (
-	upMarker   = "-- +++ UP Migration"
-	downMarker = "-- --- DOWN Migration"
+	"gorm.io/gorm"
 )
 
-// ensureMigrationsTable creates migrations table for a specific connection
-func ensureMigrationsTable(connectionName string) error {
+// defaultMigrationsDirectory holds the migrations of connections that do not
+// configure their own directory
+const defaultMigrationsDirectory = "app/database/migrations"
+
+// migrationDialects are the dialects a migration can have a variant for
+var migrationDialects = []config.DatabaseType{config.MySQL, config.PostgreSQL, config.SQLite, config.SQLServer}
+
+// migrationConnection returns the connection migrations run on, "mysql" when
+// no name is given
+func migrationConnection(connectionName string) (*database.Connection, error) {
+	if connectionName == "" {
+		connectionName = "mysql" // default connection
+	}
 	conn, err := facades.GetConnection(connectionName)
 	if err != nil {
-		return fmt.Errorf("failed to get connection '%s': %v", connectionName, err)
+		return nil, fmt.Errorf("failed to get connection '%s': %v", connectionName, err)
 	}
+	return conn, nil
+}
 
+// ensureMigrationsTable creates migrations table
+func (m *migrator) ensureMigrationsTable() error {
 	// Use different table creation syntax based on database type
 	var createTableSQL string
-	if conn.IsPostgreSQL() {
+	switch m.conn.GetType() {
+	case config.PostgreSQL:
 		createTableSQL = `
 			CREATE TABLE IF NOT EXISTS migrations (
 				id SERIAL PRIMARY KEY,
@@ -31,7 +48,24 @@ func ensureMigrationsTable(connectionName string) error {
 				batch INTEGER NOT NULL,
 				migrated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 			)`
-	} else {
+	case config.SQLite:
+		createTableSQL = `
+			CREATE TABLE IF NOT EXISTS migrations (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				filename VARCHAR(255) NOT NULL,
+				batch INTEGER NOT NULL,
+				migrated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+			)`
+	case config.SQLServer:
+		createTableSQL = `
+			IF OBJECT_ID('migrations', 'U') IS NULL
+			CREATE TABLE migrations (
+				id INT IDENTITY(1,1) PRIMARY KEY,
+				filename NVARCHAR(255) NOT NULL,
+				batch INT NOT NULL,
+				migrated_at DATETIME2 DEFAULT SYSDATETIME()
+			)`
+	default:
 		// MySQL/MariaDB
 		createTableSQL = `
 			CREATE TABLE IF NOT EXISTS migrations (
@@ -42,222 +76,272 @@ func ensureMigrationsTable(connectionName string) error {
 			)`
 	}
 
-	return conn.DB.Exec(createTableSQL).Error
+	return m.db.Exec(createTableSQL).Error
 }
 
-func getLastBatch(connectionName string) (int, error) {
-	conn, err := facades.GetConnection(connectionName)
-	if err != nil {
-		return 0, fmt.Errorf("failed to get connection '%s': %v", connectionName, err)
-	}
-
+func (m *migrator) lastBatch() (int, error) {
 	var res struct{ Batch int }
-	if err := conn.DB.Raw("SELECT COALESCE(MAX(batch),0) AS batch FROM migrations").Scan(&res).Error; err != nil {
+	if err := m.db.Raw("SELECT COALESCE(MAX(batch),0) AS batch FROM migrations").Scan(&res).Error; err != nil {
 		return 0, err
 	}
 	return res.Batch, nil
 }
 
-func isMigrationApplied(filename, connectionName string) (bool, error) {
-	conn, err := facades.GetConnection(connectionName)
-	if err != nil {
-		return false, fmt.Errorf("failed to get connection '%s': %v", connectionName, err)
-	}
-
+func (m *migrator) isApplied(filename string) (bool, error) {
 	var cnt int64
-	if err := conn.DB.Raw("SELECT COUNT(*) FROM migrations WHERE filename = ?", filename).Scan(&cnt).Error; err != nil {
+	if err := m.db.Raw("SELECT COUNT(*) FROM migrations WHERE filename = ?", filename).Scan(&cnt).Error; err != nil {
 		return false, err
 	}
 	return cnt > 0, nil
 }
 
-func parseMigrationFile(content string) (upStmts, downStmts []string) {
-	parts := strings.Split(content, downMarker)
-	upPart := parts[0]
-	downPart := ""
-	if len(parts) > 1 {
-		downPart = parts[1]
-	}
-	upPart = strings.Replace(upPart, upMarker, "", 1)
-	return parseSQLStatements(upPart), parseSQLStatements(downPart)
-}
-
-// RunMigration runs a specific migration on the default connection
-func RunMigration(filename string) error {
-	return RunMigrationOnConnection(filename, "")
-}
-
-// RunMigrationOnConnection runs a specific migration on a specified connection
-func RunMigrationOnConnection(filename, connectionName string) error {
-	if connectionName == "" {
-		connectionName = "mysql" // default connection
-	}
-
-	if err := ensureMigrationsTable(connectionName); err != nil {
-		return err
+// migrate runs the UP part of a migration and records it in batch
+func (m *migrator) migrate(filename string, batch int) error {
+	data, err := m.read(filename)
+	if err != nil {
+		return fmt.Errorf("gagal membaca file migrasi: %v", err)
 	}
 
-	last, err := getLastBatch(connectionName)
+	migration, err := m.parse(filename, data)
 	if err != nil {
 		return err
 	}
-	batch := last + 1
+	return m.apply(filename, migration.Up, func(tx *gorm.DB) error {
+		if err := tx.Exec(
+			"INSERT INTO migrations(filename,batch) VALUES(?,?)", filename, batch,
+		).Error; err != nil {
+			return fmt.Errorf("gagal mencatat migrasi %s: %v", filename, err)
+		}
+		return nil
+	})
+}
 
-	path := fmt.Sprintf("app/database/migrations/%s.sql", filename)
-	data, err := os.ReadFile(path)
+// rollback runs the DOWN part of a migration and removes its record
+func (m *migrator) rollback(filename string) error {
+	data, err := m.read(filename)
 	if err != nil {
-		return fmt.Errorf("gagal membaca file migrasi: %v", err)
+		return fmt.Errorf("gagal membaca file rollback: %v", err)
 	}
 
-	conn, err := facades.GetConnection(connectionName)
+	migration, err := m.parse(filename, data)
 	if err != nil {
-		return fmt.Errorf("failed to get connection '%s': %v", connectionName, err)
+		return err
 	}
-
-	ups, _ := parseMigrationFile(string(data))
-	for _, sql := range ups {
-		if err := conn.DB.Exec(sql).Error; err != nil {
-			return fmt.Errorf("gagal menjalankan migrasi: %v", err)
+	return m.apply(filename, migration.Down, func(tx *gorm.DB) error {
+		if err := tx.Exec("DELETE FROM migrations WHERE filename=?", filename).Error; err != nil {
+			return fmt.Errorf("gagal menghapus record migrasi: %v", err)
 		}
-	}
-
-	if err := conn.DB.Exec(
-		"INSERT INTO migrations(filename,batch) VALUES(?,?)", filename, batch,
-	).Error; err != nil {
-		return fmt.Errorf("gagal mencatat migrasi: %v", err)
-	}
-
-	fmt.Printf("Migrated: %s\n", filename)
-	return nil
-}
-
-// RollbackMigration rolls back a specific migration on the default connection
-func RollbackMigration(filename string) error {
-	return RollbackMigrationOnConnection(filename, "")
+		return nil
+	})
 }
 
-// RollbackMigrationOnConnection rolls back a specific migration on a specified connection
-func RollbackMigrationOnConnection(filename, connectionName string) error {
-	if connectionName == "" {
-		connectionName = "mysql" // default connection
+// directory is where the migrations of the connection are
+func (m *migrator) directory() string {
+	if m.conn.Config.MigrationsPath != "" {
+		return m.conn.Config.MigrationsPath
 	}
+	return defaultMigrationsDirectory
+}
 
-	path := fmt.Sprintf("app/database/migrations/%s.sql", filename)
-	data, err := os.ReadFile(path)
+// migrationFiles returns the file of every migration in the directory of the
+// connection. A migration named name is read from name.<dialect>.sql when
+// there is a variant for the dialect of the connection, and from name.sql
+// otherwise.
+func (m *migrator) migrationFiles() (map[string]string, error) {
+	entries, err := os.ReadDir(m.directory())
 	if err != nil {
-		return fmt.Errorf("gagal membaca file rollback: %v", err)
+		return nil, fmt.Errorf("gagal baca folder: %v", err)
 	}
 
-	conn, err := facades.GetConnection(connectionName)
-	if err != nil {
-		return fmt.Errorf("failed to get connection '%s': %v", connectionName, err)
+	files := make(map[string]string)
+	variants := make(map[string][]string)
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".sql") {
+			continue
+		}
+		name, dialect := splitMigrationName(entry.Name())
+		switch dialect {
+		case "":
+			if _, ok := files[name]; !ok {
+				files[name] = entry.Name()
+			}
+		case m.conn.GetType():
+			files[name] = entry.Name()
+		default:
+			variants[name] = append(variants[name], string(dialect))
+		}
 	}
 
-	_, downs := parseMigrationFile(string(data))
-	for _, sql := range downs {
-		if err := conn.DB.Exec(sql).Error; err != nil {
-			return fmt.Errorf("gagal rollback: %v", err)
+	for name, dialects := range variants {
+		if _, ok := files[name]; !ok {
+			return nil, fmt.Errorf("migrasi %s hanya punya varian %s, tambahkan %s.sql atau %s.%s.sql",
+				name, strings.Join(dialects, ", "), name, name, m.conn.GetType())
 		}
 	}
-
-	// Remove from migrations table
-	if err := conn.DB.Exec("DELETE FROM migrations WHERE filename=?", filename).Error; err != nil {
-		return fmt.Errorf("gagal menghapus record migrasi: %v", err)
+	for name, file := range files {
+		files[name] = filepath.Join(m.directory(), file)
 	}
-
-	fmt.Printf("Rolled back: %s\n", filename)
-	return nil
+	return files, nil
 }
 
-func parseSQLStatements(content string) []string {
-	var stmts []string
-	for _, s := range strings.Split(content, ";") {
-		if t := strings.TrimSpace(s); t != "" {
-			stmts = append(stmts, t)
-		}
+// read returns the content of the file of a migration
+func (m *migrator) read(filename string) ([]byte, error) {
+	files, err := m.migrationFiles()
+	if err != nil {
+		return nil, err
+	}
+	path, ok := files[filename]
+	if !ok {
+		return nil, fmt.Errorf("migrasi %s tidak ada di %s", filename, m.directory())
 	}
-	return stmts
+	return os.ReadFile(path)
 }
 
-// RunAllMigrations runs all pending migrations on the default connection
-func RunAllMigrations() error {
-	return RunAllMigrationsOnConnection("")
+// splitMigrationName splits a file name like name.postgres.sql into the name
+// of the migration and the dialect of the variant, empty for name.sql
+func splitMigrationName(file string) (string, config.DatabaseType) {
+	name := strings.TrimSuffix(file, ".sql")
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		for _, dialect := range migrationDialects {
+			if name[i+1:] == string(dialect) {
+				return name[:i], dialect
+			}
+		}
+	}
+	return name, ""
 }
 
-// RunAllMigrationsOnConnection runs all pending migrations on a specified connection
-func RunAllMigrationsOnConnection(connectionName string) error {
-	if connectionName == "" {
-		connectionName = "mysql" // default connection
+// parse splits a migration file into statements following the SQL dialect of
+// the connection
+func (m *migrator) parse(filename string, data []byte) (sqlsplit.Migration, error) {
+	migration, err := sqlsplit.ParseMigration(string(data), sqlsplit.Dialect(m.conn.GetType()))
+	if err != nil {
+		return migration, fmt.Errorf("gagal membaca migrasi %s: %v", filename, err)
 	}
+	return migration, nil
+}
 
-	if err := ensureMigrationsTable(connectionName); err != nil {
+// rollbackBatch rolls back the migrations of a batch, latest first
+func (m *migrator) rollbackBatch(batch int) error {
+	var rows []struct{ Filename string }
+	if err := m.db.Raw("SELECT filename FROM migrations WHERE batch=? ORDER BY id DESC", batch).Scan(&rows).Error; err != nil {
 		return err
 	}
-
-	var lastBatch struct{ Batch int }
-	conn, err := facades.GetConnection(connectionName)
-	if err != nil {
-		return fmt.Errorf("failed to get connection '%s': %v", connectionName, err)
+	for _, r := range rows {
+		fmt.Printf("Rolling back: %s\n", r.Filename)
+		if err := m.rollback(r.Filename); err != nil {
+			return err
+		}
 	}
+	fmt.Printf("Batch %d rolled back.\n", batch)
+	return nil
+}
 
-	if err := conn.DB.Raw(
-		"SELECT COALESCE(MAX(batch),0) AS batch FROM migrations",
-	).Scan(&lastBatch).Error; err != nil {
+// migrateAll runs the pending migrations in one new batch
+func (m *migrator) migrateAll() error {
+	last, err := m.lastBatch()
+	if err != nil {
 		return err
 	}
-	batch := lastBatch.Batch + 1
+	batch := last + 1
 
-	files, err := os.ReadDir("app/database/migrations")
+	files, err := m.migrationFiles()
 	if err != nil {
-		return fmt.Errorf("gagal baca folder: %v", err)
+		return err
 	}
 	var toRun []string
-	for _, f := range files {
-		if strings.HasSuffix(f.Name(), ".sql") {
-			name := strings.TrimSuffix(f.Name(), ".sql")
-			var cnt int64
-			conn.DB.Raw(
-				"SELECT COUNT(*) FROM migrations WHERE filename = ?", name,
-			).Scan(&cnt)
-			if cnt == 0 {
-				toRun = append(toRun, name)
-			}
+	for name := range files {
+		applied, err := m.isApplied(name)
+		if err != nil {
+			return err
+		}
+		if !applied {
+			toRun = append(toRun, name)
 		}
 	}
 	sort.Strings(toRun)
 
 	for _, name := range toRun {
 		fmt.Printf("Migrating: %s\n", name)
+		if err := m.migrate(name, batch); err != nil {
+			return err
+		}
+	}
+
+	fmt.Printf("Batch %d applied.\n", batch)
+	return nil
+}
+
+// RunMigration runs a specific migration on the default connection
+func RunMigration(filename string) error {
+	return RunMigrationOnConnection(filename, "")
+}
+
+// RunMigrationOnConnection runs a specific migration on a specified connection
+func RunMigrationOnConnection(filename, connectionName string) error {
+	conn, err := migrationConnection(connectionName)
+	if err != nil {
+		return err
+	}
 
-		data, err := os.ReadFile(
-			fmt.Sprintf("app/database/migrations/%s.sql", name),
-		)
+	return withMigrationLock(conn, func(m *migrator) error {
+		if err := m.ensureMigrationsTable(); err != nil {
+			return err
+		}
+
+		last, err := m.lastBatch()
 		if err != nil {
-			return fmt.Errorf("gagal membaca %s: %v", name, err)
+			return err
 		}
-		parts := strings.Split(
-			string(data), "-- --- DOWN Migration",
-		)
-		up := strings.Replace(
-			parts[0], "-- +++ UP Migration", "", 1,
-		)
-
-		for _, stmt := range parseSQLStatements(up) {
-			if err := conn.DB.Exec(stmt).Error; err != nil {
-				return fmt.Errorf("gagal %s: %v", name, err)
-			}
+		if err := m.migrate(filename, last+1); err != nil {
+			return err
 		}
 
-		if err := conn.DB.Exec(
-			"INSERT INTO migrations(filename,batch) VALUES(?,?)",
-			name, batch,
-		).Error; err != nil {
-			return fmt.Errorf("gagal mencatat %s: %v", name, err)
+		fmt.Printf("Migrated: %s\n", filename)
+		return nil
+	})
+}
+
+// RollbackMigration rolls back a specific migration on the default connection
+func RollbackMigration(filename string) error {
+	return RollbackMigrationOnConnection(filename, "")
+}
+
+// RollbackMigrationOnConnection rolls back a specific migration on a specified connection
+func RollbackMigrationOnConnection(filename, connectionName string) error {
+	conn, err := migrationConnection(connectionName)
+	if err != nil {
+		return err
+	}
+
+	return withMigrationLock(conn, func(m *migrator) error {
+		if err := m.rollback(filename); err != nil {
+			return err
 		}
+
+		fmt.Printf("Rolled back: %s\n", filename)
+		return nil
+	})
+}
+
+// RunAllMigrations runs all pending migrations on the default connection
+func RunAllMigrations() error {
+	return RunAllMigrationsOnConnection("")
+}
+
+// RunAllMigrationsOnConnection runs all pending migrations on a specified connection
+func RunAllMigrationsOnConnection(connectionName string) error {
+	conn, err := migrationConnection(connectionName)
+	if err != nil {
+		return err
 	}
 
-	fmt.Printf("Batch %d applied.\n", batch)
-	return nil
+	return withMigrationLock(conn, func(m *migrator) error {
+		if err := m.ensureMigrationsTable(); err != nil {
+			return err
+		}
+		return m.migrateAll()
+	})
 }
 
 // RunAllRollbacks rolls back all migrations on the default connection
@@ -267,20 +351,26 @@ func RunAllRollbacks() error {
 
 // RunAllRollbacksOnConnection rolls back all migrations on a specified connection
 func RunAllRollbacksOnConnection(connectionName string) error {
-	if connectionName == "" {
-		connectionName = "mysql" // default connection
-	}
-
-	if err := ensureMigrationsTable(connectionName); err != nil {
+	conn, err := migrationConnection(connectionName)
+	if err != nil {
 		return err
 	}
-	last, _ := getLastBatch(connectionName)
-	for b := last; b >= 1; b-- {
-		if err := RollbackBatchOnConnection(b, connectionName); err != nil {
+
+	return withMigrationLock(conn, func(m *migrator) error {
+		if err := m.ensureMigrationsTable(); err != nil {
 			return err
 		}
-	}
-	return nil
+		last, err := m.lastBatch()
+		if err != nil {
+			return err
+		}
+		for b := last; b >= 1; b-- {
+			if err := m.rollbackBatch(b); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
 }
 
 // RollbackBatch rolls back a specific batch on the default connection
@@ -290,29 +380,17 @@ func RollbackBatch(batch int) error {
 
 // RollbackBatchOnConnection rolls back a specific batch on a specified connection
 func RollbackBatchOnConnection(batch int, connectionName string) error {
-	if connectionName == "" {
-		connectionName = "mysql" // default connection
-	}
-
-	if err := ensureMigrationsTable(connectionName); err != nil {
-		return err
-	}
-
-	conn, err := facades.GetConnection(connectionName)
+	conn, err := migrationConnection(connectionName)
 	if err != nil {
-		return fmt.Errorf("failed to get connection '%s': %v", connectionName, err)
+		return err
 	}
 
-	var rows []struct{ Filename string }
-	conn.DB.Raw("SELECT filename FROM migrations WHERE batch=? ORDER BY id DESC", batch).Scan(&rows)
-	for _, r := range rows {
-		fmt.Printf("Rolling back: %s\n", r.Filename)
-		if err := RollbackMigrationOnConnection(r.Filename, connectionName); err != nil {
+	return withMigrationLock(conn, func(m *migrator) error {
+		if err := m.ensureMigrationsTable(); err != nil {
 			return err
 		}
-	}
-	fmt.Printf("Batch %d rolled back.\n", batch)
-	return nil
+		return m.rollbackBatch(batch)
+	})
 }
 
 // RollbackLastBatch rolls back the last batch on the default connection
@@ -322,16 +400,25 @@ func RollbackLastBatch() error {
 
 // RollbackLastBatchOnConnection rolls back the last batch on a specified connection
 func RollbackLastBatchOnConnection(connectionName string) error {
-	if connectionName == "" {
-		connectionName = "mysql" // default connection
+	conn, err := migrationConnection(connectionName)
+	if err != nil {
+		return err
 	}
 
-	last, _ := getLastBatch(connectionName)
-	if last == 0 {
-		fmt.Printf("No batch to rollback.\n")
-		return nil
-	}
-	return RollbackBatchOnConnection(last, connectionName)
+	return withMigrationLock(conn, func(m *migrator) error {
+		if err := m.ensureMigrationsTable(); err != nil {
+			return err
+		}
+		last, err := m.lastBatch()
+		if err != nil {
+			return err
+		}
+		if last == 0 {
+			fmt.Printf("No batch to rollback.\n")
+			return nil
+		}
+		return m.rollbackBatch(last)
+	})
 }
 
 // FreshMigrations truncates migrations and re-runs all on the default connection
@@ -341,25 +428,29 @@ func FreshMigrations() error {
 
 // FreshMigrationsOnConnection truncates migrations and re-runs all on a specified connection
 func FreshMigrationsOnConnection(connectionName string) error {
-	if connectionName == "" {
-		connectionName = "mysql" // default connection
-	}
-
-	if err := ensureMigrationsTable(connectionName); err != nil {
+	conn, err := migrationConnection(connectionName)
+	if err != nil {
 		return err
 	}
 
-	conn, err := facades.GetConnection(connectionName)
-	if err != nil {
-		return fmt.Errorf("failed to get connection '%s': %v", connectionName, err)
-	}
+	return withMigrationLock(conn, func(m *migrator) error {
+		if err := m.ensureMigrationsTable(); err != nil {
+			return err
+		}
 
-	// Use different truncate syntax for PostgreSQL
-	if conn.IsPostgreSQL() {
-		conn.DB.Exec("TRUNCATE migrations RESTART IDENTITY")
-	} else {
-		conn.DB.Exec("TRUNCATE migrations")
-	}
+		// Use different truncate syntax per database type
+		switch m.conn.GetType() {
+		case config.PostgreSQL:
+			err = m.db.Exec("TRUNCATE migrations RESTART IDENTITY").Error
+		case config.SQLite:
+			err = m.db.Exec("DELETE FROM migrations").Error
+		default:
+			err = m.db.Exec("TRUNCATE TABLE migrations").Error
+		}
+		if err != nil {
+			return err
+		}
 
-	return RunAllMigrationsOnConnection(connectionName)
+		return m.migrateAll()
+	})
 }
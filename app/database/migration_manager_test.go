@@ -0,0 +1,182 @@
+package database_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+
+	"golang_starter_kit_2025/app/database"
+	"golang_starter_kit_2025/config"
+	rootdb "golang_starter_kit_2025/database"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// sqliteConnection opens a fresh SQLite database whose migrations are read
+// from dir
+func sqliteConnection(dir string) *rootdb.Connection {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(GinkgoT().TempDir(), "test.db")), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	Expect(err).NotTo(HaveOccurred())
+	sqlDB, err := db.DB()
+	Expect(err).NotTo(HaveOccurred())
+	DeferCleanup(sqlDB.Close)
+
+	return &rootdb.Connection{
+		DB:     db,
+		SqlDB:  sqlDB,
+		Name:   "sqlite",
+		Config: &config.DatabaseConfig{Type: config.SQLite, MigrationsPath: dir},
+	}
+}
+
+func writeMigration(dir, file, content string) {
+	Expect(os.WriteFile(filepath.Join(dir, file), []byte(content), 0644)).To(Succeed())
+}
+
+func tables(conn *rootdb.Connection) []string {
+	var names []string
+	Expect(conn.DB.Raw("SELECT name FROM sqlite_master WHERE type = 'table' AND name NOT LIKE 'sqlite_%' ORDER BY name").
+		Scan(&names).Error).To(Succeed())
+	return names
+}
+
+func applied(conn *rootdb.Connection) []string {
+	var names []string
+	Expect(conn.DB.Raw("SELECT filename FROM migrations ORDER BY id").Scan(&names).Error).To(Succeed())
+	return names
+}
+
+var _ = Describe("Migrations", func() {
+	var dir string
+
+	BeforeEach(func() {
+		dir = GinkgoT().TempDir()
+	})
+
+	It("rolls back a migration whose later statement fails and does not record it", func() {
+		writeMigration(dir, "001_create_accounts.sql", `-- +++ UP Migration
+CREATE TABLE accounts (id INTEGER PRIMARY KEY);
+-- --- DOWN Migration
+DROP TABLE accounts;`)
+		writeMigration(dir, "002_create_orders.sql", `-- +++ UP Migration
+CREATE TABLE orders (id INTEGER PRIMARY KEY);
+CREATE INDEX orders_id_index ON orders (id);
+INSERT INTO missing_table (id) VALUES (1);
+-- --- DOWN Migration
+DROP TABLE orders;`)
+		conn := sqliteConnection(dir)
+
+		err := database.MigrateAll(conn)
+		var migrationErr *database.MigrationError
+		Expect(errors.As(err, &migrationErr)).To(BeTrue())
+		Expect(migrationErr.Migration).To(Equal("002_create_orders"))
+		Expect(migrationErr.Statement).To(Equal(3))
+		Expect(migrationErr.Total).To(Equal(3))
+		Expect(migrationErr.SQL).To(HavePrefix("INSERT INTO missing_table"))
+		Expect(migrationErr.RolledBack).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring("statement 3 dari 3"))
+		Expect(err.Error()).To(ContainSubstring("seluruh migrasi di-rollback"))
+
+		// the earlier migration stays, the failed one left nothing behind
+		Expect(tables(conn)).To(Equal([]string{"accounts", "migrations"}))
+		Expect(applied(conn)).To(Equal([]string{"001_create_accounts"}))
+	})
+
+	It("runs the fixed migration on the next attempt", func() {
+		writeMigration(dir, "001_create_orders.sql", `-- +++ UP Migration
+CREATE TABLE orders (id INTEGER PRIMARY KEY);
+INSERT INTO missing_table (id) VALUES (1);
+-- --- DOWN Migration
+DROP TABLE orders;`)
+		conn := sqliteConnection(dir)
+		Expect(database.MigrateAll(conn)).NotTo(Succeed())
+
+		writeMigration(dir, "001_create_orders.sql", `-- +++ UP Migration
+CREATE TABLE orders (id INTEGER PRIMARY KEY);
+INSERT INTO orders (id) VALUES (1);
+-- --- DOWN Migration
+DROP TABLE orders;`)
+		Expect(database.MigrateAll(conn)).To(Succeed())
+		Expect(applied(conn)).To(Equal([]string{"001_create_orders"}))
+
+		// nothing is left to run
+		Expect(database.MigrateAll(conn)).To(Succeed())
+		Expect(applied(conn)).To(Equal([]string{"001_create_orders"}))
+	})
+})
+
+var _ = Describe("Migration variants", func() {
+	var dir string
+
+	BeforeEach(func() {
+		dir = GinkgoT().TempDir()
+	})
+
+	DescribeTable("splitMigrationName",
+		func(file, name string, dialect config.DatabaseType) {
+			gotName, gotDialect := database.SplitMigrationName(file)
+			Expect(gotName).To(Equal(name))
+			Expect(gotDialect).To(Equal(dialect))
+		},
+		Entry("base file", "001_create_users.sql", "001_create_users", config.DatabaseType("")),
+		Entry("sqlite variant", "001_create_users.sqlite.sql", "001_create_users", config.SQLite),
+		Entry("postgres variant", "001_create_users.postgres.sql", "001_create_users", config.PostgreSQL),
+		Entry("unknown suffix is part of the name", "001_create_users.v2.sql", "001_create_users.v2", config.DatabaseType("")),
+	)
+
+	It("prefers the variant of the connection dialect over the base file", func() {
+		writeMigration(dir, "001_create_users.sql", "-- +++ UP Migration\nSELECT 1;")
+		writeMigration(dir, "001_create_users.sqlite.sql", "-- +++ UP Migration\nSELECT 1;")
+		writeMigration(dir, "001_create_users.postgres.sql", "-- +++ UP Migration\nSELECT 1;")
+		writeMigration(dir, "002_create_roles.sql", "-- +++ UP Migration\nSELECT 1;")
+		writeMigration(dir, "002_create_roles.mysql.sql", "-- +++ UP Migration\nSELECT 1;")
+
+		files, err := database.MigrationFiles(sqliteConnection(dir))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(files).To(Equal(map[string]string{
+			"001_create_users": filepath.Join(dir, "001_create_users.sqlite.sql"),
+			"002_create_roles": filepath.Join(dir, "002_create_roles.sql"),
+		}))
+	})
+
+	It("fails when a migration only has variants for other dialects", func() {
+		writeMigration(dir, "001_create_users.sql", "-- +++ UP Migration\nSELECT 1;")
+		writeMigration(dir, "003_create_jobs.postgres.sql", "-- +++ UP Migration\nSELECT 1;")
+
+		_, err := database.MigrationFiles(sqliteConnection(dir))
+		Expect(err).To(MatchError(ContainSubstring("migrasi 003_create_jobs hanya punya varian postgres")))
+		Expect(err).To(MatchError(ContainSubstring("003_create_jobs.sqlite.sql")))
+	})
+
+	It("accepts a migration that only has a variant for the connection dialect", func() {
+		writeMigration(dir, "001_create_users.sqlite.sql", "-- +++ UP Migration\nSELECT 1;")
+
+		files, err := database.MigrationFiles(sqliteConnection(dir))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(files).To(Equal(map[string]string{"001_create_users": filepath.Join(dir, "001_create_users.sqlite.sql")}))
+	})
+
+	It("reads migrations from the directory of each connection", func() {
+		reporting := GinkgoT().TempDir()
+		writeMigration(dir, "001_create_users.sql", `-- +++ UP Migration
+CREATE TABLE users (id INTEGER PRIMARY KEY);`)
+		writeMigration(reporting, "001_create_reports.sql", `-- +++ UP Migration
+CREATE TABLE reports (id INTEGER PRIMARY KEY);`)
+
+		main := sqliteConnection(dir)
+		reports := sqliteConnection(reporting)
+		Expect(database.MigrateAll(main)).To(Succeed())
+		Expect(database.MigrateAll(reports)).To(Succeed())
+
+		Expect(tables(main)).To(Equal([]string{"migrations", "users"}))
+		Expect(applied(main)).To(Equal([]string{"001_create_users"}))
+		Expect(tables(reports)).To(Equal([]string{"migrations", "reports"}))
+		Expect(applied(reports)).To(Equal([]string{"001_create_reports"}))
+	})
+})
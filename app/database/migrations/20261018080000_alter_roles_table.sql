@@ -0,0 +1,11 @@
+-- +++ UP Migration
+ALTER TABLE roles
+CHANGE COLUMN role name VARCHAR(255) NOT NULL,
+ADD COLUMN `group` VARCHAR(255) NULL AFTER name,
+ADD UNIQUE INDEX roles_name_unique (name);
+
+-- --- DOWN Migration
+ALTER TABLE roles
+DROP INDEX roles_name_unique,
+DROP COLUMN `group`,
+CHANGE COLUMN name role VARCHAR(255) NOT NULL;
@@ -0,0 +1,13 @@
+-- +++ UP Migration
+ALTER TABLE permissions
+CHANGE COLUMN permission name VARCHAR(255) NOT NULL,
+ADD COLUMN `group` VARCHAR(255) NULL AFTER name,
+ADD COLUMN description TEXT NULL AFTER `group`,
+ADD UNIQUE INDEX permissions_name_unique (name);
+
+-- --- DOWN Migration
+ALTER TABLE permissions
+DROP INDEX permissions_name_unique,
+DROP COLUMN description,
+DROP COLUMN `group`,
+CHANGE COLUMN name permission VARCHAR(255) NOT NULL;
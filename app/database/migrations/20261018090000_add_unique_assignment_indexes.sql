@@ -0,0 +1,27 @@
+-- +++ UP Migration
+-- assignments made before the unique indexes may be duplicated; keep the
+-- oldest row of each pair
+DELETE FROM duplicate
+USING role_has_permissions AS duplicate
+JOIN role_has_permissions AS kept
+ON kept.role_id = duplicate.role_id
+AND kept.permission_id = duplicate.permission_id
+AND kept.id < duplicate.id;
+ALTER TABLE role_has_permissions
+ADD UNIQUE INDEX role_has_permissions_role_permission_unique (role_id, permission_id);
+DELETE FROM duplicate
+USING users_has_roles AS duplicate
+JOIN users_has_roles AS kept
+ON kept.user_id = duplicate.user_id
+AND kept.role_id = duplicate.role_id
+AND kept.id < duplicate.id;
+ALTER TABLE users_has_roles
+ADD UNIQUE INDEX users_has_roles_user_role_unique (user_id, role_id);
+
+-- --- DOWN Migration
+ALTER TABLE users_has_roles
+ADD INDEX users_has_roles_user_id_index (user_id),
+DROP INDEX users_has_roles_user_role_unique;
+ALTER TABLE role_has_permissions
+ADD INDEX role_has_permissions_role_id_index (role_id),
+DROP INDEX role_has_permissions_role_permission_unique;
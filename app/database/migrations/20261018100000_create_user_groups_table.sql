@@ -0,0 +1,34 @@
+-- +++ UP Migration
+CREATE TABLE user_groups (
+	id BIGINT AUTO_INCREMENT PRIMARY KEY,
+	name VARCHAR(255) NOT NULL,
+	description TEXT NULL,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+	UNIQUE INDEX user_groups_name_unique (name)
+);
+CREATE TABLE user_groups_has_users (
+	id BIGINT AUTO_INCREMENT PRIMARY KEY,
+	user_group_id BIGINT NOT NULL,
+	user_id BIGINT NOT NULL,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+	UNIQUE INDEX user_groups_has_users_group_user_unique (user_group_id, user_id),
+	FOREIGN KEY (user_group_id) REFERENCES user_groups(id) ON DELETE CASCADE,
+	FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+CREATE TABLE user_groups_has_roles (
+	id BIGINT AUTO_INCREMENT PRIMARY KEY,
+	user_group_id BIGINT NOT NULL,
+	role_id BIGINT NOT NULL,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+	UNIQUE INDEX user_groups_has_roles_group_role_unique (user_group_id, role_id),
+	FOREIGN KEY (user_group_id) REFERENCES user_groups(id) ON DELETE CASCADE,
+	FOREIGN KEY (role_id) REFERENCES roles(id) ON DELETE CASCADE
+);
+
+-- --- DOWN Migration
+DROP TABLE IF EXISTS user_groups_has_roles;
+DROP TABLE IF EXISTS user_groups_has_users;
+DROP TABLE IF EXISTS user_groups;
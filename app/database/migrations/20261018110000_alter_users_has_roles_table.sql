@@ -0,0 +1,11 @@
+-- +++ UP Migration
+ALTER TABLE users_has_roles
+ADD COLUMN starts_at TIMESTAMP NULL DEFAULT NULL AFTER role_id,
+ADD COLUMN expires_at TIMESTAMP NULL DEFAULT NULL AFTER starts_at,
+ADD INDEX users_has_roles_expires_at_index (expires_at);
+
+-- --- DOWN Migration
+ALTER TABLE users_has_roles
+DROP INDEX users_has_roles_expires_at_index,
+DROP COLUMN expires_at,
+DROP COLUMN starts_at;
@@ -0,0 +1,23 @@
+-- +++ UP Migration
+CREATE TABLE access_requests (
+	id BIGINT AUTO_INCREMENT PRIMARY KEY,
+	user_id BIGINT NOT NULL,
+	role_id BIGINT NOT NULL,
+	justification TEXT NOT NULL,
+	duration_minutes INT NOT NULL,
+	status VARCHAR(20) NOT NULL DEFAULT 'pending',
+	reviewer_id BIGINT NULL,
+	review_note TEXT NULL,
+	reviewed_at TIMESTAMP NULL DEFAULT NULL,
+	grant_starts_at TIMESTAMP NULL DEFAULT NULL,
+	grant_expires_at TIMESTAMP NULL DEFAULT NULL,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+	INDEX access_requests_status_index (status),
+	FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+	FOREIGN KEY (role_id) REFERENCES roles(id) ON DELETE CASCADE,
+	FOREIGN KEY (reviewer_id) REFERENCES users(id) ON DELETE SET NULL
+);
+
+-- --- DOWN Migration
+DROP TABLE IF EXISTS access_requests;
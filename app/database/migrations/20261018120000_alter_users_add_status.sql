@@ -0,0 +1,26 @@
+-- +++ UP Migration
+ALTER TABLE users
+ADD COLUMN status VARCHAR(20) NOT NULL DEFAULT 'active' AFTER pin,
+ADD COLUMN status_reason VARCHAR(255) NULL AFTER status,
+ADD COLUMN status_changed_at TIMESTAMP NULL DEFAULT NULL AFTER status_reason,
+ADD INDEX users_status_index (status);
+CREATE TABLE user_status_histories (
+	id BIGINT AUTO_INCREMENT PRIMARY KEY,
+	user_id BIGINT NOT NULL,
+	from_status VARCHAR(20) NOT NULL,
+	to_status VARCHAR(20) NOT NULL,
+	reason VARCHAR(255) NULL,
+	changed_by BIGINT NULL,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	INDEX user_status_histories_user_id_index (user_id),
+	FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+	FOREIGN KEY (changed_by) REFERENCES users(id) ON DELETE SET NULL
+);
+
+-- --- DOWN Migration
+DROP TABLE IF EXISTS user_status_histories;
+ALTER TABLE users
+DROP INDEX users_status_index,
+DROP COLUMN status_changed_at,
+DROP COLUMN status_reason,
+DROP COLUMN status;
@@ -0,0 +1,15 @@
+-- +++ UP Migration
+ALTER TABLE users
+ADD COLUMN nik VARCHAR(16) NULL AFTER email,
+ADD UNIQUE INDEX users_nik_unique (nik);
+ALTER TABLE stores
+ADD COLUMN owner_nik VARCHAR(16) NULL AFTER name,
+ADD INDEX stores_owner_nik_index (owner_nik);
+
+-- --- DOWN Migration
+ALTER TABLE stores
+DROP INDEX stores_owner_nik_index,
+DROP COLUMN owner_nik;
+ALTER TABLE users
+DROP INDEX users_nik_unique,
+DROP COLUMN nik;
@@ -0,0 +1,56 @@
+-- +++ UP Migration
+CREATE TABLE kyc_submissions (
+	id BIGINT AUTO_INCREMENT PRIMARY KEY,
+	reference VARCHAR(100) NOT NULL,
+	user_id BIGINT NOT NULL,
+	status VARCHAR(30) NOT NULL DEFAULT 'draft',
+	nik VARCHAR(16) NULL,
+	full_name VARCHAR(255) NULL,
+	birth_place VARCHAR(100) NULL,
+	birth_date DATE NULL,
+	gender VARCHAR(10) NULL,
+	address TEXT NULL,
+	phone VARCHAR(30) NULL,
+	reviewer_id BIGINT NULL,
+	reason_code VARCHAR(50) NULL,
+	reviewer_comment TEXT NULL,
+	submitted_at TIMESTAMP NULL DEFAULT NULL,
+	review_started_at TIMESTAMP NULL DEFAULT NULL,
+	decided_at TIMESTAMP NULL DEFAULT NULL,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+	UNIQUE INDEX kyc_submissions_reference_unique (reference),
+	INDEX kyc_submissions_user_id_index (user_id),
+	INDEX kyc_submissions_status_index (status),
+	FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+	FOREIGN KEY (reviewer_id) REFERENCES users(id) ON DELETE SET NULL
+);
+CREATE TABLE kyc_documents (
+	id BIGINT AUTO_INCREMENT PRIMARY KEY,
+	submission_id BIGINT NOT NULL,
+	type VARCHAR(30) NOT NULL,
+	`key` VARCHAR(100) NOT NULL,
+	file_name VARCHAR(255) NOT NULL,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+	UNIQUE INDEX kyc_documents_submission_type_unique (submission_id, type),
+	FOREIGN KEY (submission_id) REFERENCES kyc_submissions(id) ON DELETE CASCADE
+);
+CREATE TABLE kyc_transitions (
+	id BIGINT AUTO_INCREMENT PRIMARY KEY,
+	submission_id BIGINT NOT NULL,
+	from_status VARCHAR(30) NOT NULL,
+	to_status VARCHAR(30) NOT NULL,
+	actor_id BIGINT NULL,
+	reason_code VARCHAR(50) NULL,
+	comment TEXT NULL,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	INDEX kyc_transitions_submission_id_index (submission_id),
+	FOREIGN KEY (submission_id) REFERENCES kyc_submissions(id) ON DELETE CASCADE,
+	FOREIGN KEY (actor_id) REFERENCES users(id) ON DELETE SET NULL
+);
+
+-- --- DOWN Migration
+DROP TABLE IF EXISTS kyc_transitions;
+DROP TABLE IF EXISTS kyc_documents;
+DROP TABLE IF EXISTS kyc_submissions;
@@ -0,0 +1,25 @@
+-- +++ UP Migration
+ALTER TABLE kyc_documents
+ADD COLUMN mime_type VARCHAR(50) NULL AFTER file_name,
+ADD COLUMN width INT NOT NULL DEFAULT 0 AFTER mime_type,
+ADD COLUMN height INT NOT NULL DEFAULT 0 AFTER width,
+ADD COLUMN sharpness DOUBLE NOT NULL DEFAULT 0 AFTER height,
+ADD COLUMN brightness DOUBLE NOT NULL DEFAULT 0 AFTER sharpness,
+ADD COLUMN phash VARCHAR(16) NULL AFTER brightness,
+ADD COLUMN quality_issues JSON NULL AFTER phash,
+ADD COLUMN quality_passed TINYINT(1) NOT NULL DEFAULT 0 AFTER quality_issues,
+ADD COLUMN duplicate_of JSON NULL AFTER quality_passed,
+ADD INDEX kyc_documents_phash_index (phash);
+
+-- --- DOWN Migration
+ALTER TABLE kyc_documents
+DROP INDEX kyc_documents_phash_index,
+DROP COLUMN duplicate_of,
+DROP COLUMN quality_passed,
+DROP COLUMN quality_issues,
+DROP COLUMN phash,
+DROP COLUMN brightness,
+DROP COLUMN sharpness,
+DROP COLUMN height,
+DROP COLUMN width,
+DROP COLUMN mime_type;
@@ -0,0 +1,53 @@
+-- +++ UP Migration
+-- Encrypted values do not fit the original column types, and equal values no
+-- longer produce equal ciphertext, so uniqueness and lookups move to the
+-- HMAC blind index columns. Existing rows are encrypted and indexed by
+-- running `crypto:rotate` after this migration.
+ALTER TABLE users
+DROP INDEX email,
+DROP INDEX users_nik_unique,
+MODIFY email TEXT NULL,
+MODIFY nik TEXT NULL,
+ADD COLUMN email_bidx VARCHAR(64) NULL AFTER email,
+ADD COLUMN nik_bidx VARCHAR(64) NULL AFTER nik,
+ADD UNIQUE INDEX users_email_bidx_unique (email_bidx),
+ADD UNIQUE INDEX users_nik_bidx_unique (nik_bidx);
+ALTER TABLE kyc_submissions
+MODIFY nik TEXT NULL,
+MODIFY full_name TEXT NULL,
+MODIFY birth_place TEXT NULL,
+MODIFY birth_date TEXT NULL,
+MODIFY phone TEXT NULL,
+ADD COLUMN nik_bidx VARCHAR(64) NULL AFTER nik,
+ADD INDEX kyc_submissions_nik_bidx_index (nik_bidx);
+ALTER TABLE stores
+DROP INDEX stores_owner_nik_index,
+DROP COLUMN owner_nik,
+ADD COLUMN owner_nik_bidx VARCHAR(64) NULL AFTER name,
+ADD INDEX stores_owner_nik_bidx_index (owner_nik_bidx);
+
+-- --- DOWN Migration
+-- Only the schema is restored: encrypted rows must be decrypted before
+-- rolling back, the ciphertext does not fit the original columns.
+ALTER TABLE stores
+DROP INDEX stores_owner_nik_bidx_index,
+DROP COLUMN owner_nik_bidx,
+ADD COLUMN owner_nik VARCHAR(16) NULL AFTER name,
+ADD INDEX stores_owner_nik_index (owner_nik);
+ALTER TABLE kyc_submissions
+DROP INDEX kyc_submissions_nik_bidx_index,
+DROP COLUMN nik_bidx,
+MODIFY nik VARCHAR(16) NULL,
+MODIFY full_name VARCHAR(255) NULL,
+MODIFY birth_place VARCHAR(100) NULL,
+MODIFY birth_date DATE NULL,
+MODIFY phone VARCHAR(30) NULL;
+ALTER TABLE users
+DROP INDEX users_nik_bidx_unique,
+DROP INDEX users_email_bidx_unique,
+DROP COLUMN nik_bidx,
+DROP COLUMN email_bidx,
+MODIFY nik VARCHAR(16) NULL,
+MODIFY email VARCHAR(100) NOT NULL,
+ADD UNIQUE INDEX users_nik_unique (nik),
+ADD UNIQUE INDEX email (email);
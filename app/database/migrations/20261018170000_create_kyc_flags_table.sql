@@ -0,0 +1,47 @@
+-- +++ UP Migration
+-- The new blind indexes are filled for existing rows by `crypto:rotate`,
+-- which rewrites rows whose blind indexes are NULL and runs on every deploy.
+ALTER TABLE users
+ADD COLUMN email_canonical_bidx VARCHAR(64) NULL AFTER email_bidx,
+ADD INDEX users_email_canonical_bidx_index (email_canonical_bidx);
+ALTER TABLE kyc_submissions
+ADD COLUMN birth_date_bidx VARCHAR(64) NULL AFTER birth_date,
+ADD COLUMN phone_bidx VARCHAR(64) NULL AFTER phone,
+ADD INDEX kyc_submissions_birth_date_bidx_index (birth_date_bidx),
+ADD INDEX kyc_submissions_phone_bidx_index (phone_bidx);
+CREATE TABLE kyc_flags (
+	id BIGINT AUTO_INCREMENT PRIMARY KEY,
+	submission_id BIGINT NOT NULL,
+	user_id BIGINT NOT NULL,
+	matched_user_id BIGINT NOT NULL,
+	matched_submission_id BIGINT NULL,
+	`signal` VARCHAR(20) NOT NULL,
+	score DOUBLE NOT NULL DEFAULT 0,
+	detail VARCHAR(255) NULL,
+	status VARCHAR(20) NOT NULL DEFAULT 'open',
+	resolved_by BIGINT NULL,
+	resolved_at TIMESTAMP NULL DEFAULT NULL,
+	note TEXT NULL,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+	INDEX kyc_flags_submission_id_index (submission_id),
+	INDEX kyc_flags_user_id_index (user_id),
+	INDEX kyc_flags_matched_user_id_index (matched_user_id),
+	INDEX kyc_flags_status_index (status),
+	FOREIGN KEY (submission_id) REFERENCES kyc_submissions(id) ON DELETE CASCADE,
+	FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+	FOREIGN KEY (matched_user_id) REFERENCES users(id) ON DELETE CASCADE,
+	FOREIGN KEY (matched_submission_id) REFERENCES kyc_submissions(id) ON DELETE CASCADE,
+	FOREIGN KEY (resolved_by) REFERENCES users(id) ON DELETE SET NULL
+);
+
+-- --- DOWN Migration
+DROP TABLE IF EXISTS kyc_flags;
+ALTER TABLE kyc_submissions
+DROP INDEX kyc_submissions_phone_bidx_index,
+DROP INDEX kyc_submissions_birth_date_bidx_index,
+DROP COLUMN phone_bidx,
+DROP COLUMN birth_date_bidx;
+ALTER TABLE users
+DROP INDEX users_email_canonical_bidx_index,
+DROP COLUMN email_canonical_bidx;
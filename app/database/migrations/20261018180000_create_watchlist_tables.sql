@@ -0,0 +1,83 @@
+-- +++ UP Migration
+CREATE TABLE watchlists (
+	id BIGINT AUTO_INCREMENT PRIMARY KEY,
+	name VARCHAR(100) NOT NULL,
+	category VARCHAR(20) NOT NULL,
+	active_version_id BIGINT NULL,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+	UNIQUE INDEX watchlists_name_unique (name)
+);
+CREATE TABLE watchlist_versions (
+	id BIGINT AUTO_INCREMENT PRIMARY KEY,
+	watchlist_id BIGINT NOT NULL,
+	version INT NOT NULL,
+	source_file VARCHAR(255) NULL,
+	checksum VARCHAR(64) NOT NULL,
+	entry_count INT NOT NULL DEFAULT 0,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	UNIQUE INDEX watchlist_versions_watchlist_version_unique (watchlist_id, version),
+	FOREIGN KEY (watchlist_id) REFERENCES watchlists(id) ON DELETE CASCADE
+);
+ALTER TABLE watchlists
+ADD FOREIGN KEY (active_version_id) REFERENCES watchlist_versions(id) ON DELETE SET NULL;
+CREATE TABLE watchlist_entries (
+	id BIGINT AUTO_INCREMENT PRIMARY KEY,
+	watchlist_id BIGINT NOT NULL,
+	version_id BIGINT NOT NULL,
+	external_id VARCHAR(100) NULL,
+	name VARCHAR(255) NOT NULL,
+	aliases JSON NULL,
+	birth_dates JSON NULL,
+	nationalities JSON NULL,
+	remarks TEXT NULL,
+	INDEX watchlist_entries_watchlist_id_index (watchlist_id),
+	INDEX watchlist_entries_version_id_index (version_id),
+	FOREIGN KEY (version_id) REFERENCES watchlist_versions(id) ON DELETE CASCADE
+);
+CREATE TABLE screening_results (
+	id BIGINT AUTO_INCREMENT PRIMARY KEY,
+	user_id BIGINT NOT NULL,
+	submission_id BIGINT NOT NULL,
+	status VARCHAR(20) NOT NULL,
+	version_ids JSON NULL,
+	threshold DOUBLE NOT NULL,
+	hit_count INT NOT NULL DEFAULT 0,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	INDEX screening_results_user_id_index (user_id),
+	INDEX screening_results_submission_id_index (submission_id),
+	INDEX screening_results_status_index (status),
+	FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+	FOREIGN KEY (submission_id) REFERENCES kyc_submissions(id) ON DELETE CASCADE
+);
+CREATE TABLE screening_hits (
+	id BIGINT AUTO_INCREMENT PRIMARY KEY,
+	result_id BIGINT NOT NULL,
+	user_id BIGINT NOT NULL,
+	watchlist_id BIGINT NOT NULL,
+	entry_id BIGINT NOT NULL,
+	external_id VARCHAR(100) NULL,
+	entry_name VARCHAR(255) NOT NULL,
+	matched_name VARCHAR(255) NOT NULL,
+	score DOUBLE NOT NULL,
+	status VARCHAR(20) NOT NULL DEFAULT 'open',
+	reviewed_by BIGINT NULL,
+	reviewed_at TIMESTAMP NULL DEFAULT NULL,
+	note TEXT NULL,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	INDEX screening_hits_result_id_index (result_id),
+	INDEX screening_hits_user_id_index (user_id),
+	INDEX screening_hits_status_index (status),
+	FOREIGN KEY (result_id) REFERENCES screening_results(id) ON DELETE CASCADE,
+	FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+	FOREIGN KEY (entry_id) REFERENCES watchlist_entries(id) ON DELETE CASCADE,
+	FOREIGN KEY (reviewed_by) REFERENCES users(id) ON DELETE SET NULL
+);
+
+-- --- DOWN Migration
+DROP TABLE IF EXISTS screening_hits;
+DROP TABLE IF EXISTS screening_results;
+DROP TABLE IF EXISTS watchlist_entries;
+ALTER TABLE watchlists DROP FOREIGN KEY watchlists_ibfk_1;
+DROP TABLE IF EXISTS watchlist_versions;
+DROP TABLE IF EXISTS watchlists;
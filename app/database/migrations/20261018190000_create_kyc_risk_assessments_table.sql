@@ -0,0 +1,21 @@
+-- +++ UP Migration
+CREATE TABLE kyc_risk_assessments (
+	id BIGINT AUTO_INCREMENT PRIMARY KEY,
+	submission_id BIGINT NOT NULL,
+	user_id BIGINT NOT NULL,
+	score DOUBLE NOT NULL DEFAULT 0,
+	tier VARCHAR(10) NOT NULL,
+	rules_version VARCHAR(50) NULL,
+	rules_checksum VARCHAR(64) NULL,
+	contributions JSON NULL,
+	facts JSON NULL,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	INDEX kyc_risk_assessments_submission_id_index (submission_id),
+	INDEX kyc_risk_assessments_user_id_index (user_id),
+	INDEX kyc_risk_assessments_tier_index (tier),
+	FOREIGN KEY (submission_id) REFERENCES kyc_submissions(id) ON DELETE CASCADE,
+	FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+-- --- DOWN Migration
+DROP TABLE IF EXISTS kyc_risk_assessments;
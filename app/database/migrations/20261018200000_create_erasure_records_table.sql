@@ -0,0 +1,21 @@
+-- +++ UP Migration
+ALTER TABLE kyc_submissions
+ADD COLUMN anonymized_at TIMESTAMP NULL DEFAULT NULL AFTER decided_at;
+CREATE TABLE erasure_records (
+	id BIGINT AUTO_INCREMENT PRIMARY KEY,
+	user_id BIGINT NOT NULL,
+	user_reference VARCHAR(100) NOT NULL,
+	reason VARCHAR(255) NULL,
+	requested_by BIGINT NULL,
+	submissions JSON NULL,
+	files_deleted INT NOT NULL DEFAULT 0,
+	erased_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	UNIQUE INDEX erasure_records_user_id_unique (user_id),
+	FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+	FOREIGN KEY (requested_by) REFERENCES users(id) ON DELETE SET NULL
+);
+
+-- --- DOWN Migration
+DROP TABLE IF EXISTS erasure_records;
+ALTER TABLE kyc_submissions
+DROP COLUMN anonymized_at;
@@ -0,0 +1,83 @@
+-- +++ UP Migration
+-- alter_stores_table only added placeholder columns. They are dropped here
+-- instead of editing that migration, which has already run on existing
+-- databases.
+ALTER TABLE stores
+DROP COLUMN new_column_name,
+DROP COLUMN another_column_name,
+DROP COLUMN yet_another_column_name,
+MODIFY id BIGINT NOT NULL AUTO_INCREMENT,
+ADD COLUMN reference VARCHAR(100) NULL AFTER id,
+ADD COLUMN owner_id BIGINT NULL AFTER reference,
+ADD COLUMN legal_name VARCHAR(255) NULL AFTER owner_nik_bidx,
+ADD COLUMN business_type VARCHAR(20) NULL AFTER legal_name,
+ADD COLUMN nib VARCHAR(13) NULL AFTER business_type,
+ADD COLUMN npwp VARCHAR(16) NULL AFTER nib,
+ADD COLUMN owner_submission_id BIGINT NULL AFTER zip,
+ADD COLUMN status VARCHAR(30) NOT NULL DEFAULT 'draft' AFTER owner_submission_id,
+ADD COLUMN reviewer_id BIGINT NULL AFTER status,
+ADD COLUMN reason_code VARCHAR(50) NULL AFTER reviewer_id,
+ADD COLUMN reviewer_comment TEXT NULL AFTER reason_code,
+ADD COLUMN submitted_at TIMESTAMP NULL DEFAULT NULL AFTER reviewer_comment,
+ADD COLUMN review_started_at TIMESTAMP NULL DEFAULT NULL AFTER submitted_at,
+ADD COLUMN decided_at TIMESTAMP NULL DEFAULT NULL AFTER review_started_at,
+ADD UNIQUE INDEX stores_reference_unique (reference),
+ADD INDEX stores_owner_id_index (owner_id),
+ADD INDEX stores_status_index (status),
+ADD CONSTRAINT stores_owner_id_foreign FOREIGN KEY (owner_id) REFERENCES users(id) ON DELETE CASCADE,
+ADD CONSTRAINT stores_owner_submission_id_foreign FOREIGN KEY (owner_submission_id) REFERENCES kyc_submissions(id) ON DELETE SET NULL,
+ADD CONSTRAINT stores_reviewer_id_foreign FOREIGN KEY (reviewer_id) REFERENCES users(id) ON DELETE SET NULL;
+CREATE TABLE store_documents (
+	id BIGINT AUTO_INCREMENT PRIMARY KEY,
+	store_id BIGINT NOT NULL,
+	type VARCHAR(30) NOT NULL,
+	`key` VARCHAR(100) NOT NULL,
+	file_name VARCHAR(255) NOT NULL,
+	mime_type VARCHAR(50) NULL,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+	UNIQUE INDEX store_documents_store_type_unique (store_id, type),
+	FOREIGN KEY (store_id) REFERENCES stores(id) ON DELETE CASCADE
+);
+CREATE TABLE store_transitions (
+	id BIGINT AUTO_INCREMENT PRIMARY KEY,
+	store_id BIGINT NOT NULL,
+	from_status VARCHAR(30) NOT NULL,
+	to_status VARCHAR(30) NOT NULL,
+	actor_id BIGINT NULL,
+	reason_code VARCHAR(50) NULL,
+	comment TEXT NULL,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	INDEX store_transitions_store_id_index (store_id),
+	FOREIGN KEY (store_id) REFERENCES stores(id) ON DELETE CASCADE,
+	FOREIGN KEY (actor_id) REFERENCES users(id) ON DELETE SET NULL
+);
+
+-- --- DOWN Migration
+DROP TABLE IF EXISTS store_transitions;
+DROP TABLE IF EXISTS store_documents;
+ALTER TABLE stores
+DROP FOREIGN KEY stores_reviewer_id_foreign,
+DROP FOREIGN KEY stores_owner_submission_id_foreign,
+DROP FOREIGN KEY stores_owner_id_foreign,
+DROP INDEX stores_status_index,
+DROP INDEX stores_owner_id_index,
+DROP INDEX stores_reference_unique,
+DROP COLUMN decided_at,
+DROP COLUMN review_started_at,
+DROP COLUMN submitted_at,
+DROP COLUMN reviewer_comment,
+DROP COLUMN reason_code,
+DROP COLUMN reviewer_id,
+DROP COLUMN status,
+DROP COLUMN owner_submission_id,
+DROP COLUMN npwp,
+DROP COLUMN nib,
+DROP COLUMN business_type,
+DROP COLUMN legal_name,
+DROP COLUMN owner_id,
+DROP COLUMN reference,
+MODIFY id INT NOT NULL AUTO_INCREMENT,
+ADD COLUMN new_column_name VARCHAR(255) NOT NULL DEFAULT 'default_value',
+ADD COLUMN another_column_name INT NOT NULL DEFAULT 0,
+ADD COLUMN yet_another_column_name TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP;
@@ -0,0 +1,25 @@
+-- +++ UP Migration
+CREATE TABLE uploads (
+	id BIGINT AUTO_INCREMENT PRIMARY KEY,
+	reference VARCHAR(100) NOT NULL,
+	user_id BIGINT NOT NULL,
+	purpose VARCHAR(30) NOT NULL,
+	length BIGINT NOT NULL,
+	`offset` BIGINT NOT NULL DEFAULT 0,
+	metadata JSON NULL,
+	parts JSON NULL,
+	`key` VARCHAR(100) NULL,
+	file_name VARCHAR(255) NULL,
+	mime_type VARCHAR(100) NULL,
+	expires_at TIMESTAMP NOT NULL,
+	completed_at TIMESTAMP NULL DEFAULT NULL,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+	UNIQUE INDEX uploads_reference_unique (reference),
+	INDEX uploads_user_id_index (user_id),
+	INDEX uploads_expires_at_index (expires_at),
+	FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+-- --- DOWN Migration
+DROP TABLE IF EXISTS uploads;
@@ -0,0 +1,25 @@
+-- +++ UP Migration
+CREATE TABLE quarantined_files (
+	id BIGINT AUTO_INCREMENT PRIMARY KEY,
+	reference VARCHAR(100) NOT NULL,
+	user_id BIGINT NULL,
+	purpose VARCHAR(30) NOT NULL,
+	original_name VARCHAR(255) NULL,
+	declared_type VARCHAR(100) NULL,
+	detected_type VARCHAR(100) NULL,
+	size BIGINT NOT NULL DEFAULT 0,
+	sha256 CHAR(64) NOT NULL,
+	reason VARCHAR(30) NOT NULL,
+	detail VARCHAR(255) NULL,
+	`key` VARCHAR(100) NULL,
+	file_name VARCHAR(255) NULL,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	UNIQUE INDEX quarantined_files_reference_unique (reference),
+	INDEX quarantined_files_user_id_index (user_id),
+	INDEX quarantined_files_sha256_index (sha256),
+	INDEX quarantined_files_reason_index (reason),
+	FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE SET NULL
+);
+
+-- --- DOWN Migration
+DROP TABLE IF EXISTS quarantined_files;
@@ -0,0 +1,48 @@
+-- +++ UP Migration
+CREATE TABLE files (
+	id BIGINT AUTO_INCREMENT PRIMARY KEY,
+	reference VARCHAR(100) NOT NULL,
+	owner_id BIGINT NULL,
+	purpose VARCHAR(30) NOT NULL,
+	original_name VARCHAR(255) NULL,
+	mime_type VARCHAR(100) NULL,
+	size BIGINT NOT NULL DEFAULT 0,
+	sha256 CHAR(64) NULL,
+	disk VARCHAR(50) NOT NULL,
+	path VARCHAR(255) NOT NULL,
+	unreferenced_at TIMESTAMP NULL,
+	missing_at TIMESTAMP NULL,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+	UNIQUE INDEX files_reference_unique (reference),
+	INDEX files_owner_id_index (owner_id),
+	INDEX files_sha256_index (sha256),
+	INDEX files_path_index (path),
+	INDEX files_unreferenced_at_index (unreferenced_at),
+	FOREIGN KEY (owner_id) REFERENCES users(id) ON DELETE SET NULL
+);
+
+-- documents written before this migration keep key and file_name until
+-- files:index has moved them to the files table
+ALTER TABLE kyc_documents
+ADD COLUMN file_id BIGINT NULL AFTER type,
+MODIFY `key` VARCHAR(100) NULL,
+MODIFY file_name VARCHAR(255) NULL,
+ADD CONSTRAINT kyc_documents_file_id_foreign FOREIGN KEY (file_id) REFERENCES files(id);
+
+ALTER TABLE store_documents
+ADD COLUMN file_id BIGINT NULL AFTER type,
+MODIFY `key` VARCHAR(100) NULL,
+MODIFY file_name VARCHAR(255) NULL,
+ADD CONSTRAINT store_documents_file_id_foreign FOREIGN KEY (file_id) REFERENCES files(id);
+
+-- --- DOWN Migration
+ALTER TABLE store_documents
+DROP FOREIGN KEY store_documents_file_id_foreign,
+DROP COLUMN file_id;
+
+ALTER TABLE kyc_documents
+DROP FOREIGN KEY kyc_documents_file_id_foreign,
+DROP COLUMN file_id;
+
+DROP TABLE IF EXISTS files;
@@ -0,0 +1,33 @@
+-- +++ UP Migration
+ALTER TABLE files
+ADD COLUMN access VARCHAR(20) NOT NULL DEFAULT 'signed' AFTER path,
+ADD COLUMN permission VARCHAR(100) NULL AFTER access,
+ADD COLUMN sensitive TINYINT(1) NOT NULL DEFAULT 0 AFTER permission;
+
+UPDATE files SET access = 'owner', permission = 'kyc.view', sensitive = 1 WHERE purpose = 'kyc_document';
+UPDATE files SET access = 'owner', permission = 'store.view', sensitive = 1 WHERE purpose = 'store_document';
+
+-- logs outlive the files they are about, so file_id has no foreign key
+CREATE TABLE file_access_logs (
+	id BIGINT AUTO_INCREMENT PRIMARY KEY,
+	file_id BIGINT NOT NULL,
+	user_id BIGINT NULL,
+	via VARCHAR(20) NULL,
+	granted TINYINT(1) NOT NULL DEFAULT 0,
+	ip VARCHAR(45) NULL,
+	user_agent VARCHAR(255) NULL,
+	variant VARCHAR(255) NULL,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	INDEX file_access_logs_file_id_index (file_id),
+	INDEX file_access_logs_user_id_index (user_id),
+	INDEX file_access_logs_created_at_index (created_at),
+	FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE SET NULL
+);
+
+-- --- DOWN Migration
+DROP TABLE IF EXISTS file_access_logs;
+
+ALTER TABLE files
+DROP COLUMN sensitive,
+DROP COLUMN permission,
+DROP COLUMN access;
@@ -0,0 +1,27 @@
+-- +++ UP Migration
+ALTER TABLE kyc_documents
+ADD COLUMN phash_band0 SMALLINT UNSIGNED NULL AFTER phash,
+ADD COLUMN phash_band1 SMALLINT UNSIGNED NULL AFTER phash_band0,
+ADD COLUMN phash_band2 SMALLINT UNSIGNED NULL AFTER phash_band1,
+ADD COLUMN phash_band3 SMALLINT UNSIGNED NULL AFTER phash_band2,
+ADD INDEX kyc_documents_phash_band0_index (phash_band0),
+ADD INDEX kyc_documents_phash_band1_index (phash_band1),
+ADD INDEX kyc_documents_phash_band2_index (phash_band2),
+ADD INDEX kyc_documents_phash_band3_index (phash_band3);
+UPDATE kyc_documents SET
+phash_band0 = CONV(SUBSTRING(phash, 1, 4), 16, 10),
+phash_band1 = CONV(SUBSTRING(phash, 5, 4), 16, 10),
+phash_band2 = CONV(SUBSTRING(phash, 9, 4), 16, 10),
+phash_band3 = CONV(SUBSTRING(phash, 13, 4), 16, 10)
+WHERE phash IS NOT NULL AND phash <> '';
+
+-- --- DOWN Migration
+ALTER TABLE kyc_documents
+DROP INDEX kyc_documents_phash_band3_index,
+DROP INDEX kyc_documents_phash_band2_index,
+DROP INDEX kyc_documents_phash_band1_index,
+DROP INDEX kyc_documents_phash_band0_index,
+DROP COLUMN phash_band3,
+DROP COLUMN phash_band2,
+DROP COLUMN phash_band1,
+DROP COLUMN phash_band0;
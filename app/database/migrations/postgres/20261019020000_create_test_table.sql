@@ -0,0 +1,17 @@
+-- +++ UP Migration
+CREATE TABLE IF NOT EXISTS test (
+	id BIGSERIAL PRIMARY KEY,
+	name VARCHAR(255) NOT NULL DEFAULT '',
+	description TEXT,
+	age INTEGER NOT NULL DEFAULT 0,
+	price NUMERIC(12, 2) NOT NULL DEFAULT 0,
+	is_active BOOLEAN NOT NULL DEFAULT FALSE,
+	birth_date TIMESTAMPTZ,
+	login_time VARCHAR(50),
+	ip_address VARCHAR(45),
+	data_json TEXT,
+	file_bytea BYTEA
+);
+
+-- --- DOWN Migration
+DROP TABLE IF EXISTS test;
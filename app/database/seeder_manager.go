@@ -24,6 +24,10 @@ var SeederList = []Seeder{
 		Run:      seeds.SeedUserSeeder,
 		Rollback: seeds.RollbackUserSeeder,
 	},
+	{Name: "UserRoleSeeder",
+		Run:      seeds.SeedUserRoleSeeder,
+		Rollback: seeds.RollbackUserRoleSeeder,
+	},
 }
 
 func ensureSeedsTable() error {
@@ -0,0 +1,35 @@
+package seeds
+
+import (
+	"log"
+
+	"golang_starter_kit_2025/app/models"
+	"golang_starter_kit_2025/app/permissions"
+
+	"gorm.io/gorm"
+)
+
+func SeedUserRoleSeeder(db *gorm.DB) error {
+	log.Println("🌱 Seeding UserRoleSeeder...")
+
+	var user models.User
+	if err := db.Where("username = ?", "admin").First(&user).Error; err != nil {
+		return err
+	}
+
+	role := models.Role{Name: permissions.RoleAdmin}
+	if err := db.Where("name = ?", permissions.RoleAdmin).Attrs(models.Role{Group: "System"}).FirstOrCreate(&role).Error; err != nil {
+		return err
+	}
+
+	return db.Where(models.UserHasRole{UserID: user.ID, RoleID: role.ID}).
+		FirstOrCreate(&models.UserHasRole{}).
+		Error
+}
+
+func RollbackUserRoleSeeder(db *gorm.DB) error {
+	log.Println("🗑️ Rolling back UserRoleSeeder…")
+	return db.Where("user_id IN (?)", db.Model(&models.User{}).Select("id").Where("username = ?", "admin")).
+		Delete(&models.UserHasRole{}).
+		Error
+}
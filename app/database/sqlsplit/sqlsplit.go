@@ -0,0 +1,462 @@
+// Package sqlsplit splits migration files into the statements that are sent
+// to the database one by one. It lexes the SQL of each dialect instead of
+// cutting at every semicolon, so semicolons inside string literals, quoted
+// identifiers, comments, PostgreSQL dollar-quoted bodies and the BEGIN ... END
+// blocks of triggers and procedures do not end a statement. MySQL DELIMITER
+// commands and SQL Server GO separators are understood as well.
+package sqlsplit
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Dialect selects the lexical rules of a database. The values match the
+// database types of the connection configuration.
+type Dialect string
+
+const (
+	MySQL      Dialect = "mysql"
+	PostgreSQL Dialect = "postgres"
+	SQLite     Dialect = "sqlite"
+	SQLServer  Dialect = "sqlserver"
+)
+
+var ErrUnterminated = errors.New("unterminated")
+
+// Migration holds the statements of the two sections of a migration file
+type Migration struct {
+	Up   []string
+	Down []string
+}
+
+// Section markers, one per line. Text before the first marker belongs to the
+// UP section.
+var (
+	upMarkers = []string{"-- +++ UP Migration", "-- +goose Up", "-- +migrate Up", "-- migrate:up", "-- UP"}
+
+	downMarkers = []string{"-- --- DOWN Migration", "-- +goose Down", "-- +migrate Down", "-- migrate:down", "-- DOWN"}
+
+	// everything between these lines is sent as one statement, untouched
+	statementBeginMarkers = []string{"-- +++ StatementBegin", "-- +goose StatementBegin", "-- +migrate StatementBegin"}
+
+	statementEndMarkers = []string{"-- +++ StatementEnd", "-- +goose StatementEnd", "-- +migrate StatementEnd"}
+)
+
+// notCompound are words that show a CREATE statement does not define a
+// trigger, procedure or function, even when one of those words follows
+var notCompound = map[string]bool{
+	"TABLE": true, "INDEX": true, "VIEW": true, "SEQUENCE": true, "TYPE": true, "SCHEMA": true,
+	"DATABASE": true, "EXTENSION": true, "DOMAIN": true, "ROLE": true, "USER": true, "UNIQUE": true,
+	"MATERIALIZED": true,
+}
+
+// ParseMigration returns the UP and DOWN statements of a migration file
+func ParseMigration(content string, dialect Dialect) (Migration, error) {
+	l := newLexer(content, dialect)
+	l.markers = true
+	if err := l.run(); err != nil {
+		return Migration{}, err
+	}
+	return Migration{Up: l.statements[0], Down: l.statements[1]}, nil
+}
+
+// Split returns the statements of a SQL script. Section markers are plain
+// comments here.
+func Split(content string, dialect Dialect) ([]string, error) {
+	l := newLexer(content, dialect)
+	if err := l.run(); err != nil {
+		return nil, err
+	}
+	return l.statements[0], nil
+}
+
+type lexer struct {
+	src     string
+	dialect Dialect
+	// markers enables the section markers
+	markers bool
+
+	pos       int
+	delimiter string
+	section   int
+	// start is where the current statement begins
+	start int
+	// code is set once the current statement has more than comments
+	code bool
+	// words are the first words of the current statement, upper-cased
+	words []string
+	// compound is set for statements defining a trigger, procedure,
+	// function or event, whose BEGIN ... END blocks are counted in depth
+	compound   bool
+	depth      int
+	depthStart int
+
+	statements [2][]string
+}
+
+func newLexer(content string, dialect Dialect) *lexer {
+	return &lexer{src: content, dialect: dialect, delimiter: ";"}
+}
+
+func (l *lexer) run() error {
+	for l.pos < len(l.src) {
+		if l.pos == 0 || l.src[l.pos-1] == '\n' {
+			handled, err := l.directive()
+			if err != nil {
+				return err
+			}
+			if handled {
+				continue
+			}
+		}
+
+		if strings.HasPrefix(l.src[l.pos:], l.delimiter) && (l.delimiter != ";" || l.depth == 0) {
+			if err := l.emit(l.pos); err != nil {
+				return err
+			}
+			l.pos += len(l.delimiter)
+			l.start = l.pos
+			continue
+		}
+
+		var err error
+		c := l.src[l.pos]
+		switch {
+		case c == '-' && l.peek(1) == '-', c == '#' && l.dialect == MySQL:
+			l.skipLine()
+		case c == '/' && l.peek(1) == '*':
+			err = l.skipBlockComment()
+		case c == '\'':
+			err = l.skipQuoted('\'', '\'', l.dialect == MySQL)
+		case c == '"':
+			err = l.skipQuoted('"', '"', l.dialect == MySQL)
+		case c == '`' && (l.dialect == MySQL || l.dialect == SQLite):
+			err = l.skipQuoted('`', '`', false)
+		case c == '[' && (l.dialect == SQLServer || l.dialect == SQLite):
+			err = l.skipQuoted('[', ']', false)
+		case c == '$' && l.dialect == PostgreSQL:
+			err = l.skipDollarQuoted()
+		case isWordStart(c):
+			err = l.word()
+		default:
+			if !isSpace(c) {
+				l.code = true
+			}
+			l.pos++
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return l.emit(len(l.src))
+}
+
+// emit ends the current statement at end
+func (l *lexer) emit(end int) error {
+	if l.depth > 0 {
+		return fmt.Errorf("%w BEGIN block starting at line %d", ErrUnterminated, l.line(l.depthStart))
+	}
+	if l.code {
+		l.add(l.src[l.start:end])
+	}
+	l.code, l.words, l.compound = false, nil, false
+	return nil
+}
+
+func (l *lexer) add(statement string) {
+	l.statements[l.section] = append(l.statements[l.section], strings.TrimSpace(statement))
+}
+
+// directive handles a line that is a section marker, a statement block, a
+// MySQL DELIMITER command or a SQL Server GO separator. l.pos is at the
+// start of the line.
+func (l *lexer) directive() (bool, error) {
+	end := l.lineEnd(l.pos)
+	line := strings.TrimSpace(l.src[l.pos:end])
+	if line == "" {
+		return false, nil
+	}
+
+	switch {
+	case l.markers && isMarker(line, upMarkers), l.markers && isMarker(line, downMarkers):
+		if err := l.emit(l.pos); err != nil {
+			return false, err
+		}
+		l.section = 0
+		if isMarker(line, downMarkers) {
+			l.section = 1
+		}
+
+	case isMarker(line, statementBeginMarkers):
+		if err := l.emit(l.pos); err != nil {
+			return false, err
+		}
+		blockStart := l.skipNewline(end)
+		for lineStart := blockStart; ; lineStart = l.skipNewline(l.lineEnd(lineStart)) {
+			if lineStart >= len(l.src) {
+				return false, fmt.Errorf("%w statement block starting at line %d", ErrUnterminated, l.line(l.pos))
+			}
+			lineEnd := l.lineEnd(lineStart)
+			if isMarker(strings.TrimSpace(l.src[lineStart:lineEnd]), statementEndMarkers) {
+				statement := strings.TrimSpace(l.src[blockStart:lineStart])
+				statement = strings.TrimSpace(strings.TrimSuffix(statement, l.delimiter))
+				if statement != "" {
+					l.add(statement)
+				}
+				end = lineEnd
+				break
+			}
+		}
+
+	case l.dialect == MySQL && strings.EqualFold(firstField(line), "DELIMITER"):
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			return false, fmt.Errorf("line %d: DELIMITER needs exactly one delimiter", l.line(l.pos))
+		}
+		if err := l.emit(l.pos); err != nil {
+			return false, err
+		}
+		l.delimiter = fields[1]
+
+	case l.dialect == SQLServer && isGo(line):
+		l.depth = 0
+		if err := l.emit(l.pos); err != nil {
+			return false, err
+		}
+
+	default:
+		return false, nil
+	}
+
+	l.pos = end
+	l.start = end
+	return true, nil
+}
+
+// word reads a keyword or identifier and tracks the blocks of compound
+// statements
+func (l *lexer) word() error {
+	start := l.pos
+	for l.pos < len(l.src) && l.isWordChar(l.src[l.pos]) {
+		l.pos++
+	}
+	l.code = true
+	word := strings.ToUpper(l.src[start:l.pos])
+
+	// PostgreSQL E'...' strings use backslash escapes
+	if word == "E" && l.dialect == PostgreSQL && l.peek(0) == '\'' {
+		return l.skipQuoted('\'', '\'', true)
+	}
+
+	if len(l.words) < 8 {
+		l.words = append(l.words, word)
+		switch word {
+		case "TRIGGER", "PROCEDURE", "PROC", "FUNCTION", "EVENT":
+			l.compound = l.compound || l.words[0] == "CREATE" && !hasAny(l.words, notCompound)
+		}
+	}
+	if !l.compound {
+		return nil
+	}
+
+	switch word {
+	case "BEGIN":
+		switch next, _ := l.nextWord(); next {
+		case "TRAN", "TRANSACTION", "DISTRIBUTED", "WORK":
+		default:
+			if l.depth == 0 {
+				l.depthStart = start
+			}
+			l.depth++
+		}
+	case "CASE":
+		if l.depth == 0 {
+			l.depthStart = start
+		}
+		l.depth++
+	case "END":
+		// IF, LOOP, WHILE and REPEAT blocks are not counted, so neither are
+		// their ends
+		next, end := l.nextWord()
+		switch next {
+		case "IF", "LOOP", "WHILE", "REPEAT":
+		default:
+			if next == "CASE" {
+				// the CASE of END CASE opens nothing
+				l.pos = end
+			}
+			if l.depth > 0 {
+				l.depth--
+			}
+		}
+	}
+	return nil
+}
+
+// nextWord returns the upper-cased word after the current position and
+// where it ends
+func (l *lexer) nextWord() (string, int) {
+	i := l.pos
+	for i < len(l.src) && isSpace(l.src[i]) {
+		i++
+	}
+	start := i
+	for i < len(l.src) && l.isWordChar(l.src[i]) {
+		i++
+	}
+	return strings.ToUpper(l.src[start:i]), i
+}
+
+// skipQuoted skips a string or quoted identifier opened at l.pos. The
+// closing quote is escaped by doubling it and, where backslash is set, by a
+// backslash.
+func (l *lexer) skipQuoted(open, close byte, backslash bool) error {
+	start := l.pos
+	l.code = true
+	for i := l.pos + 1; i < len(l.src); i++ {
+		switch {
+		case backslash && l.src[i] == '\\':
+			i++
+		case l.src[i] == close:
+			if i+1 < len(l.src) && l.src[i+1] == close {
+				i++
+				continue
+			}
+			l.pos = i + 1
+			return nil
+		}
+	}
+	return fmt.Errorf("%w %c quote starting at line %d", ErrUnterminated, open, l.line(start))
+}
+
+// skipDollarQuoted skips a PostgreSQL $tag$ ... $tag$ string. A $ that does
+// not open one, like the $1 of a parameter, is an ordinary character.
+func (l *lexer) skipDollarQuoted() error {
+	start := l.pos
+	i := l.pos + 1
+	for i < len(l.src) && isTagChar(l.src[i], i == l.pos+1) {
+		i++
+	}
+	l.code = true
+	if i >= len(l.src) || l.src[i] != '$' {
+		l.pos++
+		return nil
+	}
+
+	tag := l.src[start : i+1]
+	closing := strings.Index(l.src[i+1:], tag)
+	if closing < 0 {
+		return fmt.Errorf("%w %s quote starting at line %d", ErrUnterminated, tag, l.line(start))
+	}
+	l.pos = i + 1 + closing + len(tag)
+	return nil
+}
+
+// skipBlockComment skips a /* */ comment, which nests in PostgreSQL. MySQL
+// /*! ... */ comments hold code that MySQL runs.
+func (l *lexer) skipBlockComment() error {
+	start := l.pos
+	if l.dialect == MySQL && l.peek(2) == '!' {
+		l.code = true
+	}
+	depth := 0
+	for i := l.pos; i < len(l.src)-1; i++ {
+		switch {
+		case l.src[i] == '/' && l.src[i+1] == '*':
+			if depth == 0 || l.dialect == PostgreSQL {
+				depth++
+			}
+			i++
+		case l.src[i] == '*' && l.src[i+1] == '/':
+			depth--
+			i++
+			if depth == 0 {
+				l.pos = i + 1
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf("%w comment starting at line %d", ErrUnterminated, l.line(start))
+}
+
+func (l *lexer) skipLine() {
+	l.pos = l.lineEnd(l.pos)
+}
+
+func (l *lexer) lineEnd(from int) int {
+	if i := strings.IndexByte(l.src[from:], '\n'); i >= 0 {
+		return from + i
+	}
+	return len(l.src)
+}
+
+func (l *lexer) skipNewline(at int) int {
+	if at < len(l.src) && l.src[at] == '\n' {
+		return at + 1
+	}
+	return at
+}
+
+func (l *lexer) line(at int) int {
+	return strings.Count(l.src[:at], "\n") + 1
+}
+
+func (l *lexer) peek(offset int) byte {
+	if l.pos+offset < len(l.src) {
+		return l.src[l.pos+offset]
+	}
+	return 0
+}
+
+func (l *lexer) isWordChar(c byte) bool {
+	return isWordStart(c) || c >= '0' && c <= '9' || c == '$' && l.dialect == PostgreSQL
+}
+
+func isWordStart(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c == '_' || c >= 0x80
+}
+
+func isTagChar(c byte, first bool) bool {
+	return isWordStart(c) || !first && c >= '0' && c <= '9'
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f' || c == '\v'
+}
+
+// isMarker reports whether line is one of markers, ignoring case and the
+// amount of space
+func isMarker(line string, markers []string) bool {
+	line = strings.Join(strings.Fields(line), " ")
+	for _, marker := range markers {
+		if strings.EqualFold(line, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// isGo reports whether line is a SQL Server batch separator
+func isGo(line string) bool {
+	fields := strings.Fields(line)
+	return len(fields) >= 1 && len(fields) <= 2 && strings.EqualFold(fields[0], "GO") &&
+		(len(fields) == 1 || strings.Trim(fields[1], "0123456789") == "")
+}
+
+func firstField(line string) string {
+	if fields := strings.Fields(line); len(fields) > 0 {
+		return fields[0]
+	}
+	return ""
+}
+
+func hasAny(words []string, set map[string]bool) bool {
+	for _, word := range words {
+		if set[word] {
+			return true
+		}
+	}
+	return false
+}
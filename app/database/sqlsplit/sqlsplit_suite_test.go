@@ -0,0 +1,13 @@
+package sqlsplit_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestSqlsplitSuite(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Sqlsplit Test Suite")
+}
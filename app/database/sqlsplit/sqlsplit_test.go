@@ -0,0 +1,124 @@
+package sqlsplit_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+
+	"golang_starter_kit_2025/app/database/sqlsplit"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func parseFile(path string, dialect sqlsplit.Dialect) sqlsplit.Migration {
+	content, err := os.ReadFile(path)
+	Expect(err).NotTo(HaveOccurred())
+	migration, err := sqlsplit.ParseMigration(string(content), dialect)
+	Expect(err).NotTo(HaveOccurred())
+	return migration
+}
+
+var _ = Describe("ParseMigration", func() {
+	DescribeTable("splits the corpus into whole statements",
+		func(file string, dialect sqlsplit.Dialect, up []string, down int) {
+			migration := parseFile(filepath.Join("testdata", file), dialect)
+			Expect(migration.Up).To(HaveLen(len(up)))
+			for i, prefix := range up {
+				Expect(migration.Up[i]).To(HavePrefix(prefix))
+			}
+			Expect(migration.Down).To(HaveLen(down))
+			for _, statement := range append(migration.Up, migration.Down...) {
+				Expect(statement).NotTo(HaveSuffix(";"))
+			}
+		},
+		Entry("MySQL DELIMITER blocks", "mysql_delimiter_trigger.sql", sqlsplit.MySQL,
+			[]string{"CREATE TABLE audit_logs", "CREATE TRIGGER users_after_update", "INSERT INTO audit_logs"}, 2),
+		Entry("MySQL procedures and triggers without DELIMITER", "mysql_compound.sql", sqlsplit.MySQL,
+			[]string{"# procedures", "CREATE TRIGGER stores_before_insert", "CREATE TABLE `weird;name`", "/* a comment; with semicolons */", "/*!40101"}, 3),
+		Entry("PostgreSQL functions", "postgres_functions.sql", sqlsplit.PostgreSQL,
+			[]string{"CREATE OR REPLACE FUNCTION set_updated_at", "CREATE TRIGGER", "CREATE FUNCTION masked", "CREATE FUNCTION add_one", "CREATE FUNCTION is_active", "/* nested"}, 5),
+		Entry("goose statement blocks", "goose_statement_block.sql", sqlsplit.PostgreSQL,
+			[]string{"CREATE OR REPLACE FUNCTION notify_change", "CREATE INDEX"}, 2),
+		Entry("SQLite triggers", "sqlite_trigger.sql", sqlsplit.SQLite,
+			[]string{"CREATE TABLE notes", "CREATE TRIGGER IF NOT EXISTS notes_touch"}, 2),
+		Entry("SQL Server batches", "sqlserver_batches.sql", sqlsplit.SQLServer,
+			[]string{"CREATE TABLE [dbo].[ledger;entries]", "CREATE PROCEDURE dbo.post_entry", "CREATE INDEX"}, 2),
+	)
+
+	It("keeps the bodies of triggers and functions in one statement", func() {
+		migration := parseFile("testdata/mysql_delimiter_trigger.sql", sqlsplit.MySQL)
+		Expect(migration.Up[1]).To(HaveSuffix("END IF;\nEND"))
+
+		migration = parseFile("testdata/mysql_compound.sql", sqlsplit.MySQL)
+		Expect(migration.Up[0]).To(HaveSuffix("UNTIL done <= 0 END REPEAT;\nEND"))
+		Expect(migration.Up[1]).To(HaveSuffix("END CASE;\nEND"))
+
+		migration = parseFile("testdata/postgres_functions.sql", sqlsplit.PostgreSQL)
+		Expect(migration.Up[0]).To(HaveSuffix("$$ LANGUAGE plpgsql"))
+		Expect(migration.Up[2]).To(HaveSuffix("$body$ LANGUAGE sql IMMUTABLE"))
+
+		migration = parseFile("testdata/sqlserver_batches.sql", sqlsplit.SQLServer)
+		Expect(migration.Up[1]).To(HaveSuffix("END CATCH\nEND"))
+	})
+
+	It("parses every migration of the application", func() {
+		files, err := filepath.Glob("../migrations/*.sql")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(files).NotTo(BeEmpty())
+		for _, file := range files {
+			Expect(parseFile(file, sqlsplit.MySQL).Up).NotTo(BeEmpty(), file)
+		}
+	})
+
+	It("ends a statement without semicolon at a section marker", func() {
+		migration, err := sqlsplit.ParseMigration("-- UP\nCREATE TABLE a (id INT)\n-- DOWN\nDROP TABLE a\n", sqlsplit.MySQL)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(migration).To(Equal(sqlsplit.Migration{Up: []string{"CREATE TABLE a (id INT)"}, Down: []string{"DROP TABLE a"}}))
+	})
+
+	It("drops statements that only hold comments", func() {
+		migration, err := sqlsplit.ParseMigration("-- +++ UP Migration\nALTER TABLE a\n-- ADD COLUMN b INT\n;\n-- nothing else;\n/* ; */", sqlsplit.MySQL)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(migration.Up).To(Equal([]string{"ALTER TABLE a\n-- ADD COLUMN b INT"}))
+		Expect(migration.Down).To(BeEmpty())
+	})
+
+	DescribeTable("reports unterminated input with its line",
+		func(content string, dialect sqlsplit.Dialect, message string) {
+			_, err := sqlsplit.ParseMigration(content, dialect)
+			Expect(errors.Is(err, sqlsplit.ErrUnterminated)).To(BeTrue())
+			Expect(err.Error()).To(ContainSubstring(message))
+		},
+		Entry("string", "SELECT 1;\nSELECT 'a;", sqlsplit.MySQL, "line 2"),
+		Entry("dollar quote", "SELECT $x$ a;", sqlsplit.PostgreSQL, "$x$ quote starting at line 1"),
+		Entry("comment", "\n\n/* a;", sqlsplit.SQLite, "comment starting at line 3"),
+		Entry("BEGIN block", "CREATE TRIGGER t BEFORE INSERT ON a\nFOR EACH ROW BEGIN\nSET NEW.b = 1;", sqlsplit.MySQL, "BEGIN block starting at line 2"),
+		Entry("statement block", "-- +goose StatementBegin\nSELECT 1;", sqlsplit.PostgreSQL, "statement block starting at line 1"),
+	)
+})
+
+var _ = Describe("Split", func() {
+	It("treats section markers as comments", func() {
+		statements, err := sqlsplit.Split("-- +++ UP Migration\nSELECT 1;\n-- --- DOWN Migration\nSELECT 2;", sqlsplit.MySQL)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(statements).To(Equal([]string{"-- +++ UP Migration\nSELECT 1", "-- --- DOWN Migration\nSELECT 2"}))
+	})
+
+	It("only applies the lexical rules of the dialect", func() {
+		// backslashes escape quotes in MySQL only
+		statements, err := sqlsplit.Split(`SELECT 'a\'; SELECT 1;`, sqlsplit.PostgreSQL)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(statements).To(Equal([]string{`SELECT 'a\'`, "SELECT 1"}))
+
+		// # starts a comment in MySQL only
+		statements, err = sqlsplit.Split("SELECT 1 # a; b\n;", sqlsplit.MySQL)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(statements).To(HaveLen(1))
+
+		// GO only separates SQL Server batches
+		statements, err = sqlsplit.Split("SELECT 1\nGO\nSELECT 2", sqlsplit.SQLServer)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(statements).To(Equal([]string{"SELECT 1", "SELECT 2"}))
+	})
+})
@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION notify_change() RETURNS trigger AS '
+BEGIN
+	PERFORM pg_notify(''changes'', NEW.id::text);
+	RETURN NEW;
+END;
+' LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+CREATE INDEX users_email_index ON users (email);
+
+-- +goose Down
+-- +goose StatementBegin
+DROP FUNCTION IF EXISTS notify_change();
+-- +goose StatementEnd
+DROP INDEX IF EXISTS users_email_index;
@@ -0,0 +1,48 @@
+-- +++ UP Migration
+# procedures and triggers without DELIMITER, as sent by an application
+CREATE DEFINER=`root`@`%` PROCEDURE close_expired_sessions(IN max_age INT)
+BEGIN
+	DECLARE done INT DEFAULT 0;
+	DECLARE session_id BIGINT;
+	DECLARE cur CURSOR FOR SELECT id FROM sessions WHERE created_at < NOW() - INTERVAL max_age SECOND;
+	DECLARE CONTINUE HANDLER FOR NOT FOUND SET done = 1;
+
+	OPEN cur;
+	read_loop: LOOP
+		FETCH cur INTO session_id;
+		IF done THEN
+			LEAVE read_loop;
+		END IF;
+		UPDATE sessions
+		SET status = CASE WHEN revoked THEN 'revoked' ELSE 'expired' END
+		WHERE id = session_id;
+	END LOOP;
+	CLOSE cur;
+
+	WHILE done = 0 DO
+		SET done = 1;
+	END WHILE;
+
+	REPEAT
+		SET done = done - 1;
+	UNTIL done <= 0 END REPEAT;
+END;
+
+CREATE TRIGGER stores_before_insert BEFORE INSERT ON stores
+FOR EACH ROW
+BEGIN
+	CASE NEW.type
+		WHEN 'online' THEN SET NEW.address = NULL;
+		ELSE SET NEW.type = 'offline';
+	END CASE;
+END;
+
+CREATE TABLE `weird;name` (`col;umn` INT) COMMENT = 'it\'s; fine';
+/* a comment; with semicolons */
+INSERT INTO settings (`key`, value) VALUES ("greeting", "say \"hi\"; then leave");
+/*!40101 SET NAMES utf8mb4 */;
+
+-- --- DOWN Migration
+DROP TABLE IF EXISTS `weird;name`;
+DROP TRIGGER IF EXISTS stores_before_insert;
+DROP PROCEDURE IF EXISTS close_expired_sessions;
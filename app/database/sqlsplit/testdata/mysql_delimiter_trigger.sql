@@ -0,0 +1,23 @@
+-- +++ UP Migration
+CREATE TABLE audit_logs (
+	id BIGINT AUTO_INCREMENT PRIMARY KEY,
+	message VARCHAR(255) NOT NULL DEFAULT 'n/a; none',
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+DELIMITER $$
+CREATE TRIGGER users_after_update
+AFTER UPDATE ON users
+FOR EACH ROW
+BEGIN
+	IF NEW.status <> OLD.status THEN
+		INSERT INTO audit_logs (message) VALUES (CONCAT('status; ', OLD.status, ' -> ', NEW.status));
+	END IF;
+END$$
+DELIMITER ;
+
+INSERT INTO audit_logs (message) VALUES ('trigger installed');
+
+-- --- DOWN Migration
+DROP TRIGGER IF EXISTS users_after_update;
+DROP TABLE IF EXISTS audit_logs;
@@ -0,0 +1,34 @@
+-- +goose Up
+CREATE OR REPLACE FUNCTION set_updated_at() RETURNS trigger AS $$
+BEGIN
+	NEW.updated_at = NOW();
+	RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER users_set_updated_at
+BEFORE UPDATE ON users
+FOR EACH ROW EXECUTE FUNCTION set_updated_at();
+
+CREATE FUNCTION masked(value text) RETURNS text AS $body$
+	-- $$ and ; inside a tagged body
+	SELECT CASE WHEN length(value) > 4 THEN '****' || right(value, 4) ELSE value END;
+$body$ LANGUAGE sql IMMUTABLE;
+
+CREATE FUNCTION add_one(integer) RETURNS integer AS 'SELECT $1 + 1;' LANGUAGE sql;
+
+CREATE FUNCTION is_active(status text) RETURNS boolean
+LANGUAGE sql
+BEGIN ATOMIC
+	SELECT CASE WHEN status = 'active' THEN true ELSE false END;
+END;
+
+/* nested /* comments; */ still a comment; */
+INSERT INTO "odd;table" ("col;1", note) VALUES (1, E'it\'s; escaped');
+
+-- +goose Down
+DROP FUNCTION IF EXISTS is_active(text);
+DROP FUNCTION IF EXISTS add_one(integer);
+DROP FUNCTION IF EXISTS masked(text);
+DROP TRIGGER IF EXISTS users_set_updated_at ON users;
+DROP FUNCTION IF EXISTS set_updated_at();
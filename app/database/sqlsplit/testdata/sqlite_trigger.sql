@@ -0,0 +1,17 @@
+-- migrate:up
+CREATE TABLE notes (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	body TEXT NOT NULL DEFAULT '',
+	[order;index] INTEGER,
+	updated_at DATETIME
+);
+
+CREATE TRIGGER IF NOT EXISTS notes_touch AFTER UPDATE ON notes
+BEGIN
+	UPDATE notes SET updated_at = CURRENT_TIMESTAMP WHERE id = NEW.id;
+	INSERT INTO note_events (note_id, kind) VALUES (NEW.id, CASE WHEN NEW.body = '' THEN 'cleared' ELSE 'edited' END);
+END;
+
+-- migrate:down
+DROP TRIGGER IF EXISTS notes_touch;
+DROP TABLE IF EXISTS notes;
@@ -0,0 +1,30 @@
+-- +++ UP Migration
+CREATE TABLE [dbo].[ledger;entries] (
+	[id] INT IDENTITY(1,1) PRIMARY KEY,
+	[memo] NVARCHAR(255) NOT NULL DEFAULT N'none; yet',
+	[amount] DECIMAL(18, 2) NOT NULL
+);
+GO
+
+CREATE PROCEDURE dbo.post_entry @memo NVARCHAR(255), @amount DECIMAL(18, 2)
+AS
+BEGIN
+	SET NOCOUNT ON;
+	BEGIN TRANSACTION;
+	BEGIN TRY
+		INSERT INTO [dbo].[ledger;entries] ([memo], [amount]) VALUES (@memo, @amount);
+		COMMIT;
+	END TRY
+	BEGIN CATCH
+		ROLLBACK;
+		THROW;
+	END CATCH
+END;
+GO
+
+CREATE INDEX ledger_entries_amount_index ON [dbo].[ledger;entries] ([amount]);
+
+-- --- DOWN Migration
+DROP PROCEDURE IF EXISTS dbo.post_entry;
+GO
+DROP TABLE IF EXISTS [dbo].[ledger;entries];
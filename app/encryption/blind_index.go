@@ -0,0 +1,28 @@
+package encryption
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// BlindIndex returns a keyed hash of value for exact-match lookups on
+// encrypted columns. The purpose separates indexes of different fields, so
+// equal values in different columns do not produce equal hashes. Callers
+// normalise value first.
+func (r *KeyRing) BlindIndex(purpose, value string) string {
+	mac := hmac.New(sha256.New, r.blindKey)
+	mac.Write([]byte(purpose))
+	mac.Write([]byte{0})
+	mac.Write([]byte(value))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// BlindIndex computes a blind index with the default key ring
+func BlindIndex(purpose, value string) (string, error) {
+	ring, err := Default()
+	if err != nil {
+		return "", err
+	}
+	return ring.BlindIndex(purpose, value), nil
+}
@@ -0,0 +1,13 @@
+package encryption_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestEncryptionSuite(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Encryption Test Suite")
+}
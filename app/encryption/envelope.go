@@ -0,0 +1,143 @@
+package encryption
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// prefix marks encrypted values: enc:v<version>:<wrapped data key>:<ciphertext>
+const prefix = "enc:v"
+
+var wrapAAD = []byte("data-key")
+
+// WrapKey encrypts a data key with the active master key
+func (r *KeyRing) WrapKey(dataKey []byte) (uint32, []byte, error) {
+	master, err := r.key(r.active)
+	if err != nil {
+		return 0, nil, err
+	}
+	wrapped, err := seal(master, dataKey, wrapAAD)
+	return r.active, wrapped, err
+}
+
+// UnwrapKey decrypts a data key wrapped under the given master key version
+func (r *KeyRing) UnwrapKey(version uint32, wrapped []byte) ([]byte, error) {
+	master, err := r.key(version)
+	if err != nil {
+		return nil, err
+	}
+	return open(master, wrapped, wrapAAD)
+}
+
+// NewDataKey returns a random key for a single value or file
+func NewDataKey() ([]byte, error) {
+	key := make([]byte, keySize)
+	_, err := rand.Read(key)
+	return key, err
+}
+
+// Encrypt seals plaintext under a new data key wrapped by the active master
+// key and returns the textual envelope
+func (r *KeyRing) Encrypt(plaintext []byte) (string, error) {
+	dataKey, err := NewDataKey()
+	if err != nil {
+		return "", err
+	}
+	version, wrapped, err := r.WrapKey(dataKey)
+	if err != nil {
+		return "", err
+	}
+	sealed, err := seal(dataKey, plaintext, nil)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%s%d:%s:%s", prefix, version,
+		base64.RawStdEncoding.EncodeToString(wrapped),
+		base64.RawStdEncoding.EncodeToString(sealed)), nil
+}
+
+// Decrypt opens an envelope produced by Encrypt
+func (r *KeyRing) Decrypt(value string) ([]byte, error) {
+	version, wrapped, sealed, err := parseEnvelope(value)
+	if err != nil {
+		return nil, err
+	}
+	dataKey, err := r.UnwrapKey(version, wrapped)
+	if err != nil {
+		return nil, err
+	}
+	return open(dataKey, sealed, nil)
+}
+
+// NeedsRotation reports whether value is plaintext or encrypted under a key
+// version other than the active one
+func (r *KeyRing) NeedsRotation(value string) bool {
+	version, _, _, err := parseEnvelope(value)
+	return err != nil || version != r.active
+}
+
+// IsEncrypted reports whether value looks like an envelope
+func IsEncrypted(value string) bool {
+	return strings.HasPrefix(value, prefix)
+}
+
+func parseEnvelope(value string) (uint32, []byte, []byte, error) {
+	if !IsEncrypted(value) {
+		return 0, nil, nil, ErrMalformed
+	}
+	parts := strings.Split(strings.TrimPrefix(value, prefix), ":")
+	if len(parts) != 3 {
+		return 0, nil, nil, ErrMalformed
+	}
+	version, err := strconv.ParseUint(parts[0], 10, 32)
+	if err != nil {
+		return 0, nil, nil, ErrMalformed
+	}
+	wrapped, err := base64.RawStdEncoding.DecodeString(parts[1])
+	if err != nil {
+		return 0, nil, nil, ErrMalformed
+	}
+	sealed, err := base64.RawStdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return 0, nil, nil, ErrMalformed
+	}
+	return uint32(version), wrapped, sealed, nil
+}
+
+// seal encrypts with AES-GCM and prepends the random nonce
+func seal(key, plaintext, aad []byte) ([]byte, error) {
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, plaintext, aad), nil
+}
+
+func open(key, sealed, aad []byte) ([]byte, error) {
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < aead.NonceSize() {
+		return nil, ErrMalformed
+	}
+	return aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], aad)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
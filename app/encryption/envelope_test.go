@@ -0,0 +1,128 @@
+package encryption_test
+
+import (
+	"bytes"
+	"encoding/base64"
+	"os"
+	"strings"
+
+	"golang_starter_kit_2025/app/encryption"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func testKey(b byte) string {
+	return base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{b}, 32))
+}
+
+var _ = Describe("KeyRing", func() {
+	var ring *encryption.KeyRing
+
+	BeforeEach(func() {
+		var err error
+		ring, err = encryption.ParseKeyRing("1:"+testKey(1), "", testKey(9))
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("should round trip a value without exposing the plaintext", func() {
+		value, err := ring.Encrypt([]byte("3171011708900001"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(value).To(HavePrefix("enc:v1:"))
+		Expect(value).NotTo(ContainSubstring("3171011708900001"))
+
+		plaintext, err := ring.Decrypt(value)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(plaintext)).To(Equal("3171011708900001"))
+	})
+
+	It("should use a fresh data key for every value", func() {
+		a, _ := ring.Encrypt([]byte("same"))
+		b, _ := ring.Encrypt([]byte("same"))
+		Expect(a).NotTo(Equal(b))
+	})
+
+	It("should reject tampered values", func() {
+		value, _ := ring.Encrypt([]byte("secret"))
+		tampered := value[:len(value)-2] + strings.Repeat("A", 2)
+		_, err := ring.Decrypt(tampered)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should keep reading old versions after rotation", func() {
+		old, _ := ring.Encrypt([]byte("secret"))
+
+		rotated, err := encryption.ParseKeyRing("1:"+testKey(1)+",2:"+testKey(2), "", testKey(9))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(rotated.ActiveVersion()).To(Equal(uint32(2)))
+		Expect(rotated.NeedsRotation(old)).To(BeTrue())
+
+		plaintext, err := rotated.Decrypt(old)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(plaintext)).To(Equal("secret"))
+
+		fresh, _ := rotated.Encrypt(plaintext)
+		Expect(fresh).To(HavePrefix("enc:v2:"))
+		Expect(rotated.NeedsRotation(fresh)).To(BeFalse())
+		Expect(rotated.NeedsRotation("plaintext")).To(BeTrue())
+	})
+
+	It("should fail on unknown key versions", func() {
+		other, _ := encryption.ParseKeyRing("5:"+testKey(5), "", testKey(9))
+		value, _ := other.Encrypt([]byte("secret"))
+		_, err := ring.Decrypt(value)
+		Expect(err).To(MatchError(encryption.ErrUnknownVersion))
+	})
+
+	It("should reject keys of the wrong size", func() {
+		_, err := encryption.ParseKeyRing("1:"+base64.StdEncoding.EncodeToString([]byte("short")), "", testKey(9))
+		Expect(err).To(MatchError(encryption.ErrInvalidKey))
+	})
+
+	It("should compute stable, purpose separated blind indexes", func() {
+		Expect(ring.BlindIndex("nik", "3171011708900001")).To(Equal(ring.BlindIndex("nik", "3171011708900001")))
+		Expect(ring.BlindIndex("nik", "3171011708900001")).NotTo(Equal(ring.BlindIndex("phone", "3171011708900001")))
+		Expect(ring.BlindIndex("nik", "3171011708900001")).To(HaveLen(64))
+	})
+})
+
+var _ = Describe("LoadKeyRing", func() {
+	BeforeEach(func() {
+		for _, name := range []string{"APP_ENV", "APP_KEY", "ENCRYPTION_KEYS", "ENCRYPTION_ACTIVE_KEY", "BLIND_INDEX_KEY"} {
+			previous, set := os.LookupEnv(name)
+			os.Unsetenv(name)
+			DeferCleanup(func() {
+				if set {
+					os.Setenv(name, previous)
+				}
+			})
+		}
+	})
+
+	It("should refuse to run without configured keys", func() {
+		_, err := encryption.LoadKeyRing()
+		Expect(err).To(MatchError(encryption.ErrNotConfigured))
+	})
+
+	It("should require the blind index key with the master keys", func() {
+		os.Setenv("ENCRYPTION_KEYS", "1:"+testKey(1))
+		_, err := encryption.LoadKeyRing()
+		Expect(err).To(MatchError(encryption.ErrNotConfigured))
+	})
+
+	It("should read configured keys", func() {
+		os.Setenv("ENCRYPTION_KEYS", "1:"+testKey(1)+",2:"+testKey(2))
+		os.Setenv("BLIND_INDEX_KEY", testKey(9))
+		ring, err := encryption.LoadKeyRing()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ring.ActiveVersion()).To(Equal(uint32(2)))
+	})
+
+	It("should derive keys from APP_KEY only for local development", func() {
+		os.Setenv("APP_ENV", "local")
+		os.Setenv("APP_KEY", "local development key")
+		ring, err := encryption.LoadKeyRing()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ring.ActiveVersion()).To(Equal(uint32(1)))
+	})
+})
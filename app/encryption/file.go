@@ -0,0 +1,224 @@
+package encryption
+
+import (
+	"bufio"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+// Encrypted files start with a fixed-size header followed by the content
+// split into chunks, each sealed with AES-GCM under the file's data key:
+//
+//	magic "ENC1" | key version (4) | wrapped data key (60) | nonce prefix (8)
+//	chunk 0 | chunk 1 | ... | final chunk
+//
+// A chunk nonce is the nonce prefix followed by the chunk counter, and the
+// final chunk is authenticated as such, so reordered, truncated or modified
+// files fail to decrypt. The header has a fixed size so that the data key can
+// be re-wrapped in place when the master key is rotated.
+const (
+	fileMagic       = "ENC1"
+	fileChunkSize   = 64 * 1024
+	wrappedKeySize  = 12 + keySize + 16
+	noncePrefixSize = 8
+	tagSize         = 16
+
+	// FileHeaderSize is the length of the header of an encrypted file
+	FileHeaderSize = len(fileMagic) + 4 + wrappedKeySize + noncePrefixSize
+)
+
+var ErrTampered = errors.New("encrypted file failed its integrity check")
+
+// IsEncryptedFile reports whether header starts an encrypted file
+func IsEncryptedFile(header []byte) bool {
+	return len(header) >= len(fileMagic) && string(header[:len(fileMagic)]) == fileMagic
+}
+
+// FileKeyVersion returns the master key version an encrypted file's data key
+// is wrapped with
+func FileKeyVersion(header []byte) (uint32, error) {
+	if len(header) < FileHeaderSize || !IsEncryptedFile(header) {
+		return 0, ErrMalformed
+	}
+	return binary.BigEndian.Uint32(header[len(fileMagic):]), nil
+}
+
+// PlaintextSize returns the size of the content of an encrypted file of the
+// given size
+func PlaintextSize(encryptedSize int64) int64 {
+	body := encryptedSize - int64(FileHeaderSize)
+	if body <= 0 {
+		return 0
+	}
+	chunks := (body + fileChunkSize + tagSize - 1) / (fileChunkSize + tagSize)
+	return body - chunks*tagSize
+}
+
+// EncryptStream writes src to dst as an encrypted file under a new data key
+func (r *KeyRing) EncryptStream(dst io.Writer, src io.Reader) error {
+	dataKey, err := NewDataKey()
+	if err != nil {
+		return err
+	}
+	version, wrapped, err := r.WrapKey(dataKey)
+	if err != nil {
+		return err
+	}
+	aead, err := newGCM(dataKey)
+	if err != nil {
+		return err
+	}
+
+	header := make([]byte, 0, FileHeaderSize)
+	header = append(header, fileMagic...)
+	header = binary.BigEndian.AppendUint32(header, version)
+	header = append(header, wrapped...)
+	prefix := make([]byte, noncePrefixSize)
+	if _, err := rand.Read(prefix); err != nil {
+		return err
+	}
+	header = append(header, prefix...)
+	if _, err := dst.Write(header); err != nil {
+		return err
+	}
+
+	reader := bufio.NewReaderSize(src, fileChunkSize)
+	plain := make([]byte, fileChunkSize)
+	sealed := make([]byte, 0, fileChunkSize+tagSize)
+	for counter := uint32(0); ; counter++ {
+		n, err := io.ReadFull(reader, plain)
+		final := err == io.EOF || err == io.ErrUnexpectedEOF
+		if err != nil && !final {
+			return err
+		}
+		if !final {
+			_, err := reader.Peek(1)
+			final = err == io.EOF
+		}
+
+		sealed = aead.Seal(sealed[:0], chunkNonce(prefix, counter), plain[:n], chunkAAD(final))
+		if _, err := dst.Write(sealed); err != nil {
+			return err
+		}
+		if final {
+			return nil
+		}
+	}
+}
+
+// NewDecryptReader returns a reader over the content of the encrypted file
+// read from src. Each chunk is authenticated before it is returned; Read
+// fails with ErrTampered on the first chunk that does not verify.
+func (r *KeyRing) NewDecryptReader(src io.Reader) (io.Reader, error) {
+	header := make([]byte, FileHeaderSize)
+	if _, err := io.ReadFull(src, header); err != nil {
+		return nil, ErrMalformed
+	}
+	version, err := FileKeyVersion(header)
+	if err != nil {
+		return nil, err
+	}
+	dataKey, err := r.UnwrapKey(version, header[len(fileMagic)+4:len(fileMagic)+4+wrappedKeySize])
+	if err != nil {
+		return nil, ErrTampered
+	}
+	aead, err := newGCM(dataKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return &decryptReader{
+		src:    bufio.NewReaderSize(src, fileChunkSize+tagSize),
+		aead:   aead,
+		prefix: header[FileHeaderSize-noncePrefixSize:],
+		sealed: make([]byte, fileChunkSize+tagSize),
+	}, nil
+}
+
+// RewrapFileHeader returns header with the data key re-wrapped under the
+// active master key. The content of the file does not change.
+func (r *KeyRing) RewrapFileHeader(header []byte) ([]byte, error) {
+	version, err := FileKeyVersion(header)
+	if err != nil {
+		return nil, err
+	}
+	offset := len(fileMagic) + 4
+	dataKey, err := r.UnwrapKey(version, header[offset:offset+wrappedKeySize])
+	if err != nil {
+		return nil, ErrTampered
+	}
+	active, wrapped, err := r.WrapKey(dataKey)
+	if err != nil {
+		return nil, err
+	}
+
+	rewrapped := append([]byte(nil), header[:FileHeaderSize]...)
+	binary.BigEndian.PutUint32(rewrapped[len(fileMagic):], active)
+	copy(rewrapped[offset:], wrapped)
+	return rewrapped, nil
+}
+
+type decryptReader struct {
+	src     *bufio.Reader
+	aead    cipher.AEAD
+	prefix  []byte
+	counter uint32
+	sealed  []byte
+	pending []byte
+	done    bool
+}
+
+func (d *decryptReader) Read(p []byte) (int, error) {
+	for len(d.pending) == 0 {
+		if d.done {
+			return 0, io.EOF
+		}
+		if err := d.next(); err != nil {
+			return 0, err
+		}
+	}
+
+	n := copy(p, d.pending)
+	d.pending = d.pending[n:]
+	return n, nil
+}
+
+func (d *decryptReader) next() error {
+	n, err := io.ReadFull(d.src, d.sealed)
+	final := err == io.ErrUnexpectedEOF
+	switch {
+	case err == io.EOF:
+		// the final chunk is missing
+		return ErrTampered
+	case err != nil && !final:
+		return err
+	case !final:
+		_, err := d.src.Peek(1)
+		final = err == io.EOF
+	}
+
+	plain, err := d.aead.Open(d.sealed[:0], chunkNonce(d.prefix, d.counter), d.sealed[:n], chunkAAD(final))
+	if err != nil {
+		return ErrTampered
+	}
+	d.pending = plain
+	d.done = final
+	d.counter++
+	return nil
+}
+
+func chunkNonce(prefix []byte, counter uint32) []byte {
+	nonce := make([]byte, 0, noncePrefixSize+4)
+	nonce = append(nonce, prefix...)
+	return binary.BigEndian.AppendUint32(nonce, counter)
+}
+
+func chunkAAD(final bool) []byte {
+	if final {
+		return []byte{1}
+	}
+	return []byte{0}
+}
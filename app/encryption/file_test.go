@@ -0,0 +1,89 @@
+package encryption_test
+
+import (
+	"bytes"
+	"crypto/rand"
+	"io"
+
+	"golang_starter_kit_2025/app/encryption"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("File encryption", func() {
+	var ring *encryption.KeyRing
+
+	encrypt := func(content []byte) []byte {
+		var buf bytes.Buffer
+		Expect(ring.EncryptStream(&buf, bytes.NewReader(content))).To(Succeed())
+		return buf.Bytes()
+	}
+
+	decrypt := func(file []byte) ([]byte, error) {
+		reader, err := ring.NewDecryptReader(bytes.NewReader(file))
+		if err != nil {
+			return nil, err
+		}
+		return io.ReadAll(reader)
+	}
+
+	BeforeEach(func() {
+		var err error
+		ring, err = encryption.ParseKeyRing("1:"+testKey(1), "", testKey(9))
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	DescribeTable("should round trip content of any size",
+		func(size int) {
+			content := make([]byte, size)
+			_, _ = rand.Read(content)
+
+			file := encrypt(content)
+			Expect(encryption.IsEncryptedFile(file)).To(BeTrue())
+			Expect(encryption.PlaintextSize(int64(len(file)))).To(Equal(int64(size)))
+
+			plain, err := decrypt(file)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(plain).To(Equal(content))
+		},
+		Entry("empty", 0),
+		Entry("smaller than a chunk", 1000),
+		Entry("exactly one chunk", 64*1024),
+		Entry("several chunks", 3*64*1024+17),
+	)
+
+	It("should reject modified content", func() {
+		file := encrypt(bytes.Repeat([]byte("x"), 100*1024))
+		file[len(file)-100] ^= 1
+
+		_, err := decrypt(file)
+		Expect(err).To(MatchError(encryption.ErrTampered))
+	})
+
+	It("should reject truncated files", func() {
+		file := encrypt(bytes.Repeat([]byte("x"), 100*1024))
+
+		_, err := decrypt(file[:encryption.FileHeaderSize+64*1024+16])
+		Expect(err).To(MatchError(encryption.ErrTampered))
+	})
+
+	It("should keep files readable after re-wrapping under a new key", func() {
+		file := encrypt([]byte("selfie"))
+
+		rotated, err := encryption.ParseKeyRing("1:"+testKey(1)+",2:"+testKey(2), "", testKey(9))
+		Expect(err).NotTo(HaveOccurred())
+		header, err := rotated.RewrapFileHeader(file)
+		Expect(err).NotTo(HaveOccurred())
+		copy(file, header)
+
+		version, err := encryption.FileKeyVersion(file)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(version).To(Equal(uint32(2)))
+
+		ring = rotated
+		plain, err := decrypt(file)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(plain)).To(Equal("selfie"))
+	})
+})
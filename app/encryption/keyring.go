@@ -0,0 +1,179 @@
+// Package encryption protects personal data at rest. Values are sealed with
+// AES-256-GCM under a fresh data key, and the data key is wrapped by a
+// versioned master key from the key ring (envelope encryption). Rotating the
+// active master key only requires re-wrapping; old versions stay readable as
+// long as they remain in the ring.
+package encryption
+
+import (
+	"crypto/hkdf"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"golang_starter_kit_2025/app/helpers"
+)
+
+const keySize = 32
+
+var (
+	ErrInvalidKey     = errors.New("encryption keys must be 32 bytes, base64 encoded")
+	ErrUnknownVersion = errors.New("encryption key version is not in the key ring")
+	ErrMalformed      = errors.New("encrypted value is malformed")
+	ErrNotConfigured  = errors.New("ENCRYPTION_KEYS and BLIND_INDEX_KEY must be set")
+)
+
+// KeyRing holds the master keys by version and the key used for blind
+// indexes. New data is always encrypted with the active version.
+type KeyRing struct {
+	keys     map[uint32][]byte
+	active   uint32
+	blindKey []byte
+}
+
+// NewKeyRing builds a key ring from raw 32-byte keys
+func NewKeyRing(keys map[uint32][]byte, active uint32, blindKey []byte) (*KeyRing, error) {
+	if _, ok := keys[active]; !ok {
+		return nil, fmt.Errorf("%w: %d", ErrUnknownVersion, active)
+	}
+	for _, key := range keys {
+		if len(key) != keySize {
+			return nil, ErrInvalidKey
+		}
+	}
+	if len(blindKey) != keySize {
+		return nil, ErrInvalidKey
+	}
+	return &KeyRing{keys: keys, active: active, blindKey: blindKey}, nil
+}
+
+// ParseKeyRing reads a key ring from its configuration form: keys as
+// comma-separated "version:base64key" pairs, the active version and a base64
+// blind index key. When active is empty the highest version is used.
+func ParseKeyRing(spec, active, blindKey string) (*KeyRing, error) {
+	keys := make(map[uint32][]byte)
+	var versions []uint32
+	for _, pair := range strings.Split(spec, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		version, encoded, ok := strings.Cut(pair, ":")
+		if !ok {
+			return nil, ErrInvalidKey
+		}
+		v, err := strconv.ParseUint(version, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid key version %q", version)
+		}
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, ErrInvalidKey
+		}
+		keys[uint32(v)] = key
+		versions = append(versions, uint32(v))
+	}
+	if len(versions) == 0 {
+		return nil, ErrInvalidKey
+	}
+
+	sort.Slice(versions, func(i, j int) bool { return versions[i] < versions[j] })
+	activeVersion := versions[len(versions)-1]
+	if active != "" {
+		v, err := strconv.ParseUint(active, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid active key version %q", active)
+		}
+		activeVersion = uint32(v)
+	}
+
+	blind, err := base64.StdEncoding.DecodeString(blindKey)
+	if err != nil {
+		return nil, ErrInvalidKey
+	}
+	return NewKeyRing(keys, activeVersion, blind)
+}
+
+// ActiveVersion is the key version new data is encrypted with
+func (r *KeyRing) ActiveVersion() uint32 {
+	return r.active
+}
+
+func (r *KeyRing) key(version uint32) ([]byte, error) {
+	key, ok := r.keys[version]
+	if !ok {
+		return nil, fmt.Errorf("%w: %d", ErrUnknownVersion, version)
+	}
+	return key, nil
+}
+
+var (
+	defaultRing *KeyRing
+	defaultErr  error
+	defaultOnce sync.Once
+	defaultMu   sync.RWMutex
+)
+
+// Default returns the key ring configured through ENCRYPTION_KEYS,
+// ENCRYPTION_ACTIVE_KEY and BLIND_INDEX_KEY. Only with APP_ENV=local may the
+// keys be left out, they are then derived from APP_KEY; anywhere else data
+// would end up encrypted under a key that is not secret, so the key ring
+// fails instead.
+func Default() (*KeyRing, error) {
+	defaultMu.RLock()
+	ring := defaultRing
+	defaultMu.RUnlock()
+	if ring != nil {
+		return ring, nil
+	}
+
+	defaultOnce.Do(func() {
+		ring, err := LoadKeyRing()
+		defaultMu.Lock()
+		if defaultRing == nil {
+			defaultRing, defaultErr = ring, err
+		}
+		defaultMu.Unlock()
+	})
+
+	defaultMu.RLock()
+	defer defaultMu.RUnlock()
+	return defaultRing, defaultErr
+}
+
+// SetDefault replaces the key ring returned by Default
+func SetDefault(ring *KeyRing) {
+	defaultMu.Lock()
+	defaultRing, defaultErr = ring, nil
+	defaultMu.Unlock()
+}
+
+// LoadKeyRing reads the key ring from the environment, see Default
+func LoadKeyRing() (*KeyRing, error) {
+	spec := helpers.GetEnv("ENCRYPTION_KEYS", "")
+	blindKey := helpers.GetEnv("BLIND_INDEX_KEY", "")
+	if spec != "" && blindKey != "" {
+		return ParseKeyRing(spec, helpers.GetEnv("ENCRYPTION_ACTIVE_KEY", ""), blindKey)
+	}
+	if helpers.GetEnv("APP_ENV", "") != "local" {
+		return nil, ErrNotConfigured
+	}
+
+	log.Println("⚠️ ENCRYPTION_KEYS or BLIND_INDEX_KEY is not set, deriving encryption keys from APP_KEY (APP_ENV=local only)")
+	appKey := []byte(helpers.GetEnv("APP_KEY", "your_secret_key"))
+	master, err := hkdf.Key(sha256.New, appKey, nil, "encryption master key", keySize)
+	if err != nil {
+		return nil, err
+	}
+	blind, err := hkdf.Key(sha256.New, appKey, nil, "encryption blind index key", keySize)
+	if err != nil {
+		return nil, err
+	}
+	return NewKeyRing(map[uint32][]byte{1: master}, 1, blind)
+}
@@ -0,0 +1,120 @@
+package encryption
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"time"
+
+	"gorm.io/gorm/schema"
+)
+
+func init() {
+	schema.RegisterSerializer("encrypted", Serializer{})
+}
+
+// Serializer is the GORM serializer behind `gorm:"serializer:encrypted"`.
+// Values are JSON encoded and then encrypted with the default key ring, so
+// any field type works; the column must be a text type. Zero values are
+// stored as NULL.
+//
+// Serializers only apply when GORM writes from a struct. Updates with a map
+// bypass them, so encrypted columns must be updated through a struct, e.g.
+// tx.Model(&row).Select("column").Updates(&row).
+//
+// Plaintext that predates encryption is still read, so columns can be
+// encrypted in place by the crypto:rotate command.
+type Serializer struct{}
+
+// Scan implements schema.SerializerInterface
+func (Serializer) Scan(ctx context.Context, field *schema.Field, dst reflect.Value, dbValue interface{}) error {
+	fieldValue := reflect.New(field.FieldType)
+
+	var raw string
+	switch v := dbValue.(type) {
+	case nil:
+	case []byte:
+		raw = string(v)
+	case string:
+		raw = v
+	case time.Time:
+		// a plaintext DATE or DATETIME column that has not been converted yet
+		data, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		raw = string(data)
+	default:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		raw = string(data)
+	}
+
+	if raw != "" {
+		if IsEncrypted(raw) {
+			ring, err := Default()
+			if err != nil {
+				return err
+			}
+			plaintext, err := ring.Decrypt(raw)
+			if err != nil {
+				return err
+			}
+			if err := json.Unmarshal(plaintext, fieldValue.Interface()); err != nil {
+				return err
+			}
+		} else if err := scanPlaintext(raw, fieldValue); err != nil {
+			return err
+		}
+	}
+
+	field.ReflectValueOf(ctx, dst).Set(fieldValue.Elem())
+	return nil
+}
+
+// Value implements schema.SerializerValuerInterface
+func (Serializer) Value(ctx context.Context, field *schema.Field, dst reflect.Value, fieldValue interface{}) (interface{}, error) {
+	if fieldValue == nil || reflect.ValueOf(fieldValue).IsZero() {
+		return nil, nil
+	}
+
+	data, err := json.Marshal(fieldValue)
+	if err != nil {
+		return nil, err
+	}
+	ring, err := Default()
+	if err != nil {
+		return nil, err
+	}
+	return ring.Encrypt(data)
+}
+
+// scanPlaintext reads a legacy unencrypted value: JSON when it parses, the
+// raw text for string fields, or a date for time fields
+func scanPlaintext(raw string, fieldValue reflect.Value) error {
+	if json.Unmarshal([]byte(raw), fieldValue.Interface()) == nil {
+		return nil
+	}
+
+	target := fieldValue.Elem()
+	if target.Kind() == reflect.Pointer {
+		target.Set(reflect.New(target.Type().Elem()))
+		target = target.Elem()
+	}
+
+	switch target.Interface().(type) {
+	case string:
+		target.SetString(raw)
+		return nil
+	case time.Time:
+		for _, layout := range []string{"2006-01-02", "2006-01-02 15:04:05", time.RFC3339} {
+			if t, err := time.Parse(layout, raw); err == nil {
+				target.Set(reflect.ValueOf(t))
+				return nil
+			}
+		}
+	}
+	return ErrMalformed
+}
@@ -0,0 +1,65 @@
+package encryption_test
+
+import (
+	"time"
+
+	"golang_starter_kit_2025/app/encryption"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+type person struct {
+	ID        uint
+	Name      string     `gorm:"type:text;serializer:encrypted"`
+	BirthDate *time.Time `gorm:"type:text;serializer:encrypted"`
+}
+
+var _ = Describe("Serializer", func() {
+	var db *gorm.DB
+
+	BeforeEach(func() {
+		ring, err := encryption.ParseKeyRing("1:"+testKey(1), "", testKey(9))
+		Expect(err).NotTo(HaveOccurred())
+		encryption.SetDefault(ring)
+
+		db, err = gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(db.AutoMigrate(&person{})).To(Succeed())
+	})
+
+	It("should store ciphertext and read back the original values", func() {
+		birthDate := time.Date(1990, 8, 17, 0, 0, 0, 0, time.UTC)
+		Expect(db.Create(&person{Name: "Budi Santoso", BirthDate: &birthDate}).Error).To(Succeed())
+
+		var raw string
+		Expect(db.Raw("SELECT name FROM people").Scan(&raw).Error).To(Succeed())
+		Expect(encryption.IsEncrypted(raw)).To(BeTrue())
+		Expect(raw).NotTo(ContainSubstring("Budi"))
+
+		var loaded person
+		Expect(db.First(&loaded).Error).To(Succeed())
+		Expect(loaded.Name).To(Equal("Budi Santoso"))
+		Expect(loaded.BirthDate.Equal(birthDate)).To(BeTrue())
+	})
+
+	It("should store zero values as NULL", func() {
+		Expect(db.Create(&person{}).Error).To(Succeed())
+
+		var count int64
+		Expect(db.Model(&person{}).Where("name IS NULL AND birth_date IS NULL").Count(&count).Error).To(Succeed())
+		Expect(count).To(Equal(int64(1)))
+	})
+
+	It("should read plaintext written before encryption", func() {
+		Expect(db.Exec("INSERT INTO people (name, birth_date) VALUES (?, ?)", "Siti Aminah", "1985-01-02").Error).To(Succeed())
+
+		var loaded person
+		Expect(db.First(&loaded).Error).To(Succeed())
+		Expect(loaded.Name).To(Equal("Siti Aminah"))
+		Expect(loaded.BirthDate.Format("2006-01-02")).To(Equal("1985-01-02"))
+	})
+})
@@ -0,0 +1,86 @@
+package filescan
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+)
+
+// clamavChunkSize is the size of the chunks streamed to clamd. It must stay
+// below the StreamMaxLength of the daemon.
+const clamavChunkSize = 64 << 10
+
+// ClamAV scans files with a clamd daemon using its INSTREAM command
+type ClamAV struct {
+	network string
+	address string
+	timeout time.Duration
+}
+
+// NewClamAV creates a scanner for the daemon at address, either
+// "tcp://host:port" or "unix:///path/to/clamd.sock"
+func NewClamAV(address string, timeout time.Duration) *ClamAV {
+	network, addr, ok := strings.Cut(address, "://")
+	if !ok {
+		network, addr = "tcp", address
+	}
+	return &ClamAV{network: network, address: addr, timeout: timeout}
+}
+
+// Scan streams r to clamd in chunks, each prefixed with its length, and
+// reads the verdict, e.g. "stream: OK" or "stream: Eicar-Signature FOUND"
+func (c *ClamAV) Scan(r io.Reader) (Result, error) {
+	conn, err := net.DialTimeout(c.network, c.address, c.timeout)
+	if err != nil {
+		return Result{}, fmt.Errorf("%w: %w", ErrUnavailable, err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(c.timeout))
+
+	if _, err := conn.Write([]byte("zINSTREAM\x00")); err != nil {
+		return Result{}, fmt.Errorf("%w: %w", ErrUnavailable, err)
+	}
+	buf := make([]byte, 4+clamavChunkSize)
+	for {
+		n, readErr := io.ReadFull(r, buf[4:])
+		if n > 0 {
+			binary.BigEndian.PutUint32(buf, uint32(n))
+			if _, err := conn.Write(buf[:4+n]); err != nil {
+				// clamd closes the connection when the stream is too long
+				// and has already written why
+				break
+			}
+		}
+		if readErr == io.EOF || readErr == io.ErrUnexpectedEOF {
+			if _, err := conn.Write([]byte{0, 0, 0, 0}); err != nil {
+				return Result{}, fmt.Errorf("%w: %w", ErrUnavailable, err)
+			}
+			break
+		}
+		if readErr != nil {
+			return Result{}, readErr
+		}
+	}
+
+	reply, err := bufio.NewReader(conn).ReadString(0)
+	if err != nil && reply == "" {
+		return Result{}, fmt.Errorf("%w: %w", ErrUnavailable, err)
+	}
+	return parseClamAVReply(strings.TrimRight(reply, "\x00\n"))
+}
+
+func parseClamAVReply(reply string) (Result, error) {
+	verdict := strings.TrimSpace(strings.TrimPrefix(reply, "stream:"))
+	switch {
+	case verdict == "OK":
+		return Result{}, nil
+	case strings.HasSuffix(verdict, " FOUND"):
+		return Result{Infected: true, Signature: strings.TrimSuffix(verdict, " FOUND")}, nil
+	default:
+		return Result{}, fmt.Errorf("%w: clamd answered %q", ErrUnavailable, reply)
+	}
+}
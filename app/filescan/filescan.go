@@ -0,0 +1,100 @@
+// Package filescan checks uploaded files for malware. Scanners are pluggable:
+// a ClamAV daemon in production, a fake that only knows the EICAR test file
+// for local development and tests, or none at all.
+package filescan
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"golang_starter_kit_2025/app/helpers"
+)
+
+// ErrUnavailable is returned when the scanner cannot give a verdict. Files
+// must then be refused rather than accepted unscanned.
+var ErrUnavailable = errors.New("file scanner is unavailable")
+
+// Result is the verdict of a scan
+type Result struct {
+	Infected bool
+	// Signature names what was found in an infected file
+	Signature string
+}
+
+// Scanner scans the content read from r
+type Scanner interface {
+	Scan(r io.Reader) (Result, error)
+}
+
+// Nop accepts every file. It is used when no scanner is configured.
+type Nop struct{}
+
+func (Nop) Scan(r io.Reader) (Result, error) {
+	_, err := io.Copy(io.Discard, r)
+	return Result{}, err
+}
+
+// eicar is the standard antivirus test file, see https://www.eicar.org
+var eicar = []byte(`X5O!P%@AP[4\PZX54(P^)7CC)7}$EICAR-STANDARD-ANTIVIRUS-TEST-FILE!$H+H*`)
+
+// Fake reports files containing the EICAR test string, like a real scanner
+// would, without needing one
+type Fake struct{}
+
+func (Fake) Scan(r io.Reader) (Result, error) {
+	content, err := io.ReadAll(r)
+	if err != nil {
+		return Result{}, err
+	}
+	if bytes.Contains(content, eicar) {
+		return Result{Infected: true, Signature: "Eicar-Test-Signature"}, nil
+	}
+	return Result{}, nil
+}
+
+var (
+	defaultMu      sync.Mutex
+	defaultScanner Scanner
+)
+
+// Default returns the scanner chosen by FILE_SCANNER: "clamav" (at
+// CLAMAV_ADDRESS), "fake" or "none". An unknown FILE_SCANNER is an error;
+// the application checks it at startup.
+func Default() (Scanner, error) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+
+	if defaultScanner == nil {
+		scanner, err := fromEnv()
+		if err != nil {
+			return nil, err
+		}
+		defaultScanner = scanner
+	}
+	return defaultScanner, nil
+}
+
+// SetDefault replaces the default scanner, e.g. with Fake in tests
+func SetDefault(scanner Scanner) {
+	defaultMu.Lock()
+	defaultScanner = scanner
+	defaultMu.Unlock()
+}
+
+func fromEnv() (Scanner, error) {
+	switch driver := helpers.GetEnv("FILE_SCANNER", "none"); driver {
+	case "clamav":
+		timeout := time.Duration(helpers.GetEnvInt("CLAMAV_TIMEOUT_SECONDS", 30)) * time.Second
+		return NewClamAV(helpers.GetEnv("CLAMAV_ADDRESS", "tcp://127.0.0.1:3310"), timeout), nil
+	case "fake":
+		return Fake{}, nil
+	case "none", "":
+		return Nop{}, nil
+	default:
+		return nil, fmt.Errorf("unknown FILE_SCANNER %q", driver)
+	}
+}
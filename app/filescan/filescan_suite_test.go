@@ -0,0 +1,13 @@
+package filescan_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestFilescanSuite(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Filescan Test Suite")
+}
@@ -0,0 +1,156 @@
+package filescan_test
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"io"
+	"net"
+	"strings"
+	"time"
+
+	"golang_starter_kit_2025/app/filescan"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+const eicar = `X5O!P%@AP[4\PZX54(P^)7CC)7}$EICAR-STANDARD-ANTIVIRUS-TEST-FILE!$H+H*`
+
+// fakeClamd speaks the clamd INSTREAM protocol and reports the EICAR string
+type fakeClamd struct {
+	listener  net.Listener
+	received  chan []byte
+	maxStream int
+}
+
+func newFakeClamd(maxStream int) *fakeClamd {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	Expect(err).NotTo(HaveOccurred())
+	d := &fakeClamd{listener: listener, received: make(chan []byte, 10), maxStream: maxStream}
+	go d.serve()
+	DeferCleanup(listener.Close)
+	return d
+}
+
+func (d *fakeClamd) address() string {
+	return "tcp://" + d.listener.Addr().String()
+}
+
+func (d *fakeClamd) serve() {
+	for {
+		conn, err := d.listener.Accept()
+		if err != nil {
+			return
+		}
+		go d.handle(conn)
+	}
+}
+
+func (d *fakeClamd) handle(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	command, err := r.ReadString(0)
+	if err != nil || command != "zINSTREAM\x00" {
+		conn.Write([]byte("UNKNOWN COMMAND\x00"))
+		return
+	}
+
+	var stream []byte
+	for {
+		var size uint32
+		if err := binary.Read(r, binary.BigEndian, &size); err != nil {
+			return
+		}
+		if size == 0 {
+			break
+		}
+		chunk := make([]byte, size)
+		if _, err := io.ReadFull(r, chunk); err != nil {
+			return
+		}
+		stream = append(stream, chunk...)
+		if len(stream) > d.maxStream {
+			conn.Write([]byte("INSTREAM size limit exceeded. ERROR\x00"))
+			return
+		}
+	}
+	d.received <- stream
+
+	if bytes.Contains(stream, []byte(eicar)) {
+		conn.Write([]byte("stream: Eicar-Signature FOUND\x00"))
+		return
+	}
+	conn.Write([]byte("stream: OK\x00"))
+}
+
+var _ = Describe("ClamAV", func() {
+	It("should stream the whole file in chunks and accept clean files", func() {
+		clamd := newFakeClamd(1 << 20)
+		content := bytes.Repeat([]byte("clean"), 50000)
+
+		result, err := filescan.NewClamAV(clamd.address(), time.Second).Scan(bytes.NewReader(content))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.Infected).To(BeFalse())
+		Expect(<-clamd.received).To(Equal(content))
+	})
+
+	It("should report the signature of infected files", func() {
+		clamd := newFakeClamd(1 << 20)
+
+		result, err := filescan.NewClamAV(clamd.address(), time.Second).Scan(strings.NewReader("header " + eicar))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result).To(Equal(filescan.Result{Infected: true, Signature: "Eicar-Signature"}))
+	})
+
+	It("should be unavailable when the daemon refuses the file", func() {
+		clamd := newFakeClamd(100)
+
+		_, err := filescan.NewClamAV(clamd.address(), time.Second).Scan(bytes.NewReader(make([]byte, 200<<10)))
+		Expect(err).To(MatchError(filescan.ErrUnavailable))
+	})
+
+	It("should be unavailable when the daemon cannot be reached", func() {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		Expect(err).NotTo(HaveOccurred())
+		address := listener.Addr().String()
+		listener.Close()
+
+		_, err = filescan.NewClamAV(address, time.Second).Scan(strings.NewReader("x"))
+		Expect(err).To(MatchError(filescan.ErrUnavailable))
+	})
+})
+
+var _ = Describe("Fake", func() {
+	It("should only report the EICAR test file", func() {
+		result, err := filescan.Fake{}.Scan(strings.NewReader(eicar))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.Infected).To(BeTrue())
+
+		result, err = filescan.Fake{}.Scan(strings.NewReader("hello"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.Infected).To(BeFalse())
+	})
+})
+
+var _ = Describe("Default", func() {
+	BeforeEach(func() {
+		filescan.SetDefault(nil)
+		DeferCleanup(func() {
+			filescan.SetDefault(nil)
+		})
+	})
+
+	It("should pick the scanner named by FILE_SCANNER", func() {
+		GinkgoT().Setenv("FILE_SCANNER", "fake")
+		scanner, err := filescan.Default()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(scanner).To(Equal(filescan.Fake{}))
+	})
+
+	It("should fail on an unknown FILE_SCANNER", func() {
+		GinkgoT().Setenv("FILE_SCANNER", "clam")
+		_, err := filescan.Default()
+		Expect(err).To(MatchError(`unknown FILE_SCANNER "clam"`))
+	})
+})
@@ -2,61 +2,66 @@ package helpers
 
 import (
 	"encoding/base64"
-	"os"
+	"errors"
+	"mime"
+	"net/http"
+	"strings"
 )
 
-func Base64FileToBytes(base64File string) ([]byte, error) {
-	dec, err := base64.StdEncoding.DecodeString(base64File)
-	if err != nil {
-		return nil, err
-	}
-
-	return dec, nil
+// knownExtensions fixes the extension of common types for which
+// mime.ExtensionsByType returns several candidates
+var knownExtensions = map[string]string{
+	"image/jpeg":      ".jpg",
+	"image/png":       ".png",
+	"image/gif":       ".gif",
+	"image/webp":      ".webp",
+	"application/pdf": ".pdf",
 }
 
-func StoreBase64File(base64File, path string, filename string) error {
-	dec, err := Base64FileToBytes(base64File)
-	if err != nil {
-		return err
-	}
-
-	if len(path) == 0 {
-		path = "/"
-	}
-
-	if len(filename) == 0 {
-		filename = "file"
-	}
-
-	// add slash on suffix of path if not exists
-	if path[len(path)-1:] != "/" {
-		path += "/"
-	}
-
-	// remove slash on prefix of filename if exists
-	if filename[:1] == "/" {
-		filename = filename[1:]
-	}
+// Base64FileToBytes decodes a base64 encoded file, which may also be given
+// as a data URI
+func Base64FileToBytes(base64File string) ([]byte, error) {
+	dec, _, err := DecodeDataURI(base64File)
+	return dec, err
+}
 
-	err = os.MkdirAll(StoragePath()+path, os.ModePerm)
-	if err != nil {
-		return err
+// DecodeDataURI decodes a base64 data URI such as
+// "data:image/png;base64,iVBORw0KGgo..." and returns its content and the
+// media type it declares. Plain base64 is accepted too and declares no media
+// type.
+func DecodeDataURI(uri string) ([]byte, string, error) {
+	mediaType := ""
+	data := strings.TrimSpace(uri)
+	if rest, ok := strings.CutPrefix(data, "data:"); ok {
+		header, encoded, found := strings.Cut(rest, ",")
+		if !found {
+			return nil, "", errors.New("data URI has no data")
+		}
+		params := strings.Split(header, ";")
+		if params[len(params)-1] != "base64" {
+			return nil, "", errors.New("data URI must be base64 encoded")
+		}
+		mediaType = strings.ToLower(strings.TrimSpace(params[0]))
+		data = encoded
 	}
 
-	file, err := os.Create(StoragePath() + path + filename)
+	dec, err := base64.StdEncoding.DecodeString(data)
 	if err != nil {
-		return err
+		return nil, "", err
 	}
+	return dec, mediaType, nil
+}
 
-	defer file.Close()
-
-	if _, err := file.Write(dec); err != nil {
-		return err
+// ExtensionByContent sniffs the content type of a file and returns the
+// matching extension, ".bin" when it cannot be determined
+func ExtensionByContent(content []byte) string {
+	contentType := http.DetectContentType(content)
+	contentType = strings.TrimSpace(strings.SplitN(contentType, ";", 2)[0])
+	if ext, ok := knownExtensions[contentType]; ok {
+		return ext
 	}
-
-	if err := file.Sync(); err != nil {
-		return err
+	if exts, err := mime.ExtensionsByType(contentType); err == nil && len(exts) > 0 {
+		return exts[0]
 	}
-
-	return nil
-}
\ No newline at end of file
+	return ".bin"
+}
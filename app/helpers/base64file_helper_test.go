@@ -0,0 +1,41 @@
+package helpers_test
+
+import (
+	"golang_starter_kit_2025/app/helpers"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ExtensionByContent", func() {
+	It("should follow the sniffed content type", func() {
+		Expect(helpers.ExtensionByContent([]byte("\xff\xd8\xff\xe0\x00\x10JFIF\x00"))).To(Equal(".jpg"))
+		Expect(helpers.ExtensionByContent([]byte("\x89PNG\r\n\x1a\n\x00\x00\x00\x0dIHDR"))).To(Equal(".png"))
+		Expect(helpers.ExtensionByContent([]byte("%PDF-1.4\n"))).To(Equal(".pdf"))
+	})
+
+	It("should fall back to .bin for unknown content", func() {
+		Expect(helpers.ExtensionByContent([]byte{0x00, 0x01, 0x02, 0x03})).To(Equal(".bin"))
+	})
+})
+
+var _ = Describe("DecodeDataURI", func() {
+	It("should decode data URIs and return the declared media type", func() {
+		content, mediaType, err := helpers.DecodeDataURI("data:image/PNG;name=ktp.png;base64,aGVsbG8=")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(content)).To(Equal("hello"))
+		Expect(mediaType).To(Equal("image/png"))
+	})
+
+	It("should accept plain base64", func() {
+		content, mediaType, err := helpers.DecodeDataURI("aGVsbG8=")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(content)).To(Equal("hello"))
+		Expect(mediaType).To(BeEmpty())
+	})
+
+	It("should refuse data URIs that are not base64", func() {
+		_, _, err := helpers.DecodeDataURI("data:text/plain,hello")
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -31,4 +31,20 @@ func GetEnvInt(key string, defaultValue int) int {
 	}
 
 	return intValue
-}
\ No newline at end of file
+}
+func GetEnvFloat(key string, defaultValue float64) float64 {
+	value := os.Getenv(key)
+
+	// if value is empty, return default value
+	if len(value) == 0 {
+		return defaultValue
+	}
+
+	// convert string to float
+	floatValue, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return defaultValue
+	}
+
+	return floatValue
+}
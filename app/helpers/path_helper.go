@@ -1,5 +0,0 @@
-package helpers
-
-func StoragePath() string {
-	return "storage/"
-}
@@ -0,0 +1,250 @@
+// Package imagemeta removes metadata from images before they are stored.
+// Photos taken on phones carry EXIF and XMP data such as the GPS position,
+// the device and the time the photo was taken, which we do not need and must
+// not keep. Only the data needed to display the image is preserved: colour
+// profiles, and the EXIF orientation of JPEG photos.
+package imagemeta
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+)
+
+var ErrMalformed = errors.New("image structure is malformed")
+
+// Strip returns content without metadata. Content types other than JPEG, PNG
+// and WebP are returned unchanged.
+func Strip(content []byte, contentType string) ([]byte, error) {
+	switch contentType {
+	case "image/jpeg":
+		return stripJPEG(content)
+	case "image/png":
+		return stripPNG(content)
+	case "image/webp":
+		return stripWebP(content)
+	default:
+		return content, nil
+	}
+}
+
+// Orientation returns the EXIF orientation of a JPEG photo, 1 (upright) when
+// it has none
+func Orientation(content []byte) int {
+	orientation := 1
+	walkJPEG(content, func(marker byte, segment []byte) bool {
+		if marker == 0xE1 {
+			if o := exifOrientation(segment[4:]); o != 0 {
+				orientation = o
+				return false
+			}
+		}
+		return true
+	})
+	return orientation
+}
+
+// walkJPEG calls fn with every marker segment before the image data, marker
+// and length included, until fn returns false. It returns the offset of the
+// image data (the SOS segment).
+func walkJPEG(content []byte, fn func(marker byte, segment []byte) bool) (int, error) {
+	if len(content) < 4 || content[0] != 0xFF || content[1] != 0xD8 {
+		return 0, ErrMalformed
+	}
+	pos := 2
+	for {
+		if pos+4 > len(content) || content[pos] != 0xFF {
+			return 0, ErrMalformed
+		}
+		marker := content[pos+1]
+		if marker == 0xFF {
+			// fill byte
+			pos++
+			continue
+		}
+		if marker == 0xDA || marker == 0xD9 {
+			return pos, nil
+		}
+		end := pos + 2 + int(binary.BigEndian.Uint16(content[pos+2:]))
+		if end > len(content) || end < pos+4 {
+			return 0, ErrMalformed
+		}
+		if !fn(marker, content[pos:end]) {
+			return pos, nil
+		}
+		pos = end
+	}
+}
+
+// jpegMetadata reports whether a JPEG segment only holds metadata. APP0
+// (JFIF), APP2 (ICC profile) and APP14 (Adobe colour transform) affect how
+// the image is displayed and are kept.
+func jpegMetadata(marker byte) bool {
+	return marker == 0xFE || marker >= 0xE1 && marker <= 0xEF && marker != 0xE2 && marker != 0xEE
+}
+
+func stripJPEG(content []byte) ([]byte, error) {
+	var kept [][]byte
+	orientation := 1
+	data, err := walkJPEG(content, func(marker byte, segment []byte) bool {
+		if !jpegMetadata(marker) {
+			kept = append(kept, segment)
+		} else if marker == 0xE1 {
+			if o := exifOrientation(segment[4:]); o != 0 {
+				orientation = o
+			}
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// the orientation goes back as the only EXIF data, after JFIF or first
+	// without it
+	if orientation != 1 {
+		at := 0
+		if len(kept) > 0 && kept[0][1] == 0xE0 {
+			at = 1
+		}
+		kept = append(kept[:at], append([][]byte{orientationSegment(orientation)}, kept[at:]...)...)
+	}
+
+	out := bytes.NewBuffer(make([]byte, 0, len(content)))
+	out.Write(content[:2])
+	for _, segment := range kept {
+		out.Write(segment)
+	}
+	out.Write(content[data:])
+	return out.Bytes(), nil
+}
+
+// exifOrientation reads the orientation tag from the first IFD of an APP1
+// Exif payload, 0 when it has none
+func exifOrientation(payload []byte) int {
+	if !bytes.HasPrefix(payload, []byte("Exif\x00\x00")) {
+		return 0
+	}
+	tiff := payload[6:]
+	if len(tiff) < 8 {
+		return 0
+	}
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 0
+	}
+	ifd := int(order.Uint32(tiff[4:]))
+	if ifd+2 > len(tiff) || ifd < 8 {
+		return 0
+	}
+	entries := int(order.Uint16(tiff[ifd:]))
+	for i := 0; i < entries; i++ {
+		entry := ifd + 2 + i*12
+		if entry+12 > len(tiff) {
+			return 0
+		}
+		if order.Uint16(tiff[entry:]) == 0x0112 && order.Uint16(tiff[entry+2:]) == 3 {
+			if o := int(order.Uint16(tiff[entry+8:])); o >= 1 && o <= 8 {
+				return o
+			}
+		}
+	}
+	return 0
+}
+
+// orientationSegment builds an APP1 Exif segment holding only the
+// orientation tag
+func orientationSegment(orientation int) []byte {
+	payload := []byte("Exif\x00\x00MM\x00\x2a\x00\x00\x00\x08")
+	payload = binary.BigEndian.AppendUint16(payload, 1)
+	payload = binary.BigEndian.AppendUint16(payload, 0x0112)
+	payload = binary.BigEndian.AppendUint16(payload, 3)
+	payload = binary.BigEndian.AppendUint32(payload, 1)
+	payload = binary.BigEndian.AppendUint16(payload, uint16(orientation))
+	payload = binary.BigEndian.AppendUint16(payload, 0)
+	payload = binary.BigEndian.AppendUint32(payload, 0)
+
+	segment := []byte{0xFF, 0xE1}
+	segment = binary.BigEndian.AppendUint16(segment, uint16(len(payload)+2))
+	return append(segment, payload...)
+}
+
+var pngSignature = []byte("\x89PNG\r\n\x1a\n")
+
+// pngMetadata are the PNG chunks holding EXIF data, text and timestamps
+var pngMetadata = map[string]bool{"eXIf": true, "tEXt": true, "zTXt": true, "iTXt": true, "tIME": true}
+
+func stripPNG(content []byte) ([]byte, error) {
+	if !bytes.HasPrefix(content, pngSignature) {
+		return nil, ErrMalformed
+	}
+	out := bytes.NewBuffer(make([]byte, 0, len(content)))
+	out.Write(pngSignature)
+	pos := len(pngSignature)
+	for {
+		if pos+12 > len(content) {
+			return nil, ErrMalformed
+		}
+		end := pos + 12 + int(binary.BigEndian.Uint32(content[pos:]))
+		if end > len(content) || end < pos+12 {
+			return nil, ErrMalformed
+		}
+		chunk := string(content[pos+4 : pos+8])
+		if !pngMetadata[chunk] {
+			out.Write(content[pos:end])
+		}
+		if chunk == "IEND" {
+			return out.Bytes(), nil
+		}
+		pos = end
+	}
+}
+
+const (
+	webpFlagXMP  = 0x04
+	webpFlagEXIF = 0x08
+)
+
+func stripWebP(content []byte) ([]byte, error) {
+	if len(content) < 12 || string(content[:4]) != "RIFF" || string(content[8:12]) != "WEBP" {
+		return nil, ErrMalformed
+	}
+	size := int(binary.LittleEndian.Uint32(content[4:])) + 8
+	if size > len(content) || size < 12 {
+		return nil, ErrMalformed
+	}
+
+	out := bytes.NewBuffer(make([]byte, 0, size))
+	out.Write(content[:12])
+	for pos := 12; pos < size; {
+		if pos+8 > size {
+			return nil, ErrMalformed
+		}
+		length := int(binary.LittleEndian.Uint32(content[pos+4:]))
+		end := pos + 8 + length + length%2
+		if end > size || end < pos+8 {
+			return nil, ErrMalformed
+		}
+		switch string(content[pos : pos+4]) {
+		case "EXIF", "XMP ":
+		case "VP8X":
+			chunk := append([]byte{}, content[pos:end]...)
+			if len(chunk) > 8 {
+				chunk[8] &^= webpFlagEXIF | webpFlagXMP
+			}
+			out.Write(chunk)
+		default:
+			out.Write(content[pos:end])
+		}
+		pos = end
+	}
+
+	stripped := out.Bytes()
+	binary.LittleEndian.PutUint32(stripped[4:], uint32(len(stripped)-8))
+	return stripped, nil
+}
@@ -0,0 +1,13 @@
+package imagemeta_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestImagemetaSuite(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Imagemeta Test Suite")
+}
@@ -0,0 +1,177 @@
+package imagemeta_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+
+	"golang_starter_kit_2025/app/imagemeta"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func testImage() image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, 16, 16))
+	for x := 0; x < 16; x++ {
+		for y := 0; y < 16; y++ {
+			img.Set(x, y, color.RGBA{uint8(x * 16), uint8(y * 16), 128, 255})
+		}
+	}
+	return img
+}
+
+func segment(marker byte, payload []byte) []byte {
+	s := []byte{0xFF, marker}
+	s = binary.BigEndian.AppendUint16(s, uint16(len(payload)+2))
+	return append(s, payload...)
+}
+
+// exif builds an APP1 Exif payload (little endian) with an orientation and
+// a GPS latitude reference
+func exif(orientation uint16) []byte {
+	payload := []byte("Exif\x00\x00II\x2a\x00\x08\x00\x00\x00")
+	payload = binary.LittleEndian.AppendUint16(payload, 2)
+	payload = append(payload, 0x12, 0x01, 3, 0, 1, 0, 0, 0)
+	payload = binary.LittleEndian.AppendUint16(payload, orientation)
+	payload = append(payload, 0, 0)
+	payload = append(payload, 0x01, 0x00, 2, 0, 2, 0, 0, 0, 'S', 0, 0, 0)
+	payload = append(payload, 0, 0, 0, 0)
+	return append(payload, []byte("GPS-SECRET-LOCATION")...)
+}
+
+// withSegments inserts segments right after the SOI marker of a JPEG
+func withSegments(jpg []byte, segments ...[]byte) []byte {
+	out := append([]byte{}, jpg[:2]...)
+	for _, s := range segments {
+		out = append(out, s...)
+	}
+	return append(out, jpg[2:]...)
+}
+
+func pngChunk(kind string, data []byte) []byte {
+	c := binary.BigEndian.AppendUint32(nil, uint32(len(data)))
+	c = append(c, kind...)
+	c = append(c, data...)
+	return binary.BigEndian.AppendUint32(c, crc32.ChecksumIEEE(append([]byte(kind), data...)))
+}
+
+func webpChunk(kind string, data []byte) []byte {
+	c := append([]byte(kind), binary.LittleEndian.AppendUint32(nil, uint32(len(data)))...)
+	c = append(c, data...)
+	if len(data)%2 == 1 {
+		c = append(c, 0)
+	}
+	return c
+}
+
+func webp(chunks ...[]byte) []byte {
+	body := []byte("WEBP")
+	for _, c := range chunks {
+		body = append(body, c...)
+	}
+	return append(append([]byte("RIFF"), binary.LittleEndian.AppendUint32(nil, uint32(len(body)))...), body...)
+}
+
+var _ = Describe("Strip", func() {
+	var plainJPEG []byte
+
+	BeforeEach(func() {
+		var buf bytes.Buffer
+		Expect(jpeg.Encode(&buf, testImage(), nil)).To(Succeed())
+		plainJPEG = buf.Bytes()
+	})
+
+	Describe("JPEG", func() {
+		It("should remove EXIF, XMP, IPTC and comments but keep the image", func() {
+			jpg := withSegments(plainJPEG,
+				segment(0xE1, exif(1)),
+				segment(0xE1, []byte("http://ns.adobe.com/xap/1.0/\x00<x:xmpmeta>creator</x:xmpmeta>")),
+				segment(0xED, []byte("Photoshop 3.0\x00IPTC")),
+				segment(0xFE, []byte("taken at home")),
+			)
+
+			stripped, err := imagemeta.Strip(jpg, "image/jpeg")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(stripped).To(Equal(plainJPEG))
+			_, err = jpeg.Decode(bytes.NewReader(stripped))
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("should keep the orientation of rotated photos", func() {
+			jfif := segment(0xE0, []byte("JFIF\x00\x01\x01\x00\x00\x01\x00\x01\x00\x00"))
+			jpg := withSegments(plainJPEG, jfif, segment(0xE1, exif(6)))
+			Expect(imagemeta.Orientation(jpg)).To(Equal(6))
+
+			stripped, err := imagemeta.Strip(jpg, "image/jpeg")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(stripped).NotTo(ContainSubstring("GPS-SECRET-LOCATION"))
+			Expect(imagemeta.Orientation(stripped)).To(Equal(6))
+			// JFIF stays the first segment
+			Expect(stripped[2:4]).To(Equal([]byte{0xFF, 0xE0}))
+			_, err = jpeg.Decode(bytes.NewReader(stripped))
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("should keep colour profiles", func() {
+			icc := segment(0xE2, []byte("ICC_PROFILE\x00\x01\x01profile"))
+			stripped, err := imagemeta.Strip(withSegments(plainJPEG, icc), "image/jpeg")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(bytes.Contains(stripped, icc)).To(BeTrue())
+		})
+
+		It("should refuse truncated files", func() {
+			jpg := withSegments(plainJPEG, segment(0xE1, exif(1)))
+			_, err := imagemeta.Strip(jpg[:20], "image/jpeg")
+			Expect(err).To(MatchError(imagemeta.ErrMalformed))
+		})
+	})
+
+	Describe("PNG", func() {
+		It("should remove text, EXIF and time chunks", func() {
+			var buf bytes.Buffer
+			Expect(png.Encode(&buf, testImage())).To(Succeed())
+			plain := buf.Bytes()
+			// insert the metadata after the IHDR chunk
+			ihdr := 8 + 12 + 13
+			withMeta := append(append([]byte{}, plain[:ihdr]...), pngChunk("tEXt", []byte("Author\x00Budi"))...)
+			withMeta = append(withMeta, pngChunk("eXIf", exif(1)[6:])...)
+			withMeta = append(withMeta, pngChunk("tIME", []byte{0x07, 0xea, 10, 18, 12, 0, 0})...)
+			withMeta = append(withMeta, plain[ihdr:]...)
+
+			stripped, err := imagemeta.Strip(withMeta, "image/png")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(stripped).To(Equal(plain))
+		})
+
+		It("should refuse truncated files", func() {
+			var buf bytes.Buffer
+			Expect(png.Encode(&buf, testImage())).To(Succeed())
+			_, err := imagemeta.Strip(buf.Bytes()[:40], "image/png")
+			Expect(err).To(MatchError(imagemeta.ErrMalformed))
+		})
+	})
+
+	Describe("WebP", func() {
+		It("should remove EXIF and XMP chunks and their flags", func() {
+			vp8x := []byte{0x08 | 0x04 | 0x10, 0, 0, 0, 15, 0, 0, 15, 0, 0}
+			image := webpChunk("VP8L", []byte{0x2f, 1, 2, 3, 4})
+			withMeta := webp(webpChunk("VP8X", vp8x), image, webpChunk("EXIF", exif(1)[6:]), webpChunk("XMP ", []byte("<x:xmpmeta/>")))
+
+			stripped, err := imagemeta.Strip(withMeta, "image/webp")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(stripped).To(Equal(webp(webpChunk("VP8X", []byte{0x10, 0, 0, 0, 15, 0, 0, 15, 0, 0}), image)))
+		})
+	})
+
+	It("should leave other files untouched", func() {
+		pdf := []byte("%PDF-1.4\n/Author (Budi)")
+		stripped, err := imagemeta.Strip(pdf, "application/pdf")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(stripped).To(Equal(pdf))
+	})
+})
@@ -0,0 +1,160 @@
+// Package imagequality checks whether an uploaded photo is usable for
+// identity verification: real image type, resolution, aspect ratio,
+// sharpness and exposure. It also computes a perceptual hash so that the
+// same picture uploaded twice can be recognised.
+package imagequality
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"math"
+	"net/http"
+)
+
+type Issue string
+
+const (
+	IssueLowResolution Issue = "low_resolution"
+	IssueAspectRatio   Issue = "bad_aspect_ratio"
+	IssueBlurry        Issue = "blurry"
+	IssueUnderexposed  Issue = "underexposed"
+	IssueOverexposed   Issue = "overexposed"
+	IssueDuplicate     Issue = "duplicate"
+)
+
+// MaxPixels limits the size of images that are decoded, so a small file
+// cannot expand into a huge bitmap
+const MaxPixels = 40_000_000
+
+var (
+	ErrUnsupportedType = errors.New("file is not a supported image (jpeg, png)")
+	ErrTooLarge        = errors.New("image has too many pixels")
+)
+
+var extensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+}
+
+// Options holds the thresholds an image is measured against. Aspect ratios
+// are long side over short side, so portrait and landscape photos are
+// treated alike.
+type Options struct {
+	MinWidth       int
+	MinHeight      int
+	MinAspectRatio float64
+	MaxAspectRatio float64
+	// MinSharpness is the lowest acceptable variance of the Laplacian
+	MinSharpness float64
+	// MinBrightness and MaxBrightness bound the mean luminance (0-255)
+	MinBrightness float64
+	MaxBrightness float64
+	// MaxClipped is the largest acceptable share of pure black or pure
+	// white pixels
+	MaxClipped float64
+}
+
+// DefaultOptions suits a generic document photo
+var DefaultOptions = Options{
+	MinWidth:       640,
+	MinHeight:      480,
+	MinAspectRatio: 1,
+	MaxAspectRatio: 2.5,
+	MinSharpness:   100,
+	MinBrightness:  50,
+	MaxBrightness:  210,
+	MaxClipped:     0.25,
+}
+
+// Report is the outcome of Analyze
+type Report struct {
+	MimeType    string  `json:"mime_type"`
+	Extension   string  `json:"extension"`
+	Width       int     `json:"width"`
+	Height      int     `json:"height"`
+	AspectRatio float64 `json:"aspect_ratio"`
+	Sharpness   float64 `json:"sharpness"`
+	Brightness  float64 `json:"brightness"`
+	Clipped     float64 `json:"clipped"`
+	PHash       string  `json:"phash"`
+	Issues      []Issue `json:"issues"`
+}
+
+// Passed reports whether the image has no issue that calls for a retake
+func (r Report) Passed() bool {
+	for _, issue := range r.Issues {
+		if issue != IssueDuplicate {
+			return false
+		}
+	}
+	return true
+}
+
+// Analyze sniffs, decodes and measures an image. Images of more than
+// MaxPixels pixels are refused before they are decoded.
+func Analyze(data []byte, opts Options) (Report, error) {
+	report := Report{MimeType: http.DetectContentType(data), Issues: []Issue{}}
+
+	var decodeConfig func(io.Reader) (image.Config, error)
+	var decode func(io.Reader) (image.Image, error)
+	switch report.MimeType {
+	case "image/jpeg":
+		decodeConfig, decode = jpeg.DecodeConfig, jpeg.Decode
+	case "image/png":
+		decodeConfig, decode = png.DecodeConfig, png.Decode
+	default:
+		return report, ErrUnsupportedType
+	}
+
+	config, err := decodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return report, ErrUnsupportedType
+	}
+	if config.Width*config.Height > MaxPixels {
+		return report, fmt.Errorf("%w: more than %d pixels", ErrTooLarge, MaxPixels)
+	}
+	img, err := decode(bytes.NewReader(data))
+	if err != nil {
+		return report, ErrUnsupportedType
+	}
+	report.Extension = extensions[report.MimeType]
+
+	bounds := img.Bounds()
+	report.Width, report.Height = bounds.Dx(), bounds.Dy()
+	if report.Width == 0 || report.Height == 0 {
+		return report, ErrUnsupportedType
+	}
+	long, short := math.Max(float64(report.Width), float64(report.Height)), math.Min(float64(report.Width), float64(report.Height))
+	report.AspectRatio = math.Round(long/short*1000) / 1000
+
+	if long < float64(max(opts.MinWidth, opts.MinHeight)) || short < float64(min(opts.MinWidth, opts.MinHeight)) {
+		report.Issues = append(report.Issues, IssueLowResolution)
+	}
+	if report.AspectRatio < opts.MinAspectRatio || (opts.MaxAspectRatio > 0 && report.AspectRatio > opts.MaxAspectRatio) {
+		report.Issues = append(report.Issues, IssueAspectRatio)
+	}
+
+	gray := grayscale(img, 1024)
+	report.Sharpness = math.Round(laplacianVariance(gray)*100) / 100
+	if report.Sharpness < opts.MinSharpness {
+		report.Issues = append(report.Issues, IssueBlurry)
+	}
+
+	brightness, dark, bright := exposure(gray)
+	report.Brightness = math.Round(brightness*100) / 100
+	report.Clipped = math.Round((dark+bright)*1000) / 1000
+	if brightness < opts.MinBrightness || dark > opts.MaxClipped {
+		report.Issues = append(report.Issues, IssueUnderexposed)
+	}
+	if brightness > opts.MaxBrightness || bright > opts.MaxClipped {
+		report.Issues = append(report.Issues, IssueOverexposed)
+	}
+
+	report.PHash = PHash(img).String()
+	return report, nil
+}
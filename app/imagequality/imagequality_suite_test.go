@@ -0,0 +1,13 @@
+package imagequality_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestImageQualitySuite(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Image Quality Test Suite")
+}
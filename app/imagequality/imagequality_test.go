@@ -0,0 +1,166 @@
+package imagequality_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"math"
+
+	"golang_starter_kit_2025/app/imagequality"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// pattern draws a checkerboard of two gray levels with the given cell size
+func pattern(width, height, cell int, dark, light uint8) image.Image {
+	img := image.NewGray(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			v := dark
+			if (x/cell+y/cell)%2 == 0 {
+				v = light
+			}
+			img.SetGray(x, y, color.Gray{Y: v})
+		}
+	}
+	return img
+}
+
+// scene draws smooth waves, closer to a photo than a checkerboard
+func scene(width, height int, fx, fy float64) image.Image {
+	img := image.NewGray(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			v := 120 + 60*math.Sin(float64(x)/fx)*math.Cos(float64(y)/fy) + 40*float64(x)/float64(width)
+			img.SetGray(x, y, color.Gray{Y: uint8(v)})
+		}
+	}
+	return img
+}
+
+func encodePNG(img image.Image) []byte {
+	var buf bytes.Buffer
+	Expect(png.Encode(&buf, img)).To(Succeed())
+	return buf.Bytes()
+}
+
+func encodeJPEG(img image.Image) []byte {
+	var buf bytes.Buffer
+	Expect(jpeg.Encode(&buf, img, &jpeg.Options{Quality: 90})).To(Succeed())
+	return buf.Bytes()
+}
+
+var _ = Describe("Analyze", func() {
+	opts := imagequality.DefaultOptions
+
+	It("should pass a sharp, well exposed image", func() {
+		report, err := imagequality.Analyze(encodeJPEG(pattern(800, 600, 8, 60, 190)), opts)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(report.MimeType).To(Equal("image/jpeg"))
+		Expect(report.Extension).To(Equal(".jpg"))
+		Expect(report.Width).To(Equal(800))
+		Expect(report.AspectRatio).To(BeNumerically("~", 1.333, 0.001))
+		Expect(report.Issues).To(BeEmpty())
+		Expect(report.Passed()).To(BeTrue())
+	})
+
+	It("should reject content that is not an image", func() {
+		_, err := imagequality.Analyze([]byte("%PDF-1.4 not an image"), opts)
+		Expect(err).To(MatchError(imagequality.ErrUnsupportedType))
+	})
+
+	It("should refuse images with too many pixels before decoding them", func() {
+		// claim 10000x5000 pixels in the header of a tiny PNG
+		data := encodePNG(pattern(8, 8, 2, 60, 190))
+		binary.BigEndian.PutUint32(data[16:], 10000)
+		binary.BigEndian.PutUint32(data[20:], 5000)
+		binary.BigEndian.PutUint32(data[29:], crc32.ChecksumIEEE(data[12:29]))
+
+		_, err := imagequality.Analyze(data, opts)
+		Expect(err).To(MatchError(imagequality.ErrTooLarge))
+	})
+
+	It("should flag small images", func() {
+		report, err := imagequality.Analyze(encodePNG(pattern(320, 240, 4, 60, 190)), opts)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(report.Issues).To(ContainElement(imagequality.IssueLowResolution))
+	})
+
+	It("should flag unusual aspect ratios", func() {
+		report, err := imagequality.Analyze(encodePNG(pattern(2000, 640, 8, 60, 190)), opts)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(report.Issues).To(ContainElement(imagequality.IssueAspectRatio))
+	})
+
+	It("should measure narrow images without producing NaN", func() {
+		report, err := imagequality.Analyze(encodePNG(pattern(1, 1100, 4, 60, 190)), opts)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(math.IsNaN(report.Brightness)).To(BeFalse())
+		Expect(math.IsNaN(report.Clipped)).To(BeFalse())
+		Expect(report.Issues).To(ContainElements(imagequality.IssueLowResolution, imagequality.IssueAspectRatio))
+
+		_, err = json.Marshal(report)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("should flag blurry images", func() {
+		report, err := imagequality.Analyze(encodePNG(pattern(800, 600, 800, 120, 130)), opts)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(report.Issues).To(ConsistOf(imagequality.IssueBlurry))
+		Expect(report.Passed()).To(BeFalse())
+	})
+
+	It("should flag dark and bright images", func() {
+		report, err := imagequality.Analyze(encodePNG(pattern(800, 600, 8, 0, 40)), opts)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(report.Issues).To(ContainElement(imagequality.IssueUnderexposed))
+
+		report, err = imagequality.Analyze(encodePNG(pattern(800, 600, 8, 220, 255)), opts)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(report.Issues).To(ContainElement(imagequality.IssueOverexposed))
+	})
+})
+
+var _ = Describe("PHash", func() {
+	It("should give near hashes to the same picture in different encodings", func() {
+		img := scene(800, 600, 37, 23)
+		decoded, err := jpeg.Decode(bytes.NewReader(encodeJPEG(img)))
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(imagequality.PHash(img).Distance(imagequality.PHash(decoded))).To(BeNumerically("<=", 4))
+	})
+
+	It("should give distant hashes to different pictures", func() {
+		a := imagequality.PHash(scene(800, 600, 37, 23))
+		b := imagequality.PHash(scene(800, 600, 90, 140))
+		Expect(a.Distance(b)).To(BeNumerically(">", 10))
+	})
+
+	It("should round trip through its string form", func() {
+		hash := imagequality.PHash(scene(800, 600, 37, 23))
+		parsed, err := imagequality.ParseHash(hash.String())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(parsed).To(Equal(hash))
+	})
+
+	It("should split into bands that find every hash within the distance", func() {
+		hash := imagequality.Hash(0x0123456789abcdef)
+		Expect(hash.Bands()).To(Equal([imagequality.HashBands]uint16{0x0123, 0x4567, 0x89ab, 0xcdef}))
+
+		probes := hash.BandProbes(7)
+		Expect(probes[0]).To(HaveLen(17))
+		// seven bits spread over all bands leave one band a single bit away
+		near := hash ^ 0x0003_0003_0003_0001
+		Expect(hash.Distance(near)).To(Equal(7))
+		Expect(probes[3]).To(ContainElement(near.Bands()[3]))
+		for i := 0; i < 3; i++ {
+			Expect(probes[i]).NotTo(ContainElement(near.Bands()[i]))
+		}
+	})
+})
@@ -0,0 +1,78 @@
+package imagequality
+
+import (
+	"image"
+)
+
+// grayImage is a luminance raster, row-major
+type grayImage struct {
+	width, height int
+	pix           []float64
+}
+
+func (g grayImage) at(x, y int) float64 {
+	return g.pix[y*g.width+x]
+}
+
+// grayscale converts img to luminance, sampling it down so that its long
+// side is at most maxSide pixels
+func grayscale(img image.Image, maxSide int) grayImage {
+	bounds := img.Bounds()
+	step := 1
+	for bounds.Dx()/step > maxSide || bounds.Dy()/step > maxSide {
+		step++
+	}
+
+	// a narrow image keeps at least one column or row
+	g := grayImage{width: max(bounds.Dx()/step, 1), height: max(bounds.Dy()/step, 1)}
+	g.pix = make([]float64, g.width*g.height)
+	for y := 0; y < g.height; y++ {
+		for x := 0; x < g.width; x++ {
+			r, gr, b, _ := img.At(bounds.Min.X+x*step, bounds.Min.Y+y*step).RGBA()
+			g.pix[y*g.width+x] = (0.299*float64(r) + 0.587*float64(gr) + 0.114*float64(b)) / 257
+		}
+	}
+	return g
+}
+
+// laplacianVariance is the variance of the 4-neighbour Laplacian. Sharp
+// images have strong edges and therefore a high variance.
+func laplacianVariance(g grayImage) float64 {
+	if g.width < 3 || g.height < 3 {
+		return 0
+	}
+
+	var sum, sumSq float64
+	n := float64((g.width - 2) * (g.height - 2))
+	for y := 1; y < g.height-1; y++ {
+		for x := 1; x < g.width-1; x++ {
+			v := g.at(x-1, y) + g.at(x+1, y) + g.at(x, y-1) + g.at(x, y+1) - 4*g.at(x, y)
+			sum += v
+			sumSq += v * v
+		}
+	}
+	mean := sum / n
+	return sumSq/n - mean*mean
+}
+
+// exposure returns the mean luminance and the share of pixels clipped to
+// black and to white, all zero for an empty raster
+func exposure(g grayImage) (mean, dark, bright float64) {
+	if len(g.pix) == 0 {
+		return 0, 0, 0
+	}
+
+	var sum float64
+	var darkCount, brightCount int
+	for _, v := range g.pix {
+		sum += v
+		if v <= 5 {
+			darkCount++
+		}
+		if v >= 250 {
+			brightCount++
+		}
+	}
+	n := float64(len(g.pix))
+	return sum / n, float64(darkCount) / n, float64(brightCount) / n
+}
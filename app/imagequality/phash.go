@@ -0,0 +1,132 @@
+package imagequality
+
+import (
+	"fmt"
+	"image"
+	"math"
+	"math/bits"
+	"sort"
+	"strconv"
+)
+
+// Hash is a 64-bit perceptual hash. Visually similar images have hashes
+// with a small Hamming distance.
+type Hash uint64
+
+func (h Hash) String() string {
+	return fmt.Sprintf("%016x", uint64(h))
+}
+
+// ParseHash reads a hash produced by Hash.String
+func ParseHash(s string) (Hash, error) {
+	v, err := strconv.ParseUint(s, 16, 64)
+	return Hash(v), err
+}
+
+// Distance is the number of differing bits between two hashes
+func (h Hash) Distance(other Hash) int {
+	return bits.OnesCount64(uint64(h ^ other))
+}
+
+// HashBands is the number of 16-bit bands a hash is split into for lookups
+const HashBands = 4
+
+// Bands splits the hash into HashBands 16-bit values, most significant first.
+// Stored in indexed columns they let near hashes be found without comparing
+// against every stored hash.
+func (h Hash) Bands() [HashBands]uint16 {
+	var bands [HashBands]uint16
+	for i := range bands {
+		bands[i] = uint16(h >> (16 * (HashBands - 1 - i)))
+	}
+	return bands
+}
+
+// BandProbes returns, for each band, the band values of hashes that may lie
+// within maxDistance bits of h. Two hashes maxDistance bits apart differ in
+// at most maxDistance/HashBands bits in one of their bands, so every such
+// hash has one band among the probes; the probes grow quickly with the
+// distance, which is meant to stay small.
+func (h Hash) BandProbes(maxDistance int) [HashBands][]uint16 {
+	radius := max(0, maxDistance) / HashBands
+	var probes [HashBands][]uint16
+	for i, band := range h.Bands() {
+		probes[i] = nearBands(band, radius)
+	}
+	return probes
+}
+
+// nearBands lists the values at most radius bits away from band
+func nearBands(band uint16, radius int) []uint16 {
+	values := []uint16{band}
+	var flip func(value uint16, from, left int)
+	flip = func(value uint16, from, left int) {
+		for bit := from; bit < 16 && left > 0; bit++ {
+			next := value ^ 1<<bit
+			values = append(values, next)
+			flip(next, bit+1, left-1)
+		}
+	}
+	flip(band, 0, radius)
+	return values
+}
+
+// PHash computes the DCT based perceptual hash of img: the image is reduced
+// to 32x32 luminance, transformed, and the 8x8 lowest frequencies (without
+// the DC term) are compared with their median.
+func PHash(img image.Image) Hash {
+	const size = 32
+	bounds := img.Bounds()
+
+	var pixels [size][size]float64
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			// average the block of source pixels mapped onto this cell
+			x0 := bounds.Min.X + x*bounds.Dx()/size
+			x1 := max(bounds.Min.X+(x+1)*bounds.Dx()/size, x0+1)
+			y0 := bounds.Min.Y + y*bounds.Dy()/size
+			y1 := max(bounds.Min.Y+(y+1)*bounds.Dy()/size, y0+1)
+
+			var sum float64
+			var n int
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					r, g, b, _ := img.At(sx, sy).RGBA()
+					sum += (0.299*float64(r) + 0.587*float64(g) + 0.114*float64(b)) / 257
+					n++
+				}
+			}
+			pixels[y][x] = sum / float64(n)
+		}
+	}
+
+	var coefficients []float64
+	for v := 0; v < 8; v++ {
+		for u := 0; u < 8; u++ {
+			if u == 0 && v == 0 {
+				continue
+			}
+			var sum float64
+			for y := 0; y < size; y++ {
+				for x := 0; x < size; x++ {
+					sum += pixels[y][x] *
+						math.Cos(float64(2*x+1)*float64(u)*math.Pi/(2*size)) *
+						math.Cos(float64(2*y+1)*float64(v)*math.Pi/(2*size))
+				}
+			}
+			coefficients = append(coefficients, sum)
+		}
+	}
+
+	sorted := append([]float64(nil), coefficients...)
+	sort.Float64s(sorted)
+	median := sorted[len(sorted)/2]
+
+	var hash Hash
+	for i, c := range coefficients {
+		if c > median {
+			hash |= 1 << uint(i)
+		}
+	}
+	return hash
+}
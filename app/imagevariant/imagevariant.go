@@ -0,0 +1,298 @@
+// Package imagevariant renders smaller or converted versions of stored
+// images, such as thumbnails for lists, so clients do not have to download
+// the full photo. Everything is done with the standard library: JPEG, PNG and
+// GIF images can be read, JPEG and PNG written.
+package imagevariant
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"golang_starter_kit_2025/app/imagemeta"
+)
+
+// Query parameters of a variant
+const (
+	ParamWidth   = "width"
+	ParamHeight  = "height"
+	ParamFit     = "fit"
+	ParamCrop    = "crop"
+	ParamFormat  = "format"
+	ParamQuality = "quality"
+)
+
+const (
+	// MaxDimension limits the width and height of a variant
+	MaxDimension = 4096
+	// MaxSourcePixels limits the size of images variants are made of, so a
+	// small file cannot expand into a huge bitmap
+	MaxSourcePixels = 40_000_000
+
+	defaultQuality = 85
+)
+
+var (
+	ErrInvalidOptions = errors.New("image variant options are invalid")
+	ErrUnsupported    = errors.New("image format is not supported for variants")
+)
+
+// Fit is how an image is fitted into the requested width and height
+type Fit string
+
+const (
+	// FitContain scales the image to fit inside the box, keeping its aspect
+	// ratio
+	FitContain Fit = "contain"
+	// FitCover scales the image to fill the box and crops what sticks out,
+	// keeping the centre
+	FitCover Fit = "cover"
+)
+
+// Format is the encoding of a variant
+type Format string
+
+const (
+	FormatJPEG Format = "jpeg"
+	FormatPNG  Format = "png"
+)
+
+// Options describe a variant. The zero value is the original image.
+type Options struct {
+	// Width and Height of the box the image is fitted into. When only one
+	// is set the other follows the aspect ratio. Images are never enlarged.
+	Width  int
+	Height int
+	// Fit is FitContain when empty
+	Fit Fit
+	// Crop is a region of the upright source image to use instead of the
+	// whole image, applied before resizing
+	Crop image.Rectangle
+	// Format is the format of the source when empty (PNG for GIF sources)
+	Format Format
+	// Quality of JPEG variants, 1 to 100, 85 when zero
+	Quality int
+}
+
+// Parse reads variant options from a query, ignoring other parameters
+func Parse(query url.Values) (Options, error) {
+	var opts Options
+	var err error
+
+	if opts.Width, err = parseDimension(query, ParamWidth); err != nil {
+		return opts, err
+	}
+	if opts.Height, err = parseDimension(query, ParamHeight); err != nil {
+		return opts, err
+	}
+
+	switch fit := Fit(query.Get(ParamFit)); fit {
+	case "", FitContain, FitCover:
+		opts.Fit = fit
+	default:
+		return opts, fmt.Errorf("%w: fit must be contain or cover", ErrInvalidOptions)
+	}
+	if opts.Fit == FitCover && (opts.Width == 0 || opts.Height == 0) {
+		return opts, fmt.Errorf("%w: fit cover needs width and height", ErrInvalidOptions)
+	}
+
+	if crop := query.Get(ParamCrop); crop != "" {
+		parts := strings.Split(crop, ",")
+		if len(parts) != 4 {
+			return opts, fmt.Errorf("%w: crop must be x,y,width,height", ErrInvalidOptions)
+		}
+		var values [4]int
+		for i, part := range parts {
+			value, err := strconv.Atoi(part)
+			if err != nil || value < 0 || (i >= 2 && value == 0) {
+				return opts, fmt.Errorf("%w: crop must be x,y,width,height", ErrInvalidOptions)
+			}
+			values[i] = value
+		}
+		opts.Crop = image.Rect(values[0], values[1], values[0]+values[2], values[1]+values[3])
+	}
+
+	switch format := Format(query.Get(ParamFormat)); format {
+	case "", FormatJPEG, FormatPNG:
+		opts.Format = format
+	case "jpg":
+		opts.Format = FormatJPEG
+	default:
+		return opts, fmt.Errorf("%w: format must be jpeg or png", ErrInvalidOptions)
+	}
+
+	if quality := query.Get(ParamQuality); quality != "" {
+		value, err := strconv.Atoi(quality)
+		if err != nil || value < 1 || value > 100 {
+			return opts, fmt.Errorf("%w: quality must be between 1 and 100", ErrInvalidOptions)
+		}
+		opts.Quality = value
+	}
+	return opts, nil
+}
+
+func parseDimension(query url.Values, param string) (int, error) {
+	raw := query.Get(param)
+	if raw == "" {
+		return 0, nil
+	}
+	value, err := strconv.Atoi(raw)
+	if err != nil || value < 1 || value > MaxDimension {
+		return 0, fmt.Errorf("%w: %s must be between 1 and %d", ErrInvalidOptions, param, MaxDimension)
+	}
+	return value, nil
+}
+
+// IsZero reports whether the options ask for the original image
+func (o Options) IsZero() bool {
+	return o == Options{}
+}
+
+// Values returns the options as query parameters. Equal options give equal
+// values, so their encoding can be used as a cache key.
+func (o Options) Values() url.Values {
+	values := url.Values{}
+	if o.Width != 0 {
+		values.Set(ParamWidth, strconv.Itoa(o.Width))
+	}
+	if o.Height != 0 {
+		values.Set(ParamHeight, strconv.Itoa(o.Height))
+	}
+	if o.Fit != "" {
+		values.Set(ParamFit, string(o.Fit))
+	}
+	if !o.Crop.Empty() {
+		values.Set(ParamCrop, fmt.Sprintf("%d,%d,%d,%d", o.Crop.Min.X, o.Crop.Min.Y, o.Crop.Dx(), o.Crop.Dy()))
+	}
+	if o.Format != "" {
+		values.Set(ParamFormat, string(o.Format))
+	}
+	if o.Quality != 0 {
+		values.Set(ParamQuality, strconv.Itoa(o.Quality))
+	}
+	return values
+}
+
+// Render returns the variant of an image and its content type. JPEG photos
+// are turned upright following their EXIF orientation first, since the
+// variant does not carry the orientation.
+func Render(content []byte, opts Options) ([]byte, string, error) {
+	sourceType := strings.SplitN(http.DetectContentType(content), ";", 2)[0]
+	var decode func([]byte) (image.Image, error)
+	var decodeConfig func([]byte) (image.Config, error)
+	switch sourceType {
+	case "image/jpeg":
+		decode = func(b []byte) (image.Image, error) { return jpeg.Decode(bytes.NewReader(b)) }
+		decodeConfig = func(b []byte) (image.Config, error) { return jpeg.DecodeConfig(bytes.NewReader(b)) }
+	case "image/png":
+		decode = func(b []byte) (image.Image, error) { return png.Decode(bytes.NewReader(b)) }
+		decodeConfig = func(b []byte) (image.Config, error) { return png.DecodeConfig(bytes.NewReader(b)) }
+	case "image/gif":
+		decode = func(b []byte) (image.Image, error) { return gif.Decode(bytes.NewReader(b)) }
+		decodeConfig = func(b []byte) (image.Config, error) { return gif.DecodeConfig(bytes.NewReader(b)) }
+	default:
+		return nil, "", fmt.Errorf("%w: %s", ErrUnsupported, sourceType)
+	}
+
+	config, err := decodeConfig(content)
+	if err != nil {
+		return nil, "", err
+	}
+	if config.Width*config.Height > MaxSourcePixels {
+		return nil, "", fmt.Errorf("%w: image has more than %d pixels", ErrUnsupported, MaxSourcePixels)
+	}
+	decoded, err := decode(content)
+	if err != nil {
+		return nil, "", err
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, decoded.Bounds().Dx(), decoded.Bounds().Dy()))
+	draw.Draw(img, img.Bounds(), decoded, decoded.Bounds().Min, draw.Src)
+	if sourceType == "image/jpeg" {
+		img = orient(img, imagemeta.Orientation(content))
+	}
+
+	if !opts.Crop.Empty() {
+		crop := opts.Crop.Intersect(img.Bounds())
+		if crop.Empty() {
+			return nil, "", fmt.Errorf("%w: crop lies outside the image", ErrInvalidOptions)
+		}
+		img = copyRegion(img, crop)
+	}
+	img = fit(img, opts)
+
+	format := opts.Format
+	if format == "" {
+		format = FormatPNG
+		if sourceType == "image/jpeg" {
+			format = FormatJPEG
+		}
+	}
+
+	var out bytes.Buffer
+	switch format {
+	case FormatJPEG:
+		quality := opts.Quality
+		if quality == 0 {
+			quality = defaultQuality
+		}
+		err = jpeg.Encode(&out, img, &jpeg.Options{Quality: quality})
+	default:
+		err = png.Encode(&out, img)
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	return out.Bytes(), "image/" + string(format), nil
+}
+
+// fit scales img into the box of opts without enlarging it
+func fit(img *image.RGBA, opts Options) *image.RGBA {
+	width, height := img.Bounds().Dx(), img.Bounds().Dy()
+	boxWidth, boxHeight := opts.Width, opts.Height
+	if boxWidth == 0 && boxHeight == 0 {
+		return img
+	}
+
+	if opts.Fit == FitCover {
+		scale := max(float64(boxWidth)/float64(width), float64(boxHeight)/float64(height))
+		if scale > 1 {
+			// shrink the box instead of enlarging the image
+			boxWidth = max(1, int(float64(boxWidth)/scale+0.5))
+			boxHeight = max(1, int(float64(boxHeight)/scale+0.5))
+			scale = 1
+		}
+		scaled := resize(img, max(boxWidth, int(float64(width)*scale+0.5)), max(boxHeight, int(float64(height)*scale+0.5)))
+		x := (scaled.Bounds().Dx() - boxWidth) / 2
+		y := (scaled.Bounds().Dy() - boxHeight) / 2
+		return copyRegion(scaled, image.Rect(x, y, x+boxWidth, y+boxHeight))
+	}
+
+	scale := 1.0
+	if boxWidth != 0 {
+		scale = float64(boxWidth) / float64(width)
+	}
+	if boxHeight != 0 && (boxWidth == 0 || float64(boxHeight)/float64(height) < scale) {
+		scale = float64(boxHeight) / float64(height)
+	}
+	if scale >= 1 {
+		return img
+	}
+	return resize(img, max(1, int(float64(width)*scale+0.5)), max(1, int(float64(height)*scale+0.5)))
+}
+
+// copyRegion returns a copy of r of img whose bounds start at the origin
+func copyRegion(img *image.RGBA, r image.Rectangle) *image.RGBA {
+	out := image.NewRGBA(image.Rect(0, 0, r.Dx(), r.Dy()))
+	draw.Draw(out, out.Bounds(), img, r.Min, draw.Src)
+	return out
+}
@@ -0,0 +1,13 @@
+package imagevariant_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestImagevariantSuite(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Imagevariant Test Suite")
+}
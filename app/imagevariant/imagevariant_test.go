@@ -0,0 +1,204 @@
+package imagevariant_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"net/url"
+
+	"golang_starter_kit_2025/app/imagevariant"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// quadrants is red on the left half and blue on the right, with a green top
+// left corner, so orientation and cropping can be checked by colour
+func quadrants(width, height int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for x := 0; x < width; x++ {
+		for y := 0; y < height; y++ {
+			c := color.RGBA{255, 0, 0, 255}
+			if x >= width/2 {
+				c = color.RGBA{0, 0, 255, 255}
+			}
+			if x < width/4 && y < height/4 {
+				c = color.RGBA{0, 255, 0, 255}
+			}
+			img.Set(x, y, c)
+		}
+	}
+	return img
+}
+
+func encodePNG(img image.Image) []byte {
+	var buf bytes.Buffer
+	Expect(png.Encode(&buf, img)).To(Succeed())
+	return buf.Bytes()
+}
+
+func encodeJPEG(img image.Image) []byte {
+	var buf bytes.Buffer
+	Expect(jpeg.Encode(&buf, img, &jpeg.Options{Quality: 95})).To(Succeed())
+	return buf.Bytes()
+}
+
+// withOrientation inserts an APP1 Exif segment carrying orientation
+func withOrientation(jpg []byte, orientation uint16) []byte {
+	payload := []byte("Exif\x00\x00II\x2a\x00\x08\x00\x00\x00")
+	payload = binary.LittleEndian.AppendUint16(payload, 1)
+	payload = append(payload, 0x12, 0x01, 3, 0, 1, 0, 0, 0)
+	payload = binary.LittleEndian.AppendUint16(payload, orientation)
+	payload = append(payload, 0, 0, 0, 0, 0, 0)
+
+	segment := []byte{0xFF, 0xE1}
+	segment = binary.BigEndian.AppendUint16(segment, uint16(len(payload)+2))
+	segment = append(segment, payload...)
+
+	out := append([]byte{}, jpg[:2]...)
+	out = append(out, segment...)
+	return append(out, jpg[2:]...)
+}
+
+func decode(content []byte) image.Image {
+	img, _, err := image.Decode(bytes.NewReader(content))
+	Expect(err).NotTo(HaveOccurred())
+	return img
+}
+
+func near(c color.Color, r, g, b uint8) bool {
+	cr, cg, cb, _ := c.RGBA()
+	diff := func(a uint32, b uint8) int {
+		d := int(a>>8) - int(b)
+		if d < 0 {
+			return -d
+		}
+		return d
+	}
+	return diff(cr, r) < 40 && diff(cg, g) < 40 && diff(cb, b) < 40
+}
+
+var _ = Describe("Parse", func() {
+	It("reads every option and ignores other parameters", func() {
+		opts, err := imagevariant.Parse(url.Values{
+			"width": {"200"}, "height": {"100"}, "fit": {"cover"}, "crop": {"10,20,30,40"},
+			"format": {"jpg"}, "quality": {"70"}, "expires": {"123"}, "signature": {"abc"},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(opts).To(Equal(imagevariant.Options{
+			Width: 200, Height: 100, Fit: imagevariant.FitCover, Crop: image.Rect(10, 20, 40, 60),
+			Format: imagevariant.FormatJPEG, Quality: 70,
+		}))
+	})
+
+	It("returns the zero options without parameters", func() {
+		opts, err := imagevariant.Parse(url.Values{"expires": {"123"}})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(opts.IsZero()).To(BeTrue())
+	})
+
+	DescribeTable("refuses invalid options",
+		func(query url.Values) {
+			_, err := imagevariant.Parse(query)
+			Expect(errors.Is(err, imagevariant.ErrInvalidOptions)).To(BeTrue())
+		},
+		Entry("zero width", url.Values{"width": {"0"}}),
+		Entry("too large", url.Values{"height": {"5000"}}),
+		Entry("not a number", url.Values{"width": {"abc"}}),
+		Entry("unknown fit", url.Values{"fit": {"stretch"}}),
+		Entry("cover without both sides", url.Values{"fit": {"cover"}, "width": {"10"}}),
+		Entry("short crop", url.Values{"crop": {"1,2,3"}}),
+		Entry("empty crop", url.Values{"crop": {"1,2,0,3"}}),
+		Entry("unknown format", url.Values{"format": {"webp"}}),
+		Entry("quality out of range", url.Values{"quality": {"101"}}),
+	)
+
+	It("round-trips through Values", func() {
+		opts := imagevariant.Options{Width: 64, Fit: imagevariant.FitContain, Crop: image.Rect(1, 2, 5, 8), Format: imagevariant.FormatPNG}
+		parsed, err := imagevariant.Parse(opts.Values())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(parsed).To(Equal(opts))
+	})
+})
+
+var _ = Describe("Render", func() {
+	It("scales an image into the box keeping its aspect ratio", func() {
+		out, contentType, err := imagevariant.Render(encodePNG(quadrants(400, 200)), imagevariant.Options{Width: 100, Height: 100})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(contentType).To(Equal("image/png"))
+
+		img := decode(out)
+		Expect(img.Bounds().Size()).To(Equal(image.Pt(100, 50)))
+		Expect(near(img.At(10, 40), 255, 0, 0)).To(BeTrue())
+		Expect(near(img.At(90, 25), 0, 0, 255)).To(BeTrue())
+	})
+
+	It("fills the box and crops the overflow with fit cover", func() {
+		out, _, err := imagevariant.Render(encodePNG(quadrants(400, 200)), imagevariant.Options{Width: 50, Height: 50, Fit: imagevariant.FitCover})
+		Expect(err).NotTo(HaveOccurred())
+
+		img := decode(out)
+		Expect(img.Bounds().Size()).To(Equal(image.Pt(50, 50)))
+		// the centre is kept: red on the left, blue on the right
+		Expect(near(img.At(5, 40), 255, 0, 0)).To(BeTrue())
+		Expect(near(img.At(45, 40), 0, 0, 255)).To(BeTrue())
+	})
+
+	It("never enlarges an image", func() {
+		out, _, err := imagevariant.Render(encodePNG(quadrants(40, 20)), imagevariant.Options{Width: 400})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(decode(out).Bounds().Size()).To(Equal(image.Pt(40, 20)))
+
+		out, _, err = imagevariant.Render(encodePNG(quadrants(40, 20)), imagevariant.Options{Width: 100, Height: 100, Fit: imagevariant.FitCover})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(decode(out).Bounds().Size()).To(Equal(image.Pt(20, 20)))
+	})
+
+	It("crops before resizing", func() {
+		out, _, err := imagevariant.Render(encodePNG(quadrants(400, 200)), imagevariant.Options{Crop: image.Rect(0, 0, 100, 50), Width: 20})
+		Expect(err).NotTo(HaveOccurred())
+
+		img := decode(out)
+		Expect(img.Bounds().Size()).To(Equal(image.Pt(20, 10)))
+		Expect(near(img.At(10, 5), 0, 255, 0)).To(BeTrue())
+	})
+
+	It("refuses a crop outside the image", func() {
+		_, _, err := imagevariant.Render(encodePNG(quadrants(40, 20)), imagevariant.Options{Crop: image.Rect(100, 100, 110, 110)})
+		Expect(errors.Is(err, imagevariant.ErrInvalidOptions)).To(BeTrue())
+	})
+
+	It("converts the format and keeps JPEG sources as JPEG by default", func() {
+		out, contentType, err := imagevariant.Render(encodePNG(quadrants(40, 20)), imagevariant.Options{Format: imagevariant.FormatJPEG, Quality: 50})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(contentType).To(Equal("image/jpeg"))
+		_, err = jpeg.Decode(bytes.NewReader(out))
+		Expect(err).NotTo(HaveOccurred())
+
+		_, contentType, err = imagevariant.Render(encodeJPEG(quadrants(40, 20)), imagevariant.Options{Width: 20})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(contentType).To(Equal("image/jpeg"))
+	})
+
+	It("turns JPEG photos upright following their EXIF orientation", func() {
+		// orientation 6: the camera was rotated, the image must be turned
+		// 90 degrees clockwise, which moves the green corner to the top right
+		out, _, err := imagevariant.Render(withOrientation(encodeJPEG(quadrants(80, 40)), 6), imagevariant.Options{Format: imagevariant.FormatPNG})
+		Expect(err).NotTo(HaveOccurred())
+
+		img := decode(out)
+		Expect(img.Bounds().Size()).To(Equal(image.Pt(40, 80)))
+		Expect(near(img.At(35, 5), 0, 255, 0)).To(BeTrue())
+		Expect(near(img.At(5, 5), 255, 0, 0)).To(BeTrue())
+		Expect(near(img.At(20, 70), 0, 0, 255)).To(BeTrue())
+	})
+
+	It("refuses content it cannot decode", func() {
+		_, _, err := imagevariant.Render([]byte("%PDF-1.4\n"), imagevariant.Options{Width: 10})
+		Expect(errors.Is(err, imagevariant.ErrUnsupported)).To(BeTrue())
+	})
+})
@@ -0,0 +1,139 @@
+package imagevariant
+
+import (
+	"image"
+	"math"
+)
+
+// orient turns an image upright according to its EXIF orientation
+func orient(img *image.RGBA, orientation int) *image.RGBA {
+	if orientation < 2 || orientation > 8 {
+		return img
+	}
+	width, height := img.Bounds().Dx(), img.Bounds().Dy()
+	outWidth, outHeight := width, height
+	if orientation >= 5 {
+		outWidth, outHeight = height, width
+	}
+
+	out := image.NewRGBA(image.Rect(0, 0, outWidth, outHeight))
+	for y := 0; y < outHeight; y++ {
+		for x := 0; x < outWidth; x++ {
+			// source pixel shown at (x, y) once upright
+			var sx, sy int
+			switch orientation {
+			case 2:
+				sx, sy = width-1-x, y
+			case 3:
+				sx, sy = width-1-x, height-1-y
+			case 4:
+				sx, sy = x, height-1-y
+			case 5:
+				sx, sy = y, x
+			case 6:
+				sx, sy = y, height-1-x
+			case 7:
+				sx, sy = width-1-y, height-1-x
+			case 8:
+				sx, sy = width-1-y, x
+			}
+			copy(out.Pix[out.PixOffset(x, y):out.PixOffset(x, y)+4], img.Pix[img.PixOffset(sx, sy):img.PixOffset(sx, sy)+4])
+		}
+	}
+	return out
+}
+
+// resize scales img to width x height with a triangle filter that widens
+// when shrinking, so every source pixel contributes to the result
+func resize(img *image.RGBA, width, height int) *image.RGBA {
+	if img.Bounds().Dx() == width && img.Bounds().Dy() == height {
+		return img
+	}
+	return resample(resample(img, width, true), height, false)
+}
+
+// contribution lists the source pixels a destination pixel is made of
+type contribution struct {
+	start   int
+	weights []float64
+}
+
+func contributions(srcSize, dstSize int) []contribution {
+	scale := float64(srcSize) / float64(dstSize)
+	support := max(scale, 1)
+
+	result := make([]contribution, dstSize)
+	for i := range result {
+		center := (float64(i) + 0.5) * scale
+		start := max(0, int(math.Floor(center-support)))
+		end := min(srcSize, int(math.Ceil(center+support)))
+
+		weights := make([]float64, end-start)
+		sum := 0.0
+		for j := start; j < end; j++ {
+			w := 1 - math.Abs(float64(j)+0.5-center)/support
+			if w > 0 {
+				weights[j-start] = w
+				sum += w
+			}
+		}
+		if sum == 0 {
+			nearest := min(srcSize-1, int(center))
+			result[i] = contribution{start: nearest, weights: []float64{1}}
+			continue
+		}
+		for k := range weights {
+			weights[k] /= sum
+		}
+		result[i] = contribution{start: start, weights: weights}
+	}
+	return result
+}
+
+// resample scales img along one axis. Pixels are premultiplied, so blending
+// transparent pixels does not darken the edges.
+func resample(img *image.RGBA, size int, horizontal bool) *image.RGBA {
+	width, height := img.Bounds().Dx(), img.Bounds().Dy()
+	srcSize := height
+	outWidth, outHeight := width, size
+	if horizontal {
+		srcSize = width
+		outWidth, outHeight = size, height
+	}
+	if srcSize == size {
+		return img
+	}
+
+	out := image.NewRGBA(image.Rect(0, 0, outWidth, outHeight))
+	weights := contributions(srcSize, size)
+	for y := 0; y < outHeight; y++ {
+		for x := 0; x < outWidth; x++ {
+			var c contribution
+			var fixed int
+			if horizontal {
+				c, fixed = weights[x], y
+			} else {
+				c, fixed = weights[y], x
+			}
+
+			var acc [4]float64
+			for k, w := range c.weights {
+				offset := img.PixOffset(c.start+k, fixed)
+				if !horizontal {
+					offset = img.PixOffset(fixed, c.start+k)
+				}
+				for ch := 0; ch < 4; ch++ {
+					acc[ch] += w * float64(img.Pix[offset+ch])
+				}
+			}
+
+			offset := out.PixOffset(x, y)
+			alpha := min(255, max(0, math.Round(acc[3])))
+			for ch := 0; ch < 3; ch++ {
+				out.Pix[offset+ch] = uint8(min(alpha, max(0, math.Round(acc[ch]))))
+			}
+			out.Pix[offset+3] = uint8(alpha)
+		}
+	}
+	return out
+}
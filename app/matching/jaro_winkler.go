@@ -0,0 +1,71 @@
+package matching
+
+// winklerPrefix is the longest common prefix rewarded by JaroWinkler, and
+// winklerScale the weight of each prefix character
+const (
+	winklerPrefix = 4
+	winklerScale  = 0.1
+)
+
+// Jaro returns the Jaro similarity of a and b between 0 and 1
+func Jaro(a, b string) float64 {
+	s, t := []rune(a), []rune(b)
+	if len(s) == 0 && len(t) == 0 {
+		return 1
+	}
+	if len(s) == 0 || len(t) == 0 {
+		return 0
+	}
+
+	window := max(len(s), len(t))/2 - 1
+	if window < 0 {
+		window = 0
+	}
+
+	sMatched := make([]bool, len(s))
+	tMatched := make([]bool, len(t))
+	matches := 0
+	for i := range s {
+		for j := max(0, i-window); j < min(len(t), i+window+1); j++ {
+			if !tMatched[j] && s[i] == t[j] {
+				sMatched[i], tMatched[j] = true, true
+				matches++
+				break
+			}
+		}
+	}
+	if matches == 0 {
+		return 0
+	}
+
+	transpositions := 0
+	j := 0
+	for i := range s {
+		if !sMatched[i] {
+			continue
+		}
+		for !tMatched[j] {
+			j++
+		}
+		if s[i] != t[j] {
+			transpositions++
+		}
+		j++
+	}
+
+	m := float64(matches)
+	return (m/float64(len(s)) + m/float64(len(t)) + (m-float64(transpositions)/2)/m) / 3
+}
+
+// JaroWinkler returns the Jaro similarity of a and b boosted by the length
+// of their common prefix, up to four characters
+func JaroWinkler(a, b string) float64 {
+	jaro := Jaro(a, b)
+
+	s, t := []rune(a), []rune(b)
+	prefix := 0
+	for prefix < min(len(s), len(t), winklerPrefix) && s[prefix] == t[prefix] {
+		prefix++
+	}
+	return jaro + float64(prefix)*winklerScale*(1-jaro)
+}
@@ -0,0 +1,13 @@
+package matching_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestMatchingSuite(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Matching Test Suite")
+}
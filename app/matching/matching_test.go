@@ -0,0 +1,73 @@
+package matching_test
+
+import (
+	"golang_starter_kit_2025/app/matching"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("JaroWinkler", func() {
+	It("should match the reference values", func() {
+		Expect(matching.Jaro("martha", "marhta")).To(BeNumerically("~", 0.9444, 0.0001))
+		Expect(matching.JaroWinkler("martha", "marhta")).To(BeNumerically("~", 0.9611, 0.0001))
+		Expect(matching.JaroWinkler("dixon", "dicksonx")).To(BeNumerically("~", 0.8133, 0.0001))
+	})
+
+	It("should handle empty and identical strings", func() {
+		Expect(matching.JaroWinkler("", "")).To(Equal(1.0))
+		Expect(matching.JaroWinkler("budi", "")).To(Equal(0.0))
+		Expect(matching.JaroWinkler("budi", "budi")).To(Equal(1.0))
+		Expect(matching.JaroWinkler("abc", "xyz")).To(Equal(0.0))
+	})
+})
+
+var _ = Describe("NormalizeName", func() {
+	It("should strip honorifics, degrees, accents and punctuation", func() {
+		Expect(matching.NormalizeName("H. Budi  Santoso, S.Kom.")).To(Equal("budi santoso"))
+		Expect(matching.NormalizeName("Dr. Ir. Siti Nurhaliza")).To(Equal("siti nurhaliza"))
+		Expect(matching.NormalizeName("Ma'ruf Amin")).To(Equal("maruf amin"))
+		Expect(matching.NormalizeName("José Ramírez")).To(Equal("jose ramirez"))
+	})
+
+	It("should keep a title that is the whole name", func() {
+		Expect(matching.NormalizeName("Ibu")).To(Equal("ibu"))
+	})
+})
+
+var _ = Describe("NameSimilarity", func() {
+	It("should ignore word order", func() {
+		Expect(matching.NameSimilarity("Santoso Budi", "BUDI SANTOSO")).To(Equal(1.0))
+	})
+
+	It("should score slightly altered names high", func() {
+		Expect(matching.NameSimilarity("Budi Santoso", "Budi Santosa")).To(BeNumerically(">", 0.95))
+		Expect(matching.NameSimilarity("Muhammad Rizki", "Muhamad Rizky")).To(BeNumerically(">", 0.9))
+	})
+
+	It("should score different names low", func() {
+		Expect(matching.NameSimilarity("Budi Santoso", "Siti Aminah")).To(BeNumerically("<", 0.7))
+		Expect(matching.NameSimilarity("", "Siti Aminah")).To(Equal(0.0))
+	})
+})
+
+var _ = Describe("Transliterate", func() {
+	It("should romanise Cyrillic, Greek and special Latin letters", func() {
+		Expect(matching.Transliterate("Владимир Путин")).To(Equal("vladimir putin"))
+		Expect(matching.Transliterate("Γιώργος")).To(Equal("giorgos"))
+		Expect(matching.Transliterate("Łukasz Groß")).To(Equal("lukasz gross"))
+		Expect(matching.Transliterate("José")).To(Equal("jose"))
+	})
+})
+
+var _ = Describe("spelling variants and contained names", func() {
+	It("should unify common spellings", func() {
+		Expect(matching.NormalizeName("Moh. Yousef")).To(Equal("muhammad yusuf"))
+		Expect(matching.NameSimilarity("Mohammed Yusuf", "Muhamad Jusuf")).To(Equal(1.0))
+	})
+
+	It("should match a full name inside a longer alias", func() {
+		Expect(matching.NameSimilarity("Usama bin Laden", "Osama bin Muhammad bin Awad bin Laden")).To(BeNumerically(">", 0.95))
+		Expect(matching.NameSimilarity("Budi", "Budi Santoso")).To(BeNumerically("<", 0.9))
+	})
+})
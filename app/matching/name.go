@@ -0,0 +1,120 @@
+// Package matching compares personal names the way people write them on
+// forms: ignoring case, accents, script, punctuation, honorifics, common
+// spelling variants and word order, and scoring what remains with
+// Jaro-Winkler similarity.
+package matching
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// prefixTitles are honorifics written before a name. Academic degrees are
+// written after a comma and are dropped with everything else that follows it.
+var prefixTitles = map[string]bool{
+	"bapak": true, "bpk": true, "ibu": true, "sdr": true, "sdri": true,
+	"tn": true, "ny": true, "nn": true, "mr": true, "mrs": true, "ms": true,
+	"h": true, "hj": true, "dr": true, "drs": true, "dra": true, "ir": true, "prof": true,
+}
+
+// nameVariants maps common spellings of the same name to one form
+var nameVariants = map[string]string{
+	"mohammad": "muhammad", "mohammed": "muhammad", "mohamed": "muhammad", "mohamad": "muhammad",
+	"muhamad": "muhammad", "muhammed": "muhammad", "mochammad": "muhammad", "mochamad": "muhammad",
+	"moh": "muhammad", "muh": "muhammad", "mhd": "muhammad", "md": "muhammad",
+	"abdul": "abdul", "abd": "abdul", "abdel": "abdul", "abdoul": "abdul",
+	"usama": "osama", "usamah": "osama", "osamah": "osama",
+	"achmad": "ahmad", "ahmed": "ahmad", "akhmad": "ahmad",
+	"yusuf": "yusuf", "yousef": "yusuf", "yousuf": "yusuf", "youssef": "yusuf", "jusuf": "yusuf",
+}
+
+// NormalizeName transliterates and lowercases name, strips accents,
+// punctuation, leading honorifics and trailing degrees, unifies spelling
+// variants and collapses whitespace, so that "H. Moh. Budi  Santoso, S.Kom."
+// and "muhammad budi santoso" normalise the same
+func NormalizeName(name string) string {
+	if i := strings.IndexByte(name, ','); i >= 0 {
+		name = name[:i]
+	}
+
+	var b strings.Builder
+	for _, r := range Transliterate(name) {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			b.WriteRune(r)
+		case r == '\'' || r == '`' || r == '’':
+			// apostrophes join the parts of a name: Ma'ruf, O'Neil
+		default:
+			b.WriteRune(' ')
+		}
+	}
+
+	words := strings.Fields(b.String())
+	for len(words) > 1 && prefixTitles[words[0]] {
+		words = words[1:]
+	}
+	for i, word := range words {
+		if variant, ok := nameVariants[word]; ok {
+			words[i] = variant
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+// NameSimilarity scores two names between 0 and 1 after normalisation. Word
+// order is ignored, so "Santoso Budi" matches "Budi Santoso".
+func NameSimilarity(a, b string) float64 {
+	return NormalizedSimilarity(NormalizeName(a), NormalizeName(b))
+}
+
+// NormalizedSimilarity is NameSimilarity for names already passed through
+// NormalizeName, for callers comparing one name against many. A name of at
+// least two words written in full inside a longer one, such as "Osama bin
+// Laden" in "Osama bin Muhammad bin Awad bin Laden", scores by its own words.
+func NormalizedSimilarity(a, b string) float64 {
+	if a == "" || b == "" {
+		return 0
+	}
+	if a == b {
+		return 1
+	}
+
+	score := JaroWinkler(a, b)
+	if sorted := JaroWinkler(sortWords(a), sortWords(b)); sorted > score {
+		score = sorted
+	}
+	if contained := tokenSimilarity(a, b); contained > score {
+		score = contained
+	}
+	return score
+}
+
+// tokenSimilarity averages, over the words of the shorter name, the best
+// score against any word of the longer one. Single-word names are left out
+// because a lone given name is contained in too many others.
+func tokenSimilarity(a, b string) float64 {
+	short, long := strings.Fields(a), strings.Fields(b)
+	if len(short) > len(long) {
+		short, long = long, short
+	}
+	if len(short) < 2 {
+		return 0
+	}
+
+	var total float64
+	for _, word := range short {
+		var best float64
+		for _, other := range long {
+			best = max(best, JaroWinkler(word, other))
+		}
+		total += best
+	}
+	return total / float64(len(short))
+}
+
+func sortWords(s string) string {
+	words := strings.Fields(s)
+	sort.Strings(words)
+	return strings.Join(words, " ")
+}
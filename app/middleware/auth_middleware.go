@@ -1,6 +1,7 @@
 package middleware
 
 import (
+	"errors"
 	"net/http"
 	"strings"
 	"time"
@@ -17,6 +18,8 @@ var JwtKey = []byte("your_secret_key")
 
 var jwtService services.JwtService
 
+var authService services.AuthService
+
 func AuthMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		tokenString, shouldReturn := CheckTokenExist(c)
@@ -50,6 +53,24 @@ func AuthMiddleware() gin.HandlerFunc {
 			return
 		}
 
+		// Reject tokens of accounts that were suspended, locked or closed after the token was issued
+		if err := authService.EnsureActiveById(claims.UserID); err != nil {
+			if !errors.Is(err, services.ErrAccountInactive) && !errors.Is(err, services.ErrUserNotFound) {
+				helpers.ResponseError(c, &helpers.ResponseParams[any]{
+					Reference: "ERROR-3",
+					Message:   "Gagal memeriksa status akun",
+				}, http.StatusInternalServerError)
+				c.Abort()
+				return
+			}
+			helpers.ResponseError(c, &helpers.ResponseParams[any]{
+				Reference: "ERROR-7",
+				Message:   err.Error(),
+			}, http.StatusForbidden)
+			c.Abort()
+			return
+		}
+
 		// set token and user id to context
 		c.Set("token", tokenString)
 		c.Set("user_id", claims.UserID)
@@ -66,6 +87,20 @@ func AuthMiddleware() gin.HandlerFunc {
 	}
 }
 
+// OptionalAuthMiddleware authenticates requests that send a token, like
+// AuthMiddleware, and lets anonymous requests through without "user_id".
+// A token that is sent must be valid.
+func OptionalAuthMiddleware() gin.HandlerFunc {
+	auth := AuthMiddleware()
+	return func(c *gin.Context) {
+		if c.GetHeader("Authorization") == "" {
+			c.Next()
+			return
+		}
+		auth(c)
+	}
+}
+
 func CheckTokenValidity(tokenString string, c *gin.Context) (*jwt.Token, bool) {
 	token, err := jwtService.ValidateToken(tokenString)
 	if err != nil || !token.Valid {
@@ -0,0 +1,23 @@
+package middleware_test
+
+import (
+	"testing"
+
+	"golang_starter_kit_2025/app/signedurl"
+
+	"github.com/gin-gonic/gin"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestMiddlewareSuite(t *testing.T) {
+	RegisterFailHandler(Fail)
+	gin.SetMode(gin.TestMode)
+	RunSpecs(t, "Middleware Test Suite")
+}
+
+var _ = BeforeSuite(func() {
+	signer, err := signedurl.New([]byte("middleware test key"), "http://localhost:8080")
+	Expect(err).NotTo(HaveOccurred())
+	signedurl.SetDefault(signer)
+})
@@ -0,0 +1,40 @@
+package middleware
+
+import (
+	"net/http"
+
+	"golang_starter_kit_2025/app/helpers"
+	"golang_starter_kit_2025/app/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+var permissionService services.PermissionService
+
+// PermissionMiddleware only lets the request through when the authenticated
+// user holds at least one of the given permissions. It must run after
+// AuthMiddleware so that "user_id" is present in the context.
+func PermissionMiddleware(permissions ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		allowed, err := permissionService.HasAnyPermission(c.GetUint("user_id"), permissions...)
+		if err != nil {
+			helpers.ResponseError(c, &helpers.ResponseParams[any]{
+				Reference: "ERROR-6",
+				Message:   "Gagal memeriksa hak akses",
+			}, http.StatusInternalServerError)
+			c.Abort()
+			return
+		}
+
+		if !allowed {
+			helpers.ResponseError(c, &helpers.ResponseParams[any]{
+				Reference: "ERROR-5",
+				Message:   "Tidak memiliki hak akses",
+			}, http.StatusForbidden)
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
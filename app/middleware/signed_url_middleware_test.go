@@ -0,0 +1,76 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"time"
+
+	"golang_starter_kit_2025/app/middleware"
+	"golang_starter_kit_2025/app/signedurl"
+
+	"github.com/gin-gonic/gin"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// serve requests target through a route guarded by guard, answering with
+// whether the request was marked as signed
+func serve(guard gin.HandlerFunc, target string) *httptest.ResponseRecorder {
+	router := gin.New()
+	router.GET("/report/:id", guard, func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"signed_url": c.GetBool("signed_url")})
+	})
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, target, nil))
+	return recorder
+}
+
+func sign(path string, opts signedurl.Options) string {
+	signer, err := signedurl.Default()
+	Expect(err).NotTo(HaveOccurred())
+	signed, err := signer.Sign(path, opts)
+	Expect(err).NotTo(HaveOccurred())
+	parsed, err := url.Parse(signed)
+	Expect(err).NotTo(HaveOccurred())
+	return parsed.RequestURI()
+}
+
+var _ = Describe("SignedURLMiddleware", func() {
+	It("should let a signed URL through", func() {
+		response := serve(middleware.SignedURLMiddleware(), sign("/report/1", signedurl.Options{Expires: time.Minute}))
+		Expect(response.Code).To(Equal(http.StatusOK))
+		Expect(response.Body.String()).To(MatchJSON(`{"signed_url": true}`))
+	})
+
+	DescribeTable("should refuse",
+		func(target func() string) {
+			Expect(serve(middleware.SignedURLMiddleware(), target()).Code).To(Equal(http.StatusForbidden))
+		},
+		Entry("a URL without a signature", func() string { return "/report/1" }),
+		Entry("a URL signed for another path", func() string {
+			parsed, _ := url.Parse(sign("/report/1", signedurl.Options{Expires: time.Minute}))
+			return "/report/2?" + parsed.RawQuery
+		}),
+		Entry("a URL signed for another method", func() string {
+			return sign("/report/1", signedurl.Options{Method: http.MethodPost, Expires: time.Minute})
+		}),
+	)
+})
+
+var _ = Describe("OptionalSignedURLMiddleware", func() {
+	It("should let an unsigned request through without marking it signed", func() {
+		response := serve(middleware.OptionalSignedURLMiddleware(), "/report/1")
+		Expect(response.Code).To(Equal(http.StatusOK))
+		Expect(response.Body.String()).To(MatchJSON(`{"signed_url": false}`))
+	})
+
+	It("should mark a signed URL and refuse a forged one", func() {
+		response := serve(middleware.OptionalSignedURLMiddleware(), sign("/report/1", signedurl.Options{Expires: time.Minute}))
+		Expect(response.Body.String()).To(MatchJSON(`{"signed_url": true}`))
+
+		parsed, _ := url.Parse(sign("/report/1", signedurl.Options{Expires: time.Minute}))
+		response = serve(middleware.OptionalSignedURLMiddleware(), "/report/2?"+parsed.RawQuery)
+		Expect(response.Code).To(Equal(http.StatusForbidden))
+	})
+})
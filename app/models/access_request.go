@@ -0,0 +1,31 @@
+package models
+
+import "time"
+
+type AccessRequestStatus string
+
+const (
+	AccessRequestPending  AccessRequestStatus = "pending"
+	AccessRequestApproved AccessRequestStatus = "approved"
+	AccessRequestDenied   AccessRequestStatus = "denied"
+)
+
+// AccessRequest is a user's request for temporary access to a role
+type AccessRequest struct {
+	ID              uint                `gorm:"primaryKey" json:"id"`
+	UserID          uint                `json:"user_id"`
+	RoleID          uint                `json:"role_id"`
+	Justification   string              `gorm:"type:text" json:"justification"`
+	DurationMinutes int                 `json:"duration_minutes"`
+	Status          AccessRequestStatus `gorm:"type:varchar(20);index" json:"status"`
+	ReviewerID      *uint               `json:"reviewer_id"`
+	ReviewNote      string              `gorm:"type:text" json:"review_note"`
+	ReviewedAt      *time.Time          `json:"reviewed_at"`
+	GrantStartsAt   *time.Time          `json:"grant_starts_at"`
+	GrantExpiresAt  *time.Time          `json:"grant_expires_at"`
+	CreatedAt       time.Time           `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt       time.Time           `gorm:"autoUpdateTime" json:"updated_at"`
+
+	User *User `json:"user,omitempty" swaggerignore:"true"`
+	Role *Role `json:"role,omitempty" swaggerignore:"true"`
+}
@@ -0,0 +1,79 @@
+package models
+
+import (
+	"strings"
+	"time"
+	"unicode"
+
+	"golang_starter_kit_2025/app/encryption"
+)
+
+// Blind index purposes. Each encrypted column that needs exact lookups has
+// a sibling <column>_bidx holding the keyed hash of its normalised value.
+const (
+	BlindIndexEmail          = "email"
+	BlindIndexEmailCanonical = "email_canonical"
+	BlindIndexNIK            = "nik"
+	BlindIndexPhone          = "phone"
+	BlindIndexBirthDate      = "birth_date"
+)
+
+// BlindIndexSetter is implemented by models with blind index columns. It is
+// called from BeforeSave and by crypto:rotate, which writes without hooks.
+type BlindIndexSetter interface {
+	SetBlindIndexes() error
+}
+
+// EmailIndex returns the blind index of an email address
+func EmailIndex(email string) (string, error) {
+	return encryption.BlindIndex(BlindIndexEmail, strings.ToLower(strings.TrimSpace(email)))
+}
+
+// CanonicalEmailIndex returns the blind index of the mailbox an address is
+// delivered to, so that aliases of one mailbox share an index: the +tag is
+// dropped, and for Gmail the dots in the local part too.
+func CanonicalEmailIndex(email string) (string, error) {
+	email = strings.ToLower(strings.TrimSpace(email))
+	local, domain, found := strings.Cut(email, "@")
+	if !found {
+		return encryption.BlindIndex(BlindIndexEmailCanonical, email)
+	}
+
+	local, _, _ = strings.Cut(local, "+")
+	if domain == "googlemail.com" {
+		domain = "gmail.com"
+	}
+	if domain == "gmail.com" {
+		local = strings.ReplaceAll(local, ".", "")
+	}
+	return encryption.BlindIndex(BlindIndexEmailCanonical, local+"@"+domain)
+}
+
+// NIKIndex returns the blind index of a NIK
+func NIKIndex(nik string) (string, error) {
+	return encryption.BlindIndex(BlindIndexNIK, strings.TrimSpace(nik))
+}
+
+// PhoneIndex returns the blind index of an Indonesian phone number, so that
+// 0812..., +62 812-... and 62812... share an index
+func PhoneIndex(phone string) (string, error) {
+	digits := strings.Map(func(r rune) rune {
+		if unicode.IsDigit(r) {
+			return r
+		}
+		return -1
+	}, phone)
+
+	switch {
+	case strings.HasPrefix(digits, "0"):
+		digits = "62" + digits[1:]
+	case strings.HasPrefix(digits, "8"):
+		digits = "62" + digits
+	}
+	return encryption.BlindIndex(BlindIndexPhone, digits)
+}
+
+// BirthDateIndex returns the blind index of a date of birth
+func BirthDateIndex(date time.Time) (string, error) {
+	return encryption.BlindIndex(BlindIndexBirthDate, date.Format("2006-01-02"))
+}
@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// ErasureRecord is the tombstone left when a user's personal data is erased.
+// It keeps only what must be retained to prove that identity verification
+// took place: references, outcomes and dates, never the personal data.
+type ErasureRecord struct {
+	ID            uint               `gorm:"primaryKey" json:"id"`
+	UserID        uint               `gorm:"uniqueIndex" json:"user_id"`
+	UserReference string             `gorm:"type:varchar(100)" json:"user_reference"`
+	Reason        string             `gorm:"type:varchar(255)" json:"reason"`
+	RequestedBy   *uint              `json:"requested_by"`
+	Submissions   []ErasedSubmission `gorm:"type:json;serializer:json" json:"submissions"`
+	FilesDeleted  int                `json:"files_deleted"`
+	ErasedAt      time.Time          `json:"erased_at"`
+}
+
+// ErasedSubmission is what an erasure record keeps of a KYC submission
+type ErasedSubmission struct {
+	Reference   string     `json:"reference"`
+	Status      KycStatus  `json:"status"`
+	SubmittedAt *time.Time `json:"submitted_at"`
+	DecidedAt   *time.Time `json:"decided_at"`
+}
@@ -0,0 +1,63 @@
+package models
+
+import "time"
+
+// FileAccess is who may read a file
+type FileAccess string
+
+const (
+	// FileAccessPublic files can be read by anyone, also without a token
+	FileAccessPublic FileAccess = "public"
+	// FileAccessOwner files can be read by their owner and by users holding
+	// the permission of the file, if it has one
+	FileAccessOwner FileAccess = "owner"
+	// FileAccessPermission files can be read by users holding the
+	// permission of the file
+	FileAccessPermission FileAccess = "permission"
+	// FileAccessSigned files can only be read through a signed URL
+	FileAccessSigned FileAccess = "signed"
+)
+
+// File is a file kept in storage. Models point to it by ID instead of
+// storing paths themselves. Files with the same content share one stored
+// object, so Path may appear on several rows.
+type File struct {
+	ID           uint          `gorm:"primaryKey" json:"id"`
+	Reference    string        `gorm:"type:varchar(100);uniqueIndex" json:"reference"`
+	OwnerID      *uint         `gorm:"index" json:"owner_id"`
+	Purpose      UploadPurpose `gorm:"type:varchar(30)" json:"purpose"`
+	OriginalName string        `gorm:"type:varchar(255)" json:"original_name"`
+	MimeType     string        `gorm:"type:varchar(100)" json:"mime_type"`
+	Size         int64         `json:"size"`
+	SHA256       string        `gorm:"column:sha256;type:char(64);index" json:"sha256"`
+	Disk         string        `gorm:"type:varchar(50)" json:"-"`
+	Path         string        `gorm:"type:varchar(255);index" json:"-"`
+	// Access, Permission and Sensitive decide who may read the file without
+	// a signed URL and whether reads are logged. A valid signed URL is
+	// always enough.
+	Access     FileAccess `gorm:"type:varchar(20)" json:"access"`
+	Permission string     `gorm:"type:varchar(100)" json:"-"`
+	Sensitive  bool       `json:"-"`
+	// UnreferencedAt is when the garbage collector first found no model
+	// pointing to the file
+	UnreferencedAt *time.Time `gorm:"index" json:"-"`
+	// MissingAt is when the garbage collector found the stored object gone
+	MissingAt *time.Time `json:"missing_at,omitempty"`
+	CreatedAt time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// FileAccessLog records a read of a sensitive file, allowed or not
+type FileAccessLog struct {
+	ID     uint  `gorm:"primaryKey" json:"id"`
+	FileID uint  `gorm:"index" json:"file_id"`
+	UserID *uint `gorm:"index" json:"user_id"`
+	// Via is how the read was allowed: signed, public, owner or permission.
+	// Empty when it was refused.
+	Via       string    `gorm:"type:varchar(20)" json:"via"`
+	Granted   bool      `json:"granted"`
+	IP        string    `gorm:"type:varchar(45)" json:"ip"`
+	UserAgent string    `gorm:"type:varchar(255)" json:"user_agent"`
+	Variant   string    `gorm:"type:varchar(255)" json:"variant"`
+	CreatedAt time.Time `gorm:"autoCreateTime;index" json:"created_at"`
+}
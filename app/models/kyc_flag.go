@@ -0,0 +1,42 @@
+package models
+
+import "time"
+
+// KycFlagSignal names what two applicants were found to share
+type KycFlagSignal string
+
+const (
+	KycFlagNIK      KycFlagSignal = "nik"
+	KycFlagPhone    KycFlagSignal = "phone"
+	KycFlagEmail    KycFlagSignal = "email"
+	KycFlagNameDOB  KycFlagSignal = "name_dob"
+	KycFlagDocument KycFlagSignal = "document"
+)
+
+type KycFlagStatus string
+
+const (
+	KycFlagOpen      KycFlagStatus = "open"
+	KycFlagConfirmed KycFlagStatus = "confirmed"
+	KycFlagDismissed KycFlagStatus = "dismissed"
+)
+
+// KycFlag links a submission to another user who looks like the same person.
+// Flags are raised when the submission is submitted and stay open until a
+// reviewer confirms or dismisses them; dismissed flags are not raised again.
+type KycFlag struct {
+	ID                  uint          `gorm:"primaryKey" json:"id"`
+	SubmissionID        uint          `gorm:"index" json:"submission_id"`
+	UserID              uint          `gorm:"index" json:"user_id"`
+	MatchedUserID       uint          `gorm:"index" json:"matched_user_id"`
+	MatchedSubmissionID *uint         `json:"matched_submission_id"`
+	Signal              KycFlagSignal `gorm:"type:varchar(20)" json:"signal"`
+	Score               float64       `json:"score"`
+	Detail              string        `gorm:"type:varchar(255)" json:"detail"`
+	Status              KycFlagStatus `gorm:"type:varchar(20);default:open;index" json:"status"`
+	ResolvedBy          *uint         `json:"resolved_by"`
+	ResolvedAt          *time.Time    `json:"resolved_at"`
+	Note                string        `gorm:"type:text" json:"note"`
+	CreatedAt           time.Time     `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt           time.Time     `gorm:"autoUpdateTime" json:"updated_at"`
+}
@@ -0,0 +1,24 @@
+package models
+
+import (
+	"time"
+
+	"golang_starter_kit_2025/app/risk"
+)
+
+// KycRiskAssessment is one scoring of a submission. Contributions holds each
+// rule that added to the score with the fact value it saw, and Facts every
+// fact known at the time, so the score can be explained later even after the
+// rules have changed.
+type KycRiskAssessment struct {
+	ID            uint                `gorm:"primaryKey" json:"id"`
+	SubmissionID  uint                `gorm:"index" json:"submission_id"`
+	UserID        uint                `gorm:"index" json:"user_id"`
+	Score         float64             `json:"score"`
+	Tier          risk.Tier           `gorm:"type:varchar(10);index" json:"tier"`
+	RulesVersion  string              `gorm:"type:varchar(50)" json:"rules_version"`
+	RulesChecksum string              `gorm:"type:varchar(64)" json:"rules_checksum"`
+	Contributions []risk.Contribution `gorm:"type:json;serializer:json" json:"contributions"`
+	Facts         risk.Facts          `gorm:"type:json;serializer:json" json:"facts"`
+	CreatedAt     time.Time           `gorm:"autoCreateTime" json:"created_at"`
+}
@@ -0,0 +1,204 @@
+package models
+
+import (
+	"time"
+
+	"golang_starter_kit_2025/app/imagequality"
+
+	"gorm.io/gorm"
+)
+
+type KycStatus string
+
+const (
+	KycStatusDraft                KycStatus = "draft"
+	KycStatusSubmitted            KycStatus = "submitted"
+	KycStatusInReview             KycStatus = "in_review"
+	KycStatusApproved             KycStatus = "approved"
+	KycStatusRejected             KycStatus = "rejected"
+	KycStatusResubmissionRequired KycStatus = "resubmission_required"
+)
+
+var kycStatusTransitions = map[KycStatus][]KycStatus{
+	KycStatusDraft:                {KycStatusSubmitted},
+	KycStatusSubmitted:            {KycStatusInReview},
+	KycStatusInReview:             {KycStatusApproved, KycStatusRejected, KycStatusResubmissionRequired},
+	KycStatusResubmissionRequired: {KycStatusSubmitted},
+	KycStatusApproved:             {},
+	KycStatusRejected:             {},
+}
+
+// CanTransitionTo reports whether a submission may move from s to next.
+// Approved and rejected are terminal.
+func (s KycStatus) CanTransitionTo(next KycStatus) bool {
+	for _, allowed := range kycStatusTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+// Editable reports whether the applicant may still change the submission
+func (s KycStatus) Editable() bool {
+	return s == KycStatusDraft || s == KycStatusResubmissionRequired
+}
+
+// KycReasonCode explains a rejection or a request for resubmission
+type KycReasonCode string
+
+const (
+	KycReasonDocumentUnreadable KycReasonCode = "document_unreadable"
+	KycReasonDocumentExpired    KycReasonCode = "document_expired"
+	KycReasonDataMismatch       KycReasonCode = "data_mismatch"
+	KycReasonSelfieMismatch     KycReasonCode = "selfie_mismatch"
+	KycReasonIncomplete         KycReasonCode = "incomplete"
+	KycReasonSuspectedFraud     KycReasonCode = "suspected_fraud"
+	KycReasonOther              KycReasonCode = "other"
+)
+
+var kycReasonCodes = []KycReasonCode{
+	KycReasonDocumentUnreadable,
+	KycReasonDocumentExpired,
+	KycReasonDataMismatch,
+	KycReasonSelfieMismatch,
+	KycReasonIncomplete,
+	KycReasonSuspectedFraud,
+	KycReasonOther,
+}
+
+// Valid reports whether c is one of the known reason codes
+func (c KycReasonCode) Valid() bool {
+	for _, code := range kycReasonCodes {
+		if code == c {
+			return true
+		}
+	}
+	return false
+}
+
+type KycDocumentType string
+
+const (
+	KycDocumentIdentityCard KycDocumentType = "identity_card"
+	KycDocumentSelfie       KycDocumentType = "selfie"
+)
+
+// KycRequiredDocuments lists the documents a submission needs before it can
+// be submitted
+var KycRequiredDocuments = []KycDocumentType{KycDocumentIdentityCard, KycDocumentSelfie}
+
+// KycSubmission is an applicant's identity verification request
+type KycSubmission struct {
+	ID              uint          `gorm:"primaryKey" json:"id"`
+	Reference       string        `gorm:"type:varchar(100);uniqueIndex" json:"reference"`
+	UserID          uint          `gorm:"index" json:"user_id"`
+	Status          KycStatus     `gorm:"type:varchar(30);index" json:"status"`
+	NIK             string        `gorm:"column:nik;type:text;serializer:encrypted" json:"nik"`
+	NIKBidx         string        `gorm:"column:nik_bidx;type:varchar(64);index" json:"-"`
+	FullName        string        `gorm:"type:text;serializer:encrypted" json:"full_name"`
+	BirthPlace      string        `gorm:"type:text;serializer:encrypted" json:"birth_place"`
+	BirthDate       *time.Time    `gorm:"type:text;serializer:encrypted" json:"birth_date"`
+	BirthDateBidx   string        `gorm:"type:varchar(64);index" json:"-"`
+	Gender          string        `gorm:"type:varchar(10)" json:"gender"`
+	Address         string        `gorm:"type:text;serializer:encrypted" json:"address"`
+	Phone           string        `gorm:"type:text;serializer:encrypted" json:"phone"`
+	PhoneBidx       string        `gorm:"type:varchar(64);index" json:"-"`
+	ReviewerID      *uint         `json:"reviewer_id"`
+	ReasonCode      KycReasonCode `gorm:"type:varchar(50)" json:"reason_code"`
+	ReviewerComment string        `gorm:"type:text" json:"reviewer_comment"`
+	SubmittedAt     *time.Time    `json:"submitted_at"`
+	ReviewStartedAt *time.Time    `json:"review_started_at"`
+	DecidedAt       *time.Time    `json:"decided_at"`
+	AnonymizedAt    *time.Time    `json:"anonymized_at"`
+	CreatedAt       time.Time     `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt       time.Time     `gorm:"autoUpdateTime" json:"updated_at"`
+
+	Documents       []KycDocument       `gorm:"foreignKey:SubmissionID" json:"documents,omitempty" swaggerignore:"true"`
+	Transitions     []KycTransition     `gorm:"foreignKey:SubmissionID" json:"transitions,omitempty" swaggerignore:"true"`
+	Flags           []KycFlag           `gorm:"foreignKey:SubmissionID" json:"flags,omitempty" swaggerignore:"true"`
+	RiskAssessments []KycRiskAssessment `gorm:"foreignKey:SubmissionID" json:"risk_assessments,omitempty" swaggerignore:"true"`
+}
+
+func (s *KycSubmission) BeforeSave(tx *gorm.DB) (err error) {
+	return s.SetBlindIndexes()
+}
+
+// SetBlindIndexes refreshes the lookup hashes of the encrypted NIK, phone
+// and birth date
+func (s *KycSubmission) SetBlindIndexes() (err error) {
+	s.NIKBidx, s.PhoneBidx, s.BirthDateBidx = "", "", ""
+	if s.NIK != "" {
+		if s.NIKBidx, err = NIKIndex(s.NIK); err != nil {
+			return err
+		}
+	}
+	if s.Phone != "" {
+		if s.PhoneBidx, err = PhoneIndex(s.Phone); err != nil {
+			return err
+		}
+	}
+	if s.BirthDate != nil {
+		if s.BirthDateBidx, err = BirthDateIndex(*s.BirthDate); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// KycDocument is an image attached to a submission. There is at most one
+// document of each type per submission; uploading again replaces it.
+type KycDocument struct {
+	ID           uint            `gorm:"primaryKey" json:"id"`
+	SubmissionID uint            `gorm:"index" json:"submission_id"`
+	Type         KycDocumentType `gorm:"type:varchar(30)" json:"type"`
+	FileID       *uint           `gorm:"index" json:"file_id"`
+	File         *File           `json:"file,omitempty"`
+	URL          string          `gorm:"-" json:"url"`
+	ThumbnailURL string          `gorm:"-" json:"thumbnail_url,omitempty"`
+	CreatedAt    time.Time       `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt    time.Time       `gorm:"autoUpdateTime" json:"updated_at"`
+
+	// Image quality measured on upload, see package imagequality
+	MimeType   string  `gorm:"type:varchar(50)" json:"mime_type"`
+	Width      int     `json:"width"`
+	Height     int     `json:"height"`
+	Sharpness  float64 `json:"sharpness"`
+	Brightness float64 `json:"brightness"`
+	PHash      string  `gorm:"column:phash;type:varchar(16);index" json:"phash"`
+	// PHashBands are the 16-bit bands of PHash, indexed to look up near
+	// hashes, see imagequality.Hash.BandProbes
+	PHashBand0    *uint16  `gorm:"column:phash_band0;index" json:"-"`
+	PHashBand1    *uint16  `gorm:"column:phash_band1;index" json:"-"`
+	PHashBand2    *uint16  `gorm:"column:phash_band2;index" json:"-"`
+	PHashBand3    *uint16  `gorm:"column:phash_band3;index" json:"-"`
+	QualityIssues []string `gorm:"type:json;serializer:json" json:"quality_issues"`
+	QualityPassed bool     `json:"quality_passed"`
+	DuplicateOf   []uint   `gorm:"type:json;serializer:json" json:"duplicate_of"`
+}
+
+func (d *KycDocument) BeforeSave(tx *gorm.DB) (err error) {
+	d.PHashBand0, d.PHashBand1, d.PHashBand2, d.PHashBand3 = nil, nil, nil, nil
+	if d.PHash == "" {
+		return
+	}
+	hash, err := imagequality.ParseHash(d.PHash)
+	if err != nil {
+		return err
+	}
+	bands := hash.Bands()
+	d.PHashBand0, d.PHashBand1, d.PHashBand2, d.PHashBand3 = &bands[0], &bands[1], &bands[2], &bands[3]
+	return
+}
+
+// KycTransition records every status change of a submission
+type KycTransition struct {
+	ID           uint          `gorm:"primaryKey" json:"id"`
+	SubmissionID uint          `gorm:"index" json:"submission_id"`
+	FromStatus   KycStatus     `gorm:"type:varchar(30)" json:"from_status"`
+	ToStatus     KycStatus     `gorm:"type:varchar(30)" json:"to_status"`
+	ActorID      uint          `json:"actor_id"`
+	ReasonCode   KycReasonCode `gorm:"type:varchar(50)" json:"reason_code"`
+	Comment      string        `gorm:"type:text" json:"comment"`
+	CreatedAt    time.Time     `gorm:"autoCreateTime" json:"created_at"`
+}
@@ -5,9 +5,10 @@ import (
 )
 
 type Permission struct {
-	ID    uint   `gorm:"primaryKey" json:"id"`
-	Name  string `json:"name"`
-	Group string `json:"group"`
+	ID          uint   `gorm:"primaryKey" json:"id"`
+	Name        string `gorm:"type:varchar(255);uniqueIndex" json:"name"`
+	Group       string `json:"group"`
+	Description string `json:"description"`
 }
 
 type UserHasPermissions struct {
@@ -17,3 +18,7 @@ type UserHasPermissions struct {
 	CreatedAt    time.Time `json:"created_at"`
 	UpdatedAt    time.Time `json:"updated_at"`
 }
+
+func (UserHasPermissions) TableName() string {
+	return "users_has_permissions"
+}
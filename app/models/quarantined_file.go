@@ -0,0 +1,34 @@
+package models
+
+import "time"
+
+// QuarantineReason is why an uploaded file was refused
+type QuarantineReason string
+
+const (
+	QuarantineTooLarge       QuarantineReason = "too_large"
+	QuarantineTypeNotAllowed QuarantineReason = "type_not_allowed"
+	QuarantineTypeMismatch   QuarantineReason = "type_mismatch"
+	QuarantineMalformed      QuarantineReason = "malformed"
+	QuarantineInfected       QuarantineReason = "infected"
+)
+
+// QuarantinedFile records an upload refused by the upload pipeline. The
+// content is kept encrypted at Key/FileName for investigation, except for
+// files refused for their size.
+type QuarantinedFile struct {
+	ID           uint             `gorm:"primaryKey" json:"id"`
+	Reference    string           `gorm:"type:varchar(100);uniqueIndex" json:"reference"`
+	UserID       *uint            `gorm:"index" json:"user_id"`
+	Purpose      UploadPurpose    `gorm:"type:varchar(30)" json:"purpose"`
+	OriginalName string           `gorm:"type:varchar(255)" json:"original_name"`
+	DeclaredType string           `gorm:"type:varchar(100)" json:"declared_type"`
+	DetectedType string           `gorm:"type:varchar(100)" json:"detected_type"`
+	Size         int64            `json:"size"`
+	SHA256       string           `gorm:"column:sha256;type:char(64);index" json:"sha256"`
+	Reason       QuarantineReason `gorm:"type:varchar(30);index" json:"reason"`
+	Detail       string           `gorm:"type:varchar(255)" json:"detail"`
+	Key          string           `gorm:"type:varchar(100)" json:"-"`
+	FileName     string           `gorm:"type:varchar(255)" json:"-"`
+	CreatedAt    time.Time        `gorm:"autoCreateTime" json:"created_at"`
+}
@@ -2,8 +2,8 @@ package models
 
 type Role struct {
 	ID    uint   `gorm:"primaryKey" json:"id"`
-	Name  string `json:"name"`
+	Name  string `gorm:"type:varchar(255);uniqueIndex" json:"name"`
 	Group string `json:"group"`
 
-	Users []User `gorm:"many2many:user_has_roles;" json:"users"`
+	Users []User `gorm:"many2many:users_has_roles;" json:"users"`
 }
@@ -0,0 +1,22 @@
+package scopes
+
+import (
+	"fmt"
+
+	"golang_starter_kit_2025/app/imagequality"
+
+	"gorm.io/gorm"
+)
+
+// PHashNear limits kyc_documents rows to those sharing a band probe with hash,
+// a superset of the documents within maxDistance bits. table qualifies the
+// band columns in joined queries.
+func PHashNear(table string, hash imagequality.Hash, maxDistance int) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		condition := db.Session(&gorm.Session{NewDB: true})
+		for i, probes := range hash.BandProbes(maxDistance) {
+			condition = condition.Or(fmt.Sprintf("%s.phash_band%d IN ?", table, i), probes)
+		}
+		return db.Where(condition)
+	}
+}
@@ -0,0 +1,16 @@
+package scopes
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ActiveRoleGrant limits users_has_roles rows to grants in effect at the given time
+func ActiveRoleGrant(at time.Time) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.
+			Where("starts_at IS NULL OR starts_at <= ?", at).
+			Where("expires_at IS NULL OR expires_at > ?", at)
+	}
+}
@@ -0,0 +1,98 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type StoreBusinessType string
+
+const (
+	StoreBusinessIndividual  StoreBusinessType = "individual"
+	StoreBusinessCV          StoreBusinessType = "cv"
+	StoreBusinessPT          StoreBusinessType = "pt"
+	StoreBusinessCooperative StoreBusinessType = "cooperative"
+)
+
+// NeedsDeed reports whether the business is a legal entity that must provide
+// its deed of establishment
+func (t StoreBusinessType) NeedsDeed() bool {
+	return t == StoreBusinessCV || t == StoreBusinessPT || t == StoreBusinessCooperative
+}
+
+type StoreDocumentType string
+
+const (
+	StoreDocumentBusinessLicense StoreDocumentType = "business_license"
+	StoreDocumentTaxID           StoreDocumentType = "tax_id"
+	StoreDocumentDeed            StoreDocumentType = "deed"
+	StoreDocumentStorefront      StoreDocumentType = "storefront"
+)
+
+// StoreRequiredDocuments lists the documents every store needs before its
+// verification can be submitted. Legal entities also need a deed, see
+// StoreBusinessType.NeedsDeed.
+var StoreRequiredDocuments = []StoreDocumentType{StoreDocumentBusinessLicense, StoreDocumentTaxID, StoreDocumentStorefront}
+
+// Store is a merchant owned by a user. Its business verification (KYB) goes
+// through the same states as a KYC submission, and can only be submitted
+// once the owner has passed KYC.
+type Store struct {
+	ID                uint              `gorm:"primaryKey" json:"id"`
+	Reference         string            `gorm:"type:varchar(100);uniqueIndex" json:"reference"`
+	OwnerID           uint              `gorm:"index" json:"owner_id"`
+	Name              string            `gorm:"type:varchar(255)" json:"name"`
+	OwnerNIKBidx      *string           `gorm:"column:owner_nik_bidx;type:varchar(64);index" json:"-"`
+	LegalName         string            `gorm:"type:varchar(255)" json:"legal_name"`
+	BusinessType      StoreBusinessType `gorm:"type:varchar(20)" json:"business_type"`
+	NIB               string            `gorm:"column:nib;type:varchar(13)" json:"nib"`
+	NPWP              string            `gorm:"column:npwp;type:varchar(16)" json:"npwp"`
+	Phone             string            `gorm:"type:varchar(255)" json:"phone"`
+	Address           string            `gorm:"type:varchar(255)" json:"address"`
+	City              string            `gorm:"type:varchar(255)" json:"city"`
+	State             string            `gorm:"type:varchar(255)" json:"state"`
+	Country           string            `gorm:"type:varchar(255)" json:"country"`
+	Zip               string            `gorm:"type:varchar(255)" json:"zip"`
+	OwnerSubmissionID *uint             `json:"owner_submission_id"`
+	Status            KycStatus         `gorm:"type:varchar(30);index" json:"status"`
+	ReviewerID        *uint             `json:"reviewer_id"`
+	ReasonCode        KycReasonCode     `gorm:"type:varchar(50)" json:"reason_code"`
+	ReviewerComment   string            `gorm:"type:text" json:"reviewer_comment"`
+	SubmittedAt       *time.Time        `json:"submitted_at"`
+	ReviewStartedAt   *time.Time        `json:"review_started_at"`
+	DecidedAt         *time.Time        `json:"decided_at"`
+	CreatedAt         time.Time         `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt         time.Time         `gorm:"autoUpdateTime" json:"updated_at"`
+	DeletedAt         gorm.DeletedAt    `json:"deleted_at" swaggerignore:"true"`
+
+	Documents   []StoreDocument   `json:"documents,omitempty" swaggerignore:"true"`
+	Transitions []StoreTransition `json:"transitions,omitempty" swaggerignore:"true"`
+}
+
+// StoreDocument is a business document attached to a store. There is at
+// most one document of each type per store; uploading again replaces it.
+type StoreDocument struct {
+	ID           uint              `gorm:"primaryKey" json:"id"`
+	StoreID      uint              `gorm:"index" json:"store_id"`
+	Type         StoreDocumentType `gorm:"type:varchar(30)" json:"type"`
+	FileID       *uint             `gorm:"index" json:"file_id"`
+	File         *File             `json:"file,omitempty"`
+	MimeType     string            `gorm:"type:varchar(50)" json:"mime_type"`
+	URL          string            `gorm:"-" json:"url"`
+	ThumbnailURL string            `gorm:"-" json:"thumbnail_url,omitempty"`
+	CreatedAt    time.Time         `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt    time.Time         `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// StoreTransition records every status change of a store's verification
+type StoreTransition struct {
+	ID         uint          `gorm:"primaryKey" json:"id"`
+	StoreID    uint          `gorm:"index" json:"store_id"`
+	FromStatus KycStatus     `gorm:"type:varchar(30)" json:"from_status"`
+	ToStatus   KycStatus     `gorm:"type:varchar(30)" json:"to_status"`
+	ActorID    uint          `json:"actor_id"`
+	ReasonCode KycReasonCode `gorm:"type:varchar(50)" json:"reason_code"`
+	Comment    string        `gorm:"type:text" json:"comment"`
+	CreatedAt  time.Time     `gorm:"autoCreateTime" json:"created_at"`
+}
@@ -0,0 +1,38 @@
+package models
+
+import "time"
+
+// UploadPurpose is what an upload will be used for. It decides the size
+// limit of the upload and where it can be used.
+type UploadPurpose string
+
+const (
+	UploadPurposeKycDocument   UploadPurpose = "kyc_document"
+	UploadPurposeStoreDocument UploadPurpose = "store_document"
+)
+
+// Upload is a resumable (tus) upload. Until it is complete the received
+// chunks are stored as separate parts; once Offset reaches Length they are
+// joined into a single file at Key/FileName.
+type Upload struct {
+	ID          uint              `gorm:"primaryKey" json:"-"`
+	Reference   string            `gorm:"type:varchar(100);uniqueIndex" json:"id"`
+	UserID      uint              `gorm:"index" json:"user_id"`
+	Purpose     UploadPurpose     `gorm:"type:varchar(30)" json:"purpose"`
+	Length      int64             `json:"length"`
+	Offset      int64             `json:"offset"`
+	Metadata    map[string]string `gorm:"type:json;serializer:json" json:"metadata"`
+	Parts       []string          `gorm:"type:json;serializer:json" json:"-"`
+	Key         string            `gorm:"type:varchar(100)" json:"-"`
+	FileName    string            `gorm:"type:varchar(255)" json:"-"`
+	MimeType    string            `gorm:"type:varchar(100)" json:"mime_type"`
+	ExpiresAt   time.Time         `gorm:"index" json:"expires_at"`
+	CompletedAt *time.Time        `json:"completed_at"`
+	CreatedAt   time.Time         `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt   time.Time         `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// Completed reports whether every byte of the upload has been received
+func (u Upload) Completed() bool {
+	return u.CompletedAt != nil
+}
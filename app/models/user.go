@@ -12,7 +12,10 @@ type User struct {
 	ID        uint           `gorm:"primaryKey" json:"id"`
 	Reference string         `gorm:"type:varchar(100);uniqueIndex" json:"reference"`
 	Username  string         `gorm:"type:varchar(100);uniqueIndex" json:"username"`
-	Email     string         `gorm:"type:varchar(100);uniqueIndex" json:"email"`
+	Email     string         `gorm:"type:text;serializer:encrypted" json:"email"`
+	EmailBidx string         `gorm:"type:varchar(64);uniqueIndex" json:"-"`
+	NIK       *string        `gorm:"column:nik;type:text;serializer:encrypted" json:"nik"`
+	NIKBidx   *string        `gorm:"column:nik_bidx;type:varchar(64);uniqueIndex" json:"-"`
 	Password  string         `gorm:"type:varchar(255)" json:"password"`
 	JwtToken  string         `gorm:"type:varchar(255)" json:"jwt_token" swaggerignore:"true"`
 	FcmToken  string         `gorm:"type:varchar(255)" json:"fcm_token" swaggerignore:"true"`
@@ -21,7 +24,39 @@ type User struct {
 	UpdatedAt time.Time      `gorm:"autoUpdateTime" json:"updated_at" swaggerignore:"true"`
 	DeletedAt gorm.DeletedAt `json:"deleted_at" swaggerignore:"true"`
 
-	Roles []Role `gorm:"many2many:user_has_roles;" json:"roles" swaggerignore:"true"`
+	Status          UserStatus `gorm:"type:varchar(20);default:active;index" json:"status" swaggerignore:"true"`
+	StatusReason    string     `gorm:"type:varchar(255)" json:"status_reason" swaggerignore:"true"`
+	StatusChangedAt *time.Time `json:"status_changed_at" swaggerignore:"true"`
+
+	// EmailCanonicalBidx is shared by aliases of one mailbox, see CanonicalEmailIndex
+	EmailCanonicalBidx string `gorm:"type:varchar(64);index" json:"-"`
+
+	Roles []Role `gorm:"many2many:users_has_roles;" json:"roles" swaggerignore:"true"`
+}
+
+func (u *User) BeforeSave(tx *gorm.DB) (err error) {
+	return u.SetBlindIndexes()
+}
+
+// SetBlindIndexes refreshes the lookup hashes of the encrypted email and NIK
+func (u *User) SetBlindIndexes() (err error) {
+	if u.Email != "" {
+		if u.EmailBidx, err = EmailIndex(u.Email); err != nil {
+			return err
+		}
+		if u.EmailCanonicalBidx, err = CanonicalEmailIndex(u.Email); err != nil {
+			return err
+		}
+	}
+	u.NIKBidx = nil
+	if u.NIK != nil && *u.NIK != "" {
+		index, err := NIKIndex(*u.NIK)
+		if err != nil {
+			return err
+		}
+		u.NIKBidx = &index
+	}
+	return nil
 }
 
 func (u *User) BeforeCreate(tx *gorm.DB) (err error) {
@@ -0,0 +1,34 @@
+package models
+
+import "time"
+
+type UserGroup struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	Name        string    `gorm:"type:varchar(255);uniqueIndex" json:"name"`
+	Description string    `json:"description"`
+	CreatedAt   time.Time `gorm:"autoCreateTime" json:"created_at" swaggerignore:"true"`
+	UpdatedAt   time.Time `gorm:"autoUpdateTime" json:"updated_at" swaggerignore:"true"`
+
+	Users []User `gorm:"many2many:user_groups_has_users;" json:"users,omitempty" swaggerignore:"true"`
+	Roles []Role `gorm:"many2many:user_groups_has_roles;" json:"roles,omitempty" swaggerignore:"true"`
+}
+
+type UserGroupHasUser struct {
+	ID          uint `gorm:"primaryKey" json:"id"`
+	UserGroupID uint `json:"user_group_id"`
+	UserID      uint `json:"user_id"`
+}
+
+func (UserGroupHasUser) TableName() string {
+	return "user_groups_has_users"
+}
+
+type UserGroupHasRole struct {
+	ID          uint `gorm:"primaryKey" json:"id"`
+	UserGroupID uint `json:"user_group_id"`
+	RoleID      uint `json:"role_id"`
+}
+
+func (UserGroupHasRole) TableName() string {
+	return "user_groups_has_roles"
+}
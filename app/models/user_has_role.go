@@ -1,7 +1,15 @@
 package models
 
+import "time"
+
 type UserHasRole struct {
-	ID     uint `gorm:"primaryKey" json:"id"`
-	UserID uint `json:"user_id"`
-	RoleID uint `json:"role_id"`
+	ID        uint       `gorm:"primaryKey" json:"id"`
+	UserID    uint       `json:"user_id"`
+	RoleID    uint       `json:"role_id"`
+	StartsAt  *time.Time `json:"starts_at"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+func (UserHasRole) TableName() string {
+	return "users_has_roles"
 }
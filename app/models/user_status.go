@@ -0,0 +1,43 @@
+package models
+
+import "time"
+
+type UserStatus string
+
+const (
+	UserStatusPending   UserStatus = "pending"
+	UserStatusActive    UserStatus = "active"
+	UserStatusSuspended UserStatus = "suspended"
+	UserStatusLocked    UserStatus = "locked"
+	UserStatusClosed    UserStatus = "closed"
+)
+
+var userStatusTransitions = map[UserStatus][]UserStatus{
+	UserStatusPending:   {UserStatusActive, UserStatusClosed},
+	UserStatusActive:    {UserStatusSuspended, UserStatusLocked, UserStatusClosed},
+	UserStatusSuspended: {UserStatusActive, UserStatusClosed},
+	UserStatusLocked:    {UserStatusActive, UserStatusClosed},
+	UserStatusClosed:    {},
+}
+
+// CanTransitionTo reports whether an account may move from s to next.
+// Closed is terminal.
+func (s UserStatus) CanTransitionTo(next UserStatus) bool {
+	for _, allowed := range userStatusTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+// UserStatusHistory records every change of a user's account status
+type UserStatusHistory struct {
+	ID         uint       `gorm:"primaryKey" json:"id"`
+	UserID     uint       `gorm:"index" json:"user_id"`
+	FromStatus UserStatus `gorm:"type:varchar(20)" json:"from_status"`
+	ToStatus   UserStatus `gorm:"type:varchar(20)" json:"to_status"`
+	Reason     string     `gorm:"type:varchar(255)" json:"reason"`
+	ChangedBy  *uint      `json:"changed_by"`
+	CreatedAt  time.Time  `gorm:"autoCreateTime" json:"created_at"`
+}
@@ -0,0 +1,96 @@
+package models
+
+import "time"
+
+type WatchlistCategory string
+
+const (
+	WatchlistSanctions WatchlistCategory = "sanctions"
+	WatchlistPEP       WatchlistCategory = "pep"
+)
+
+// Watchlist is a sanctions or PEP list supplied by compliance. Every import
+// creates a new version; screening uses the active version of each list.
+type Watchlist struct {
+	ID              uint              `gorm:"primaryKey" json:"id"`
+	Name            string            `gorm:"type:varchar(100);uniqueIndex" json:"name"`
+	Category        WatchlistCategory `gorm:"type:varchar(20)" json:"category"`
+	ActiveVersionID *uint             `json:"active_version_id"`
+	CreatedAt       time.Time         `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt       time.Time         `gorm:"autoUpdateTime" json:"updated_at"`
+
+	ActiveVersion *WatchlistVersion `gorm:"foreignKey:ActiveVersionID" json:"active_version,omitempty" swaggerignore:"true"`
+}
+
+// WatchlistVersion is one imported file of a list. Entries of older versions
+// are kept so past screening results can still be explained.
+type WatchlistVersion struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	WatchlistID uint      `gorm:"index" json:"watchlist_id"`
+	Version     int       `json:"version"`
+	SourceFile  string    `gorm:"type:varchar(255)" json:"source_file"`
+	Checksum    string    `gorm:"type:varchar(64)" json:"checksum"`
+	EntryCount  int       `json:"entry_count"`
+	CreatedAt   time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+type WatchlistEntry struct {
+	ID            uint     `gorm:"primaryKey" json:"id"`
+	WatchlistID   uint     `gorm:"index" json:"watchlist_id"`
+	VersionID     uint     `gorm:"index" json:"version_id"`
+	ExternalID    string   `gorm:"type:varchar(100)" json:"external_id"`
+	Name          string   `gorm:"type:varchar(255)" json:"name"`
+	Aliases       []string `gorm:"type:json;serializer:json" json:"aliases"`
+	BirthDates    []string `gorm:"type:json;serializer:json" json:"birth_dates"`
+	Nationalities []string `gorm:"type:json;serializer:json" json:"nationalities"`
+	Remarks       string   `gorm:"type:text" json:"remarks"`
+}
+
+type ScreeningStatus string
+
+const (
+	ScreeningClear          ScreeningStatus = "clear"
+	ScreeningPotentialMatch ScreeningStatus = "potential_match"
+)
+
+// ScreeningResult records one screening of a user against the active list
+// versions at that time. The latest result is the user's current status.
+type ScreeningResult struct {
+	ID           uint            `gorm:"primaryKey" json:"id"`
+	UserID       uint            `gorm:"index" json:"user_id"`
+	SubmissionID uint            `gorm:"index" json:"submission_id"`
+	Status       ScreeningStatus `gorm:"type:varchar(20);index" json:"status"`
+	VersionIDs   []uint          `gorm:"type:json;serializer:json" json:"version_ids"`
+	Threshold    float64         `json:"threshold"`
+	HitCount     int             `json:"hit_count"`
+	CreatedAt    time.Time       `gorm:"autoCreateTime" json:"created_at"`
+
+	Hits []ScreeningHit `gorm:"foreignKey:ResultID" json:"hits,omitempty" swaggerignore:"true"`
+}
+
+type ScreeningHitStatus string
+
+const (
+	ScreeningHitOpen      ScreeningHitStatus = "open"
+	ScreeningHitConfirmed ScreeningHitStatus = "confirmed"
+	ScreeningHitCleared   ScreeningHitStatus = "cleared"
+)
+
+// ScreeningHit is a list entry that matched the user. A reviewer's verdict
+// carries over to the same entry when the user is screened again.
+type ScreeningHit struct {
+	ID          uint               `gorm:"primaryKey" json:"id"`
+	ResultID    uint               `gorm:"index" json:"result_id"`
+	UserID      uint               `gorm:"index" json:"user_id"`
+	WatchlistID uint               `json:"watchlist_id"`
+	EntryID     uint               `json:"entry_id"`
+	ExternalID  string             `gorm:"type:varchar(100)" json:"external_id"`
+	EntryName   string             `gorm:"type:varchar(255)" json:"entry_name"`
+	MatchedName string             `gorm:"type:varchar(255)" json:"matched_name"`
+	Score       float64            `json:"score"`
+	Status      ScreeningHitStatus `gorm:"type:varchar(20);default:open;index" json:"status"`
+	ReviewedBy  *uint              `json:"reviewed_by"`
+	ReviewedAt  *time.Time         `json:"reviewed_at"`
+	Note        string             `gorm:"type:text" json:"note"`
+	CreatedAt   time.Time          `gorm:"autoCreateTime" json:"created_at"`
+}
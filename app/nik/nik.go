@@ -0,0 +1,140 @@
+// Package nik parses and validates the Indonesian national identity number
+// (Nomor Induk Kependudukan).
+//
+// A NIK has 16 digits laid out as PP KK CC DDMMYY SSSS: province, regency and
+// district codes, the holder's date of birth (40 is added to the day for
+// women) and a serial number.
+package nik
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+const Length = 16
+
+type Gender string
+
+const (
+	Male   Gender = "male"
+	Female Gender = "female"
+)
+
+var (
+	ErrInvalidLength    = errors.New("NIK harus terdiri dari 16 digit")
+	ErrNotNumeric       = errors.New("NIK hanya boleh berisi angka")
+	ErrUnknownProvince  = errors.New("kode provinsi NIK tidak dikenal")
+	ErrInvalidRegency   = errors.New("kode kabupaten/kota NIK tidak valid")
+	ErrInvalidDistrict  = errors.New("kode kecamatan NIK tidak valid")
+	ErrUnknownRegion    = errors.New("kode wilayah NIK tidak terdaftar")
+	ErrInvalidBirthDate = errors.New("tanggal lahir pada NIK tidak valid")
+	ErrInvalidSerial    = errors.New("nomor urut NIK tidak valid")
+)
+
+// NIK holds the fields encoded in a national identity number
+type NIK struct {
+	Number       string    `json:"nik"`
+	ProvinceCode string    `json:"province_code"`
+	Province     string    `json:"province"`
+	RegencyCode  string    `json:"regency_code"`
+	DistrictCode string    `json:"district_code"`
+	BirthDate    time.Time `json:"birth_date"`
+	Gender       Gender    `json:"gender"`
+	Serial       string    `json:"serial"`
+}
+
+// Parse validates number and returns its fields. Two-digit birth years are
+// resolved to the most recent century that does not put the birth date in
+// the future.
+func Parse(number string) (NIK, error) {
+	return ParseAt(number, time.Now())
+}
+
+// ParseAt is Parse with an explicit reference time for resolving the century
+// of the birth year and rejecting future birth dates
+func ParseAt(number string, now time.Time) (NIK, error) {
+	var n NIK
+
+	if len(number) != Length {
+		return n, ErrInvalidLength
+	}
+	for _, r := range number {
+		if r < '0' || r > '9' {
+			return n, ErrNotNumeric
+		}
+	}
+
+	province, ok := provinces[number[0:2]]
+	if !ok {
+		return n, ErrUnknownProvince
+	}
+	if number[2:4] == "00" {
+		return n, ErrInvalidRegency
+	}
+	if number[4:6] == "00" {
+		return n, ErrInvalidDistrict
+	}
+	if !districtKnown(number[0:6]) {
+		return n, ErrUnknownRegion
+	}
+	if number[12:16] == "0000" {
+		return n, ErrInvalidSerial
+	}
+
+	day, _ := strconv.Atoi(number[6:8])
+	month, _ := strconv.Atoi(number[8:10])
+	year, _ := strconv.Atoi(number[10:12])
+
+	gender := Male
+	if day > 40 {
+		gender = Female
+		day -= 40
+	}
+
+	fullYear := 2000 + year
+	if fullYear > now.Year() {
+		fullYear -= 100
+	}
+	birthDate := time.Date(fullYear, time.Month(month), day, 0, 0, 0, 0, time.UTC)
+	if day < 1 || month < 1 || month > 12 || birthDate.Day() != day || birthDate.Month() != time.Month(month) {
+		return n, ErrInvalidBirthDate
+	}
+	if birthDate.After(now) {
+		birthDate = birthDate.AddDate(-100, 0, 0)
+	}
+
+	return NIK{
+		Number:       number,
+		ProvinceCode: number[0:2],
+		Province:     province,
+		RegencyCode:  number[0:4],
+		DistrictCode: number[0:6],
+		BirthDate:    birthDate,
+		Gender:       gender,
+		Serial:       number[12:16],
+	}, nil
+}
+
+// Valid reports whether number is a well-formed NIK
+func Valid(number string) bool {
+	_, err := Parse(number)
+	return err == nil
+}
+
+// Age returns the holder's age in whole years at the given time
+func (n NIK) Age(at time.Time) int {
+	age := at.Year() - n.BirthDate.Year()
+	// compare month and day, day of the year shifts after February in leap years
+	if at.Month() < n.BirthDate.Month() || (at.Month() == n.BirthDate.Month() && at.Day() < n.BirthDate.Day()) {
+		age--
+	}
+	return age
+}
+
+// Masked returns the NIK with everything but the region code and the last two
+// digits hidden, for logs and responses that must not disclose it
+func (n NIK) Masked() string {
+	return fmt.Sprintf("%s**********%s", n.Number[0:4], n.Number[14:16])
+}
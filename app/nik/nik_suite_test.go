@@ -0,0 +1,13 @@
+package nik_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestNikSuite(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "NIK Test Suite")
+}
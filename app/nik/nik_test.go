@@ -0,0 +1,91 @@
+package nik_test
+
+import (
+	"strings"
+	"time"
+
+	"golang_starter_kit_2025/app/nik"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ParseAt", func() {
+	now := time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)
+
+	Context("when the NIK belongs to a man", func() {
+		It("should return the encoded fields", func() {
+			n, err := nik.ParseAt("3171011708900001", now)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(n.Province).To(Equal("DKI Jakarta"))
+			Expect(n.RegencyCode).To(Equal("3171"))
+			Expect(n.DistrictCode).To(Equal("317101"))
+			Expect(n.BirthDate).To(Equal(time.Date(1990, 8, 17, 0, 0, 0, 0, time.UTC)))
+			Expect(n.Gender).To(Equal(nik.Male))
+			Expect(n.Serial).To(Equal("0001"))
+			Expect(n.Age(now)).To(Equal(36))
+			Expect(n.Masked()).To(Equal("3171**********01"))
+		})
+	})
+
+	Context("when the NIK belongs to a woman", func() {
+		It("should subtract 40 from the day", func() {
+			n, err := nik.ParseAt("3273015705050002", now)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(n.Gender).To(Equal(nik.Female))
+			Expect(n.BirthDate).To(Equal(time.Date(2005, 5, 17, 0, 0, 0, 0, time.UTC)))
+		})
+	})
+
+	Context("when the birthday falls around February 29", func() {
+		DescribeTable("should count whole years by month and day",
+			func(birthDate, at time.Time, expected int) {
+				Expect(nik.NIK{BirthDate: birthDate}.Age(at)).To(Equal(expected))
+			},
+			Entry("born March 1 of a leap year, on March 1",
+				time.Date(2000, 3, 1, 0, 0, 0, 0, time.UTC), time.Date(2001, 3, 1, 0, 0, 0, 0, time.UTC), 1),
+			Entry("born March 1 of a leap year, on February 28",
+				time.Date(2000, 3, 1, 0, 0, 0, 0, time.UTC), time.Date(2001, 2, 28, 0, 0, 0, 0, time.UTC), 0),
+			Entry("born March 1 of a common year, on February 29",
+				time.Date(2001, 3, 1, 0, 0, 0, 0, time.UTC), time.Date(2004, 2, 29, 0, 0, 0, 0, time.UTC), 2),
+			Entry("born February 29, on February 28 of a common year",
+				time.Date(2000, 2, 29, 0, 0, 0, 0, time.UTC), time.Date(2001, 2, 28, 0, 0, 0, 0, time.UTC), 0),
+			Entry("born February 29, on March 1 of a common year",
+				time.Date(2000, 2, 29, 0, 0, 0, 0, time.UTC), time.Date(2001, 3, 1, 0, 0, 0, 0, time.UTC), 1),
+		)
+	})
+
+	Context("when the NIK is malformed", func() {
+		DescribeTable("should return the matching error",
+			func(number string, expected error) {
+				_, err := nik.ParseAt(number, now)
+				Expect(err).To(MatchError(expected))
+			},
+			Entry("too short", "317101170890001", nik.ErrInvalidLength),
+			Entry("not numeric", "31710117089A0001", nik.ErrNotNumeric),
+			Entry("unknown province", "9971011708900001", nik.ErrUnknownProvince),
+			Entry("zero regency", "3100011708900001", nik.ErrInvalidRegency),
+			Entry("zero district", "3171001708900001", nik.ErrInvalidDistrict),
+			Entry("impossible date", "3171013102900001", nik.ErrInvalidBirthDate),
+			Entry("day above female range", "3171017208900001", nik.ErrInvalidBirthDate),
+			Entry("month 13", "3171011713900001", nik.ErrInvalidBirthDate),
+			Entry("zero serial", "3171011708900000", nik.ErrInvalidSerial),
+		)
+	})
+
+	Context("when a district list is loaded", func() {
+		AfterEach(func() {
+			Expect(nik.LoadDistricts(strings.NewReader(""))).To(Succeed())
+		})
+
+		It("should reject districts that are not listed", func() {
+			Expect(nik.LoadDistricts(strings.NewReader("31.71.01,Gambir\n"))).To(Succeed())
+
+			_, err := nik.ParseAt("3171011708900001", now)
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = nik.ParseAt("3171021708900001", now)
+			Expect(err).To(MatchError(nik.ErrUnknownRegion))
+		})
+	})
+})
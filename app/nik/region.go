@@ -0,0 +1,111 @@
+package nik
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"strings"
+	"sync"
+)
+
+// provinces maps Dukcapil province codes to province names
+var provinces = map[string]string{
+	"11": "Aceh",
+	"12": "Sumatera Utara",
+	"13": "Sumatera Barat",
+	"14": "Riau",
+	"15": "Jambi",
+	"16": "Sumatera Selatan",
+	"17": "Bengkulu",
+	"18": "Lampung",
+	"19": "Kepulauan Bangka Belitung",
+	"21": "Kepulauan Riau",
+	"31": "DKI Jakarta",
+	"32": "Jawa Barat",
+	"33": "Jawa Tengah",
+	"34": "DI Yogyakarta",
+	"35": "Jawa Timur",
+	"36": "Banten",
+	"51": "Bali",
+	"52": "Nusa Tenggara Barat",
+	"53": "Nusa Tenggara Timur",
+	"61": "Kalimantan Barat",
+	"62": "Kalimantan Tengah",
+	"63": "Kalimantan Selatan",
+	"64": "Kalimantan Timur",
+	"65": "Kalimantan Utara",
+	"71": "Sulawesi Utara",
+	"72": "Sulawesi Tengah",
+	"73": "Sulawesi Selatan",
+	"74": "Sulawesi Tenggara",
+	"75": "Gorontalo",
+	"76": "Sulawesi Barat",
+	"81": "Maluku",
+	"82": "Maluku Utara",
+	"91": "Papua",
+	"92": "Papua Barat",
+	"93": "Papua Selatan",
+	"94": "Papua Tengah",
+	"95": "Papua Pegunungan",
+	"96": "Papua Barat Daya",
+}
+
+var (
+	districts     map[string]bool
+	districtMutex sync.RWMutex
+)
+
+// ProvinceName returns the name of a two-digit province code
+func ProvinceName(code string) (string, bool) {
+	name, ok := provinces[code]
+	return name, ok
+}
+
+// LoadDistricts replaces the list of known district codes. Each line holds a
+// district code in its first comma-separated column, either as "110101" or in
+// the dotted Kemendagri form "11.01.01". Until a list is loaded only the
+// province code and the structure of the regency and district codes are
+// checked; loading an empty list switches back to that behaviour.
+func LoadDistricts(r io.Reader) error {
+	loaded := make(map[string]bool)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		code := strings.TrimSpace(strings.SplitN(scanner.Text(), ",", 2)[0])
+		code = strings.ReplaceAll(strings.Trim(code, `"`), ".", "")
+		if len(code) == 6 {
+			loaded[code] = true
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	if len(loaded) == 0 {
+		loaded = nil
+	}
+
+	districtMutex.Lock()
+	districts = loaded
+	districtMutex.Unlock()
+	return nil
+}
+
+// LoadDistrictFile loads district codes from a CSV file, see LoadDistricts
+func LoadDistrictFile(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return LoadDistricts(file)
+}
+
+func districtKnown(code string) bool {
+	districtMutex.RLock()
+	defer districtMutex.RUnlock()
+
+	if districts == nil {
+		return true
+	}
+	return districts[code]
+}
@@ -0,0 +1,11 @@
+package permissions
+
+const (
+	AccessRequestApprove = "access_request.approve"
+)
+
+func init() {
+	Register(
+		Definition{Name: AccessRequestApprove, Group: "Access Request", Description: "Melihat, menyetujui dan menolak permintaan akses role sementara"},
+	)
+}
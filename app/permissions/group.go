@@ -0,0 +1,13 @@
+package permissions
+
+const (
+	GroupView   = "group.view"
+	GroupManage = "group.manage"
+)
+
+func init() {
+	Register(
+		Definition{Name: GroupView, Group: "Group", Description: "Melihat daftar group, anggota dan role group"},
+		Definition{Name: GroupManage, Group: "Group", Description: "Membuat, mengubah, menghapus group serta mengatur anggota dan role group"},
+	)
+}
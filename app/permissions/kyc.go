@@ -0,0 +1,15 @@
+package permissions
+
+const (
+	KycView   = "kyc.view"
+	KycReview = "kyc.review"
+	KycRisk   = "kyc.risk"
+)
+
+func init() {
+	Register(
+		Definition{Name: KycView, Group: "KYC", Description: "Melihat pengajuan KYC dan dokumennya"},
+		Definition{Name: KycReview, Group: "KYC", Description: "Meninjau, menyetujui, menolak dan meminta pengajuan ulang KYC"},
+		Definition{Name: KycRisk, Group: "KYC", Description: "Melihat aturan risiko KYC dan menilai ulang pengajuan setelah aturan berubah"},
+	)
+}
@@ -0,0 +1,11 @@
+package permissions
+
+const (
+	NIKCheck = "nik.check"
+)
+
+func init() {
+	Register(
+		Definition{Name: NIKCheck, Group: "NIK", Description: "Memeriksa NIK dan status pendaftarannya"},
+	)
+}
@@ -0,0 +1,13 @@
+package permissions
+
+const (
+	PermissionView   = "permission.view"
+	PermissionManage = "permission.manage"
+)
+
+func init() {
+	Register(
+		Definition{Name: PermissionView, Group: "Permission", Description: "Melihat daftar permission"},
+		Definition{Name: PermissionManage, Group: "Permission", Description: "Membuat, mengubah dan menghapus permission"},
+	)
+}
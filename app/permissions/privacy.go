@@ -0,0 +1,13 @@
+package permissions
+
+const (
+	PrivacyExport = "privacy.export"
+	PrivacyErase  = "privacy.erase"
+)
+
+func init() {
+	Register(
+		Definition{Name: PrivacyExport, Group: "Privasi", Description: "Mengunduh ekspor data pribadi user lain dan melihat kebijakan retensi"},
+		Definition{Name: PrivacyErase, Group: "Privasi", Description: "Menghapus dan menganonimkan data pribadi user"},
+	)
+}
@@ -0,0 +1,91 @@
+package permissions
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// RoleAdmin is granted every permission declared in code by default.
+const RoleAdmin = "admin"
+
+// Definition describes a permission owned by a feature. Roles lists the role
+// names that receive the permission when `permissions:sync` runs.
+type Definition struct {
+	Name        string
+	Group       string
+	Description string
+	Roles       []string
+}
+
+var (
+	registry = make(map[string]Definition)
+	mutex    sync.RWMutex
+)
+
+// Register adds permission definitions to the registry. Features call it from
+// an init function next to the code that checks the permission.
+func Register(definitions ...Definition) {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	for _, def := range definitions {
+		if def.Name == "" {
+			panic("permissions: definition name is required")
+		}
+		if _, exists := registry[def.Name]; exists {
+			panic(fmt.Sprintf("permissions: %q registered twice", def.Name))
+		}
+		if !containsRole(def.Roles, RoleAdmin) {
+			def.Roles = append([]string{RoleAdmin}, def.Roles...)
+		}
+		registry[def.Name] = def
+	}
+}
+
+// All returns every registered definition ordered by group and name.
+func All() []Definition {
+	mutex.RLock()
+	defer mutex.RUnlock()
+
+	definitions := make([]Definition, 0, len(registry))
+	for _, def := range registry {
+		definitions = append(definitions, def)
+	}
+	sort.Slice(definitions, func(i, j int) bool {
+		if definitions[i].Group != definitions[j].Group {
+			return definitions[i].Group < definitions[j].Group
+		}
+		return definitions[i].Name < definitions[j].Name
+	})
+	return definitions
+}
+
+// Names returns the names of every registered permission, sorted.
+func Names() []string {
+	definitions := All()
+	names := make([]string, 0, len(definitions))
+	for _, def := range definitions {
+		names = append(names, def.Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Has reports whether a permission with the given name is declared in code.
+func Has(name string) bool {
+	mutex.RLock()
+	defer mutex.RUnlock()
+
+	_, exists := registry[name]
+	return exists
+}
+
+func containsRole(roles []string, role string) bool {
+	for _, r := range roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
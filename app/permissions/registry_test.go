@@ -0,0 +1,49 @@
+package permissions_test
+
+import (
+	"golang_starter_kit_2025/app/permissions"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Register", func() {
+	Context("when a permission is registered", func() {
+		It("should be listed and granted to the admin role", func() {
+			permissions.Register(permissions.Definition{
+				Name:  "test.register",
+				Group: "Test",
+				Roles: []string{"reviewer"},
+			})
+
+			Expect(permissions.Has("test.register")).To(BeTrue())
+			Expect(permissions.Names()).To(ContainElement("test.register"))
+
+			for _, def := range permissions.All() {
+				if def.Name == "test.register" {
+					Expect(def.Roles).To(ConsistOf(permissions.RoleAdmin, "reviewer"))
+				}
+			}
+		})
+	})
+
+	Context("when a permission is registered twice", func() {
+		It("should panic", func() {
+			permissions.Register(permissions.Definition{Name: "test.duplicate", Group: "Test"})
+			Expect(func() {
+				permissions.Register(permissions.Definition{Name: "test.duplicate", Group: "Test"})
+			}).To(Panic())
+		})
+	})
+
+	Context("when the built-in permissions are loaded", func() {
+		It("should be sorted by group and name", func() {
+			all := permissions.All()
+			for i := 1; i < len(all); i++ {
+				prev, curr := all[i-1], all[i]
+				Expect(prev.Group < curr.Group || (prev.Group == curr.Group && prev.Name < curr.Name)).To(BeTrue())
+			}
+			Expect(permissions.Has(permissions.UserView)).To(BeTrue())
+		})
+	})
+})
@@ -0,0 +1,13 @@
+package permissions
+
+const (
+	RoleView   = "role.view"
+	RoleManage = "role.manage"
+)
+
+func init() {
+	Register(
+		Definition{Name: RoleView, Group: "Role", Description: "Melihat daftar role dan permission milik role"},
+		Definition{Name: RoleManage, Group: "Role", Description: "Membuat, mengubah, menghapus role dan mengatur permission role"},
+	)
+}
@@ -0,0 +1,13 @@
+package permissions
+
+const (
+	ScreeningView   = "screening.view"
+	ScreeningReview = "screening.review"
+)
+
+func init() {
+	Register(
+		Definition{Name: ScreeningView, Group: "Screening", Description: "Melihat watchlist dan hasil screening sanksi/PEP"},
+		Definition{Name: ScreeningReview, Group: "Screening", Description: "Mengonfirmasi atau menghapus kecocokan screening sanksi/PEP"},
+	)
+}
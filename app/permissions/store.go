@@ -0,0 +1,13 @@
+package permissions
+
+const (
+	StoreView   = "store.view"
+	StoreReview = "store.review"
+)
+
+func init() {
+	Register(
+		Definition{Name: StoreView, Group: "Store", Description: "Melihat semua toko beserta dokumen verifikasi usahanya"},
+		Definition{Name: StoreReview, Group: "Store", Description: "Meninjau, menyetujui, menolak dan meminta pengajuan ulang verifikasi usaha (KYB)"},
+	)
+}
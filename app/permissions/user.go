@@ -0,0 +1,15 @@
+package permissions
+
+const (
+	UserView   = "user.view"
+	UserManage = "user.manage"
+	UserStatus = "user.status"
+)
+
+func init() {
+	Register(
+		Definition{Name: UserView, Group: "User", Description: "Melihat daftar dan detail user"},
+		Definition{Name: UserManage, Group: "User", Description: "Membuat, mengubah, menghapus user dan mengatur role user"},
+		Definition{Name: UserStatus, Group: "User", Description: "Menangguhkan, mengaktifkan kembali, mengunci dan menutup akun user"},
+	)
+}
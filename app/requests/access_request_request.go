@@ -0,0 +1,11 @@
+package requests
+
+type AccessRequestCreate struct {
+	RoleID          uint   `json:"role_id" form:"role_id" binding:"required" example:"1" validate:"required"`
+	Justification   string `json:"justification" form:"justification" binding:"required,min=10" example:"Investigasi insiden KYC #123" validate:"required"`
+	DurationMinutes int    `json:"duration_minutes" form:"duration_minutes" binding:"required,min=1" example:"120" validate:"required"`
+}
+
+type AccessRequestReview struct {
+	Note string `json:"note" form:"note" example:"Disetujui untuk investigasi"`
+}
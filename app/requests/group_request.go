@@ -0,0 +1,21 @@
+package requests
+
+type GroupRequestPut struct {
+	ID          uint   `json:"id" form:"id"`
+	Name        string `json:"name" form:"name" binding:"required" example:"KYC Reviewer" validate:"required"`
+	Description string `json:"description" form:"description" example:"Tim reviewer pengajuan KYC"`
+}
+
+type GroupRequestUpdateMembers struct {
+	Add    []uint `json:"add" form:"add"`
+	Remove []uint `json:"remove" form:"remove"`
+}
+
+type GroupRequestAssignRoles struct {
+	Roles []uint `json:"roles" form:"roles"`
+}
+
+type GroupRequestUpdateRoles struct {
+	Add    []uint `json:"add" form:"add"`
+	Remove []uint `json:"remove" form:"remove"`
+}
@@ -0,0 +1,30 @@
+package requests
+
+type KycRequestPut struct {
+	NIK        string `json:"nik" form:"nik" binding:"omitempty,nik" example:"3171011708900001"`
+	FullName   string `json:"full_name" form:"full_name" binding:"max=255" example:"Budi Santoso"`
+	BirthPlace string `json:"birth_place" form:"birth_place" binding:"max=100" example:"Jakarta"`
+	BirthDate  string `json:"birth_date" form:"birth_date" binding:"omitempty,datetime=2006-01-02" example:"1990-08-17"`
+	Gender     string `json:"gender" form:"gender" binding:"omitempty,oneof=male female" example:"male"`
+	Address    string `json:"address" form:"address" example:"Jl. Merdeka No. 1, Gambir"`
+	Phone      string `json:"phone" form:"phone" binding:"max=30" example:"081234567890"`
+}
+
+// KycRequestUploadDocument is sent either as JSON with a base64 encoded file
+// or the ID of a complete kyc_document upload, or as multipart form data with
+// the image in the "file" field
+type KycRequestUploadDocument struct {
+	Type     string `json:"type" form:"type" binding:"required,oneof=identity_card selfie" example:"identity_card" validate:"required"`
+	File     string `json:"file" form:"-" example:"iVBORw0KGgoAAAANSUhEUgAA..."`
+	UploadID string `json:"upload_id" form:"upload_id" example:"UPL-0192f1a2-7b3c-7d4e-8f50-123456789abc"`
+}
+
+type KycRequestDecision struct {
+	ReasonCode string `json:"reason_code" form:"reason_code" example:"document_unreadable"`
+	Comment    string `json:"comment" form:"comment" example:"Foto KTP buram, silakan unggah ulang"`
+}
+
+type KycRequestResolveFlag struct {
+	Status string `json:"status" form:"status" binding:"required,oneof=confirmed dismissed" example:"dismissed" validate:"required"`
+	Note   string `json:"note" form:"note" example:"Saudara kandung, alamat sama"`
+}
@@ -0,0 +1,5 @@
+package requests
+
+type CheckNIKRequest struct {
+	NIK string `json:"nik" form:"nik" binding:"required,nik" example:"3171011708900001" validate:"required"`
+}
@@ -0,0 +1,5 @@
+package requests
+
+type PrivacyRequestErase struct {
+	Reason string `json:"reason" form:"reason" binding:"required,max=255" example:"Permintaan penghapusan data dari user" validate:"required"`
+}
@@ -9,3 +9,12 @@ type RoleRequestPut struct {
 type RoleRequestAssignPermissions struct {
 	PermissionIDs []uint `json:"permissions" form:"permissions" binding:"required" validate:"required"`
 }
+
+type RoleRequestUpdatePermissions struct {
+	Add    []uint `json:"add" form:"add"`
+	Remove []uint `json:"remove" form:"remove"`
+}
+
+type RoleRequestPermissionMatrix struct {
+	Grants map[uint][]uint `json:"grants" binding:"required" validate:"required"`
+}
@@ -0,0 +1,6 @@
+package requests
+
+type ScreeningRequestResolveHit struct {
+	Status string `json:"status" form:"status" binding:"required,oneof=confirmed cleared" example:"cleared" validate:"required"`
+	Note   string `json:"note" form:"note" example:"Tanggal lahir dan alamat berbeda dengan daftar"`
+}
@@ -0,0 +1,24 @@
+package requests
+
+type StoreRequestPut struct {
+	Name         string `json:"name" form:"name" binding:"required,max=255" example:"Toko Sumber Rejeki" validate:"required"`
+	LegalName    string `json:"legal_name" form:"legal_name" binding:"max=255" example:"CV Sumber Rejeki Abadi"`
+	BusinessType string `json:"business_type" form:"business_type" binding:"omitempty,oneof=individual cv pt cooperative" example:"cv"`
+	NIB          string `json:"nib" form:"nib" binding:"omitempty,numeric,len=13" example:"9120001234567"`
+	NPWP         string `json:"npwp" form:"npwp" binding:"omitempty,numeric,min=15,max=16" example:"012345678901000"`
+	Phone        string `json:"phone" form:"phone" binding:"max=30" example:"0215550123"`
+	Address      string `json:"address" form:"address" binding:"max=255" example:"Jl. Pasar Baru No. 10"`
+	City         string `json:"city" form:"city" binding:"max=100" example:"Jakarta Pusat"`
+	State        string `json:"state" form:"state" binding:"max=100" example:"DKI Jakarta"`
+	Country      string `json:"country" form:"country" binding:"max=100" example:"ID"`
+	Zip          string `json:"zip" form:"zip" binding:"max=10" example:"10710"`
+}
+
+// StoreRequestUploadDocument is sent either as JSON with a base64 encoded
+// file or the ID of a complete store_document upload, or as multipart form
+// data with the file in the "file" field
+type StoreRequestUploadDocument struct {
+	Type     string `json:"type" form:"type" binding:"required,oneof=business_license tax_id deed storefront" example:"business_license" validate:"required"`
+	File     string `json:"file" form:"-" example:"JVBERi0xLjQKJ..."`
+	UploadID string `json:"upload_id" form:"upload_id" example:"UPL-0192f1a2-7b3c-7d4e-8f50-123456789abc"`
+}
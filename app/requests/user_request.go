@@ -0,0 +1,14 @@
+package requests
+
+import "time"
+
+type UserRequestUpdateRoles struct {
+	Add       []uint     `json:"add" form:"add"`
+	Remove    []uint     `json:"remove" form:"remove"`
+	StartsAt  *time.Time `json:"starts_at" form:"starts_at" example:"2026-01-01T00:00:00Z"`
+	ExpiresAt *time.Time `json:"expires_at" form:"expires_at" example:"2026-01-02T00:00:00Z"`
+}
+
+type UserRequestChangeStatus struct {
+	Reason string `json:"reason" form:"reason" binding:"required,max=255" example:"Terindikasi penipuan" validate:"required"`
+}
@@ -0,0 +1,18 @@
+package requests
+
+import (
+	"golang_starter_kit_2025/app/nik"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+// init registers the custom binding tags used by the request structs so they
+// are available wherever requests are bound
+func init() {
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		v.RegisterValidation("nik", func(fl validator.FieldLevel) bool {
+			return nik.Valid(fl.Field().String())
+		})
+	}
+}
@@ -0,0 +1,8 @@
+package responses
+
+import "golang_starter_kit_2025/app/nik"
+
+type CheckNIKResult struct {
+	nik.NIK
+	CheckNIK
+}
@@ -0,0 +1,13 @@
+package responses
+
+import "golang_starter_kit_2025/app/models"
+
+// KycDuplicateCluster groups users linked to each other by duplicate flags,
+// directly or through other users in the cluster
+type KycDuplicateCluster struct {
+	UserIDs  []uint                 `json:"user_ids"`
+	Signals  []models.KycFlagSignal `json:"signals"`
+	MaxScore float64                `json:"max_score"`
+	Open     int                    `json:"open"`
+	Flags    []models.KycFlag       `json:"flags"`
+}
@@ -0,0 +1,10 @@
+package responses
+
+import "golang_starter_kit_2025/app/models"
+
+// PermissionMatrix lists roles and permissions with the permission IDs granted to each role ID
+type PermissionMatrix struct {
+	Roles       []models.Role       `json:"roles"`
+	Permissions []models.Permission `json:"permissions"`
+	Grants      map[uint][]uint     `json:"grants"`
+}
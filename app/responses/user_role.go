@@ -0,0 +1,21 @@
+package responses
+
+import "time"
+
+// UserRole is a role held by a user, either assigned directly, inherited
+// from one or more groups, or both. StartsAt and ExpiresAt describe the
+// window of a direct, time-bound grant.
+type UserRole struct {
+	ID            uint       `json:"id"`
+	Name          string     `json:"name"`
+	Group         string     `json:"group"`
+	Direct        bool       `json:"direct"`
+	StartsAt      *time.Time `json:"starts_at,omitempty"`
+	ExpiresAt     *time.Time `json:"expires_at,omitempty"`
+	InheritedFrom []GroupRef `json:"inherited_from"`
+}
+
+type GroupRef struct {
+	ID   uint   `json:"id"`
+	Name string `json:"name"`
+}
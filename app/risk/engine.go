@@ -0,0 +1,118 @@
+package risk
+
+type Tier string
+
+const (
+	TierLow    Tier = "low"
+	TierMedium Tier = "medium"
+	TierHigh   Tier = "high"
+)
+
+// Facts maps fact names to float64, string or []string values. A fact that
+// is missing, such as the age of an applicant whose NIK cannot be parsed,
+// matches no rule.
+type Facts map[string]interface{}
+
+// Contribution is a rule that held for an applicant, with the value of the
+// fact it tested
+type Contribution struct {
+	RuleID      string      `json:"rule_id"`
+	Description string      `json:"description"`
+	Fact        string      `json:"fact"`
+	Operator    string      `json:"operator"`
+	Expected    interface{} `json:"expected"`
+	Actual      interface{} `json:"actual"`
+	Weight      float64     `json:"weight"`
+}
+
+type Assessment struct {
+	Score         float64        `json:"score"`
+	Tier          Tier           `json:"tier"`
+	Contributions []Contribution `json:"contributions"`
+}
+
+// Evaluate scores facts with every rule of the set. The score is the sum of
+// the weights of the rules that held, never below zero.
+func (set *RuleSet) Evaluate(facts Facts) Assessment {
+	assessment := Assessment{Contributions: []Contribution{}}
+	for _, rule := range set.Rules {
+		actual, ok := facts[rule.Fact]
+		if !ok || !rule.holds(actual) {
+			continue
+		}
+		assessment.Score += rule.Weight
+		assessment.Contributions = append(assessment.Contributions, Contribution{
+			RuleID:      rule.ID,
+			Description: rule.Description,
+			Fact:        rule.Fact,
+			Operator:    rule.Operator,
+			Expected:    rule.Value,
+			Actual:      actual,
+			Weight:      rule.Weight,
+		})
+	}
+
+	assessment.Score = max(assessment.Score, 0)
+	assessment.Tier = set.Tier(assessment.Score)
+	return assessment
+}
+
+// Tier returns the tier of a score
+func (set *RuleSet) Tier(score float64) Tier {
+	switch {
+	case score >= set.Tiers.High:
+		return TierHigh
+	case score >= set.Tiers.Medium:
+		return TierMedium
+	default:
+		return TierLow
+	}
+}
+
+func (rule *Rule) holds(actual interface{}) bool {
+	switch value := actual.(type) {
+	case float64:
+		switch rule.Operator {
+		case "lt":
+			return value < rule.number
+		case "lte":
+			return value <= rule.number
+		case "gt":
+			return value > rule.number
+		case "gte":
+			return value >= rule.number
+		case "eq":
+			return value == rule.number
+		case "ne":
+			return value != rule.number
+		}
+	case string:
+		switch rule.Operator {
+		case "eq":
+			return value == rule.text
+		case "ne":
+			return value != rule.text
+		case "in":
+			return contains(rule.texts, value)
+		case "not_in":
+			return !contains(rule.texts, value)
+		}
+	case []string:
+		switch rule.Operator {
+		case "contains":
+			return contains(value, rule.text)
+		case "not_contains":
+			return !contains(value, rule.text)
+		}
+	}
+	return false
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
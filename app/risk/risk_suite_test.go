@@ -0,0 +1,13 @@
+package risk_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestRiskSuite(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Risk Test Suite")
+}
@@ -0,0 +1,119 @@
+package risk_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang_starter_kit_2025/app/risk"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+const rules = `{
+	"version": "test",
+	"tiers": {"medium": 30, "high": 60},
+	"rules": [
+		{"id": "young", "fact": "applicant_age", "operator": "lt", "value": 21, "weight": 10},
+		{"id": "border", "fact": "province_code", "operator": "in", "value": ["91", "94"], "weight": 15},
+		{"id": "hits", "fact": "screening_hits", "operator": "gt", "value": 0, "weight": 50},
+		{"id": "blurry", "fact": "document_issues", "operator": "contains", "value": "blurry", "weight": 5},
+		{"id": "old_account", "fact": "account_age_days", "operator": "gte", "value": 365, "weight": -10}
+	]
+}`
+
+var _ = Describe("RuleSet", func() {
+	var set *risk.RuleSet
+
+	BeforeEach(func() {
+		var err error
+		set, err = risk.Parse(strings.NewReader(rules))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(set.Checksum).To(HaveLen(64))
+	})
+
+	It("should add the weights of the rules that hold and explain them", func() {
+		assessment := set.Evaluate(risk.Facts{
+			risk.FactApplicantAge:   float64(19),
+			risk.FactProvinceCode:   "94",
+			risk.FactScreeningHits:  float64(1),
+			risk.FactDocumentIssues: []string{"blurry"},
+			risk.FactAccountAgeDays: float64(3),
+		})
+		Expect(assessment.Score).To(Equal(80.0))
+		Expect(assessment.Tier).To(Equal(risk.TierHigh))
+		Expect(assessment.Contributions).To(HaveLen(4))
+		Expect(assessment.Contributions[0].RuleID).To(Equal("young"))
+		Expect(assessment.Contributions[0].Actual).To(Equal(float64(19)))
+	})
+
+	It("should skip missing facts and never go below zero", func() {
+		assessment := set.Evaluate(risk.Facts{
+			risk.FactProvinceCode:   "31",
+			risk.FactAccountAgeDays: float64(400),
+		})
+		Expect(assessment.Score).To(Equal(0.0))
+		Expect(assessment.Tier).To(Equal(risk.TierLow))
+		Expect(assessment.Contributions).To(HaveLen(1))
+	})
+
+	It("should place scores in tiers", func() {
+		Expect(set.Tier(29)).To(Equal(risk.TierLow))
+		Expect(set.Tier(30)).To(Equal(risk.TierMedium))
+		Expect(set.Tier(60)).To(Equal(risk.TierHigh))
+	})
+})
+
+var _ = Describe("Parse", func() {
+	DescribeTable("should refuse invalid rules",
+		func(rule string) {
+			_, err := risk.Parse(strings.NewReader(`{"tiers": {"medium": 1, "high": 2}, "rules": [` + rule + `]}`))
+			Expect(err).To(MatchError(risk.ErrInvalidRules))
+		},
+		Entry("unknown fact", `{"id": "a", "fact": "shoe_size", "operator": "gt", "value": 1, "weight": 1}`),
+		Entry("operator of another kind", `{"id": "a", "fact": "applicant_age", "operator": "in", "value": ["1"], "weight": 1}`),
+		Entry("value of another type", `{"id": "a", "fact": "applicant_age", "operator": "gt", "value": "1", "weight": 1}`),
+		Entry("missing id", `{"fact": "applicant_age", "operator": "gt", "value": 1, "weight": 1}`),
+	)
+
+	It("should refuse tiers out of order", func() {
+		_, err := risk.Parse(strings.NewReader(`{"tiers": {"medium": 5, "high": 2}, "rules": []}`))
+		Expect(err).To(MatchError(risk.ErrInvalidRules))
+	})
+})
+
+var _ = Describe("RefreshFile", func() {
+	It("should put the file in use only when its checksum changes", func() {
+		previous := risk.Current()
+		DeferCleanup(func() { risk.SetCurrent(previous) })
+
+		path := filepath.Join(GinkgoT().TempDir(), "rules.json")
+		Expect(os.WriteFile(path, []byte(rules), 0644)).To(Succeed())
+		loaded, err := risk.RefreshFile(path)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(risk.Current()).To(BeIdenticalTo(loaded))
+
+		same, err := risk.RefreshFile(path)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(same).To(BeIdenticalTo(loaded))
+
+		Expect(os.WriteFile(path, []byte(`{"version": "next", "tiers": {"medium": 1, "high": 2}, "rules": []}`), 0644)).To(Succeed())
+		next, err := risk.RefreshFile(path)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(next.Version).To(Equal("next"))
+		Expect(risk.Current()).To(BeIdenticalTo(next))
+	})
+
+	It("should keep the rules in use when the file is not valid", func() {
+		previous := risk.Current()
+		DeferCleanup(func() { risk.SetCurrent(previous) })
+
+		path := filepath.Join(GinkgoT().TempDir(), "rules.json")
+		Expect(os.WriteFile(path, []byte(`{"tiers": {"medium": 5, "high": 2}}`), 0644)).To(Succeed())
+		set, err := risk.RefreshFile(path)
+		Expect(err).To(MatchError(risk.ErrInvalidRules))
+		Expect(set).To(BeIdenticalTo(previous))
+		Expect(risk.Current()).To(BeIdenticalTo(previous))
+	})
+})
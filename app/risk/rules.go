@@ -0,0 +1,218 @@
+// Package risk scores KYC applicants with rules read from a file. Each rule
+// tests one fact about the applicant and adds its weight to the score when
+// it holds; the rules that did are kept with the score so that every
+// decision can be explained.
+package risk
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+// Facts known about an applicant. Rules refer to them by these names.
+const (
+	FactApplicantAge       = "applicant_age"
+	FactProvinceCode       = "province_code"
+	FactRegencyCode        = "regency_code"
+	FactScreeningHits      = "screening_hits"
+	FactScreeningConfirmed = "screening_confirmed"
+	FactDocumentIssues     = "document_issues"
+	FactDocumentsFailed    = "documents_failed"
+	FactDuplicateFlags     = "duplicate_flags"
+	FactDuplicateSignals   = "duplicate_signals"
+	FactAccountAgeDays     = "account_age_days"
+)
+
+type kind int
+
+const (
+	kindNumber kind = iota
+	kindText
+	kindList
+)
+
+var factKinds = map[string]kind{
+	FactApplicantAge:       kindNumber,
+	FactProvinceCode:       kindText,
+	FactRegencyCode:        kindText,
+	FactScreeningHits:      kindNumber,
+	FactScreeningConfirmed: kindNumber,
+	FactDocumentIssues:     kindList,
+	FactDocumentsFailed:    kindNumber,
+	FactDuplicateFlags:     kindNumber,
+	FactDuplicateSignals:   kindList,
+	FactAccountAgeDays:     kindNumber,
+}
+
+// operators lists the operators allowed for each kind of fact
+var operators = map[kind][]string{
+	kindNumber: {"lt", "lte", "gt", "gte", "eq", "ne"},
+	kindText:   {"eq", "ne", "in", "not_in"},
+	kindList:   {"contains", "not_contains"},
+}
+
+var (
+	ErrInvalidRules = errors.New("risk rules are not valid")
+
+	mutex   sync.RWMutex
+	current = &RuleSet{Tiers: Tiers{Medium: 30, High: 60}}
+)
+
+// Rule adds Weight to the score when Fact compared with Value by Operator
+// holds. Value is a number for number facts, a string for eq, ne and the list
+// operators, and an array of strings for in and not_in.
+type Rule struct {
+	ID          string      `json:"id"`
+	Description string      `json:"description"`
+	Fact        string      `json:"fact"`
+	Operator    string      `json:"operator"`
+	Value       interface{} `json:"value"`
+	Weight      float64     `json:"weight"`
+
+	number float64
+	text   string
+	texts  []string
+}
+
+// Tiers are the lowest scores of the medium and high tiers
+type Tiers struct {
+	Medium float64 `json:"medium"`
+	High   float64 `json:"high"`
+}
+
+type RuleSet struct {
+	Version  string `json:"version"`
+	Tiers    Tiers  `json:"tiers"`
+	Rules    []Rule `json:"rules"`
+	Checksum string `json:"checksum"`
+}
+
+// Parse reads and validates a rule set in JSON
+func Parse(r io.Reader) (*RuleSet, error) {
+	content, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var set RuleSet
+	if err := json.Unmarshal(content, &set); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidRules, err)
+	}
+	if set.Tiers.Medium > set.Tiers.High {
+		return nil, fmt.Errorf("%w: medium tier starts above high tier", ErrInvalidRules)
+	}
+
+	seen := map[string]bool{}
+	for i := range set.Rules {
+		rule := &set.Rules[i]
+		if rule.ID == "" || seen[rule.ID] {
+			return nil, fmt.Errorf("%w: rule %d needs a unique id", ErrInvalidRules, i+1)
+		}
+		seen[rule.ID] = true
+		if err := rule.compile(); err != nil {
+			return nil, fmt.Errorf("%w: rule %s: %w", ErrInvalidRules, rule.ID, err)
+		}
+	}
+
+	sum := sha256.Sum256(content)
+	set.Checksum = hex.EncodeToString(sum[:])
+	return &set, nil
+}
+
+// LoadFile reads a rule set from path and makes it the one Current returns
+func LoadFile(path string) (*RuleSet, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	set, err := Parse(file)
+	if err != nil {
+		return nil, err
+	}
+	SetCurrent(set)
+	return set, nil
+}
+
+// RefreshFile makes the rule set in path the one Current returns when its
+// checksum differs from the rule set in use. Replicas call it before scoring
+// so an edited file is picked up everywhere without a restart; the rules in
+// use are kept when the file cannot be read or is not valid.
+func RefreshFile(path string) (*RuleSet, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return Current(), err
+	}
+	sum := sha256.Sum256(content)
+	if current := Current(); current.Checksum == hex.EncodeToString(sum[:]) {
+		return current, nil
+	}
+
+	set, err := Parse(bytes.NewReader(content))
+	if err != nil {
+		return Current(), err
+	}
+	SetCurrent(set)
+	return set, nil
+}
+
+// Current returns the rule set in use. Until one is loaded it has no rules
+// and scores every applicant 0.
+func Current() *RuleSet {
+	mutex.RLock()
+	defer mutex.RUnlock()
+	return current
+}
+
+func SetCurrent(set *RuleSet) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	current = set
+}
+
+func (rule *Rule) compile() error {
+	factKind, ok := factKinds[rule.Fact]
+	if !ok {
+		return fmt.Errorf("unknown fact %q", rule.Fact)
+	}
+	allowed := false
+	for _, operator := range operators[factKind] {
+		allowed = allowed || operator == rule.Operator
+	}
+	if !allowed {
+		return fmt.Errorf("operator %q cannot be used with %s", rule.Operator, rule.Fact)
+	}
+
+	switch value := rule.Value.(type) {
+	case float64:
+		if factKind == kindNumber {
+			rule.number = value
+			return nil
+		}
+	case string:
+		if factKind != kindNumber && rule.Operator != "in" && rule.Operator != "not_in" {
+			rule.text = value
+			return nil
+		}
+	case []interface{}:
+		if rule.Operator == "in" || rule.Operator == "not_in" {
+			for _, item := range value {
+				text, ok := item.(string)
+				if !ok {
+					return errors.New("value must be an array of strings")
+				}
+				rule.texts = append(rule.texts, text)
+			}
+			return nil
+		}
+	}
+	return fmt.Errorf("value %v does not fit operator %q", rule.Value, rule.Operator)
+}
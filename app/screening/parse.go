@@ -0,0 +1,91 @@
+package screening
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+var ErrInvalidList = errors.New("watchlist file is not valid")
+
+// csvColumns are the columns a CSV list may have; only name is required.
+// Aliases, birth_dates and nationalities hold several values separated by
+// semicolons.
+var csvColumns = []string{"id", "name", "aliases", "birth_dates", "nationalities", "remarks"}
+
+// ParseCSV reads a list with a header row naming its columns
+func ParseCSV(r io.Reader) ([]Entry, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidList, err)
+	}
+	columns := map[string]int{}
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))] = i
+	}
+	if _, ok := columns["name"]; !ok {
+		return nil, fmt.Errorf("%w: missing name column, expected %s", ErrInvalidList, strings.Join(csvColumns, ","))
+	}
+
+	var entries []Entry
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return entries, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidList, err)
+		}
+
+		field := func(column string) string {
+			if i, ok := columns[column]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+		entry := Entry{
+			ExternalID:    field("id"),
+			Name:          field("name"),
+			Aliases:       splitValues(field("aliases")),
+			BirthDates:    splitValues(field("birth_dates")),
+			Nationalities: splitValues(field("nationalities")),
+			Remarks:       field("remarks"),
+		}
+		if entry.Name == "" {
+			return nil, fmt.Errorf("%w: line %d has no name", ErrInvalidList, line)
+		}
+		entries = append(entries, entry)
+	}
+}
+
+// ParseJSON reads a list given as an array of entries
+func ParseJSON(r io.Reader) ([]Entry, error) {
+	var entries []Entry
+	if err := json.NewDecoder(r).Decode(&entries); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidList, err)
+	}
+	for i := range entries {
+		entries[i].Name = strings.TrimSpace(entries[i].Name)
+		if entries[i].Name == "" {
+			return nil, fmt.Errorf("%w: entry %d has no name", ErrInvalidList, i+1)
+		}
+	}
+	return entries, nil
+}
+
+func splitValues(value string) []string {
+	var values []string
+	for _, part := range strings.Split(value, ";") {
+		if part = strings.TrimSpace(part); part != "" {
+			values = append(values, part)
+		}
+	}
+	return values
+}
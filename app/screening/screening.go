@@ -0,0 +1,147 @@
+// Package screening matches people against sanctions and PEP watchlists.
+// Names and aliases are compared after normalisation and transliteration
+// with fuzzy scoring; date of birth and nationality, when both sides have
+// them, rule out entries that cannot be the same person.
+package screening
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang_starter_kit_2025/app/matching"
+)
+
+// Entry is one listed person. BirthDates hold full dates (2006-01-02) or
+// only the year (2006) when the list does not know more; Nationalities hold
+// ISO 3166 alpha-2 country codes.
+type Entry struct {
+	ID            uint     `json:"-"`
+	ExternalID    string   `json:"id"`
+	Name          string   `json:"name"`
+	Aliases       []string `json:"aliases"`
+	BirthDates    []string `json:"birth_dates"`
+	Nationalities []string `json:"nationalities"`
+	Remarks       string   `json:"remarks"`
+}
+
+// Subject is the person being screened
+type Subject struct {
+	Name        string
+	BirthDate   *time.Time
+	Nationality string
+}
+
+type Options struct {
+	// Threshold is the minimum name similarity, between 0 and 1, of a hit
+	Threshold float64
+}
+
+var DefaultOptions = Options{Threshold: 0.88}
+
+// Hit is an entry whose name or one of whose aliases matched the subject
+type Hit struct {
+	Entry       Entry
+	MatchedName string
+	Score       float64
+}
+
+type indexedEntry struct {
+	entry Entry
+	names []string
+	raw   []string
+}
+
+// Index holds entries with their names normalised once, to screen many
+// subjects against the same lists
+type Index struct {
+	entries []indexedEntry
+}
+
+func NewIndex(entries []Entry) *Index {
+	index := &Index{entries: make([]indexedEntry, 0, len(entries))}
+	for _, entry := range entries {
+		indexed := indexedEntry{entry: entry}
+		for _, name := range append([]string{entry.Name}, entry.Aliases...) {
+			if normalized := matching.NormalizeName(name); normalized != "" {
+				indexed.names = append(indexed.names, normalized)
+				indexed.raw = append(indexed.raw, name)
+			}
+		}
+		index.entries = append(index.entries, indexed)
+	}
+	return index
+}
+
+// Len returns the number of entries in the index
+func (index *Index) Len() int {
+	return len(index.entries)
+}
+
+// Screen returns the entries matching subject, best score first
+func (index *Index) Screen(subject Subject, opts Options) []Hit {
+	name := matching.NormalizeName(subject.Name)
+	if name == "" {
+		return nil
+	}
+
+	var hits []Hit
+	for _, indexed := range index.entries {
+		if !birthDateMatches(indexed.entry.BirthDates, subject.BirthDate) ||
+			!nationalityMatches(indexed.entry.Nationalities, subject.Nationality) {
+			continue
+		}
+
+		best := Hit{Entry: indexed.entry}
+		for i, candidate := range indexed.names {
+			if score := matching.NormalizedSimilarity(name, candidate); score > best.Score {
+				best.Score = score
+				best.MatchedName = indexed.raw[i]
+			}
+		}
+		if best.Score >= opts.Threshold {
+			hits = append(hits, best)
+		}
+	}
+
+	sort.SliceStable(hits, func(i, j int) bool { return hits[i].Score > hits[j].Score })
+	return hits
+}
+
+// birthDateMatches is true when either side has no date of birth or any
+// listed date agrees with the subject's, by year for year-only dates
+func birthDateMatches(listed []string, birthDate *time.Time) bool {
+	if len(listed) == 0 || birthDate == nil {
+		return true
+	}
+
+	for _, value := range listed {
+		value = strings.TrimSpace(value)
+		if date, err := time.Parse("2006-01-02", value); err == nil {
+			if date.Year() == birthDate.Year() && date.YearDay() == birthDate.YearDay() {
+				return true
+			}
+			continue
+		}
+		if year, err := strconv.Atoi(value); err == nil && year == birthDate.Year() {
+			return true
+		}
+	}
+	return false
+}
+
+// nationalityMatches is true when either side has no nationality or the
+// subject's is listed
+func nationalityMatches(listed []string, nationality string) bool {
+	if len(listed) == 0 || nationality == "" {
+		return true
+	}
+
+	for _, value := range listed {
+		if strings.EqualFold(strings.TrimSpace(value), nationality) {
+			return true
+		}
+	}
+	return false
+}
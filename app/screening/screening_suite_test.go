@@ -0,0 +1,13 @@
+package screening_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestScreeningSuite(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Screening Test Suite")
+}
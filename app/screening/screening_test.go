@@ -0,0 +1,80 @@
+package screening_test
+
+import (
+	"strings"
+	"time"
+
+	"golang_starter_kit_2025/app/screening"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Index", func() {
+	index := screening.NewIndex([]screening.Entry{
+		{ExternalID: "1", Name: "Usama bin Muhammad bin Awad bin Ladin", Aliases: []string{"Osama bin Laden"}, BirthDates: []string{"1957"}, Nationalities: []string{"SA"}},
+		{ExternalID: "2", Name: "Владимир Иванов", BirthDates: []string{"1970-03-01"}},
+		{ExternalID: "3", Name: "Budi Santoso", Nationalities: []string{"ID"}},
+	})
+	date := func(value string) *time.Time {
+		t, _ := time.Parse("2006-01-02", value)
+		return &t
+	}
+
+	It("should match aliases and return the name that matched", func() {
+		hits := index.Screen(screening.Subject{Name: "OSAMA BIN LADEN"}, screening.DefaultOptions)
+		Expect(hits).To(HaveLen(1))
+		Expect(hits[0].Entry.ExternalID).To(Equal("1"))
+		Expect(hits[0].MatchedName).To(Equal("Osama bin Laden"))
+	})
+
+	It("should match transliterated names", func() {
+		hits := index.Screen(screening.Subject{Name: "Vladimir Ivanov", BirthDate: date("1970-03-01")}, screening.DefaultOptions)
+		Expect(hits).To(HaveLen(1))
+		Expect(hits[0].Entry.ExternalID).To(Equal("2"))
+	})
+
+	It("should rule out entries by date of birth and nationality", func() {
+		Expect(index.Screen(screening.Subject{Name: "Vladimir Ivanov", BirthDate: date("1971-03-01")}, screening.DefaultOptions)).To(BeEmpty())
+		Expect(index.Screen(screening.Subject{Name: "Osama bin Laden", BirthDate: date("1958-01-01")}, screening.DefaultOptions)).To(BeEmpty())
+		Expect(index.Screen(screening.Subject{Name: "Osama bin Laden", Nationality: "ID"}, screening.DefaultOptions)).To(BeEmpty())
+		Expect(index.Screen(screening.Subject{Name: "Budi Santoso", Nationality: "ID"}, screening.DefaultOptions)).To(HaveLen(1))
+	})
+
+	It("should respect the threshold", func() {
+		Expect(index.Screen(screening.Subject{Name: "Budi Santosa"}, screening.Options{Threshold: 0.9})).To(HaveLen(1))
+		Expect(index.Screen(screening.Subject{Name: "Budi Santosa"}, screening.Options{Threshold: 0.99})).To(BeEmpty())
+		Expect(index.Screen(screening.Subject{Name: "Siti Aminah"}, screening.DefaultOptions)).To(BeEmpty())
+	})
+})
+
+var _ = Describe("ParseCSV", func() {
+	It("should read columns by header and split multiple values", func() {
+		entries, err := screening.ParseCSV(strings.NewReader("id,name,aliases,birth_dates,nationalities\n" +
+			"A1,John Doe,Johnny Doe; J. Doe,1970-01-01;1971,US;GB\n" +
+			"A2,Jane Roe,,,\n"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(entries).To(HaveLen(2))
+		Expect(entries[0].Aliases).To(Equal([]string{"Johnny Doe", "J. Doe"}))
+		Expect(entries[0].BirthDates).To(Equal([]string{"1970-01-01", "1971"}))
+		Expect(entries[0].Nationalities).To(Equal([]string{"US", "GB"}))
+		Expect(entries[1].Aliases).To(BeEmpty())
+	})
+
+	It("should refuse lists without names", func() {
+		_, err := screening.ParseCSV(strings.NewReader("id,alias\n1,x\n"))
+		Expect(err).To(MatchError(screening.ErrInvalidList))
+		_, err = screening.ParseCSV(strings.NewReader("id,name\n1,\n"))
+		Expect(err).To(MatchError(screening.ErrInvalidList))
+	})
+})
+
+var _ = Describe("ParseJSON", func() {
+	It("should read an array of entries", func() {
+		entries, err := screening.ParseJSON(strings.NewReader(`[{"id":"1","name":"John Doe","aliases":["JD"],"nationalities":["US"]}]`))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(entries).To(HaveLen(1))
+		Expect(entries[0].ExternalID).To(Equal("1"))
+		Expect(entries[0].Aliases).To(Equal([]string{"JD"}))
+	})
+})
@@ -0,0 +1,159 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	"golang_starter_kit_2025/app/helpers"
+	"golang_starter_kit_2025/app/models"
+	"golang_starter_kit_2025/app/models/scopes"
+	"golang_starter_kit_2025/facades"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrAccessRequestNotPending = errors.New("access request has already been reviewed")
+	ErrAccessRequestSelfReview = errors.New("access request cannot be reviewed by its requester")
+	ErrAccessRequestDuration   = errors.New("requested duration exceeds the allowed maximum")
+	ErrRoleAlreadyGranted      = errors.New("role is already granted permanently")
+	ErrRoleGrantScheduled      = errors.New("role is already scheduled to be granted")
+)
+
+type AccessRequestService struct{}
+
+// Create records a request from a user for temporary access to a role
+func (*AccessRequestService) Create(userId, roleId uint, justification string, durationMinutes int) (models.AccessRequest, error) {
+	request := models.AccessRequest{
+		UserID:          userId,
+		RoleID:          roleId,
+		Justification:   justification,
+		DurationMinutes: durationMinutes,
+		Status:          models.AccessRequestPending,
+	}
+
+	if durationMinutes > helpers.GetEnvInt("ACCESS_REQUEST_MAX_MINUTES", 1440) {
+		return request, ErrAccessRequestDuration
+	}
+	if err := validateIds(facades.DB, &models.Role{}, []uint{roleId}, ErrInvalidRoleIds); err != nil {
+		return request, err
+	}
+
+	var permanent int64
+	if err := facades.DB.Model(&models.UserHasRole{}).
+		Where("user_id = ? AND role_id = ? AND expires_at IS NULL", userId, roleId).
+		Scopes(scopes.ActiveRoleGrant(time.Now())).
+		Count(&permanent).Error; err != nil {
+		return request, err
+	}
+	if permanent > 0 {
+		return request, ErrRoleAlreadyGranted
+	}
+
+	if err := facades.DB.Create(&request).Error; err != nil {
+		return request, err
+	}
+	return request, nil
+}
+
+// GetByUser lists the access requests made by a user, newest first
+func (*AccessRequestService) GetByUser(userId uint) ([]models.AccessRequest, error) {
+	var requests []models.AccessRequest
+	if err := facades.DB.Preload("Role").Where("user_id = ?", userId).Order("id DESC").Find(&requests).Error; err != nil {
+		return nil, err
+	}
+	return requests, nil
+}
+
+// GetAll lists access requests, optionally filtered by status, newest first
+func (*AccessRequestService) GetAll(status string) ([]models.AccessRequest, error) {
+	var requests []models.AccessRequest
+	query := facades.DB.Preload("User").Preload("Role").Order("id DESC")
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if err := query.Find(&requests).Error; err != nil {
+		return nil, err
+	}
+	return requests, nil
+}
+
+// Approve grants the requested role for the requested duration starting now
+func (*AccessRequestService) Approve(id string, reviewerId uint, note string) (models.AccessRequest, error) {
+	var request models.AccessRequest
+	err := facades.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if request, err = lockPendingAccessRequest(tx, id, reviewerId); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		expiresAt := now.Add(time.Duration(request.DurationMinutes) * time.Minute)
+
+		var grant models.UserHasRole
+		err = tx.Where("user_id = ? AND role_id = ?", request.UserID, request.RoleID).First(&grant).Error
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			if err := setUserRoleGrant(tx, request.UserID, request.RoleID, &now, &expiresAt); err != nil {
+				return err
+			}
+		case err != nil:
+			return err
+		case grant.StartsAt != nil && grant.StartsAt.After(now):
+			// a window starting now would replace the scheduled grant
+			return ErrRoleGrantScheduled
+		case grant.ExpiresAt == nil:
+			return ErrRoleAlreadyGranted
+		case grant.ExpiresAt.After(expiresAt):
+			// an active grant already outlives the request
+			expiresAt = *grant.ExpiresAt
+		default:
+			if err := setUserRoleGrant(tx, request.UserID, request.RoleID, &now, &expiresAt); err != nil {
+				return err
+			}
+		}
+
+		request.Status = models.AccessRequestApproved
+		request.ReviewerID = &reviewerId
+		request.ReviewNote = note
+		request.ReviewedAt = &now
+		request.GrantStartsAt = &now
+		request.GrantExpiresAt = &expiresAt
+		return tx.Save(&request).Error
+	})
+	return request, err
+}
+
+// Deny closes a pending access request without granting anything
+func (*AccessRequestService) Deny(id string, reviewerId uint, note string) (models.AccessRequest, error) {
+	var request models.AccessRequest
+	err := facades.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if request, err = lockPendingAccessRequest(tx, id, reviewerId); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		request.Status = models.AccessRequestDenied
+		request.ReviewerID = &reviewerId
+		request.ReviewNote = note
+		request.ReviewedAt = &now
+		return tx.Save(&request).Error
+	})
+	return request, err
+}
+
+func lockPendingAccessRequest(tx *gorm.DB, id string, reviewerId uint) (models.AccessRequest, error) {
+	var request models.AccessRequest
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&request, id).Error; err != nil {
+		return request, err
+	}
+	if request.Status != models.AccessRequestPending {
+		return request, ErrAccessRequestNotPending
+	}
+	if request.UserID == reviewerId {
+		return request, ErrAccessRequestSelfReview
+	}
+	return request, nil
+}
@@ -3,6 +3,7 @@ package services
 
 import (
 	"errors"
+	"fmt"
 	"strings"
 	"time"
 
@@ -14,15 +15,47 @@ import (
 
 	"github.com/golang-jwt/jwt/v5"
 	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrAccountInactive = errors.New("Akun tidak aktif")
+	ErrUserNotFound    = errors.New("user not found")
 )
 
 type AuthService struct {
 	jwt *JwtService
 }
 
+// EnsureActive rejects users whose account status does not allow signing in
+func (*AuthService) EnsureActive(user models.User) error {
+	if user.Status != "" && user.Status != models.UserStatusActive {
+		return fmt.Errorf("%w: %s", ErrAccountInactive, user.Status)
+	}
+	return nil
+}
+
+// EnsureActiveById loads the user and checks its account status, so that a
+// status change takes effect for tokens that were issued before it
+func (auth *AuthService) EnsureActiveById(userId uint) error {
+	var user models.User
+	err := facades.DB.Select("id", "status").First(&user, userId).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrUserNotFound
+	}
+	if err != nil {
+		return err
+	}
+	return auth.EnsureActive(user)
+}
+
 func (auth *AuthService) Login(request requests.LoginRequest) (*casts.Token, error) {
 	var user models.User
-	if err := facades.DB.Where("email = ?", request.Email).First(&user).Error; err != nil {
+	emailIndex, err := models.EmailIndex(request.Email)
+	if err != nil {
+		return nil, err
+	}
+	if err := facades.DB.Where("email_bidx = ?", emailIndex).First(&user).Error; err != nil {
 		return nil, errors.New("Email atau password salah")
 	}
 
@@ -37,6 +70,10 @@ func (auth *AuthService) Login(request requests.LoginRequest) (*casts.Token, err
 		return nil, errors.New("Email atau password salah")
 	}
 
+	if err := auth.EnsureActive(user); err != nil {
+		return nil, err
+	}
+
 	// NOW: user can login multiple times
 	// if user.JwtToken != "" {
 	// 	return "", errors.New("Logout terlebih dahulu")
@@ -109,6 +146,10 @@ func (auth *AuthService) RefreshToken(tokenString string) (*casts.Token, error)
 		return nil, errors.New("user not found")
 	}
 
+	if err := auth.EnsureActive(user); err != nil {
+		return nil, err
+	}
+
 	// Generate JWT token
 	expires := helpers.GetEnvInt("JWT_EXPIRE_MINUTES", 60)
 	expireAt := time.Now().Add(time.Minute * time.Duration(expires)).Unix()
@@ -0,0 +1,175 @@
+package services
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	"golang_starter_kit_2025/app/encryption"
+	"golang_starter_kit_2025/app/models"
+	"golang_starter_kit_2025/facades"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// encryptedModels lists the models with `serializer:encrypted` columns that
+// crypto:rotate walks through
+var encryptedModels = []interface{}{
+	&models.User{},
+	&models.KycSubmission{},
+}
+
+// CryptoRotateReport counts the rows of one table checked and re-encrypted
+type CryptoRotateReport struct {
+	Table   string
+	Scanned int
+	Rotated int
+}
+
+type CryptoService struct{}
+
+// Rotate re-encrypts every encrypted column that is still plaintext or was
+// encrypted under a key version other than the active one, and refreshes the
+// blind indexes of the rows it touches. Rows are handled in batches of
+// batchSize, each locked only for the duration of its own transaction, so
+// the application keeps running while it works. Rows whose blind indexes
+// are still NULL, because the column was added after they were written, are
+// rewritten too; with reindex every row is, which is needed after a change
+// to how an index is normalised.
+// progress, when not nil, is called after every batch.
+func (*CryptoService) Rotate(batchSize int, reindex bool, progress func(CryptoRotateReport)) ([]CryptoRotateReport, error) {
+	ring, err := encryption.Default()
+	if err != nil {
+		return nil, err
+	}
+	if batchSize <= 0 {
+		batchSize = 500
+	}
+
+	var reports []CryptoRotateReport
+	for _, model := range encryptedModels {
+		report, err := rotateModel(ring, model, batchSize, reindex, progress)
+		reports = append(reports, report)
+		if err != nil {
+			return reports, err
+		}
+	}
+	return reports, nil
+}
+
+func rotateModel(ring *encryption.KeyRing, model interface{}, batchSize int, reindex bool, progress func(CryptoRotateReport)) (CryptoRotateReport, error) {
+	modelSchema, err := schema.Parse(model, &sync.Map{}, facades.DB.NamingStrategy)
+	if err != nil {
+		return CryptoRotateReport{}, err
+	}
+	report := CryptoRotateReport{Table: modelSchema.Table}
+
+	pk := modelSchema.PrioritizedPrimaryField.DBName
+	var encrypted, columns, filled []string
+	for _, field := range modelSchema.Fields {
+		switch {
+		case field.TagSettings["SERIALIZER"] == "encrypted":
+			encrypted = append(encrypted, field.DBName)
+			columns = append(columns, field.DBName)
+		case strings.HasSuffix(field.DBName, "_bidx"):
+			columns = append(columns, field.DBName)
+			// a blind index that is not a pointer is written on every save,
+			// so NULL means the row is older than the column
+			if field.FieldType.Kind() != reflect.Ptr {
+				filled = append(filled, field.DBName)
+			}
+		}
+	}
+
+	var last interface{} = 0
+	for {
+		// read the raw column values, bypassing the serializer
+		var rows []map[string]interface{}
+		if err := facades.DB.Table(modelSchema.Table).
+			Select(append(append([]string{pk}, encrypted...), filled...)).
+			Where(pk+" > ?", last).
+			Order(pk).
+			Limit(batchSize).
+			Find(&rows).Error; err != nil {
+			return report, err
+		}
+		if len(rows) == 0 {
+			return report, nil
+		}
+		last = rows[len(rows)-1][pk]
+		report.Scanned += len(rows)
+
+		var ids []interface{}
+		for _, row := range rows {
+			if reindex || missingBlindIndex(row, filled) {
+				ids = append(ids, row[pk])
+				continue
+			}
+			for _, column := range encrypted {
+				if value := rawString(row[column]); value != "" && ring.NeedsRotation(value) {
+					ids = append(ids, row[pk])
+					break
+				}
+			}
+		}
+
+		if len(ids) > 0 {
+			if err := facades.DB.Transaction(func(tx *gorm.DB) error {
+				records := reflect.New(reflect.SliceOf(modelSchema.ModelType))
+				if err := tx.Unscoped().Clauses(clause.Locking{Strength: "UPDATE"}).
+					Where(pk+" IN ?", ids).
+					Find(records.Interface()).Error; err != nil {
+					return err
+				}
+
+				for i := 0; i < records.Elem().Len(); i++ {
+					record := records.Elem().Index(i).Addr().Interface()
+					if setter, ok := record.(models.BlindIndexSetter); ok {
+						if err := setter.SetBlindIndexes(); err != nil {
+							return err
+						}
+					}
+					if err := tx.Unscoped().Model(record).Select(columns).UpdateColumns(record).Error; err != nil {
+						return err
+					}
+				}
+				return nil
+			}); err != nil {
+				return report, fmt.Errorf("%s: %w", modelSchema.Table, err)
+			}
+			report.Rotated += len(ids)
+		}
+
+		if progress != nil {
+			progress(report)
+		}
+	}
+}
+
+// missingBlindIndex reports whether any of the blind index columns of row is
+// NULL
+func missingBlindIndex(row map[string]interface{}, columns []string) bool {
+	for _, column := range columns {
+		if row[column] == nil {
+			return true
+		}
+	}
+	return false
+}
+
+func rawString(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	case nil:
+		return ""
+	default:
+		// plaintext DATE columns and the like are scanned as other types
+		return fmt.Sprint(v)
+	}
+}
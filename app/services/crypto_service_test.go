@@ -0,0 +1,53 @@
+package services_test
+
+import (
+	"golang_starter_kit_2025/app/models"
+	"golang_starter_kit_2025/app/services"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"gorm.io/gorm"
+)
+
+var _ = Describe("CryptoService", func() {
+	var db *gorm.DB
+
+	BeforeEach(func() {
+		db = useSQLite(&models.User{}, &models.KycSubmission{})
+	})
+
+	rotated := func(table string) int {
+		reports, err := (&services.CryptoService{}).Rotate(100, false, nil)
+		Expect(err).NotTo(HaveOccurred())
+		for _, report := range reports {
+			if report.Table == table {
+				return report.Rotated
+			}
+		}
+		Fail("no report for " + table)
+		return 0
+	}
+
+	It("should fill blind indexes added after the rows were written", func() {
+		user := models.User{Username: "andi", Email: "Andi.Wijaya+kyc@gmail.com"}
+		Expect(db.Create(&user).Error).To(Succeed())
+		Expect(db.Exec("UPDATE users SET email_canonical_bidx = NULL").Error).To(Succeed())
+
+		Expect(rotated("users")).To(Equal(1))
+
+		var stored models.User
+		Expect(db.First(&stored, user.ID).Error).To(Succeed())
+		expected, err := models.CanonicalEmailIndex("andiwijaya@gmail.com")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(stored.EmailCanonicalBidx).To(Equal(expected))
+	})
+
+	It("should leave rows alone once their blind indexes are filled", func() {
+		// a user without NIK keeps a NULL nik_bidx, which is not missing
+		Expect(db.Create(&models.User{Username: "budi", Email: "budi@example.com"}).Error).To(Succeed())
+		Expect(db.Create(&models.KycSubmission{UserID: 1, Status: models.KycStatusDraft}).Error).To(Succeed())
+
+		Expect(rotated("users")).To(Equal(0))
+		Expect(rotated("kyc_submissions")).To(Equal(0))
+	})
+})
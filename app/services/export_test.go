@@ -0,0 +1,7 @@
+package services
+
+// AuthorizeFile exposes authorizeFile to the tests
+var AuthorizeFile = authorizeFile
+
+// VariantDirectory exposes variantDirectory to the tests
+var VariantDirectory = variantDirectory
@@ -1,38 +1,843 @@
 package services
 
 import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log"
 	"path/filepath"
+	"strings"
+	"time"
 
+	"golang_starter_kit_2025/app/encryption"
 	"golang_starter_kit_2025/app/helpers"
+	"golang_starter_kit_2025/app/imagevariant"
+	"golang_starter_kit_2025/app/models"
+	"golang_starter_kit_2025/app/permissions"
+	"golang_starter_kit_2025/app/signedurl"
+	"golang_starter_kit_2025/app/storage"
+	"golang_starter_kit_2025/config"
+	"golang_starter_kit_2025/facades"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+var (
+	ErrFileNotFound        = errors.New("file not found")
+	ErrFileUnauthenticated = errors.New("file needs a signed URL or a token")
+	ErrFileForbidden       = errors.New("file may not be read by this user")
+)
+
+// filePolicy is where the files of a purpose are stored and who may read
+// them
+type filePolicy struct {
+	directory  string
+	access     models.FileAccess
+	permission string
+	sensitive  bool
+}
+
+// filePolicies are the policies of the files of each purpose
+var filePolicies = map[models.UploadPurpose]filePolicy{
+	models.UploadPurposeKycDocument: {
+		directory:  KycDocumentKey,
+		access:     models.FileAccessOwner,
+		permission: permissions.KycView,
+		sensitive:  true,
+	},
+	models.UploadPurposeStoreDocument: {
+		directory:  StoreDocumentKey,
+		access:     models.FileAccessOwner,
+		permission: permissions.StoreView,
+		sensitive:  true,
+	},
+}
+
+// FileRequest is a request to read a stored file
+type FileRequest struct {
+	// UserID is the authenticated user, 0 for anonymous requests
+	UserID uint
+	// Signed is set when the URL signature has been verified
+	Signed bool
+	// Public is set when the file is requested through the public route,
+	// which only serves public files
+	Public    bool
+	IP        string
+	UserAgent string
+	// Variant is the query of the requested image variant, if any
+	Variant string
+}
+
+// DocumentThumbnail is the variant document images are listed with
+var DocumentThumbnail = imagevariant.Options{Width: 320, Height: 320, Format: imagevariant.FormatJPEG, Quality: 75}
+
+// FileReference is a column pointing to files.id
+type FileReference struct {
+	Table  string
+	Column string
+}
+
+// FileReferences lists every column pointing to files.id. Files no column
+// points to are deleted by CollectGarbage, so a model that starts
+// referencing files must be added here.
+var FileReferences = []FileReference{
+	{Table: "kyc_documents", Column: "file_id"},
+	{Table: "store_documents", Column: "file_id"},
+}
+
+// FileGCReport counts what CollectGarbage did
+type FileGCReport struct {
+	// Unreferenced files found for the first time
+	Marked int
+	// Files deleted after being unreferenced for the grace period
+	Deleted int
+	// Stored objects removed together with the deleted files
+	Removed int
+	// Files whose stored object is missing
+	Missing int
+}
+
+// FileIndexReport counts the documents moved to the files table by
+// IndexDocuments
+type FileIndexReport struct {
+	Indexed int
+	Missing int
+}
+
+// FileEncryptReport counts the files handled by EncryptExisting
+type FileEncryptReport struct {
+	Encrypted int
+	Rewrapped int
+	Skipped   int
+}
+
 type FileService struct{}
 
-func (service FileService) UploadFile(ctx *gin.Context, key string, path string) (*string, error) {
+// UploadFile runs an uploaded multipart file through the upload pipeline of
+// purpose and stores it encrypted at rest. The extension of the stored file
+// follows the sniffed content type, not the name sent by the client.
+func (service FileService) UploadFile(ctx *gin.Context, userId uint, purpose models.UploadPurpose, key string) (models.File, error) {
 	file, err := ctx.FormFile(key)
+	if err != nil {
+		return models.File{}, err
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		return models.File{}, err
+	}
+	defer src.Close()
+
+	// read one byte past the limit so the pipeline sees the file is too large
+	content, err := io.ReadAll(io.LimitReader(src, UploadPurposes[purpose].MaxSize+1))
+	if err != nil {
+		return models.File{}, err
+	}
+
+	return service.storeUpload(userId, purpose, UploadInput{
+		Content:      content,
+		Name:         file.Filename,
+		DeclaredType: file.Header.Get("Content-Type"),
+	})
+}
+
+// StoreBase64File runs a base64 file or data URI through the upload pipeline
+// of purpose and stores it encrypted at rest
+func (service FileService) StoreBase64File(userId uint, purpose models.UploadPurpose, base64 string) (models.File, error) {
+	content, mediaType, err := helpers.DecodeDataURI(base64)
+	if err != nil {
+		return models.File{}, err
+	}
+
+	return service.storeUpload(userId, purpose, UploadInput{Content: content, DeclaredType: mediaType})
+}
+
+func (service FileService) storeUpload(userId uint, purpose models.UploadPurpose, input UploadInput) (models.File, error) {
+	processed, err := (&UploadService{}).Process(userId, purpose, input)
+	if err != nil {
+		return models.File{}, err
+	}
+
+	return service.Store(userId, purpose, input.Name, processed)
+}
+
+// Store saves a file that passed the upload pipeline encrypted at rest and
+// records it in the files table. Content already stored with the same
+// checksum is not written again; the new row points to the existing object.
+func (service FileService) Store(ownerId uint, purpose models.UploadPurpose, name string, upload ProcessedUpload) (models.File, error) {
+	sum := sha256.Sum256(upload.Content)
+	policy := policyOf(purpose)
+	file := models.File{
+		Reference:  helpers.GenerateReference("FIL"),
+		Purpose:    purpose,
+		MimeType:   upload.MimeType,
+		Size:       int64(len(upload.Content)),
+		SHA256:     hex.EncodeToString(sum[:]),
+		Disk:       config.GetStorageConfigs().Default,
+		Access:     policy.access,
+		Permission: policy.permission,
+		Sensitive:  policy.sensitive,
+	}
+	if name != "" {
+		file.OriginalName = truncate(filepath.Base(name), 255)
+	}
+	if ownerId != 0 {
+		file.OwnerID = &ownerId
+	}
+
+	var existing models.File
+	err := facades.DB.Where("sha256 = ? AND size = ? AND disk = ? AND missing_at IS NULL", file.SHA256, file.Size, file.Disk).
+		Order("id ASC").First(&existing).Error
+	switch {
+	case err == nil:
+		file.Path = existing.Path
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		directory := policy.directory
+		if directory == "" {
+			directory = string(purpose)
+		}
+		file.Path = directory + "/" + file.Reference + helpers.ExtensionByContent(upload.Content)
+		if err := writeEncryptedFile(file.Path, bytes.NewReader(upload.Content)); err != nil {
+			return models.File{}, err
+		}
+	default:
+		return models.File{}, err
+	}
+
+	if err := facades.DB.Create(&file).Error; err != nil {
+		if existing.ID == 0 {
+			service.Delete(file.Path)
+		}
+		return models.File{}, err
+	}
+	return file, nil
+}
+
+// Remove deletes files from the files table and removes their stored
+// objects once no other file shares them
+func (service FileService) Remove(ids ...uint) error {
+	var removed []models.File
+	err := facades.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		removed, err = deleteFileRecords(tx, ids)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	removeFiles(removed)
+	return nil
+}
+
+// Authorize decides whether a stored file may be read. A valid signed URL is
+// always enough; otherwise the access policy of the file is checked against
+// the authenticated user. Only paths recorded in the files table are served.
+// Reads of sensitive files are logged, including refused ones.
+func (service FileService) Authorize(path string, request FileRequest) error {
+	// identical content shares one path, so several files may match; the
+	// request is allowed if any of them allows it
+	var files []models.File
+	if err := facades.DB.Where("disk = ? AND path = ?", config.GetStorageConfigs().Default, path).
+		Order("id ASC").Find(&files).Error; err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		return ErrFileNotFound
+	}
+
+	via, err := authorizeFile(files, request, func(userId uint, permission string) (bool, error) {
+		return (&PermissionService{}).HasAnyPermission(userId, permission)
+	})
+	for _, file := range files {
+		if file.Sensitive {
+			logFileAccess(file, request, via, err == nil)
+			break
+		}
+	}
+	return err
+}
+
+// Open returns the decrypted content of a stored file and its size. Files
+// stored before encryption was introduced are returned as they are.
+func (service FileService) Open(path string) (io.ReadCloser, int64, error) {
+	disk := storage.Default()
+	info, err := disk.Stat(path)
+	if err != nil {
+		return nil, 0, err
+	}
+	file, err := disk.Get(path)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	header := make([]byte, encryption.FileHeaderSize)
+	n, _ := io.ReadFull(file, header)
+	content := io.MultiReader(bytes.NewReader(header[:n]), file)
+	if !encryption.IsEncryptedFile(header[:n]) {
+		return struct {
+			io.Reader
+			io.Closer
+		}{content, file}, info.Size, nil
+	}
+
+	ring, err := encryption.Default()
+	if err != nil {
+		file.Close()
+		return nil, 0, err
+	}
+	reader, err := ring.NewDecryptReader(content)
+	if err != nil {
+		file.Close()
+		return nil, 0, err
+	}
+	return struct {
+		io.Reader
+		io.Closer
+	}{reader, file}, encryption.PlaintextSize(info.Size), nil
+}
+
+// URL returns a signed URL that serves the stored file decrypted for
+// IMAGE_EXPIRE_MINUTES, or an empty string when it cannot be signed
+func (service FileService) URL(path string) string {
+	return signFileURL("/file/" + path)
+}
+
+// VariantURL is URL for a resized or converted version of a stored image.
+// The variant options are part of the query and so covered by the
+// signature; clients cannot ask for other variants with it.
+func (service FileService) VariantURL(path string, variant imagevariant.Options) string {
+	if variant.IsZero() {
+		return service.URL(path)
+	}
+	return signFileURL("/file/" + path + "?" + variant.Values().Encode())
+}
+
+// Variant returns a variant of a stored image and its content type. Variants
+// are rendered once and kept encrypted on the default disk next to each
+// other under variants/, keyed by the options.
+func (service FileService) Variant(path string, variant imagevariant.Options) ([]byte, string, error) {
+	format := variant.Format
+	if format == "" {
+		format = imagevariant.FormatPNG
+		if ext := strings.ToLower(filepath.Ext(path)); ext == ".jpg" || ext == ".jpeg" {
+			format = imagevariant.FormatJPEG
+		}
+	}
+	sum := sha256.Sum256([]byte(variant.Values().Encode()))
+	cached := variantDirectory(path) + hex.EncodeToString(sum[:16]) + "." + string(format)
+
+	if content, err := readFile(cached); err == nil {
+		return content, "image/" + string(format), nil
+	} else if !errors.Is(err, storage.ErrNotExist) {
+		return nil, "", err
+	}
+
+	original, err := readFile(path)
+	if err != nil {
+		return nil, "", err
+	}
+	content, contentType, err := imagevariant.Render(original, variant)
+	if err != nil {
+		return nil, "", err
+	}
+	if err := writeEncryptedFile(cached, bytes.NewReader(content)); err != nil {
+		return nil, "", err
+	}
+	return content, contentType, nil
+}
+
+// Delete removes a stored file
+func (service FileService) Delete(path string) error {
+	return storage.Default().Delete(path)
+}
+
+// EncryptExisting encrypts every plaintext file of the default disk whose
+// path starts with prefix. Files already encrypted under an older master key
+// get their data key re-wrapped under the active one. Every file is replaced
+// as a whole, so files can be served while this runs.
+func (service FileService) EncryptExisting(prefix string, progress func(path string)) (FileEncryptReport, error) {
+	var report FileEncryptReport
+	ring, err := encryption.Default()
+	if err != nil {
+		return report, err
+	}
+
+	files, err := storage.Default().List(prefix)
+	if err != nil {
+		return report, err
+	}
+	for _, file := range files {
+		header, err := readHeader(file.Path)
+		if err != nil {
+			return report, err
+		}
+
+		switch version, err := encryption.FileKeyVersion(header); {
+		case err != nil:
+			if err := encryptInPlace(file.Path); err != nil {
+				return report, err
+			}
+			report.Encrypted++
+		case version != ring.ActiveVersion():
+			rewrapped, err := ring.RewrapFileHeader(header)
+			if err != nil {
+				return report, err
+			}
+			if err := replaceHeader(file.Path, rewrapped); err != nil {
+				return report, err
+			}
+			report.Rewrapped++
+		default:
+			report.Skipped++
+			continue
+		}
+
+		if progress != nil {
+			progress(file.Path)
+		}
+	}
+	return report, nil
+}
+
+// CollectGarbage deletes files that no model has referenced for at least
+// grace and flags files whose stored object has disappeared. A file is
+// counted as unreferenced from the first run that finds it so; it is kept
+// if a model points to it again in the meantime. missing is called for
+// every file whose object is gone.
+func (service FileService) CollectGarbage(now time.Time, grace time.Duration, missing func(file models.File)) (FileGCReport, error) {
+	var report FileGCReport
+
+	for _, reference := range FileReferences {
+		referenced := facades.DB.Table(reference.Table).Select(reference.Column).Where(reference.Column + " IS NOT NULL")
+		if err := facades.DB.Model(&models.File{}).
+			Where("unreferenced_at IS NOT NULL AND id IN (?)", referenced).
+			Update("unreferenced_at", nil).Error; err != nil {
+			return report, err
+		}
+	}
+	result := facades.DB.Model(&models.File{}).Scopes(unreferencedFiles).
+		Where("unreferenced_at IS NULL").
+		Update("unreferenced_at", now)
+	if result.Error != nil {
+		return report, result.Error
+	}
+	report.Marked = int(result.RowsAffected)
+
+	var removed []models.File
+	err := facades.DB.Transaction(func(tx *gorm.DB) error {
+		var ids []uint
+		if err := tx.Model(&models.File{}).Clauses(clause.Locking{Strength: "UPDATE"}).Scopes(unreferencedFiles).
+			Where("unreferenced_at <= ?", now.Add(-grace)).
+			Pluck("id", &ids).Error; err != nil {
+			return err
+		}
+		report.Deleted = len(ids)
+
+		var err error
+		removed, err = deleteFileRecords(tx, ids)
+		return err
+	})
+	if err != nil {
+		return report, err
+	}
+	report.Removed = removeFiles(removed)
+
+	var files []models.File
+	err = facades.DB.Model(&models.File{}).FindInBatches(&files, 100, func(tx *gorm.DB, batch int) error {
+		for _, file := range files {
+			exists, err := fileExists(file)
+			if err != nil {
+				return err
+			}
+
+			switch {
+			case !exists:
+				if file.MissingAt == nil {
+					file.MissingAt = &now
+					if err := facades.DB.Model(&file).Update("missing_at", now).Error; err != nil {
+						return err
+					}
+				}
+				report.Missing++
+				if missing != nil {
+					missing(file)
+				}
+			case file.MissingAt != nil:
+				if err := facades.DB.Model(&file).Update("missing_at", nil).Error; err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}).Error
+	return report, err
+}
+
+// IndexDocuments moves KYC and store documents stored before the files table
+// existed into it. The checksum, size and type are read from the stored
+// content; documents whose file is gone are indexed as missing.
+func (service FileService) IndexDocuments(progress func(path string)) (FileIndexReport, error) {
+	var report FileIndexReport
+
+	sources := []struct {
+		table   string
+		owner   *gorm.DB
+		purpose models.UploadPurpose
+	}{
+		{
+			table: "kyc_documents",
+			owner: facades.DB.Table("kyc_submissions").Select("user_id").
+				Where("kyc_submissions.id = kyc_documents.submission_id"),
+			purpose: models.UploadPurposeKycDocument,
+		},
+		{
+			table: "store_documents",
+			owner: facades.DB.Table("stores").Select("owner_id").
+				Where("stores.id = store_documents.store_id"),
+			purpose: models.UploadPurposeStoreDocument,
+		},
+	}
+	for _, source := range sources {
+		var documents []struct {
+			ID       uint
+			Key      string
+			FileName string
+			OwnerID  *uint
+		}
+		if err := facades.DB.Table(source.table).
+			Select("id, `key`, file_name, (?) AS owner_id", source.owner).
+			Where("file_id IS NULL AND file_name IS NOT NULL AND file_name <> ''").
+			Find(&documents).Error; err != nil {
+			return report, err
+		}
+
+		for _, document := range documents {
+			path := document.Key + "/" + document.FileName
+			file, err := indexStoredFile(path, source.purpose, document.OwnerID)
+			if err != nil {
+				return report, err
+			}
+			err = facades.DB.Transaction(func(tx *gorm.DB) error {
+				if err := tx.Create(&file).Error; err != nil {
+					return err
+				}
+				return tx.Table(source.table).Where("id = ?", document.ID).Update("file_id", file.ID).Error
+			})
+			if err != nil {
+				return report, err
+			}
+
+			report.Indexed++
+			if file.MissingAt != nil {
+				report.Missing++
+			}
+			if progress != nil {
+				progress(path)
+			}
+		}
+	}
+	return report, nil
+}
+
+func readHeader(path string) ([]byte, error) {
+	file, err := storage.Default().Get(path)
 	if err != nil {
 		return nil, err
 	}
+	defer file.Close()
 
-	// rename file with uuid as filename with extension
-	fileName := helpers.GenerateReference(key) + filepath.Ext(file.Filename)
+	header := make([]byte, encryption.FileHeaderSize)
+	n, err := io.ReadFull(file, header)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return nil, err
+	}
+	return header[:n], nil
+}
 
-	// save file
-	if err := ctx.SaveUploadedFile(file, helpers.StoragePath()+filepath.Join(path, fileName)); err != nil {
+// replaceHeader stores the file again with its fixed-size header swapped for
+// header. The encrypted content after the header is copied as it is.
+func replaceHeader(path string, header []byte) error {
+	disk := storage.Default()
+	file, err := disk.Get(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	if _, err := io.CopyN(io.Discard, file, int64(len(header))); err != nil {
+		return err
+	}
+	return disk.Put(path, io.MultiReader(bytes.NewReader(header), file))
+}
+
+func encryptInPlace(path string) error {
+	src, err := storage.Default().Get(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	return writeEncryptedFile(path, src)
+}
+
+// writeEncryptedFile encrypts src while it is stored at path on the default
+// disk
+func writeEncryptedFile(path string, src io.Reader) error {
+	ring, err := encryption.Default()
+	if err != nil {
+		return err
+	}
+
+	reader, writer := io.Pipe()
+	go func() {
+		writer.CloseWithError(ring.EncryptStream(writer, src))
+	}()
+	err = storage.Default().Put(path, reader)
+	reader.CloseWithError(err)
+	return err
+}
+
+// unreferencedFiles limits a query on files to those no FileReferences
+// column points to
+func unreferencedFiles(db *gorm.DB) *gorm.DB {
+	for _, reference := range FileReferences {
+		referenced := facades.DB.Table(reference.Table).Select(reference.Column).Where(reference.Column + " IS NOT NULL")
+		db = db.Where("id NOT IN (?)", referenced)
+	}
+	return db
+}
+
+// deleteFileRecords deletes rows of the files table and returns those whose
+// stored object no remaining row shares, to be removed once the transaction
+// has committed
+func deleteFileRecords(tx *gorm.DB, ids []uint) ([]models.File, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	var files []models.File
+	if err := tx.Where("id IN ?", ids).Find(&files).Error; err != nil {
+		return nil, err
+	}
+	if err := tx.Delete(&models.File{}, ids).Error; err != nil {
 		return nil, err
 	}
 
-	return &fileName, nil
+	var removed []models.File
+	seen := make(map[string]bool)
+	for _, file := range files {
+		key := file.Disk + ":" + file.Path
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		var shared int64
+		if err := tx.Model(&models.File{}).Where("disk = ? AND path = ?", file.Disk, file.Path).Count(&shared).Error; err != nil {
+			return nil, err
+		}
+		if shared == 0 {
+			removed = append(removed, file)
+		}
+	}
+	return removed, nil
+}
+
+// removeFiles removes the stored objects of files and their cached variants
+// and returns how many objects were removed
+func removeFiles(files []models.File) int {
+	removed := 0
+	for _, file := range files {
+		disk, err := storage.Disk(file.Disk)
+		if err != nil {
+			continue
+		}
+		if err := disk.Delete(file.Path); err == nil {
+			removed++
+		}
+
+		variants, _ := disk.List(variantDirectory(file.Path))
+		for _, variant := range variants {
+			disk.Delete(variant.Path)
+		}
+	}
+	return removed
 }
 
-func (service FileService) StoreBase64File(base64 string, key string, path string) (*string, error) {
-	fileName := helpers.GenerateReference(key) + ".png"
+// documentURLs returns the signed URL of a document file and, for images
+// variants can be made of, of its thumbnail
+func documentURLs(file *models.File) (string, string) {
+	if file == nil {
+		return "", ""
+	}
+	thumbnail := ""
+	if file.MimeType == "image/jpeg" || file.MimeType == "image/png" {
+		thumbnail = FileService{}.VariantURL(file.Path, DocumentThumbnail)
+	}
+	return FileService{}.URL(file.Path), thumbnail
+}
 
-	if err := helpers.StoreBase64File(base64, path, fileName); err != nil {
+// signFileURL signs a /file URL for IMAGE_EXPIRE_MINUTES, returning an empty
+// string when it cannot be signed
+func signFileURL(path string) string {
+	signer, err := signedurl.Default()
+	if err != nil {
+		return ""
+	}
+	expires := time.Duration(helpers.GetEnvInt("IMAGE_EXPIRE_MINUTES", 2)) * time.Minute
+	url, err := signer.Sign(path, signedurl.Options{Expires: expires})
+	if err != nil {
+		return ""
+	}
+	return url
+}
+
+// variantDirectory is where the variants of a stored image are cached
+func variantDirectory(path string) string {
+	return "variants/" + path + "/"
+}
+
+// readFile returns the whole decrypted content of a stored file
+func readFile(path string) ([]byte, error) {
+	file, _, err := FileService{}.Open(path)
+	if err != nil {
 		return nil, err
 	}
+	defer file.Close()
+	return io.ReadAll(file)
+}
+
+// fileExists reports whether the stored object of a file is still there
+func fileExists(file models.File) (bool, error) {
+	disk, err := storage.Disk(file.Disk)
+	if err != nil {
+		return false, err
+	}
+	_, err = disk.Stat(file.Path)
+	switch {
+	case errors.Is(err, storage.ErrNotExist):
+		return false, nil
+	case err != nil:
+		return false, err
+	}
+	return true, nil
+}
+
+// indexStoredFile builds the files row of an object of the default disk
+func indexStoredFile(path string, purpose models.UploadPurpose, ownerId *uint) (models.File, error) {
+	policy := policyOf(purpose)
+	file := models.File{
+		Reference:    helpers.GenerateReference("FIL"),
+		OwnerID:      ownerId,
+		Purpose:      purpose,
+		OriginalName: filepath.Base(path),
+		Disk:         config.GetStorageConfigs().Default,
+		Path:         path,
+		Access:       policy.access,
+		Permission:   policy.permission,
+		Sensitive:    policy.sensitive,
+	}
 
-	return &fileName, nil
+	src, _, err := FileService{}.Open(path)
+	if errors.Is(err, storage.ErrNotExist) {
+		now := time.Now()
+		file.MissingAt = &now
+		return file, nil
+	}
+	if err != nil {
+		return file, err
+	}
+	defer src.Close()
+
+	hash := sha256.New()
+	head := make([]byte, 512)
+	n, err := io.ReadFull(src, head)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return file, err
+	}
+	hash.Write(head[:n])
+	size, err := io.Copy(hash, src)
+	if err != nil {
+		return file, err
+	}
+
+	file.MimeType = sniffContentType(head[:n])
+	file.Size = int64(n) + size
+	file.SHA256 = hex.EncodeToString(hash.Sum(nil))
+	return file, nil
+}
+
+func policyOf(purpose models.UploadPurpose) filePolicy {
+	policy, ok := filePolicies[purpose]
+	if !ok {
+		policy = filePolicy{access: models.FileAccessSigned, sensitive: true}
+	}
+	return policy
+}
+
+// authorizeFile returns how one of files may be read by request.
+// hasPermission tells whether a user holds a permission.
+func authorizeFile(files []models.File, request FileRequest, hasPermission func(userId uint, permission string) (bool, error)) (string, error) {
+	if request.Public {
+		for _, file := range files {
+			if file.Access == models.FileAccessPublic {
+				return string(models.FileAccessPublic), nil
+			}
+		}
+		return "", ErrFileNotFound
+	}
+	if request.Signed {
+		return "signed", nil
+	}
+
+	for _, file := range files {
+		if file.Access == models.FileAccessPublic {
+			return string(models.FileAccessPublic), nil
+		}
+	}
+	if request.UserID == 0 {
+		return "", ErrFileUnauthenticated
+	}
+
+	for _, file := range files {
+		if file.Access == models.FileAccessOwner && file.OwnerID != nil && *file.OwnerID == request.UserID {
+			return string(models.FileAccessOwner), nil
+		}
+	}
+	for _, file := range files {
+		if file.Permission == "" || (file.Access != models.FileAccessOwner && file.Access != models.FileAccessPermission) {
+			continue
+		}
+		allowed, err := hasPermission(request.UserID, file.Permission)
+		if err != nil {
+			return "", err
+		}
+		if allowed {
+			return string(models.FileAccessPermission), nil
+		}
+	}
+	return "", ErrFileForbidden
+}
+
+// logFileAccess records a read of a sensitive file. A failure to log does
+// not fail the request.
+func logFileAccess(file models.File, request FileRequest, via string, granted bool) {
+	entry := models.FileAccessLog{
+		FileID:    file.ID,
+		Via:       via,
+		Granted:   granted,
+		IP:        truncate(request.IP, 45),
+		UserAgent: truncate(request.UserAgent, 255),
+		Variant:   truncate(request.Variant, 255),
+	}
+	if request.UserID != 0 {
+		entry.UserID = &request.UserID
+	}
+	if err := facades.DB.Create(&entry).Error; err != nil {
+		log.Printf("failed to log access to file %s: %v", file.Reference, err)
+	}
 }
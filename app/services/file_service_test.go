@@ -0,0 +1,244 @@
+package services_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"time"
+
+	"golang_starter_kit_2025/app/models"
+	"golang_starter_kit_2025/app/services"
+	"golang_starter_kit_2025/app/storage"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"gorm.io/gorm"
+)
+
+const (
+	owner   uint = 1
+	other   uint = 2
+	auditor uint = 3
+)
+
+func ownerFile(id uint) models.File {
+	return models.File{ID: id, OwnerID: &id, Access: models.FileAccessOwner, Permission: "kyc.view", Sensitive: true}
+}
+
+// auditorHolds grants kyc.view to the auditor only
+func auditorHolds(userId uint, permission string) (bool, error) {
+	return userId == auditor && permission == "kyc.view", nil
+}
+
+var _ = Describe("authorizeFile", func() {
+	public := models.File{ID: 9, Access: models.FileAccessPublic}
+
+	DescribeTable("grants access",
+		func(files []models.File, request services.FileRequest, via string) {
+			granted, err := services.AuthorizeFile(files, request, auditorHolds)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(granted).To(Equal(via))
+		},
+		Entry("to a public file on the public route", []models.File{public}, services.FileRequest{Public: true}, "public"),
+		Entry("to a public file without credentials", []models.File{public}, services.FileRequest{}, "public"),
+		Entry("to any file with a signed URL", []models.File{ownerFile(owner)}, services.FileRequest{Signed: true}, "signed"),
+		Entry("to the owner", []models.File{ownerFile(owner)}, services.FileRequest{UserID: owner}, "owner"),
+		Entry("to a permission holder", []models.File{ownerFile(owner)}, services.FileRequest{UserID: auditor}, "permission"),
+		Entry("to the owner of one of the files sharing the path",
+			[]models.File{ownerFile(owner), ownerFile(other)}, services.FileRequest{UserID: other}, "owner"),
+		Entry("when one of the files sharing the path is public",
+			[]models.File{ownerFile(owner), public}, services.FileRequest{}, "public"),
+	)
+
+	DescribeTable("refuses access",
+		func(files []models.File, request services.FileRequest, expected error) {
+			_, err := services.AuthorizeFile(files, request, auditorHolds)
+			Expect(err).To(MatchError(expected))
+		},
+		Entry("to a non-public file on the public route",
+			[]models.File{ownerFile(owner)}, services.FileRequest{Public: true, UserID: owner}, services.ErrFileNotFound),
+		Entry("to a signed URL on the public route",
+			[]models.File{ownerFile(owner)}, services.FileRequest{Public: true, Signed: true}, services.ErrFileNotFound),
+		Entry("to anonymous requests", []models.File{ownerFile(owner)}, services.FileRequest{}, services.ErrFileUnauthenticated),
+		Entry("to another user", []models.File{ownerFile(owner)}, services.FileRequest{UserID: other}, services.ErrFileForbidden),
+		Entry("to a user owning none of the files sharing the path",
+			[]models.File{ownerFile(owner), ownerFile(auditor + 1)}, services.FileRequest{UserID: other}, services.ErrFileForbidden),
+	)
+
+	It("fails when permissions cannot be checked", func() {
+		failure := errors.New("database is down")
+		_, err := services.AuthorizeFile([]models.File{ownerFile(owner)}, services.FileRequest{UserID: other},
+			func(uint, string) (bool, error) { return false, failure })
+		Expect(err).To(MatchError(failure))
+	})
+})
+
+var _ = Describe("FileService storage", func() {
+	var (
+		db      *gorm.DB
+		disk    *storage.Memory
+		service services.FileService
+	)
+
+	BeforeEach(func() {
+		db = useSQLite(&models.File{}, &models.KycDocument{}, &models.StoreDocument{})
+		disk = useMemoryDisk()
+		service = services.FileService{}
+	})
+
+	store := func(content string) models.File {
+		file, err := service.Store(owner, models.UploadPurposeKycDocument, "ktp.png", services.ProcessedUpload{
+			Content:  []byte(content),
+			MimeType: "image/png",
+		})
+		Expect(err).NotTo(HaveOccurred())
+		return file
+	}
+
+	reference := func(file models.File) {
+		Expect(db.Create(&models.KycDocument{SubmissionID: 1, Type: models.KycDocumentIdentityCard, FileID: &file.ID}).Error).To(Succeed())
+	}
+
+	exists := func(path string) bool {
+		_, err := disk.Stat(path)
+		if errors.Is(err, storage.ErrNotExist) {
+			return false
+		}
+		Expect(err).NotTo(HaveOccurred())
+		return true
+	}
+
+	Describe("Store and Remove", func() {
+		It("should store the same content once and share it between rows", func() {
+			first := store("same content")
+			second := store("same content")
+			other := store("other content")
+
+			Expect(second.ID).NotTo(Equal(first.ID))
+			Expect(second.Path).To(Equal(first.Path))
+			Expect(other.Path).NotTo(Equal(first.Path))
+			Expect(first.Path).To(HavePrefix(services.KycDocumentKey + "/"))
+			Expect(paths(disk, services.KycDocumentKey+"/")).To(ConsistOf(first.Path, other.Path))
+		})
+
+		It("should keep a shared object until its last row is removed", func() {
+			first := store("same content")
+			second := store("same content")
+			Expect(disk.Put(services.VariantDirectory(first.Path)+"thumb.jpg", strings.NewReader("thumbnail"))).To(Succeed())
+
+			Expect(service.Remove(first.ID)).To(Succeed())
+			Expect(exists(first.Path)).To(BeTrue())
+			Expect(db.First(&models.File{}, first.ID).Error).To(MatchError(gorm.ErrRecordNotFound))
+
+			Expect(service.Remove(second.ID)).To(Succeed())
+			Expect(exists(first.Path)).To(BeFalse())
+			Expect(paths(disk, "variants/")).To(BeEmpty())
+		})
+
+		It("should remove a shared object once when all its rows are removed together", func() {
+			first := store("same content")
+			second := store("same content")
+
+			Expect(service.Remove(first.ID, second.ID)).To(Succeed())
+			Expect(exists(first.Path)).To(BeFalse())
+			var count int64
+			Expect(db.Model(&models.File{}).Count(&count).Error).To(Succeed())
+			Expect(count).To(BeZero())
+		})
+
+		It("should not share an object that has gone missing", func() {
+			first := store("same content")
+			Expect(db.Model(&first).Update("missing_at", time.Now()).Error).To(Succeed())
+
+			second := store("same content")
+			Expect(second.Path).NotTo(Equal(first.Path))
+			Expect(exists(second.Path)).To(BeTrue())
+		})
+	})
+
+	Describe("CollectGarbage", func() {
+		grace := 7 * 24 * time.Hour
+		start := time.Date(2026, 10, 1, 3, 0, 0, 0, time.UTC)
+
+		collect := func(now time.Time) services.FileGCReport {
+			report, err := service.CollectGarbage(now, grace, nil)
+			Expect(err).NotTo(HaveOccurred())
+			return report
+		}
+
+		It("should delete unreferenced files only after the grace period", func() {
+			kept := store("referenced")
+			reference(kept)
+			orphan := store("orphan")
+
+			Expect(collect(start)).To(Equal(services.FileGCReport{Marked: 1}))
+			Expect(collect(start.Add(grace - time.Hour))).To(Equal(services.FileGCReport{}))
+			Expect(exists(orphan.Path)).To(BeTrue())
+
+			Expect(collect(start.Add(grace))).To(Equal(services.FileGCReport{Deleted: 1, Removed: 1}))
+			Expect(exists(orphan.Path)).To(BeFalse())
+			Expect(exists(kept.Path)).To(BeTrue())
+			Expect(db.First(&models.File{}, orphan.ID).Error).To(MatchError(gorm.ErrRecordNotFound))
+			Expect(db.First(&models.File{}, kept.ID).Error).To(Succeed())
+		})
+
+		It("should restart the grace period of a file that is referenced again", func() {
+			file := store("orphan for a while")
+			Expect(collect(start).Marked).To(Equal(1))
+
+			reference(file)
+			Expect(collect(start.Add(grace))).To(Equal(services.FileGCReport{}))
+			Expect(db.Where("id = ?", file.ID).Delete(&models.KycDocument{}).Error).To(Succeed())
+
+			later := start.Add(2 * grace)
+			Expect(collect(later).Marked).To(Equal(1))
+			Expect(collect(later.Add(grace - time.Hour)).Deleted).To(BeZero())
+			Expect(exists(file.Path)).To(BeTrue())
+		})
+
+		It("should keep a shared object while a referenced row still uses it", func() {
+			kept := store("shared")
+			reference(kept)
+			orphan := store("shared")
+
+			collect(start)
+			Expect(collect(start.Add(grace))).To(Equal(services.FileGCReport{Deleted: 1}))
+			Expect(db.First(&models.File{}, orphan.ID).Error).To(MatchError(gorm.ErrRecordNotFound))
+			Expect(exists(kept.Path)).To(BeTrue())
+		})
+
+		It("should report files whose stored object has disappeared", func() {
+			file := store("lost")
+			reference(file)
+			intact := store("intact")
+			reference(intact)
+			content, err := disk.Get(file.Path)
+			Expect(err).NotTo(HaveOccurred())
+			saved, err := io.ReadAll(content)
+			Expect(err).NotTo(HaveOccurred())
+			content.Close()
+			Expect(disk.Delete(file.Path)).To(Succeed())
+
+			var reported []uint
+			report, err := service.CollectGarbage(start, grace, func(missing models.File) {
+				reported = append(reported, missing.ID)
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(report.Missing).To(Equal(1))
+			Expect(reported).To(Equal([]uint{file.ID}))
+
+			var stored models.File
+			Expect(db.First(&stored, file.ID).Error).To(Succeed())
+			Expect(stored.MissingAt).NotTo(BeNil())
+			Expect(stored.MissingAt.Equal(start)).To(BeTrue())
+
+			// a restored object is no longer reported
+			Expect(disk.Put(file.Path, bytes.NewReader(saved))).To(Succeed())
+			Expect(collect(start.Add(time.Hour)).Missing).To(BeZero())
+			var restored models.File
+			Expect(db.First(&restored, file.ID).Error).To(Succeed())
+			Expect(restored.MissingAt).To(BeNil())
+		})
+	})
+})
@@ -0,0 +1,212 @@
+package services
+
+import (
+	"errors"
+
+	"golang_starter_kit_2025/app/models"
+	"golang_starter_kit_2025/facades"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrInvalidUserIds = errors.New("one or more user IDs are invalid")
+
+type GroupService struct{}
+
+func (*GroupService) GetAll() ([]models.UserGroup, error) {
+	var groups []models.UserGroup
+	if err := facades.DB.Order("name").Find(&groups).Error; err != nil {
+		return nil, err
+	}
+	return groups, nil
+}
+
+func (*GroupService) Find(id string) (models.UserGroup, error) {
+	var group models.UserGroup
+	if err := facades.DB.Preload("Roles").First(&group, id).Error; err != nil {
+		return group, err
+	}
+	return group, nil
+}
+
+func (*GroupService) Put(updatedGroup models.UserGroup) (models.UserGroup, error) {
+	var group models.UserGroup
+
+	if count := facades.DB.Model(&models.UserGroup{}).Where("id = ?", updatedGroup.ID).Find(&map[string]interface{}{}).RowsAffected; count == 0 {
+		if err := facades.DB.Create(&updatedGroup).Error; err != nil {
+			return group, err
+		}
+		return updatedGroup, nil
+	}
+
+	if err := facades.DB.Model(&models.UserGroup{}).Where("id = ?", updatedGroup.ID).Updates(map[string]interface{}{
+		"name":        updatedGroup.Name,
+		"description": updatedGroup.Description,
+	}).Error; err != nil {
+		return group, err
+	}
+
+	if err := facades.DB.First(&group, updatedGroup.ID).Error; err != nil {
+		return group, err
+	}
+	return group, nil
+}
+
+func (*GroupService) Delete(id string) error {
+	var group models.UserGroup
+	if err := facades.DB.First(&group, id).Error; err != nil {
+		return err
+	}
+	return facades.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_group_id = ?", group.ID).Delete(&models.UserGroupHasUser{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_group_id = ?", group.ID).Delete(&models.UserGroupHasRole{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&group).Error
+	})
+}
+
+func (*GroupService) GetMembers(groupId string) ([]models.User, error) {
+	var users []models.User
+	if err := facades.DB.
+		Joins("join user_groups_has_users on users.id = user_groups_has_users.user_id").
+		Where("user_groups_has_users.user_group_id = ?", groupId).
+		Find(&users).Error; err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+// UpdateMembers adds and removes group members in a single transaction
+func (*GroupService) UpdateMembers(groupId string, add, remove []uint) error {
+	return facades.DB.Transaction(func(tx *gorm.DB) error {
+		group, err := lockGroup(tx, groupId)
+		if err != nil {
+			return err
+		}
+
+		if overlaps(add, remove) {
+			return ErrConflictingIds
+		}
+		if err := validateIds(tx, &models.User{}, append(append([]uint{}, add...), remove...), ErrInvalidUserIds); err != nil {
+			return err
+		}
+
+		var current []uint
+		if err := tx.Model(&models.UserGroupHasUser{}).Where("user_group_id = ?", group.ID).Pluck("user_id", &current).Error; err != nil {
+			return err
+		}
+		toAdd, _ := diffIds(current, uniqueIds(add))
+
+		if len(remove) > 0 {
+			if err := tx.Where("user_group_id = ? AND user_id IN ?", group.ID, uniqueIds(remove)).Delete(&models.UserGroupHasUser{}).Error; err != nil {
+				return err
+			}
+		}
+		if len(toAdd) == 0 {
+			return nil
+		}
+		rows := make([]models.UserGroupHasUser, 0, len(toAdd))
+		for _, userId := range toAdd {
+			rows = append(rows, models.UserGroupHasUser{UserGroupID: group.ID, UserID: userId})
+		}
+		return tx.Create(&rows).Error
+	})
+}
+
+func (*GroupService) GetRoles(groupId string) ([]models.Role, error) {
+	var roles []models.Role
+	if err := facades.DB.
+		Joins("join user_groups_has_roles on roles.id = user_groups_has_roles.role_id").
+		Where("user_groups_has_roles.user_group_id = ?", groupId).
+		Find(&roles).Error; err != nil {
+		return nil, err
+	}
+	return roles, nil
+}
+
+// AssignRoles replaces the roles carried by a group with the given set
+func (*GroupService) AssignRoles(groupId string, roles []uint) error {
+	return facades.DB.Transaction(func(tx *gorm.DB) error {
+		group, err := lockGroup(tx, groupId)
+		if err != nil {
+			return err
+		}
+
+		roles = uniqueIds(roles)
+		if err := validateIds(tx, &models.Role{}, roles, ErrInvalidRoleIds); err != nil {
+			return err
+		}
+
+		current, err := groupRoleIdList(tx, group.ID)
+		if err != nil {
+			return err
+		}
+		add, remove := diffIds(current, roles)
+		return applyGroupRoles(tx, group.ID, add, remove)
+	})
+}
+
+// UpdateRoles adds and removes individual roles carried by a group
+func (*GroupService) UpdateRoles(groupId string, add, remove []uint) error {
+	return facades.DB.Transaction(func(tx *gorm.DB) error {
+		group, err := lockGroup(tx, groupId)
+		if err != nil {
+			return err
+		}
+
+		if overlaps(add, remove) {
+			return ErrConflictingIds
+		}
+		if err := validateIds(tx, &models.Role{}, append(append([]uint{}, add...), remove...), ErrInvalidRoleIds); err != nil {
+			return err
+		}
+
+		current, err := groupRoleIdList(tx, group.ID)
+		if err != nil {
+			return err
+		}
+		toAdd, _ := diffIds(current, uniqueIds(add))
+		return applyGroupRoles(tx, group.ID, toAdd, uniqueIds(remove))
+	})
+}
+
+func lockGroup(tx *gorm.DB, groupId string) (models.UserGroup, error) {
+	var group models.UserGroup
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&group, groupId).Error
+	return group, err
+}
+
+func groupRoleIdList(tx *gorm.DB, groupId uint) ([]uint, error) {
+	var ids []uint
+	err := tx.Model(&models.UserGroupHasRole{}).Where("user_group_id = ?", groupId).Pluck("role_id", &ids).Error
+	return ids, err
+}
+
+func applyGroupRoles(tx *gorm.DB, groupId uint, add, remove []uint) error {
+	if len(remove) > 0 {
+		if err := tx.Where("user_group_id = ? AND role_id IN ?", groupId, remove).Delete(&models.UserGroupHasRole{}).Error; err != nil {
+			return err
+		}
+	}
+	if len(add) == 0 {
+		return nil
+	}
+
+	rows := make([]models.UserGroupHasRole, 0, len(add))
+	for _, roleId := range add {
+		rows = append(rows, models.UserGroupHasRole{UserGroupID: groupId, RoleID: roleId})
+	}
+	return tx.Create(&rows).Error
+}
+
+// groupRoleIds builds a subquery selecting the IDs of the roles a user
+// inherits from the groups they are a member of
+func groupRoleIds(db *gorm.DB, userId uint) *gorm.DB {
+	return db.Model(&models.UserGroupHasRole{}).
+		Select("role_id").
+		Where("user_group_id IN (?)", db.Model(&models.UserGroupHasUser{}).Select("user_group_id").Where("user_id = ?", userId))
+}
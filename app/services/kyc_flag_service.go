@@ -0,0 +1,355 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"golang_starter_kit_2025/app/helpers"
+	"golang_starter_kit_2025/app/imagequality"
+	"golang_starter_kit_2025/app/matching"
+	"golang_starter_kit_2025/app/models"
+	"golang_starter_kit_2025/app/models/scopes"
+	"golang_starter_kit_2025/app/responses"
+	"golang_starter_kit_2025/facades"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrKycFlagInvalidStatus = errors.New("a flag can only be confirmed or dismissed")
+
+// kycMatch is a duplicate found by Detect, before it is stored as a flag
+type kycMatch struct {
+	userId       uint
+	submissionId *uint
+	signal       models.KycFlagSignal
+	score        float64
+	detail       string
+}
+
+func (m kycMatch) key() string {
+	if m.submissionId == nil {
+		return fmt.Sprintf("%s:%d", m.signal, m.userId)
+	}
+	return fmt.Sprintf("%s:%d:%d", m.signal, m.userId, *m.submissionId)
+}
+
+func kycFlagKey(flag models.KycFlag) string {
+	return kycMatch{userId: flag.MatchedUserID, submissionId: flag.MatchedSubmissionID, signal: flag.Signal}.key()
+}
+
+type KycFlagService struct{}
+
+// Detect compares a submission with the submissions and accounts of every
+// other user and records a flag for each likely duplicate: the same NIK,
+// phone number or mailbox, a similar name with the same date of birth, or a
+// document photo with a close perceptual hash. Encrypted values are compared
+// through their blind indexes; only names are decrypted, and only for
+// applicants born on the same day. Running it again for a resubmission
+// updates open flags, drops those that no longer match and leaves flags a
+// reviewer already resolved untouched.
+func (*KycFlagService) Detect(tx *gorm.DB, submission models.KycSubmission) ([]models.KycFlag, error) {
+	matches, err := findKycMatches(tx, submission)
+	if err != nil {
+		return nil, err
+	}
+
+	var existing []models.KycFlag
+	if err := tx.Where("submission_id = ?", submission.ID).Find(&existing).Error; err != nil {
+		return nil, err
+	}
+	current := make(map[string]models.KycFlag, len(existing))
+	for _, flag := range existing {
+		current[kycFlagKey(flag)] = flag
+	}
+
+	found := make(map[string]bool, len(matches))
+	for _, match := range matches {
+		found[match.key()] = true
+		flag, ok := current[match.key()]
+		switch {
+		case !ok:
+			flag = models.KycFlag{
+				SubmissionID:        submission.ID,
+				UserID:              submission.UserID,
+				MatchedUserID:       match.userId,
+				MatchedSubmissionID: match.submissionId,
+				Signal:              match.signal,
+				Score:               match.score,
+				Detail:              match.detail,
+				Status:              models.KycFlagOpen,
+			}
+			if err := tx.Create(&flag).Error; err != nil {
+				return nil, err
+			}
+		case flag.Status == models.KycFlagOpen:
+			if err := tx.Model(&flag).Updates(map[string]interface{}{
+				"score":  match.score,
+				"detail": match.detail,
+			}).Error; err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	var stale []uint
+	for key, flag := range current {
+		if !found[key] && flag.Status == models.KycFlagOpen {
+			stale = append(stale, flag.ID)
+		}
+	}
+	if len(stale) > 0 {
+		if err := tx.Delete(&models.KycFlag{}, stale).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	var flags []models.KycFlag
+	err = tx.Where("submission_id = ?", submission.ID).Order("id ASC").Find(&flags).Error
+	return flags, err
+}
+
+// Clusters groups users linked by flags into clusters of likely duplicates,
+// largest first. Dismissed flags do not link users; status narrows the flags
+// considered to open or confirmed ones.
+func (*KycFlagService) Clusters(status string) ([]responses.KycDuplicateCluster, error) {
+	var flags []models.KycFlag
+	query := facades.DB.Order("id ASC")
+	if status != "" {
+		query = query.Where("status = ?", status)
+	} else {
+		query = query.Where("status <> ?", models.KycFlagDismissed)
+	}
+	if err := query.Find(&flags).Error; err != nil {
+		return nil, err
+	}
+
+	// union-find over user IDs
+	parent := map[uint]uint{}
+	var find func(uint) uint
+	find = func(id uint) uint {
+		if _, ok := parent[id]; !ok {
+			parent[id] = id
+		}
+		if parent[id] != id {
+			parent[id] = find(parent[id])
+		}
+		return parent[id]
+	}
+	for _, flag := range flags {
+		a, b := find(flag.UserID), find(flag.MatchedUserID)
+		if a != b {
+			parent[max(a, b)] = min(a, b)
+		}
+	}
+
+	byRoot := map[uint]*responses.KycDuplicateCluster{}
+	var roots []uint
+	for _, flag := range flags {
+		root := find(flag.UserID)
+		cluster, ok := byRoot[root]
+		if !ok {
+			cluster = &responses.KycDuplicateCluster{}
+			byRoot[root] = cluster
+			roots = append(roots, root)
+		}
+		cluster.Flags = append(cluster.Flags, flag)
+		cluster.MaxScore = max(cluster.MaxScore, flag.Score)
+		if flag.Status == models.KycFlagOpen {
+			cluster.Open++
+		}
+	}
+
+	clusters := make([]responses.KycDuplicateCluster, 0, len(roots))
+	for _, root := range roots {
+		cluster := byRoot[root]
+		users := map[uint]bool{}
+		signals := map[models.KycFlagSignal]bool{}
+		for _, flag := range cluster.Flags {
+			users[flag.UserID], users[flag.MatchedUserID] = true, true
+			signals[flag.Signal] = true
+		}
+		for id := range users {
+			cluster.UserIDs = append(cluster.UserIDs, id)
+		}
+		sort.Slice(cluster.UserIDs, func(i, j int) bool { return cluster.UserIDs[i] < cluster.UserIDs[j] })
+		for signal := range signals {
+			cluster.Signals = append(cluster.Signals, signal)
+		}
+		sort.Slice(cluster.Signals, func(i, j int) bool { return cluster.Signals[i] < cluster.Signals[j] })
+		clusters = append(clusters, *cluster)
+	}
+
+	sort.SliceStable(clusters, func(i, j int) bool {
+		if len(clusters[i].UserIDs) != len(clusters[j].UserIDs) {
+			return len(clusters[i].UserIDs) > len(clusters[j].UserIDs)
+		}
+		return clusters[i].MaxScore > clusters[j].MaxScore
+	})
+	return clusters, nil
+}
+
+// Resolve records a reviewer's verdict on a flag. A reviewer cannot resolve
+// flags that concern their own account.
+func (*KycFlagService) Resolve(id string, reviewerId uint, status models.KycFlagStatus, note string) (models.KycFlag, error) {
+	var flag models.KycFlag
+	if status != models.KycFlagConfirmed && status != models.KycFlagDismissed {
+		return flag, ErrKycFlagInvalidStatus
+	}
+
+	err := facades.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&flag, id).Error; err != nil {
+			return err
+		}
+		if flag.UserID == reviewerId || flag.MatchedUserID == reviewerId {
+			return ErrKycSelfReview
+		}
+
+		if err := tx.Model(&flag).Updates(map[string]interface{}{
+			"status":      status,
+			"note":        note,
+			"resolved_by": reviewerId,
+			"resolved_at": time.Now(),
+		}).Error; err != nil {
+			return err
+		}
+		return tx.First(&flag, flag.ID).Error
+	})
+	return flag, err
+}
+
+func findKycMatches(tx *gorm.DB, submission models.KycSubmission) ([]kycMatch, error) {
+	var matches []kycMatch
+	others := func() *gorm.DB {
+		return tx.Model(&models.KycSubmission{}).Where("user_id <> ?", submission.UserID)
+	}
+
+	for _, exact := range []struct {
+		signal models.KycFlagSignal
+		column string
+		index  string
+	}{
+		{models.KycFlagNIK, "nik_bidx", submission.NIKBidx},
+		{models.KycFlagPhone, "phone_bidx", submission.PhoneBidx},
+	} {
+		if exact.index == "" {
+			continue
+		}
+		var found []models.KycSubmission
+		if err := others().Select("id", "user_id").Where(exact.column+" = ?", exact.index).Find(&found).Error; err != nil {
+			return nil, err
+		}
+		for _, other := range found {
+			matches = append(matches, kycMatch{userId: other.UserID, submissionId: &other.ID, signal: exact.signal, score: 1})
+		}
+	}
+
+	var user models.User
+	if err := tx.Select("id", "email_canonical_bidx").First(&user, submission.UserID).Error; err != nil {
+		return nil, err
+	}
+	if user.EmailCanonicalBidx != "" {
+		var found []models.User
+		if err := tx.Select("id").Where("email_canonical_bidx = ? AND id <> ?", user.EmailCanonicalBidx, user.ID).Find(&found).Error; err != nil {
+			return nil, err
+		}
+		for _, other := range found {
+			matches = append(matches, kycMatch{userId: other.ID, signal: models.KycFlagEmail, score: 1})
+		}
+	}
+
+	if submission.BirthDateBidx != "" && submission.FullName != "" {
+		threshold := helpers.GetEnvFloat("KYC_NAME_MATCH_THRESHOLD", 0.9)
+		var found []models.KycSubmission
+		if err := others().Select("id", "user_id", "full_name").Where("birth_date_bidx = ?", submission.BirthDateBidx).Find(&found).Error; err != nil {
+			return nil, err
+		}
+		for _, other := range found {
+			if score := matching.NameSimilarity(submission.FullName, other.FullName); score >= threshold {
+				matches = append(matches, kycMatch{
+					userId:       other.UserID,
+					submissionId: &other.ID,
+					signal:       models.KycFlagNameDOB,
+					score:        score,
+					detail:       fmt.Sprintf("name similarity %.2f, same date of birth", score),
+				})
+			}
+		}
+	}
+
+	documentMatches, err := findKycDocumentMatches(tx, submission)
+	if err != nil {
+		return nil, err
+	}
+	return append(matches, documentMatches...), nil
+}
+
+// findKycDocumentMatches keeps, for each submission of another user, the
+// closest pair of documents within KYC_PHASH_MAX_DISTANCE bits
+func findKycDocumentMatches(tx *gorm.DB, submission models.KycSubmission) ([]kycMatch, error) {
+	var documents []models.KycDocument
+	if err := tx.Select("id", "type", "phash").
+		Where("submission_id = ? AND phash IS NOT NULL AND phash <> ''", submission.ID).
+		Find(&documents).Error; err != nil {
+		return nil, err
+	}
+	if len(documents) == 0 {
+		return nil, nil
+	}
+
+	maxDistance := helpers.GetEnvInt("KYC_PHASH_MAX_DISTANCE", 6)
+	closest := map[uint]kycMatch{}
+	distances := map[uint]int{}
+	var order []uint
+	for _, document := range documents {
+		hash, err := imagequality.ParseHash(document.PHash)
+		if err != nil {
+			continue
+		}
+
+		// only documents sharing a hash band can be near, the bands are indexed
+		var candidates []struct {
+			models.KycDocument
+			UserID uint
+		}
+		if err := tx.Model(&models.KycDocument{}).
+			Select("kyc_documents.id", "kyc_documents.submission_id", "kyc_documents.type", "kyc_documents.phash", "kyc_submissions.user_id").
+			Joins("JOIN kyc_submissions ON kyc_submissions.id = kyc_documents.submission_id").
+			Scopes(scopes.PHashNear("kyc_documents", hash, maxDistance)).
+			Where("kyc_submissions.user_id <> ?", submission.UserID).
+			Find(&candidates).Error; err != nil {
+			return nil, err
+		}
+
+		for _, candidate := range candidates {
+			other, err := imagequality.ParseHash(candidate.PHash)
+			if err != nil {
+				continue
+			}
+			distance := hash.Distance(other)
+			if previous, ok := distances[candidate.SubmissionID]; distance > maxDistance || (ok && previous <= distance) {
+				continue
+			}
+			if _, ok := distances[candidate.SubmissionID]; !ok {
+				order = append(order, candidate.SubmissionID)
+			}
+			distances[candidate.SubmissionID] = distance
+			submissionId := candidate.SubmissionID
+			closest[submissionId] = kycMatch{
+				userId:       candidate.UserID,
+				submissionId: &submissionId,
+				signal:       models.KycFlagDocument,
+				score:        1 - float64(distance)/64,
+				detail:       fmt.Sprintf("%s matches %s, distance %d", document.Type, candidate.Type, distance),
+			}
+		}
+	}
+
+	matches := make([]kycMatch, 0, len(order))
+	for _, id := range order {
+		matches = append(matches, closest[id])
+	}
+	return matches, nil
+}
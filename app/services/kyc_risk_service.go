@@ -0,0 +1,223 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"sync/atomic"
+	"time"
+
+	"golang_starter_kit_2025/app/helpers"
+	"golang_starter_kit_2025/app/models"
+	"golang_starter_kit_2025/app/nik"
+	"golang_starter_kit_2025/app/risk"
+	"golang_starter_kit_2025/facades"
+
+	"gorm.io/gorm"
+)
+
+// KycRescoreReport summarises a re-scoring run
+type KycRescoreReport struct {
+	RulesVersion  string            `json:"rules_version"`
+	RulesChecksum string            `json:"rules_checksum"`
+	Scored        int               `json:"scored"`
+	Tiers         map[risk.Tier]int `json:"tiers"`
+}
+
+var ErrKycRescoreRunning = errors.New("a KYC risk rescore is already running")
+
+// kycRescoreRunning is set while a rescore started by StartRescore runs
+var kycRescoreRunning atomic.Bool
+
+// LoadRiskRules reads the rule set from RISK_RULES_FILE and puts it in use.
+// The rules in use are kept when the file cannot be read or is not valid.
+func LoadRiskRules() (*risk.RuleSet, error) {
+	return risk.LoadFile(riskRulesFile())
+}
+
+// activeRiskRules returns the rule set of RISK_RULES_FILE, reloading it when
+// the file changed since it was last read, so every replica scores with the
+// same rules
+func activeRiskRules() *risk.RuleSet {
+	set, err := risk.RefreshFile(riskRulesFile())
+	if err != nil {
+		log.Printf("Gagal memuat ulang aturan risiko KYC, aturan %s tetap dipakai: %v", set.Version, err)
+	}
+	return set
+}
+
+func riskRulesFile() string {
+	return helpers.GetEnv("RISK_RULES_FILE", "config/risk_rules.json")
+}
+
+type KycRiskService struct{}
+
+// Rules returns the rule set in use
+func (*KycRiskService) Rules() *risk.RuleSet {
+	return activeRiskRules()
+}
+
+// Assess scores a submission with the rules in use and records the
+// assessment with the facts it was based on
+func (*KycRiskService) Assess(tx *gorm.DB, submission models.KycSubmission) (models.KycRiskAssessment, error) {
+	facts, err := kycRiskFacts(tx, submission)
+	if err != nil {
+		return models.KycRiskAssessment{}, err
+	}
+
+	set := activeRiskRules()
+	result := set.Evaluate(facts)
+	assessment := models.KycRiskAssessment{
+		SubmissionID:  submission.ID,
+		UserID:        submission.UserID,
+		Score:         result.Score,
+		Tier:          result.Tier,
+		RulesVersion:  set.Version,
+		RulesChecksum: set.Checksum,
+		Contributions: result.Contributions,
+		Facts:         facts,
+	}
+	err = tx.Create(&assessment).Error
+	return assessment, err
+}
+
+// StartRescore checks the rules file and runs Rescore in the background,
+// returning the rules it scores with. Only one rescore runs at a time in a
+// process; kyc:risk-rescore runs it from the command line instead.
+func (s *KycRiskService) StartRescore() (KycRescoreReport, error) {
+	report := KycRescoreReport{Tiers: map[risk.Tier]int{}}
+	set, err := LoadRiskRules()
+	if err != nil {
+		return report, err
+	}
+	report.RulesVersion = set.Version
+	report.RulesChecksum = set.Checksum
+
+	if !kycRescoreRunning.CompareAndSwap(false, true) {
+		return report, ErrKycRescoreRunning
+	}
+	go func() {
+		defer kycRescoreRunning.Store(false)
+		result, err := s.Rescore()
+		if err != nil {
+			log.Printf("Gagal menilai ulang risiko KYC setelah %d pengajuan: %v", result.Scored, err)
+			return
+		}
+		log.Printf("Penilaian ulang risiko KYC selesai: %d pengajuan dengan aturan %s", result.Scored, result.RulesVersion)
+	}()
+	return report, nil
+}
+
+// Rescore reloads the rules file and scores every submission still awaiting
+// a decision again. Earlier assessments are kept.
+func (s *KycRiskService) Rescore() (KycRescoreReport, error) {
+	report := KycRescoreReport{Tiers: map[risk.Tier]int{}}
+	set, err := LoadRiskRules()
+	if err != nil {
+		return report, err
+	}
+	report.RulesVersion = set.Version
+	report.RulesChecksum = set.Checksum
+
+	var ids []uint
+	if err := facades.DB.Model(&models.KycSubmission{}).
+		Where("status IN ?", []models.KycStatus{models.KycStatusSubmitted, models.KycStatusInReview}).
+		Order("id ASC").
+		Pluck("id", &ids).Error; err != nil {
+		return report, err
+	}
+
+	for _, id := range ids {
+		err := facades.DB.Transaction(func(tx *gorm.DB) error {
+			var submission models.KycSubmission
+			if err := tx.First(&submission, id).Error; err != nil {
+				return err
+			}
+			assessment, err := s.Assess(tx, submission)
+			if err != nil {
+				return err
+			}
+			report.Tiers[assessment.Tier]++
+			return nil
+		})
+		if err != nil {
+			return report, fmt.Errorf("submission %d: %w", id, err)
+		}
+		report.Scored++
+	}
+	return report, nil
+}
+
+// kycRiskFacts gathers what the rules can test about a submission. Facts
+// that cannot be established, like the age of an applicant whose NIK does
+// not parse, are left out.
+func kycRiskFacts(tx *gorm.DB, submission models.KycSubmission) (risk.Facts, error) {
+	now := time.Now()
+	facts := risk.Facts{}
+
+	if parsed, err := nik.ParseAt(submission.NIK, now); err == nil {
+		facts[risk.FactApplicantAge] = float64(parsed.Age(now))
+		facts[risk.FactProvinceCode] = parsed.ProvinceCode
+		facts[risk.FactRegencyCode] = parsed.RegencyCode
+	}
+
+	var result models.ScreeningResult
+	err := tx.Preload("Hits").Where("user_id = ?", submission.UserID).Order("id DESC").Limit(1).Find(&result).Error
+	if err != nil {
+		return nil, err
+	}
+	if result.ID != 0 {
+		var open, confirmed float64
+		for _, hit := range result.Hits {
+			switch hit.Status {
+			case models.ScreeningHitOpen:
+				open++
+			case models.ScreeningHitConfirmed:
+				confirmed++
+			}
+		}
+		facts[risk.FactScreeningHits] = open
+		facts[risk.FactScreeningConfirmed] = confirmed
+	}
+
+	var documents []models.KycDocument
+	if err := tx.Where("submission_id = ?", submission.ID).Find(&documents).Error; err != nil {
+		return nil, err
+	}
+	issues := []string{}
+	var failed float64
+	for _, document := range documents {
+		if !document.QualityPassed {
+			failed++
+		}
+		for _, issue := range document.QualityIssues {
+			if !slices.Contains(issues, issue) {
+				issues = append(issues, issue)
+			}
+		}
+	}
+	facts[risk.FactDocumentIssues] = issues
+	facts[risk.FactDocumentsFailed] = failed
+
+	var flags []models.KycFlag
+	if err := tx.Where("submission_id = ? AND status <> ?", submission.ID, models.KycFlagDismissed).Find(&flags).Error; err != nil {
+		return nil, err
+	}
+	signals := []string{}
+	for _, flag := range flags {
+		if !slices.Contains(signals, string(flag.Signal)) {
+			signals = append(signals, string(flag.Signal))
+		}
+	}
+	facts[risk.FactDuplicateFlags] = float64(len(flags))
+	facts[risk.FactDuplicateSignals] = signals
+
+	var user models.User
+	if err := tx.Select("id", "created_at").First(&user, submission.UserID).Error; err != nil {
+		return nil, err
+	}
+	facts[risk.FactAccountAgeDays] = float64(int(now.Sub(user.CreatedAt).Hours() / 24))
+
+	return facts, nil
+}
@@ -0,0 +1,505 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"golang_starter_kit_2025/app/helpers"
+	"golang_starter_kit_2025/app/imagequality"
+	"golang_starter_kit_2025/app/models"
+	"golang_starter_kit_2025/app/models/scopes"
+	"golang_starter_kit_2025/app/nik"
+	"golang_starter_kit_2025/facades"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// KycDocumentKey is the storage directory of KYC documents, served under
+// /file/{key}/{filename}
+const KycDocumentKey = "kyc"
+
+var (
+	ErrKycSubmissionExists  = errors.New("user already has an open KYC submission")
+	ErrKycNotEditable       = errors.New("KYC submission can no longer be changed")
+	ErrKycIncomplete        = errors.New("KYC submission is incomplete")
+	ErrKycInvalidTransition = errors.New("KYC status transition is not allowed")
+	ErrKycSelfReview        = errors.New("KYC submission cannot be reviewed by its applicant")
+	ErrKycReasonRequired    = errors.New("a valid reason code is required")
+	ErrKycInvalidNIK        = errors.New("NIK is not valid")
+	ErrKycInvalidDocument   = errors.New("KYC document is not a usable image")
+	ErrKycDocumentQuality   = errors.New("KYC documents failed the quality check and must be retaken")
+	ErrKycNIKMismatch       = errors.New("NIK does not match the submitted birth date or gender")
+	ErrNIKAlreadyRegistered = errors.New("NIK is already registered to another user")
+)
+
+// kycQualityOptions holds the image requirements per document type. An
+// Indonesian identity card is an ID-1 card with an aspect ratio of 1.586.
+var kycQualityOptions = map[models.KycDocumentType]imagequality.Options{
+	models.KycDocumentIdentityCard: {
+		MinWidth:       800,
+		MinHeight:      500,
+		MinAspectRatio: 1.3,
+		MaxAspectRatio: 1.9,
+		MinSharpness:   100,
+		MinBrightness:  50,
+		MaxBrightness:  210,
+		MaxClipped:     0.25,
+	},
+	models.KycDocumentSelfie: {
+		MinWidth:       480,
+		MinHeight:      480,
+		MinAspectRatio: 1,
+		MaxAspectRatio: 2.2,
+		MinSharpness:   60,
+		MinBrightness:  50,
+		MaxBrightness:  210,
+		MaxClipped:     0.25,
+	},
+}
+
+type KycService struct{}
+
+// Create opens a new draft submission for a user. A user may only start over
+// once their previous submission has been rejected.
+func (*KycService) Create(userId uint) (models.KycSubmission, error) {
+	submission := models.KycSubmission{
+		Reference: helpers.GenerateReference("KYC"),
+		UserID:    userId,
+		Status:    models.KycStatusDraft,
+	}
+
+	var open int64
+	if err := facades.DB.Model(&models.KycSubmission{}).
+		Where("user_id = ? AND status <> ?", userId, models.KycStatusRejected).
+		Count(&open).Error; err != nil {
+		return submission, err
+	}
+	if open > 0 {
+		return submission, ErrKycSubmissionExists
+	}
+
+	if err := facades.DB.Create(&submission).Error; err != nil {
+		return submission, err
+	}
+	return submission, nil
+}
+
+// GetMine returns the latest submission of a user with its documents and
+// status history
+func (*KycService) GetMine(userId uint) (models.KycSubmission, error) {
+	submission, err := latestKycSubmission(preloadKycSubmission(facades.DB), userId)
+	if err != nil {
+		return submission, err
+	}
+	withDocumentURLs(&submission)
+	return submission, nil
+}
+
+// GetAll lists submissions for reviewers, optionally filtered by status
+func (*KycService) GetAll(status string) ([]models.KycSubmission, error) {
+	var submissions []models.KycSubmission
+	query := facades.DB.Order("id ASC")
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if err := query.Find(&submissions).Error; err != nil {
+		return nil, err
+	}
+	return submissions, nil
+}
+
+// Find returns a submission with its documents, status history, duplicate
+// flags and risk assessments, latest first. Flags and assessments are only
+// shown to reviewers.
+func (*KycService) Find(id string) (models.KycSubmission, error) {
+	var submission models.KycSubmission
+	query := preloadKycSubmission(facades.DB).Preload("Flags", func(db *gorm.DB) *gorm.DB {
+		return db.Order("id ASC")
+	}).Preload("RiskAssessments", func(db *gorm.DB) *gorm.DB {
+		return db.Order("id DESC")
+	})
+	if err := query.First(&submission, id).Error; err != nil {
+		return submission, err
+	}
+	withDocumentURLs(&submission)
+	return submission, nil
+}
+
+// UpdateMine replaces the personal data of the user's editable submission
+func (s *KycService) UpdateMine(userId uint, data models.KycSubmission) (models.KycSubmission, error) {
+	var submission models.KycSubmission
+	err := facades.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if submission, err = lockEditableKycSubmission(tx, userId); err != nil {
+			return err
+		}
+
+		// encrypted columns are only encrypted when written from a struct
+		submission.NIK = data.NIK
+		submission.FullName = data.FullName
+		submission.BirthPlace = data.BirthPlace
+		submission.BirthDate = data.BirthDate
+		submission.Gender = data.Gender
+		submission.Address = data.Address
+		submission.Phone = data.Phone
+		return tx.Model(&submission).
+			Select("nik", "nik_bidx", "full_name", "birth_place", "birth_date", "birth_date_bidx", "gender", "address", "phone", "phone_bidx").
+			Updates(&submission).Error
+	})
+	if err != nil {
+		return submission, err
+	}
+	return s.GetMine(userId)
+}
+
+// UploadDocument runs an image through the upload pipeline, checks its
+// quality, stores it and records it as the user's document of the given
+// type, replacing and deleting any previous file of that type. Quality
+// issues do not reject the upload; they are stored with the document so the
+// applicant can retake the photo, and Submit refuses documents that did not
+// pass.
+func (*KycService) UploadDocument(userId uint, docType models.KycDocumentType, input UploadInput) (models.KycDocument, error) {
+	var document models.KycDocument
+
+	submission, err := latestKycSubmission(facades.DB, userId)
+	if err != nil {
+		return document, err
+	}
+	if !submission.Status.Editable() {
+		return document, ErrKycNotEditable
+	}
+
+	processed, err := (&UploadService{}).Process(userId, models.UploadPurposeKycDocument, input)
+	if err != nil {
+		return document, err
+	}
+	content := processed.Content
+
+	opts, ok := kycQualityOptions[docType]
+	if !ok {
+		opts = imagequality.DefaultOptions
+	}
+	report, err := imagequality.Analyze(content, opts)
+	if err != nil {
+		return document, fmt.Errorf("%w: %w", ErrKycInvalidDocument, err)
+	}
+
+	duplicates, err := duplicateKycDocuments(facades.DB, submission.ID, docType, report.PHash)
+	if err != nil {
+		return document, err
+	}
+	if len(duplicates) > 0 {
+		report.Issues = append(report.Issues, imagequality.IssueDuplicate)
+	}
+
+	file, err := FileService{}.Store(userId, models.UploadPurposeKycDocument, input.Name, processed)
+	if err != nil {
+		return document, err
+	}
+
+	var replaced *models.KycDocument
+	err = facades.DB.Transaction(func(tx *gorm.DB) error {
+		submission, err := lockEditableKycSubmission(tx, userId)
+		if err != nil {
+			return err
+		}
+
+		err = tx.Where("submission_id = ? AND type = ?", submission.ID, docType).First(&document).Error
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			document = models.KycDocument{SubmissionID: submission.ID, Type: docType}
+		case err != nil:
+			return err
+		default:
+			previous := document
+			replaced = &previous
+		}
+
+		document.FileID = &file.ID
+		document.File = &file
+		document.MimeType = report.MimeType
+		document.Width = report.Width
+		document.Height = report.Height
+		document.Sharpness = report.Sharpness
+		document.Brightness = report.Brightness
+		document.PHash = report.PHash
+		document.QualityIssues = make([]string, len(report.Issues))
+		for i, issue := range report.Issues {
+			document.QualityIssues[i] = string(issue)
+		}
+		document.QualityPassed = report.Passed()
+		document.DuplicateOf = duplicates
+		return tx.Save(&document).Error
+	})
+	if err != nil {
+		FileService{}.Remove(file.ID)
+		return document, err
+	}
+
+	if replaced != nil && replaced.FileID != nil {
+		FileService{}.Remove(*replaced.FileID)
+	}
+	document.URL, document.ThumbnailURL = documentURLs(&file)
+	return document, nil
+}
+
+// Submit hands the user's editable submission over for review after checking
+// that it is complete and consistent with the NIK, flags other applicants who
+// look like the same person, screens the applicant against the watchlists
+// and scores the risk from the outcome
+func (*KycService) Submit(userId uint) (models.KycSubmission, error) {
+	var submission models.KycSubmission
+	err := facades.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if submission, err = lockEditableKycSubmission(tx, userId); err != nil {
+			return err
+		}
+		if err := validateKycSubmission(tx, submission); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		if err := transitionKyc(tx, &submission, models.KycStatusSubmitted, userId, "", "", map[string]interface{}{
+			"submitted_at":      now,
+			"review_started_at": nil,
+			"decided_at":        nil,
+			"reviewer_id":       nil,
+			"reason_code":       "",
+			"reviewer_comment":  "",
+		}); err != nil {
+			return err
+		}
+
+		if _, err := (&KycFlagService{}).Detect(tx, submission); err != nil {
+			return err
+		}
+		if _, err := (&ScreeningService{}).ScreenSubmission(tx, submission); err != nil {
+			return err
+		}
+		_, err = (&KycRiskService{}).Assess(tx, submission)
+		return err
+	})
+	return submission, err
+}
+
+// StartReview assigns a submitted submission to a reviewer
+func (*KycService) StartReview(id string, reviewerId uint) (models.KycSubmission, error) {
+	var submission models.KycSubmission
+	err := facades.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if submission, err = lockKycSubmissionForReview(tx, id, reviewerId); err != nil {
+			return err
+		}
+
+		return transitionKyc(tx, &submission, models.KycStatusInReview, reviewerId, "", "", map[string]interface{}{
+			"reviewer_id":       reviewerId,
+			"review_started_at": time.Now(),
+		})
+	})
+	return submission, err
+}
+
+// Decide closes the review of a submission. Rejections and requests for
+// resubmission need a reason code; an approval stores the verified NIK on
+// the applicant's account.
+func (*KycService) Decide(id string, reviewerId uint, status models.KycStatus, reason models.KycReasonCode, comment string) (models.KycSubmission, error) {
+	var submission models.KycSubmission
+	err := facades.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if submission, err = lockKycSubmissionForReview(tx, id, reviewerId); err != nil {
+			return err
+		}
+		if (status != models.KycStatusApproved || reason != "") && !reason.Valid() {
+			return ErrKycReasonRequired
+		}
+
+		if status == models.KycStatusApproved {
+			if err := ensureNIKAvailable(tx, submission.NIK, submission.UserID); err != nil {
+				return err
+			}
+			user := models.User{ID: submission.UserID, NIK: &submission.NIK}
+			if err := tx.Model(&user).Select("nik", "nik_bidx").Updates(&user).Error; err != nil {
+				return err
+			}
+		}
+
+		return transitionKyc(tx, &submission, status, reviewerId, reason, comment, map[string]interface{}{
+			"reviewer_id":      reviewerId,
+			"reason_code":      reason,
+			"reviewer_comment": comment,
+			"decided_at":       time.Now(),
+		})
+	})
+	return submission, err
+}
+
+func preloadKycSubmission(db *gorm.DB) *gorm.DB {
+	return db.Preload("Documents.File").Preload("Transitions", func(db *gorm.DB) *gorm.DB {
+		return db.Order("id ASC")
+	})
+}
+
+func withDocumentURLs(submission *models.KycSubmission) {
+	for i := range submission.Documents {
+		submission.Documents[i].URL, submission.Documents[i].ThumbnailURL = documentURLs(submission.Documents[i].File)
+	}
+}
+
+func latestKycSubmission(db *gorm.DB, userId uint) (models.KycSubmission, error) {
+	var submission models.KycSubmission
+	err := db.Where("user_id = ?", userId).Order("id DESC").First(&submission).Error
+	return submission, err
+}
+
+func lockEditableKycSubmission(tx *gorm.DB, userId uint) (models.KycSubmission, error) {
+	submission, err := latestKycSubmission(tx.Clauses(clause.Locking{Strength: "UPDATE"}), userId)
+	if err != nil {
+		return submission, err
+	}
+	if !submission.Status.Editable() {
+		return submission, ErrKycNotEditable
+	}
+	return submission, nil
+}
+
+func lockKycSubmissionForReview(tx *gorm.DB, id string, reviewerId uint) (models.KycSubmission, error) {
+	var submission models.KycSubmission
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&submission, id).Error; err != nil {
+		return submission, err
+	}
+	if submission.UserID == reviewerId {
+		return submission, ErrKycSelfReview
+	}
+	return submission, nil
+}
+
+// transitionKyc moves a locked submission to a new status, applies the
+// accompanying column updates and records the transition
+func transitionKyc(tx *gorm.DB, submission *models.KycSubmission, to models.KycStatus, actorId uint, reason models.KycReasonCode, comment string, updates map[string]interface{}) error {
+	from := submission.Status
+	if !from.CanTransitionTo(to) {
+		return fmt.Errorf("%w: %s -> %s", ErrKycInvalidTransition, from, to)
+	}
+
+	updates["status"] = to
+	if err := tx.Model(submission).Updates(updates).Error; err != nil {
+		return err
+	}
+	if err := tx.Create(&models.KycTransition{
+		SubmissionID: submission.ID,
+		FromStatus:   from,
+		ToStatus:     to,
+		ActorID:      actorId,
+		ReasonCode:   reason,
+		Comment:      comment,
+	}).Error; err != nil {
+		return err
+	}
+	return tx.First(submission, submission.ID).Error
+}
+
+func validateKycSubmission(tx *gorm.DB, submission models.KycSubmission) error {
+	var missing []string
+	for _, field := range []struct{ name, value string }{
+		{"nik", submission.NIK},
+		{"full_name", submission.FullName},
+		{"birth_place", submission.BirthPlace},
+		{"gender", submission.Gender},
+		{"address", submission.Address},
+	} {
+		if strings.TrimSpace(field.value) == "" {
+			missing = append(missing, field.name)
+		}
+	}
+	if submission.BirthDate == nil {
+		missing = append(missing, "birth_date")
+	}
+
+	var documents []models.KycDocument
+	if err := tx.Where("submission_id = ?", submission.ID).Find(&documents).Error; err != nil {
+		return err
+	}
+	var retake []string
+	for _, required := range models.KycRequiredDocuments {
+		found := false
+		for _, document := range documents {
+			if document.Type == required {
+				found = true
+				if !document.QualityPassed {
+					retake = append(retake, string(required))
+				}
+			}
+		}
+		if !found {
+			missing = append(missing, string(required))
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("%w: %s", ErrKycIncomplete, strings.Join(missing, ", "))
+	}
+	if len(retake) > 0 {
+		return fmt.Errorf("%w: %s", ErrKycDocumentQuality, strings.Join(retake, ", "))
+	}
+
+	parsed, err := nik.Parse(submission.NIK)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrKycInvalidNIK, err)
+	}
+	year, month, day := submission.BirthDate.Date()
+	if !parsed.BirthDate.Equal(time.Date(year, month, day, 0, 0, 0, 0, time.UTC)) || string(parsed.Gender) != submission.Gender {
+		return ErrKycNIKMismatch
+	}
+
+	return ensureNIKAvailable(tx, submission.NIK, submission.UserID)
+}
+
+func ensureNIKAvailable(tx *gorm.DB, number string, userId uint) error {
+	index, err := models.NIKIndex(number)
+	if err != nil {
+		return err
+	}
+	var taken int64
+	if err := tx.Model(&models.User{}).Where("nik_bidx = ? AND id <> ?", index, userId).Count(&taken).Error; err != nil {
+		return err
+	}
+	if taken > 0 {
+		return ErrNIKAlreadyRegistered
+	}
+	return nil
+}
+
+// duplicateKycDocuments finds documents, other than the one being replaced,
+// whose perceptual hash is within KYC_PHASH_MAX_DISTANCE bits of hash. The
+// same picture reused across submissions, or an identity card uploaded as a
+// selfie, shows up here.
+func duplicateKycDocuments(db *gorm.DB, submissionId uint, docType models.KycDocumentType, hash string) ([]uint, error) {
+	target, err := imagequality.ParseHash(hash)
+	if err != nil {
+		return nil, err
+	}
+	maxDistance := helpers.GetEnvInt("KYC_PHASH_MAX_DISTANCE", 6)
+
+	// only documents sharing a hash band can be near, the bands are indexed
+	var candidates []models.KycDocument
+	if err := db.Select("id", "phash").
+		Scopes(scopes.PHashNear("kyc_documents", target, maxDistance)).
+		Where("NOT (submission_id = ? AND type = ?)", submissionId, docType).
+		Find(&candidates).Error; err != nil {
+		return nil, err
+	}
+
+	duplicates := []uint{}
+	for _, candidate := range candidates {
+		other, err := imagequality.ParseHash(candidate.PHash)
+		if err != nil {
+			continue
+		}
+		if target.Distance(other) <= maxDistance {
+			duplicates = append(duplicates, candidate.ID)
+		}
+	}
+	return duplicates, nil
+}
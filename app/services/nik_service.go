@@ -0,0 +1,40 @@
+package services
+
+import (
+	"golang_starter_kit_2025/app/models"
+	"golang_starter_kit_2025/app/nik"
+	"golang_starter_kit_2025/app/responses"
+	"golang_starter_kit_2025/facades"
+)
+
+type NIKService struct{}
+
+// Check parses a NIK and reports whether it already belongs to a registered
+// user or to the owner of a registered store
+func (*NIKService) Check(number string) (responses.CheckNIKResult, error) {
+	var result responses.CheckNIKResult
+
+	parsed, err := nik.Parse(number)
+	if err != nil {
+		return result, err
+	}
+	result.NIK = parsed
+
+	index, err := models.NIKIndex(number)
+	if err != nil {
+		return result, err
+	}
+	var users int64
+	if err := facades.DB.Model(&models.User{}).Where("nik_bidx = ?", index).Count(&users).Error; err != nil {
+		return result, err
+	}
+	result.IsRegistered = users > 0
+
+	var stores int64
+	if err := facades.DB.Model(&models.Store{}).Where("owner_nik_bidx = ?", index).Count(&stores).Error; err != nil {
+		return result, err
+	}
+	result.IsRegisteredOnStore = stores > 0
+
+	return result, nil
+}
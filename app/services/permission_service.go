@@ -1,12 +1,28 @@
 package services
 
 import (
+	"errors"
+	"time"
+
 	"golang_starter_kit_2025/app/models"
+	"golang_starter_kit_2025/app/models/scopes"
+	"golang_starter_kit_2025/app/permissions"
 	"golang_starter_kit_2025/facades"
+
+	"gorm.io/gorm"
 )
 
 type PermissionService struct{}
 
+// PermissionSyncReport summarises what `permissions:sync` changed
+type PermissionSyncReport struct {
+	Created  []string
+	Updated  []string
+	Granted  []string
+	Orphaned []string
+	Pruned   bool
+}
+
 func (*PermissionService) GetAll() ([]models.Permission, error) {
 	var permissions []models.Permission
 	if err := facades.DB.Find(&permissions).Error; err != nil {
@@ -42,3 +58,134 @@ func (*PermissionService) Delete(id string) error {
 	}
 	return facades.DB.Delete(&permission).Error
 }
+
+// Sync upserts every permission declared in the code registry together with
+// its default role grants. Database permissions unknown to the registry are
+// reported as orphans and removed only when prune is true.
+func (*PermissionService) Sync(prune bool) (*PermissionSyncReport, error) {
+	report := &PermissionSyncReport{Pruned: prune}
+
+	err := facades.DB.Transaction(func(tx *gorm.DB) error {
+		roleIDs := make(map[string]uint)
+
+		for _, def := range permissions.All() {
+			var permission models.Permission
+			err := tx.Where("name = ?", def.Name).First(&permission).Error
+			switch {
+			case errors.Is(err, gorm.ErrRecordNotFound):
+				permission = models.Permission{Name: def.Name, Group: def.Group, Description: def.Description}
+				if err := tx.Create(&permission).Error; err != nil {
+					return err
+				}
+				report.Created = append(report.Created, def.Name)
+			case err != nil:
+				return err
+			case permission.Group != def.Group || permission.Description != def.Description:
+				if err := tx.Model(&permission).Updates(map[string]interface{}{
+					"group":       def.Group,
+					"description": def.Description,
+				}).Error; err != nil {
+					return err
+				}
+				report.Updated = append(report.Updated, def.Name)
+			}
+
+			for _, roleName := range def.Roles {
+				roleID, ok := roleIDs[roleName]
+				if !ok {
+					role := models.Role{Name: roleName}
+					if err := tx.Where("name = ?", roleName).Attrs(models.Role{Group: "System"}).FirstOrCreate(&role).Error; err != nil {
+						return err
+					}
+					roleID = role.ID
+					roleIDs[roleName] = roleID
+				}
+
+				var count int64
+				if err := tx.Model(&models.RoleHasPermissions{}).
+					Where("role_id = ? AND permission_id = ?", roleID, permission.ID).
+					Count(&count).Error; err != nil {
+					return err
+				}
+				if count == 0 {
+					if err := tx.Create(&models.RoleHasPermissions{RoleID: roleID, PermissionID: permission.ID}).Error; err != nil {
+						return err
+					}
+					report.Granted = append(report.Granted, roleName+" -> "+def.Name)
+				}
+			}
+		}
+
+		var orphans []models.Permission
+		if err := tx.Where("name NOT IN ?", permissions.Names()).Find(&orphans).Error; err != nil {
+			return err
+		}
+		orphanIDs := make([]uint, 0, len(orphans))
+		for _, orphan := range orphans {
+			report.Orphaned = append(report.Orphaned, orphan.Name)
+			orphanIDs = append(orphanIDs, orphan.ID)
+		}
+
+		if !prune || len(orphanIDs) == 0 {
+			return nil
+		}
+		if err := tx.Where("permission_id IN ?", orphanIDs).Delete(&models.RoleHasPermissions{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("permission_id IN ?", orphanIDs).Delete(&models.UserHasPermissions{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("id IN ?", orphanIDs).Delete(&models.Permission{}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return report, nil
+}
+
+// GetUserPermissionNames resolves the effective permissions of a user: those
+// granted through the user's own roles, through the roles of the groups the
+// user belongs to, and those assigned to the user directly.
+func (*PermissionService) GetUserPermissionNames(userId uint) ([]string, error) {
+	var names []string
+	if err := facades.DB.Model(&models.Permission{}).
+		Distinct("permissions.name").
+		Where("permissions.id IN (?) OR permissions.id IN (?)",
+			facades.DB.Model(&models.RoleHasPermissions{}).
+				Select("permission_id").
+				Where("role_id IN (?) OR role_id IN (?)", userRoleIds(facades.DB, userId), groupRoleIds(facades.DB, userId)),
+			facades.DB.Model(&models.UserHasPermissions{}).
+				Select("permission_id").
+				Where("user_id = ?", userId),
+		).
+		Pluck("permissions.name", &names).Error; err != nil {
+		return nil, err
+	}
+	return names, nil
+}
+
+// HasAnyPermission reports whether the user holds at least one of the given permissions
+func (service *PermissionService) HasAnyPermission(userId uint, names ...string) (bool, error) {
+	granted, err := service.GetUserPermissionNames(userId)
+	if err != nil {
+		return false, err
+	}
+	for _, name := range names {
+		for _, g := range granted {
+			if g == name {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+// userRoleIds builds a subquery selecting the IDs of the roles currently
+// granted to a user, skipping grants that have not started or have expired
+func userRoleIds(db *gorm.DB, userId uint) *gorm.DB {
+	return db.Model(&models.UserHasRole{}).
+		Select("role_id").
+		Where("user_id = ?", userId).
+		Scopes(scopes.ActiveRoleGrant(time.Now()))
+}
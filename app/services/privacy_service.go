@@ -0,0 +1,430 @@
+package services
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/fs"
+	"path"
+	"strings"
+	"time"
+
+	"golang_starter_kit_2025/app/models"
+	"golang_starter_kit_2025/facades"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrAlreadyErased      = errors.New("personal data of this user has already been erased")
+	ErrCannotEraseOwnData = errors.New("you cannot erase your own account")
+)
+
+// PersonalDataExport is what a subject access request returns: every row
+// stored about the user. Duplicate flags and sanctions/PEP screening results
+// are left out on purpose, disclosing them would tip off the applicant.
+type PersonalDataExport struct {
+	GeneratedAt    time.Time                  `json:"generated_at"`
+	User           PersonalDataUser           `json:"user"`
+	StatusHistory  []models.UserStatusHistory `json:"status_history"`
+	AccessRequests []models.AccessRequest     `json:"access_requests"`
+	KycSubmissions []models.KycSubmission     `json:"kyc_submissions"`
+	Stores         []models.Store             `json:"stores"`
+}
+
+// PersonalDataUser is the user row without credentials and tokens
+type PersonalDataUser struct {
+	ID              uint              `json:"id"`
+	Reference       string            `json:"reference"`
+	Username        string            `json:"username"`
+	Email           string            `json:"email"`
+	NIK             *string           `json:"nik"`
+	Status          models.UserStatus `json:"status"`
+	StatusReason    string            `json:"status_reason"`
+	StatusChangedAt *time.Time        `json:"status_changed_at"`
+	Roles           []string          `json:"roles"`
+	CreatedAt       time.Time         `json:"created_at"`
+	UpdatedAt       time.Time         `json:"updated_at"`
+}
+
+type PrivacyService struct{}
+
+// Collect gathers the personal data of a user for a subject access request
+func (*PrivacyService) Collect(userId string) (PersonalDataExport, error) {
+	export := PersonalDataExport{GeneratedAt: time.Now()}
+
+	var user models.User
+	if err := facades.DB.Preload("Roles").First(&user, userId).Error; err != nil {
+		return export, err
+	}
+	export.User = PersonalDataUser{
+		ID:              user.ID,
+		Reference:       user.Reference,
+		Username:        user.Username,
+		Email:           user.Email,
+		NIK:             user.NIK,
+		Status:          user.Status,
+		StatusReason:    user.StatusReason,
+		StatusChangedAt: user.StatusChangedAt,
+		Roles:           make([]string, len(user.Roles)),
+		CreatedAt:       user.CreatedAt,
+		UpdatedAt:       user.UpdatedAt,
+	}
+	for i, role := range user.Roles {
+		export.User.Roles[i] = role.Name
+	}
+
+	if err := facades.DB.Where("user_id = ?", user.ID).Order("id ASC").Find(&export.StatusHistory).Error; err != nil {
+		return export, err
+	}
+	if err := facades.DB.Where("user_id = ?", user.ID).Order("id ASC").Find(&export.AccessRequests).Error; err != nil {
+		return export, err
+	}
+	err := preloadKycSubmission(facades.DB).
+		Preload("RiskAssessments", func(db *gorm.DB) *gorm.DB {
+			return db.Order("id ASC")
+		}).
+		Where("user_id = ?", user.ID).
+		Order("id ASC").
+		Find(&export.KycSubmissions).Error
+	if err != nil {
+		return export, err
+	}
+	err = preloadStore(facades.DB).Where("owner_id = ?", user.ID).Order("id ASC").Find(&export.Stores).Error
+	return export, err
+}
+
+// WriteArchive writes export as a ZIP archive: one JSON file per kind of row,
+// the decrypted KYC document images under files/ and a manifest listing
+// everything, including files that could no longer be found in storage
+func (*PrivacyService) WriteArchive(w io.Writer, export PersonalDataExport) error {
+	archive := zip.NewWriter(w)
+
+	manifest := struct {
+		GeneratedAt  time.Time `json:"generated_at"`
+		User         string    `json:"user"`
+		Files        []string  `json:"files"`
+		MissingFiles []string  `json:"missing_files"`
+	}{GeneratedAt: export.GeneratedAt, User: export.User.Reference, Files: []string{}, MissingFiles: []string{}}
+
+	parts := []struct {
+		name  string
+		value interface{}
+	}{
+		{"user.json", export.User},
+		{"status_history.json", export.StatusHistory},
+		{"access_requests.json", export.AccessRequests},
+		{"kyc_submissions.json", export.KycSubmissions},
+		{"stores.json", export.Stores},
+	}
+	for _, part := range parts {
+		if err := writeArchiveJSON(archive, part.name, part.value); err != nil {
+			return err
+		}
+		manifest.Files = append(manifest.Files, part.name)
+	}
+
+	addFile := func(name string, file *models.File) error {
+		if file == nil {
+			manifest.MissingFiles = append(manifest.MissingFiles, name)
+			return nil
+		}
+		name += path.Ext(file.Path)
+
+		err := writeArchiveFile(archive, name, file.Path)
+		switch {
+		case errors.Is(err, fs.ErrNotExist):
+			manifest.MissingFiles = append(manifest.MissingFiles, name)
+		case err != nil:
+			return err
+		default:
+			manifest.Files = append(manifest.Files, name)
+		}
+		return nil
+	}
+	for _, submission := range export.KycSubmissions {
+		for _, document := range submission.Documents {
+			name := path.Join("files", "kyc", submission.Reference, string(document.Type))
+			if err := addFile(name, document.File); err != nil {
+				return err
+			}
+		}
+	}
+	for _, store := range export.Stores {
+		for _, document := range store.Documents {
+			name := path.Join("files", "stores", store.Reference, string(document.Type))
+			if err := addFile(name, document.File); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := writeArchiveJSON(archive, "manifest.json", manifest); err != nil {
+		return err
+	}
+	return archive.Close()
+}
+
+// Erase anonymises a user and their KYC submissions and deletes the stored
+// KYC documents. What the law requires to be kept stays: the submission
+// references and outcomes, risk scores without the facts behind them and the
+// screening results, plus an erasure record as tombstone. actorId is nil
+// when erasure is triggered by the retention policy.
+func (*PrivacyService) Erase(userId string, actorId *uint, reason string) (models.ErasureRecord, error) {
+	var record models.ErasureRecord
+	var files []string
+	var documents []models.File
+	err := facades.DB.Transaction(func(tx *gorm.DB) error {
+		var user models.User
+		if err := tx.Unscoped().Clauses(clause.Locking{Strength: "UPDATE"}).First(&user, userId).Error; err != nil {
+			return err
+		}
+		if actorId != nil && *actorId == user.ID {
+			return ErrCannotEraseOwnData
+		}
+		var erased int64
+		if err := tx.Model(&models.ErasureRecord{}).Where("user_id = ?", user.ID).Count(&erased).Error; err != nil {
+			return err
+		}
+		if erased > 0 {
+			return ErrAlreadyErased
+		}
+
+		now := time.Now()
+		record = models.ErasureRecord{
+			UserID:        user.ID,
+			UserReference: user.Reference,
+			Reason:        reason,
+			RequestedBy:   actorId,
+			Submissions:   []models.ErasedSubmission{},
+			ErasedAt:      now,
+		}
+
+		var submissions []models.KycSubmission
+		if err := tx.Where("user_id = ?", user.ID).Order("id ASC").Find(&submissions).Error; err != nil {
+			return err
+		}
+		ids := make([]uint, len(submissions))
+		for i, submission := range submissions {
+			ids[i] = submission.ID
+			record.Submissions = append(record.Submissions, models.ErasedSubmission{
+				Reference:   submission.Reference,
+				Status:      submission.Status,
+				SubmittedAt: submission.SubmittedAt,
+				DecidedAt:   submission.DecidedAt,
+			})
+		}
+		var err error
+		if documents, err = anonymiseKycSubmissions(tx, ids, now); err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ? OR matched_user_id = ?", user.ID, user.ID).Delete(&models.KycFlag{}).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Where("user_id = ?", user.ID).Delete(&models.UserHasRole{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", user.ID).Delete(&models.UserHasPermissions{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", user.ID).Delete(&models.UserGroupHasUser{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.AccessRequest{}).Where("user_id = ?", user.ID).Update("justification", "").Error; err != nil {
+			return err
+		}
+		// stores are business data and stay, only the link to the owner's NIK goes
+		if err := tx.Unscoped().Model(&models.Store{}).Where("owner_id = ?", user.ID).Update("owner_nik_bidx", nil).Error; err != nil {
+			return err
+		}
+		// files stay for the documents that are kept, without the owner
+		if err := tx.Model(&models.File{}).Where("owner_id = ?", user.ID).UpdateColumns(map[string]interface{}{
+			"owner_id":      nil,
+			"original_name": "",
+		}).Error; err != nil {
+			return err
+		}
+		// reads of sensitive files stay in the access log, without where they came from
+		if err := tx.Model(&models.FileAccessLog{}).Where("user_id = ?", user.ID).UpdateColumns(map[string]interface{}{
+			"ip":         "",
+			"user_agent": "",
+		}).Error; err != nil {
+			return err
+		}
+		uploads, err := deleteUploads(tx, tx.Where("user_id = ?", user.ID))
+		if err != nil {
+			return err
+		}
+		files = append(files, uploads...)
+		quarantined, err := eraseQuarantinedFiles(tx, user.ID)
+		if err != nil {
+			return err
+		}
+		files = append(files, quarantined...)
+
+		from := user.Status
+		if from == "" {
+			from = models.UserStatusActive
+		}
+		user.Username = "erased-" + strings.ToLower(user.Reference)
+		user.Email = strings.ToLower(user.Reference) + "@erased.invalid"
+		user.NIK = nil
+		user.Password = ""
+		user.Pin = ""
+		user.JwtToken = ""
+		user.FcmToken = ""
+		user.Status = models.UserStatusClosed
+		user.StatusReason = reason
+		user.StatusChangedAt = &now
+		if err := tx.Unscoped().Model(&user).Select(
+			"username", "email", "email_bidx", "email_canonical_bidx", "nik", "nik_bidx",
+			"password", "pin", "jwt_token", "fcm_token", "status", "status_reason", "status_changed_at",
+		).Updates(&user).Error; err != nil {
+			return err
+		}
+		if from != models.UserStatusClosed {
+			if err := tx.Create(&models.UserStatusHistory{
+				UserID:     user.ID,
+				FromStatus: from,
+				ToStatus:   models.UserStatusClosed,
+				Reason:     reason,
+				ChangedBy:  actorId,
+			}).Error; err != nil {
+				return err
+			}
+		}
+		if !user.DeletedAt.Valid {
+			if err := tx.Delete(&user).Error; err != nil {
+				return err
+			}
+		}
+
+		return tx.Create(&record).Error
+	})
+	if err != nil {
+		return record, err
+	}
+
+	record.FilesDeleted = removeStoredFiles(files) + removeFiles(documents)
+	err = facades.DB.Model(&record).Update("files_deleted", record.FilesDeleted).Error
+	return record, err
+}
+
+// ErasureRecords lists the tombstones of erased users, newest first
+func (*PrivacyService) ErasureRecords() ([]models.ErasureRecord, error) {
+	var records []models.ErasureRecord
+	if err := facades.DB.Order("id DESC").Find(&records).Error; err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+// anonymiseKycSubmissions clears the personal data of the given submissions,
+// the reviewer comments about them and the facts their risk was scored on,
+// and deletes their documents. It returns the document files to be removed
+// once the transaction has committed.
+func anonymiseKycSubmissions(tx *gorm.DB, ids []uint, now time.Time) ([]models.File, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	files, err := deleteKycDocuments(tx, tx.Where("submission_id IN ?", ids))
+	if err != nil {
+		return nil, err
+	}
+
+	// Encrypted columns are set to NULL directly, which is how the
+	// serializer stores empty values
+	if err := tx.Model(&models.KycSubmission{}).Where("id IN ?", ids).UpdateColumns(map[string]interface{}{
+		"nik":              nil,
+		"nik_bidx":         "",
+		"full_name":        nil,
+		"birth_place":      nil,
+		"birth_date":       nil,
+		"birth_date_bidx":  "",
+		"gender":           "",
+		"address":          nil,
+		"phone":            nil,
+		"phone_bidx":       "",
+		"reviewer_comment": "",
+		"anonymized_at":    now,
+	}).Error; err != nil {
+		return nil, err
+	}
+	if err := tx.Model(&models.KycTransition{}).Where("submission_id IN ?", ids).Update("comment", "").Error; err != nil {
+		return nil, err
+	}
+	if err := tx.Model(&models.KycRiskAssessment{}).Where("submission_id IN ?", ids).UpdateColumns(map[string]interface{}{
+		"facts":         nil,
+		"contributions": nil,
+	}).Error; err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+// deleteKycDocuments deletes the document rows matched by query together
+// with their rows in the files table, and returns the files whose stored
+// object is no longer shared
+func deleteKycDocuments(tx *gorm.DB, query *gorm.DB) ([]models.File, error) {
+	var documents []models.KycDocument
+	if err := query.Find(&documents).Error; err != nil {
+		return nil, err
+	}
+	if len(documents) == 0 {
+		return nil, nil
+	}
+
+	ids := make([]uint, len(documents))
+	var fileIds []uint
+	for i, document := range documents {
+		ids[i] = document.ID
+		if document.FileID != nil {
+			fileIds = append(fileIds, *document.FileID)
+		}
+	}
+	if err := tx.Delete(&models.KycDocument{}, ids).Error; err != nil {
+		return nil, err
+	}
+	return deleteFileRecords(tx, fileIds)
+}
+
+// removeStoredFiles deletes files from storage and returns how many were
+// removed
+func removeStoredFiles(files []string) int {
+	removed := 0
+	for _, file := range files {
+		if err := (FileService{}).Delete(file); err == nil {
+			removed++
+		}
+	}
+	return removed
+}
+
+func writeArchiveJSON(archive *zip.Writer, name string, value interface{}) error {
+	w, err := archive.Create(name)
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(value)
+}
+
+func writeArchiveFile(archive *zip.Writer, name string, file string) error {
+	src, _, err := FileService{}.Open(file)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	w, err := archive.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(w, src)
+	return err
+}
@@ -0,0 +1,197 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"golang_starter_kit_2025/app/helpers"
+	"golang_starter_kit_2025/app/models"
+	"golang_starter_kit_2025/facades"
+
+	"gorm.io/gorm"
+)
+
+type RetentionCategory string
+
+const (
+	RetentionDraftSubmissions    RetentionCategory = "draft_submissions"
+	RetentionRejectedSubmissions RetentionCategory = "rejected_submissions"
+	RetentionKycDocuments        RetentionCategory = "kyc_documents"
+	RetentionScreeningHistory    RetentionCategory = "screening_history"
+	RetentionRiskHistory         RetentionCategory = "risk_history"
+	RetentionClosedAccounts      RetentionCategory = "closed_accounts"
+)
+
+// RetentionPolicy says how many days data of a category is kept. Zero keeps
+// it forever.
+type RetentionPolicy struct {
+	Category    RetentionCategory `json:"category"`
+	Days        int               `json:"days"`
+	Description string            `json:"description"`
+}
+
+// Env is the variable overriding the default number of days
+func (p RetentionPolicy) Env() string {
+	return "RETENTION_" + strings.ToUpper(string(p.Category)) + "_DAYS"
+}
+
+var defaultRetentionPolicies = []RetentionPolicy{
+	{RetentionDraftSubmissions, 90, "Pengajuan KYC draft yang tidak pernah dikirim dihapus beserta dokumennya"},
+	{RetentionRejectedSubmissions, 365, "Data pribadi pengajuan KYC yang ditolak dianonimkan setelah keputusan"},
+	{RetentionKycDocuments, 1825, "Foto dokumen KYC yang sudah diputuskan dihapus dari storage"},
+	{RetentionScreeningHistory, 1825, "Hasil screening lama dihapus, hasil terakhir setiap user tetap disimpan"},
+	{RetentionRiskHistory, 1825, "Penilaian risiko lama dihapus, penilaian terakhir setiap pengajuan tetap disimpan"},
+	{RetentionClosedAccounts, 30, "Akun yang ditutup atau dihapus dianonimkan seperti permintaan penghapusan data"},
+}
+
+// RetentionResult is what enforcing one policy did
+type RetentionResult struct {
+	RetentionPolicy
+	Affected     int `json:"affected"`
+	FilesDeleted int `json:"files_deleted"`
+}
+
+type RetentionService struct{}
+
+// Policies returns the retention policy of every category with the days
+// overridden from RETENTION_<CATEGORY>_DAYS
+func (*RetentionService) Policies() []RetentionPolicy {
+	policies := make([]RetentionPolicy, len(defaultRetentionPolicies))
+	for i, policy := range defaultRetentionPolicies {
+		policy.Days = helpers.GetEnvInt(policy.Env(), policy.Days)
+		policies[i] = policy
+	}
+	return policies
+}
+
+// Enforce applies every policy to the data older than its cutoff relative to
+// now. Each category runs in its own transaction, so a failing category does
+// not roll back those before it.
+func (s *RetentionService) Enforce(now time.Time, progress func(RetentionResult)) ([]RetentionResult, error) {
+	var results []RetentionResult
+	for _, policy := range s.Policies() {
+		result := RetentionResult{RetentionPolicy: policy}
+		if policy.Days > 0 {
+			cutoff := now.AddDate(0, 0, -policy.Days)
+			var err error
+			if result.Affected, result.FilesDeleted, err = enforceRetention(policy.Category, cutoff); err != nil {
+				return results, fmt.Errorf("%s: %w", policy.Category, err)
+			}
+		}
+		results = append(results, result)
+		if progress != nil {
+			progress(result)
+		}
+	}
+	return results, nil
+}
+
+func enforceRetention(category RetentionCategory, cutoff time.Time) (affected int, filesDeleted int, err error) {
+	if category == RetentionClosedAccounts {
+		return eraseClosedAccounts(cutoff)
+	}
+
+	var files []models.File
+	err = facades.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		switch category {
+		case RetentionDraftSubmissions:
+			affected, files, err = deleteDraftSubmissions(tx, cutoff)
+		case RetentionRejectedSubmissions:
+			var ids []uint
+			if err := tx.Model(&models.KycSubmission{}).
+				Where("status = ? AND decided_at < ? AND anonymized_at IS NULL", models.KycStatusRejected, cutoff).
+				Pluck("id", &ids).Error; err != nil {
+				return err
+			}
+			affected = len(ids)
+			files, err = anonymiseKycSubmissions(tx, ids, time.Now())
+		case RetentionKycDocuments:
+			decided := tx.Model(&models.KycSubmission{}).Select("id").
+				Where("status IN ? AND decided_at < ?", []models.KycStatus{models.KycStatusApproved, models.KycStatusRejected}, cutoff)
+			var count int64
+			if err := tx.Model(&models.KycDocument{}).Where("submission_id IN (?)", decided).Count(&count).Error; err != nil {
+				return err
+			}
+			affected = int(count)
+			files, err = deleteKycDocuments(tx, tx.Where("submission_id IN (?)", decided))
+		case RetentionScreeningHistory:
+			latest := tx.Model(&models.ScreeningResult{}).Select("MAX(id)").Group("user_id")
+			var ids []uint
+			if err := tx.Model(&models.ScreeningResult{}).
+				Where("created_at < ? AND id NOT IN (?)", cutoff, latest).
+				Pluck("id", &ids).Error; err != nil {
+				return err
+			}
+			if len(ids) == 0 {
+				return nil
+			}
+			if err := tx.Where("result_id IN ?", ids).Delete(&models.ScreeningHit{}).Error; err != nil {
+				return err
+			}
+			affected = len(ids)
+			err = tx.Delete(&models.ScreeningResult{}, ids).Error
+		case RetentionRiskHistory:
+			latest := tx.Model(&models.KycRiskAssessment{}).Select("MAX(id)").Group("submission_id")
+			result := tx.Where("created_at < ? AND id NOT IN (?)", cutoff, latest).Delete(&models.KycRiskAssessment{})
+			affected, err = int(result.RowsAffected), result.Error
+		}
+		return err
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+	return affected, removeFiles(files), nil
+}
+
+// deleteDraftSubmissions deletes drafts untouched since cutoff together with
+// their documents. A draft was never submitted, so nothing of it has to be
+// kept.
+func deleteDraftSubmissions(tx *gorm.DB, cutoff time.Time) (int, []models.File, error) {
+	var ids []uint
+	if err := tx.Model(&models.KycSubmission{}).
+		Where("status = ? AND updated_at < ?", models.KycStatusDraft, cutoff).
+		Pluck("id", &ids).Error; err != nil {
+		return 0, nil, err
+	}
+	if len(ids) == 0 {
+		return 0, nil, nil
+	}
+
+	files, err := deleteKycDocuments(tx, tx.Where("submission_id IN ?", ids))
+	if err != nil {
+		return 0, nil, err
+	}
+	if err := tx.Where("submission_id IN ?", ids).Delete(&models.KycTransition{}).Error; err != nil {
+		return 0, nil, err
+	}
+	if err := tx.Delete(&models.KycSubmission{}, ids).Error; err != nil {
+		return 0, nil, err
+	}
+	return len(ids), files, nil
+}
+
+// eraseClosedAccounts erases every user closed or deleted before cutoff that
+// has not been erased yet
+func eraseClosedAccounts(cutoff time.Time) (int, int, error) {
+	erased := facades.DB.Model(&models.ErasureRecord{}).Select("user_id")
+	var ids []uint
+	if err := facades.DB.Unscoped().Model(&models.User{}).
+		Where("(status = ? AND status_changed_at < ?) OR deleted_at < ?", models.UserStatusClosed, cutoff, cutoff).
+		Where("id NOT IN (?)", erased).
+		Pluck("id", &ids).Error; err != nil {
+		return 0, 0, err
+	}
+
+	service := PrivacyService{}
+	filesDeleted := 0
+	for _, id := range ids {
+		record, err := service.Erase(fmt.Sprint(id), nil, "Retensi: "+string(RetentionClosedAccounts))
+		if err != nil {
+			return 0, 0, err
+		}
+		filesDeleted += record.FilesDeleted
+	}
+	return len(ids), filesDeleted, nil
+}
@@ -4,7 +4,17 @@ import (
 	"errors"
 
 	"golang_starter_kit_2025/app/models"
+	"golang_starter_kit_2025/app/responses"
 	"golang_starter_kit_2025/facades"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrInvalidPermissionIds = errors.New("one or more permission IDs are invalid")
+	ErrInvalidRoleIds       = errors.New("one or more role IDs are invalid")
+	ErrConflictingIds       = errors.New("the same ID cannot be both added and removed")
 )
 
 type RoleService struct{}
@@ -45,35 +55,40 @@ func (*RoleService) Delete(id string) error {
 	return facades.DB.Delete(&role).Error
 }
 
+// AssignPermissionsToRole replaces the permissions of a role with the given set
 func (*RoleService) AssignPermissionsToRole(roleId string, permissions []uint) error {
-	var role models.Role
-	if err := facades.DB.First(&role, roleId).Error; err != nil {
-		return err
-	}
-
-	// Validasi permissions sebelum diassign
-	var validPermissions []uint
-	facades.DB.Table("permissions").Where("id IN ?", permissions).Pluck("id", &validPermissions)
-
-	if len(validPermissions) != len(permissions) {
-		return errors.New("one or more permission IDs are invalid")
-	}
+	return facades.DB.Transaction(func(tx *gorm.DB) error {
+		role, err := lockRole(tx, roleId)
+		if err != nil {
+			return err
+		}
+		return replaceRolePermissions(tx, role.ID, permissions)
+	})
+}
 
-	// Clear existing permissions for the role
-	facades.DB.Where("role_id = ?", role.ID).Delete(&models.RoleHasPermissions{})
+// UpdateRolePermissions grants and revokes individual permissions of a role,
+// leaving every other grant untouched
+func (*RoleService) UpdateRolePermissions(roleId string, add, remove []uint) error {
+	return facades.DB.Transaction(func(tx *gorm.DB) error {
+		role, err := lockRole(tx, roleId)
+		if err != nil {
+			return err
+		}
 
-	// Assign new permissions
-	for _, permId := range validPermissions {
-		rolePerm := models.RoleHasPermissions{
-			RoleID:       role.ID,
-			PermissionID: permId,
+		if overlaps(add, remove) {
+			return ErrConflictingIds
 		}
-		if err := facades.DB.Create(&rolePerm).Error; err != nil {
+		if err := validateIds(tx, &models.Permission{}, append(append([]uint{}, add...), remove...), ErrInvalidPermissionIds); err != nil {
 			return err
 		}
-	}
 
-	return nil
+		current, err := rolePermissionIds(tx, role.ID)
+		if err != nil {
+			return err
+		}
+		toAdd, _ := diffIds(current, uniqueIds(add))
+		return applyRolePermissions(tx, role.ID, toAdd, uniqueIds(remove))
+	})
 }
 
 func (*RoleService) GetPermissionsByRoleId(roleId string) ([]models.Permission, error) {
@@ -87,3 +102,163 @@ func (*RoleService) GetPermissionsByRoleId(roleId string) ([]models.Permission,
 	}
 	return permissions, nil
 }
+
+// GetPermissionMatrix returns every role, every permission and the grants
+// between them so that an admin UI can render a role×permission grid
+func (*RoleService) GetPermissionMatrix() (*responses.PermissionMatrix, error) {
+	matrix := &responses.PermissionMatrix{Grants: make(map[uint][]uint)}
+
+	if err := facades.DB.Order("name").Find(&matrix.Roles).Error; err != nil {
+		return nil, err
+	}
+	if err := facades.DB.Order(clause.OrderByColumn{Column: clause.Column{Name: "group"}}).Order("name").Find(&matrix.Permissions).Error; err != nil {
+		return nil, err
+	}
+
+	var grants []models.RoleHasPermissions
+	if err := facades.DB.Order("role_id, permission_id").Find(&grants).Error; err != nil {
+		return nil, err
+	}
+	for _, role := range matrix.Roles {
+		matrix.Grants[role.ID] = []uint{}
+	}
+	for _, grant := range grants {
+		matrix.Grants[grant.RoleID] = append(matrix.Grants[grant.RoleID], grant.PermissionID)
+	}
+
+	return matrix, nil
+}
+
+// UpdatePermissionMatrix replaces the permissions of every role present in
+// grants within a single transaction. Roles not present are left untouched.
+func (*RoleService) UpdatePermissionMatrix(grants map[uint][]uint) error {
+	return facades.DB.Transaction(func(tx *gorm.DB) error {
+		roleIds := make([]uint, 0, len(grants))
+		for roleId := range grants {
+			roleIds = append(roleIds, roleId)
+		}
+		if err := validateIds(tx, &models.Role{}, roleIds, ErrInvalidRoleIds); err != nil {
+			return err
+		}
+
+		var roles []models.Role
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id IN ?", roleIds).Find(&roles).Error; err != nil {
+			return err
+		}
+
+		for _, role := range roles {
+			if err := replaceRolePermissions(tx, role.ID, grants[role.ID]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// lockRole loads a role and locks its row for the rest of the transaction so
+// that concurrent assignments to the same role are serialised
+func lockRole(tx *gorm.DB, roleId string) (models.Role, error) {
+	var role models.Role
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&role, roleId).Error
+	return role, err
+}
+
+func replaceRolePermissions(tx *gorm.DB, roleId uint, permissions []uint) error {
+	permissions = uniqueIds(permissions)
+	if err := validateIds(tx, &models.Permission{}, permissions, ErrInvalidPermissionIds); err != nil {
+		return err
+	}
+
+	current, err := rolePermissionIds(tx, roleId)
+	if err != nil {
+		return err
+	}
+	add, remove := diffIds(current, permissions)
+	return applyRolePermissions(tx, roleId, add, remove)
+}
+
+func rolePermissionIds(tx *gorm.DB, roleId uint) ([]uint, error) {
+	var ids []uint
+	err := tx.Model(&models.RoleHasPermissions{}).Where("role_id = ?", roleId).Pluck("permission_id", &ids).Error
+	return ids, err
+}
+
+func applyRolePermissions(tx *gorm.DB, roleId uint, add, remove []uint) error {
+	if len(remove) > 0 {
+		if err := tx.Where("role_id = ? AND permission_id IN ?", roleId, remove).Delete(&models.RoleHasPermissions{}).Error; err != nil {
+			return err
+		}
+	}
+	if len(add) == 0 {
+		return nil
+	}
+
+	rows := make([]models.RoleHasPermissions, 0, len(add))
+	for _, permissionId := range add {
+		rows = append(rows, models.RoleHasPermissions{RoleID: roleId, PermissionID: permissionId})
+	}
+	return tx.Create(&rows).Error
+}
+
+// validateIds checks that every ID refers to an existing row of model
+func validateIds(tx *gorm.DB, model interface{}, ids []uint, invalid error) error {
+	ids = uniqueIds(ids)
+	if len(ids) == 0 {
+		return nil
+	}
+
+	var count int64
+	if err := tx.Model(model).Where("id IN ?", ids).Count(&count).Error; err != nil {
+		return err
+	}
+	if count != int64(len(ids)) {
+		return invalid
+	}
+	return nil
+}
+
+func uniqueIds(ids []uint) []uint {
+	seen := make(map[uint]bool, len(ids))
+	unique := make([]uint, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	return unique
+}
+
+func overlaps(a, b []uint) bool {
+	inA := make(map[uint]bool, len(a))
+	for _, id := range a {
+		inA[id] = true
+	}
+	for _, id := range b {
+		if inA[id] {
+			return true
+		}
+	}
+	return false
+}
+
+// diffIds returns the IDs of next missing from current and the IDs of current missing from next
+func diffIds(current, next []uint) (add, remove []uint) {
+	inCurrent := make(map[uint]bool, len(current))
+	for _, id := range current {
+		inCurrent[id] = true
+	}
+	inNext := make(map[uint]bool, len(next))
+	for _, id := range next {
+		inNext[id] = true
+		if !inCurrent[id] {
+			add = append(add, id)
+		}
+	}
+	for _, id := range current {
+		if !inNext[id] {
+			remove = append(remove, id)
+		}
+	}
+	return add, remove
+}
@@ -0,0 +1,361 @@
+package services
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"golang_starter_kit_2025/app/helpers"
+	"golang_starter_kit_2025/app/models"
+	"golang_starter_kit_2025/app/screening"
+	"golang_starter_kit_2025/facades"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// kycNationality is the nationality of KYC applicants: a NIK is only issued
+// to Indonesian citizens
+const kycNationality = "ID"
+
+var (
+	ErrWatchlistFormat           = errors.New("watchlist file must be .csv or .json")
+	ErrScreeningHitInvalidStatus = errors.New("a screening hit can only be confirmed or cleared")
+)
+
+// ScreeningRescreenReport counts the users screened by Rescreen
+type ScreeningRescreenReport struct {
+	Screened         int
+	PotentialMatches int
+}
+
+// screeningIndex caches the entries of the active list versions, rebuilt
+// whenever the set of active versions changes
+var screeningIndex struct {
+	sync.Mutex
+	versions []uint
+	index    *screening.Index
+	lists    map[uint]uint
+}
+
+type ScreeningService struct{}
+
+// Import loads a CSV or JSON list file as a new version of the named list and
+// makes it the active one. Importing a file identical to the active version
+// changes nothing and returns created false.
+func (*ScreeningService) Import(name string, category models.WatchlistCategory, path string) (version models.WatchlistVersion, created bool, err error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return version, false, err
+	}
+	var entries []screening.Entry
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		entries, err = screening.ParseCSV(bytes.NewReader(content))
+	case ".json":
+		entries, err = screening.ParseJSON(bytes.NewReader(content))
+	default:
+		err = ErrWatchlistFormat
+	}
+	if err != nil {
+		return version, false, err
+	}
+	sum := sha256.Sum256(content)
+	checksum := hex.EncodeToString(sum[:])
+
+	err = facades.DB.Transaction(func(tx *gorm.DB) error {
+		var list models.Watchlist
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Preload("ActiveVersion").
+			Where(models.Watchlist{Name: name}).
+			Attrs(models.Watchlist{Category: category}).
+			FirstOrCreate(&list).Error; err != nil {
+			return err
+		}
+		if list.ActiveVersion != nil && list.ActiveVersion.Checksum == checksum {
+			version = *list.ActiveVersion
+			return nil
+		}
+
+		var latest int
+		if err := tx.Model(&models.WatchlistVersion{}).Where("watchlist_id = ?", list.ID).
+			Select("COALESCE(MAX(version), 0)").Scan(&latest).Error; err != nil {
+			return err
+		}
+		version = models.WatchlistVersion{
+			WatchlistID: list.ID,
+			Version:     latest + 1,
+			SourceFile:  filepath.Base(path),
+			Checksum:    checksum,
+			EntryCount:  len(entries),
+		}
+		if err := tx.Create(&version).Error; err != nil {
+			return err
+		}
+
+		rows := make([]models.WatchlistEntry, len(entries))
+		for i, entry := range entries {
+			rows[i] = models.WatchlistEntry{
+				WatchlistID:   list.ID,
+				VersionID:     version.ID,
+				ExternalID:    entry.ExternalID,
+				Name:          entry.Name,
+				Aliases:       entry.Aliases,
+				BirthDates:    entry.BirthDates,
+				Nationalities: entry.Nationalities,
+				Remarks:       entry.Remarks,
+			}
+		}
+		if err := tx.CreateInBatches(rows, 500).Error; err != nil {
+			return err
+		}
+
+		created = true
+		// the preloaded ActiveVersion would otherwise overwrite active_version_id
+		return tx.Model(&list).Omit(clause.Associations).Updates(map[string]interface{}{
+			"category":          category,
+			"active_version_id": version.ID,
+		}).Error
+	})
+	return version, created, err
+}
+
+// Watchlists returns the loaded lists with their active version
+func (*ScreeningService) Watchlists() ([]models.Watchlist, error) {
+	var lists []models.Watchlist
+	err := facades.DB.Preload("ActiveVersion").Order("name ASC").Find(&lists).Error
+	return lists, err
+}
+
+// Rescreen screens the latest submitted KYC data of every user against the
+// active list versions. Each user is screened in a transaction of its own,
+// so the application keeps running while it works. progress, when not nil,
+// is called after every user.
+func (s *ScreeningService) Rescreen(progress func(ScreeningRescreenReport)) (ScreeningRescreenReport, error) {
+	var report ScreeningRescreenReport
+
+	var ids []uint
+	if err := facades.DB.Model(&models.KycSubmission{}).
+		Select("MAX(id)").
+		Where("status <> ?", models.KycStatusDraft).
+		Group("user_id").
+		Order("MAX(id)").
+		Pluck("MAX(id)", &ids).Error; err != nil {
+		return report, err
+	}
+
+	for _, id := range ids {
+		err := facades.DB.Transaction(func(tx *gorm.DB) error {
+			var submission models.KycSubmission
+			if err := tx.First(&submission, id).Error; err != nil {
+				return err
+			}
+			result, err := s.ScreenSubmission(tx, submission)
+			if err != nil {
+				return err
+			}
+			if result.Status == models.ScreeningPotentialMatch {
+				report.PotentialMatches++
+			}
+			return nil
+		})
+		if err != nil {
+			return report, fmt.Errorf("submission %d: %w", id, err)
+		}
+
+		report.Screened++
+		if progress != nil {
+			progress(report)
+		}
+	}
+	return report, nil
+}
+
+// ScreenSubmission screens the applicant of a submission against the active
+// list versions and records the result. Hits on entries a reviewer already
+// confirmed or cleared for this user keep that verdict. Nothing is recorded
+// while no list has been imported.
+func (*ScreeningService) ScreenSubmission(tx *gorm.DB, submission models.KycSubmission) (models.ScreeningResult, error) {
+	result := models.ScreeningResult{UserID: submission.UserID, SubmissionID: submission.ID, Status: models.ScreeningClear}
+
+	index, lists, versions, err := activeScreeningIndex(tx)
+	if err != nil || len(versions) == 0 {
+		return result, err
+	}
+
+	opts := screening.Options{Threshold: helpers.GetEnvFloat("SCREENING_MATCH_THRESHOLD", screening.DefaultOptions.Threshold)}
+	hits := index.Screen(screening.Subject{
+		Name:        submission.FullName,
+		BirthDate:   submission.BirthDate,
+		Nationality: kycNationality,
+	}, opts)
+
+	var reviewed []models.ScreeningHit
+	if err := tx.Where("user_id = ? AND status <> ?", submission.UserID, models.ScreeningHitOpen).
+		Order("id ASC").Find(&reviewed).Error; err != nil {
+		return result, err
+	}
+	verdicts := map[string]models.ScreeningHit{}
+	for _, hit := range reviewed {
+		verdicts[screeningHitKey(hit.WatchlistID, hit.ExternalID, hit.EntryName)] = hit
+	}
+
+	result.VersionIDs = versions
+	result.Threshold = opts.Threshold
+	result.HitCount = len(hits)
+	for _, hit := range hits {
+		row := models.ScreeningHit{
+			UserID:      submission.UserID,
+			WatchlistID: lists[hit.Entry.ID],
+			EntryID:     hit.Entry.ID,
+			ExternalID:  hit.Entry.ExternalID,
+			EntryName:   hit.Entry.Name,
+			MatchedName: hit.MatchedName,
+			Score:       hit.Score,
+			Status:      models.ScreeningHitOpen,
+		}
+		if verdict, ok := verdicts[screeningHitKey(row.WatchlistID, row.ExternalID, row.EntryName)]; ok {
+			row.Status = verdict.Status
+			row.ReviewedBy = verdict.ReviewedBy
+			row.ReviewedAt = verdict.ReviewedAt
+			row.Note = verdict.Note
+		}
+		if row.Status != models.ScreeningHitCleared {
+			result.Status = models.ScreeningPotentialMatch
+		}
+		result.Hits = append(result.Hits, row)
+	}
+
+	err = tx.Create(&result).Error
+	return result, err
+}
+
+// Results returns the screening history of a user, latest first
+func (*ScreeningService) Results(userId string) ([]models.ScreeningResult, error) {
+	var results []models.ScreeningResult
+	err := facades.DB.Preload("Hits", func(db *gorm.DB) *gorm.DB {
+		return db.Order("score DESC")
+	}).Where("user_id = ?", userId).Order("id DESC").Find(&results).Error
+	return results, err
+}
+
+// Hits lists the hits of every user's latest screening, open ones by
+// default, best score first
+func (*ScreeningService) Hits(status string) ([]models.ScreeningHit, error) {
+	if status == "" {
+		status = string(models.ScreeningHitOpen)
+	}
+
+	var hits []models.ScreeningHit
+	err := facades.DB.
+		Where("result_id IN (?)", facades.DB.Model(&models.ScreeningResult{}).Select("MAX(id)").Group("user_id")).
+		Where("status = ?", status).
+		Order("score DESC").
+		Find(&hits).Error
+	return hits, err
+}
+
+// ResolveHit records a reviewer's verdict on a hit. A reviewer cannot
+// resolve hits on their own account.
+func (*ScreeningService) ResolveHit(id string, reviewerId uint, status models.ScreeningHitStatus, note string) (models.ScreeningHit, error) {
+	var hit models.ScreeningHit
+	if status != models.ScreeningHitConfirmed && status != models.ScreeningHitCleared {
+		return hit, ErrScreeningHitInvalidStatus
+	}
+
+	err := facades.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&hit, id).Error; err != nil {
+			return err
+		}
+		if hit.UserID == reviewerId {
+			return ErrKycSelfReview
+		}
+
+		if err := tx.Model(&hit).Updates(map[string]interface{}{
+			"status":      status,
+			"note":        note,
+			"reviewed_by": reviewerId,
+			"reviewed_at": time.Now(),
+		}).Error; err != nil {
+			return err
+		}
+
+		// the result stays a potential match while any of its hits is not cleared
+		var pending int64
+		if err := tx.Model(&models.ScreeningHit{}).
+			Where("result_id = ? AND status <> ?", hit.ResultID, models.ScreeningHitCleared).
+			Count(&pending).Error; err != nil {
+			return err
+		}
+		resultStatus := models.ScreeningClear
+		if pending > 0 {
+			resultStatus = models.ScreeningPotentialMatch
+		}
+		if err := tx.Model(&models.ScreeningResult{}).Where("id = ?", hit.ResultID).
+			Update("status", resultStatus).Error; err != nil {
+			return err
+		}
+		return tx.First(&hit, hit.ID).Error
+	})
+	return hit, err
+}
+
+// activeScreeningIndex returns the index of the active list versions, the
+// list of each entry and the version IDs
+func activeScreeningIndex(db *gorm.DB) (*screening.Index, map[uint]uint, []uint, error) {
+	var versions []uint
+	if err := db.Model(&models.Watchlist{}).
+		Where("active_version_id IS NOT NULL").
+		Pluck("active_version_id", &versions).Error; err != nil {
+		return nil, nil, nil, err
+	}
+	sort.Slice(versions, func(i, j int) bool { return versions[i] < versions[j] })
+
+	screeningIndex.Lock()
+	defer screeningIndex.Unlock()
+	if screeningIndex.index != nil && fmt.Sprint(screeningIndex.versions) == fmt.Sprint(versions) {
+		return screeningIndex.index, screeningIndex.lists, versions, nil
+	}
+
+	var rows []models.WatchlistEntry
+	if len(versions) > 0 {
+		if err := db.Where("version_id IN ?", versions).Find(&rows).Error; err != nil {
+			return nil, nil, nil, err
+		}
+	}
+	entries := make([]screening.Entry, len(rows))
+	lists := make(map[uint]uint, len(rows))
+	for i, row := range rows {
+		entries[i] = screening.Entry{
+			ID:            row.ID,
+			ExternalID:    row.ExternalID,
+			Name:          row.Name,
+			Aliases:       row.Aliases,
+			BirthDates:    row.BirthDates,
+			Nationalities: row.Nationalities,
+			Remarks:       row.Remarks,
+		}
+		lists[row.ID] = row.WatchlistID
+	}
+
+	screeningIndex.versions = versions
+	screeningIndex.index = screening.NewIndex(entries)
+	screeningIndex.lists = lists
+	return screeningIndex.index, lists, versions, nil
+}
+
+// screeningHitKey identifies a listed person across list versions: by the
+// list's own ID when it has one, otherwise by name
+func screeningHitKey(watchlistId uint, externalId, name string) string {
+	if externalId != "" {
+		return fmt.Sprintf("%d:id:%s", watchlistId, externalId)
+	}
+	return fmt.Sprintf("%d:name:%s", watchlistId, name)
+}
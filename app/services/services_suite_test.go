@@ -0,0 +1,75 @@
+package services_test
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+
+	"golang_starter_kit_2025/app/encryption"
+	"golang_starter_kit_2025/app/storage"
+	"golang_starter_kit_2025/config"
+	"golang_starter_kit_2025/facades"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestServicesSuite(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Services Test Suite")
+}
+
+var _ = BeforeSuite(func() {
+	ring, err := encryption.NewKeyRing(map[uint32][]byte{1: bytes.Repeat([]byte{1}, 32)}, 1, bytes.Repeat([]byte{9}, 32))
+	Expect(err).NotTo(HaveOccurred())
+	encryption.SetDefault(ring)
+})
+
+// useSQLite points facades.DB at a fresh SQLite database with tables for
+// the given models, for the duration of the current spec
+func useSQLite(models ...interface{}) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(GinkgoT().TempDir(), "test.db")), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	Expect(err).NotTo(HaveOccurred())
+	Expect(db.AutoMigrate(models...)).To(Succeed())
+
+	sqlDB, err := db.DB()
+	Expect(err).NotTo(HaveOccurred())
+	previous := facades.DB
+	facades.DB = db
+	DeferCleanup(func() {
+		facades.DB = previous
+		sqlDB.Close()
+	})
+	return db
+}
+
+// useMemoryDisk replaces the default disk with an empty Memory disk for the
+// duration of the current spec
+func useMemoryDisk() *storage.Memory {
+	name := config.GetStorageConfigs().Default
+	previous, err := storage.Disk(name)
+	Expect(err).NotTo(HaveOccurred())
+
+	disk := storage.NewMemory()
+	storage.SetDisk(name, disk)
+	DeferCleanup(func() {
+		storage.SetDisk(name, previous)
+	})
+	return disk
+}
+
+// paths lists the paths stored on disk under prefix
+func paths(disk storage.Storage, prefix string) []string {
+	files, err := disk.List(prefix)
+	Expect(err).NotTo(HaveOccurred())
+	var paths []string
+	for _, file := range files {
+		paths = append(paths, file.Path)
+	}
+	return paths
+}
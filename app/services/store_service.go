@@ -0,0 +1,380 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"golang_starter_kit_2025/app/helpers"
+	"golang_starter_kit_2025/app/models"
+	"golang_starter_kit_2025/facades"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// StoreDocumentKey is the storage directory of store verification documents
+const StoreDocumentKey = "kyb"
+
+var (
+	ErrStoreNotEditable     = errors.New("store can no longer be changed")
+	ErrStoreUnderReview     = errors.New("store cannot be deleted while its verification is pending")
+	ErrKybIncomplete        = errors.New("store verification is incomplete")
+	ErrKybInvalidIdentifier = errors.New("NIB must have 13 digits and NPWP 15 or 16 digits")
+	ErrKybOwnerNotVerified  = errors.New("store owner has not passed KYC")
+	ErrKybInvalidTransition = errors.New("store verification status transition is not allowed")
+	ErrKybSelfReview        = errors.New("store verification cannot be reviewed by its owner")
+)
+
+var (
+	storeNIBPattern  = regexp.MustCompile(`^\d{13}$`)
+	storeNPWPPattern = regexp.MustCompile(`^\d{15,16}$`)
+)
+
+// storeEditableColumns are the columns an owner may change while the store
+// is editable
+var storeEditableColumns = []string{"name", "legal_name", "business_type", "nib", "npwp", "phone", "address", "city", "state", "country", "zip"}
+
+type StoreService struct{}
+
+// Create registers a store for its owner as a draft. If the owner's NIK is
+// already verified the store is linked to it right away, so NIK checks see
+// the store before its verification is submitted.
+func (*StoreService) Create(ownerId uint, data models.Store) (models.Store, error) {
+	var owner models.User
+	if err := facades.DB.First(&owner, ownerId).Error; err != nil {
+		return data, err
+	}
+
+	data.ID = 0
+	data.Reference = helpers.GenerateReference("STR")
+	data.OwnerID = ownerId
+	data.OwnerNIKBidx = owner.NIKBidx
+	data.Status = models.KycStatusDraft
+	if err := facades.DB.Create(&data).Error; err != nil {
+		return data, err
+	}
+	return data, nil
+}
+
+// GetMine lists the stores of an owner
+func (*StoreService) GetMine(ownerId uint) ([]models.Store, error) {
+	var stores []models.Store
+	if err := facades.DB.Where("owner_id = ?", ownerId).Order("id ASC").Find(&stores).Error; err != nil {
+		return nil, err
+	}
+	return stores, nil
+}
+
+// FindMine returns a store of the owner with its documents and status
+// history
+func (*StoreService) FindMine(ownerId uint, id string) (models.Store, error) {
+	var store models.Store
+	if err := preloadStore(facades.DB).Where("owner_id = ?", ownerId).First(&store, id).Error; err != nil {
+		return store, err
+	}
+	withStoreDocumentURLs(&store)
+	return store, nil
+}
+
+// UpdateMine replaces the business data of an editable store of the owner
+func (s *StoreService) UpdateMine(ownerId uint, id string, data models.Store) (models.Store, error) {
+	err := facades.DB.Transaction(func(tx *gorm.DB) error {
+		store, err := lockEditableStore(tx, ownerId, id)
+		if err != nil {
+			return err
+		}
+		data.ID = store.ID
+		return tx.Model(&data).Select(storeEditableColumns).Updates(&data).Error
+	})
+	if err != nil {
+		return data, err
+	}
+	return s.FindMine(ownerId, id)
+}
+
+// DeleteMine soft deletes a store of the owner. A store whose verification
+// is waiting for or under review cannot be deleted.
+func (*StoreService) DeleteMine(ownerId uint, id string) error {
+	return facades.DB.Transaction(func(tx *gorm.DB) error {
+		store, err := lockStore(tx.Where("owner_id = ?", ownerId), id)
+		if err != nil {
+			return err
+		}
+		if store.Status == models.KycStatusSubmitted || store.Status == models.KycStatusInReview {
+			return ErrStoreUnderReview
+		}
+		return tx.Delete(&store).Error
+	})
+}
+
+// UploadDocument runs a business document of an editable store through the
+// upload pipeline and stores it, replacing and deleting any previous file of
+// that type
+func (*StoreService) UploadDocument(ownerId uint, id string, docType models.StoreDocumentType, input UploadInput) (models.StoreDocument, error) {
+	var document models.StoreDocument
+
+	if _, err := editableStore(facades.DB, ownerId, id); err != nil {
+		return document, err
+	}
+	processed, err := (&UploadService{}).Process(ownerId, models.UploadPurposeStoreDocument, input)
+	if err != nil {
+		return document, err
+	}
+	file, err := FileService{}.Store(ownerId, models.UploadPurposeStoreDocument, input.Name, processed)
+	if err != nil {
+		return document, err
+	}
+
+	var replaced *models.StoreDocument
+	err = facades.DB.Transaction(func(tx *gorm.DB) error {
+		store, err := lockEditableStore(tx, ownerId, id)
+		if err != nil {
+			return err
+		}
+
+		err = tx.Where("store_id = ? AND type = ?", store.ID, docType).First(&document).Error
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			document = models.StoreDocument{StoreID: store.ID, Type: docType}
+		case err != nil:
+			return err
+		default:
+			previous := document
+			replaced = &previous
+		}
+
+		document.FileID = &file.ID
+		document.File = &file
+		document.MimeType = processed.MimeType
+		return tx.Save(&document).Error
+	})
+	if err != nil {
+		FileService{}.Remove(file.ID)
+		return document, err
+	}
+
+	if replaced != nil && replaced.FileID != nil {
+		FileService{}.Remove(*replaced.FileID)
+	}
+	document.URL, document.ThumbnailURL = documentURLs(&file)
+	return document, nil
+}
+
+// Submit hands an editable store over for verification after checking that
+// its business data and documents are complete and that the owner has an
+// approved KYC submission, which is linked to the store
+func (*StoreService) Submit(ownerId uint, id string) (models.Store, error) {
+	var store models.Store
+	err := facades.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if store, err = lockEditableStore(tx, ownerId, id); err != nil {
+			return err
+		}
+		if err := validateStore(tx, store); err != nil {
+			return err
+		}
+
+		var submission models.KycSubmission
+		err = tx.Where("user_id = ? AND status = ?", ownerId, models.KycStatusApproved).Order("id DESC").First(&submission).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrKybOwnerNotVerified
+		}
+		if err != nil {
+			return err
+		}
+
+		return transitionStore(tx, &store, models.KycStatusSubmitted, ownerId, "", "", map[string]interface{}{
+			"owner_submission_id": submission.ID,
+			"owner_nik_bidx":      submission.NIKBidx,
+			"submitted_at":        time.Now(),
+			"review_started_at":   nil,
+			"decided_at":          nil,
+			"reviewer_id":         nil,
+			"reason_code":         "",
+			"reviewer_comment":    "",
+		})
+	})
+	return store, err
+}
+
+// GetAll lists stores for reviewers, optionally filtered by verification
+// status
+func (*StoreService) GetAll(status string) ([]models.Store, error) {
+	var stores []models.Store
+	query := facades.DB.Order("id ASC")
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if err := query.Find(&stores).Error; err != nil {
+		return nil, err
+	}
+	return stores, nil
+}
+
+// Find returns a store with its documents and status history
+func (*StoreService) Find(id string) (models.Store, error) {
+	var store models.Store
+	if err := preloadStore(facades.DB).First(&store, id).Error; err != nil {
+		return store, err
+	}
+	withStoreDocumentURLs(&store)
+	return store, nil
+}
+
+// StartReview assigns a submitted store to a reviewer
+func (*StoreService) StartReview(id string, reviewerId uint) (models.Store, error) {
+	var store models.Store
+	err := facades.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if store, err = lockStoreForReview(tx, id, reviewerId); err != nil {
+			return err
+		}
+
+		return transitionStore(tx, &store, models.KycStatusInReview, reviewerId, "", "", map[string]interface{}{
+			"reviewer_id":       reviewerId,
+			"review_started_at": time.Now(),
+		})
+	})
+	return store, err
+}
+
+// Decide closes the review of a store. Rejections and requests for
+// resubmission need a reason code.
+func (*StoreService) Decide(id string, reviewerId uint, status models.KycStatus, reason models.KycReasonCode, comment string) (models.Store, error) {
+	var store models.Store
+	err := facades.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if store, err = lockStoreForReview(tx, id, reviewerId); err != nil {
+			return err
+		}
+		if (status != models.KycStatusApproved || reason != "") && !reason.Valid() {
+			return ErrKycReasonRequired
+		}
+
+		return transitionStore(tx, &store, status, reviewerId, reason, comment, map[string]interface{}{
+			"reviewer_id":      reviewerId,
+			"reason_code":      reason,
+			"reviewer_comment": comment,
+			"decided_at":       time.Now(),
+		})
+	})
+	return store, err
+}
+
+func preloadStore(db *gorm.DB) *gorm.DB {
+	return db.Preload("Documents.File").Preload("Transitions", func(db *gorm.DB) *gorm.DB {
+		return db.Order("id ASC")
+	})
+}
+
+func withStoreDocumentURLs(store *models.Store) {
+	for i := range store.Documents {
+		store.Documents[i].URL, store.Documents[i].ThumbnailURL = documentURLs(store.Documents[i].File)
+	}
+}
+
+func lockStore(tx *gorm.DB, id string) (models.Store, error) {
+	var store models.Store
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&store, id).Error
+	return store, err
+}
+
+// editableStore returns a store of the owner that is still editable
+func editableStore(db *gorm.DB, ownerId uint, id string) (models.Store, error) {
+	var store models.Store
+	if err := db.Where("owner_id = ?", ownerId).First(&store, id).Error; err != nil {
+		return store, err
+	}
+	if !store.Status.Editable() {
+		return store, ErrStoreNotEditable
+	}
+	return store, nil
+}
+
+func lockEditableStore(tx *gorm.DB, ownerId uint, id string) (models.Store, error) {
+	return editableStore(tx.Clauses(clause.Locking{Strength: "UPDATE"}), ownerId, id)
+}
+
+func lockStoreForReview(tx *gorm.DB, id string, reviewerId uint) (models.Store, error) {
+	store, err := lockStore(tx, id)
+	if err != nil {
+		return store, err
+	}
+	if store.OwnerID == reviewerId {
+		return store, ErrKybSelfReview
+	}
+	return store, nil
+}
+
+// transitionStore moves a locked store to a new verification status, applies
+// the accompanying column updates and records the transition
+func transitionStore(tx *gorm.DB, store *models.Store, to models.KycStatus, actorId uint, reason models.KycReasonCode, comment string, updates map[string]interface{}) error {
+	from := store.Status
+	if !from.CanTransitionTo(to) {
+		return fmt.Errorf("%w: %s -> %s", ErrKybInvalidTransition, from, to)
+	}
+
+	updates["status"] = to
+	if err := tx.Model(store).Updates(updates).Error; err != nil {
+		return err
+	}
+	if err := tx.Create(&models.StoreTransition{
+		StoreID:    store.ID,
+		FromStatus: from,
+		ToStatus:   to,
+		ActorID:    actorId,
+		ReasonCode: reason,
+		Comment:    comment,
+	}).Error; err != nil {
+		return err
+	}
+	return tx.First(store, store.ID).Error
+}
+
+func validateStore(tx *gorm.DB, store models.Store) error {
+	var missing []string
+	for _, field := range []struct{ name, value string }{
+		{"name", store.Name},
+		{"legal_name", store.LegalName},
+		{"business_type", string(store.BusinessType)},
+		{"nib", store.NIB},
+		{"npwp", store.NPWP},
+		{"address", store.Address},
+		{"city", store.City},
+	} {
+		if strings.TrimSpace(field.value) == "" {
+			missing = append(missing, field.name)
+		}
+	}
+
+	var documents []models.StoreDocument
+	if err := tx.Where("store_id = ?", store.ID).Find(&documents).Error; err != nil {
+		return err
+	}
+	required := append([]models.StoreDocumentType{}, models.StoreRequiredDocuments...)
+	if store.BusinessType.NeedsDeed() {
+		required = append(required, models.StoreDocumentDeed)
+	}
+	for _, docType := range required {
+		found := false
+		for _, document := range documents {
+			if document.Type == docType {
+				found = true
+			}
+		}
+		if !found {
+			missing = append(missing, string(docType))
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("%w: %s", ErrKybIncomplete, strings.Join(missing, ", "))
+	}
+
+	if !storeNIBPattern.MatchString(store.NIB) || !storeNPWPPattern.MatchString(store.NPWP) {
+		return ErrKybInvalidIdentifier
+	}
+	return nil
+}
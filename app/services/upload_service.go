@@ -0,0 +1,475 @@
+package services
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"golang_starter_kit_2025/app/filescan"
+	"golang_starter_kit_2025/app/helpers"
+	"golang_starter_kit_2025/app/imagemeta"
+	"golang_starter_kit_2025/app/models"
+	"golang_starter_kit_2025/facades"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// UploadDirectory is where uploads and their parts are stored
+	UploadDirectory = "uploads"
+	// QuarantineDirectory is where files refused by the upload pipeline are
+	// kept
+	QuarantineDirectory = "quarantine"
+)
+
+var (
+	ErrUploadNotFound       = errors.New("upload not found")
+	ErrUploadExpired        = errors.New("upload has expired")
+	ErrUploadUnknownPurpose = errors.New("unknown upload purpose")
+	ErrUploadTooLarge       = errors.New("upload exceeds the size limit of its purpose")
+	ErrUploadOffsetMismatch = errors.New("upload offset does not match the received bytes")
+	ErrUploadIncomplete     = errors.New("upload is not complete yet")
+	ErrUploadWrongPurpose   = errors.New("upload was made for another purpose")
+	ErrUploadRejected       = errors.New("file was rejected and quarantined")
+)
+
+// UploadPurposeConfig limits the uploads of a purpose
+type UploadPurposeConfig struct {
+	MaxSize int64
+	// AllowedTypes are the sniffed content types accepted
+	AllowedTypes []string
+}
+
+// UploadPurposes lists the purposes uploads can be made for
+var UploadPurposes = map[models.UploadPurpose]UploadPurposeConfig{
+	models.UploadPurposeKycDocument: {
+		MaxSize:      10 << 20,
+		AllowedTypes: []string{"image/jpeg", "image/png"},
+	},
+	models.UploadPurposeStoreDocument: {
+		MaxSize:      10 << 20,
+		AllowedTypes: []string{"image/jpeg", "image/png", "image/webp", "application/pdf"},
+	},
+}
+
+// UploadInput is a file received from a client, before the upload pipeline
+// has checked it
+type UploadInput struct {
+	Content []byte
+	// Name is the original file name, if the client sent one
+	Name string
+	// DeclaredType is the content type claimed by the client, if any
+	DeclaredType string
+}
+
+// ProcessedUpload is a file that passed the upload pipeline
+type ProcessedUpload struct {
+	Content  []byte
+	MimeType string
+}
+
+// UploadRejectedError tells why the upload pipeline refused a file
+type UploadRejectedError struct {
+	Reason models.QuarantineReason
+	Detail string
+}
+
+func (e *UploadRejectedError) Error() string {
+	if e.Detail == "" {
+		return fmt.Sprintf("file was rejected: %s", e.Reason)
+	}
+	return fmt.Sprintf("file was rejected: %s (%s)", e.Reason, e.Detail)
+}
+
+func (e *UploadRejectedError) Unwrap() error {
+	return ErrUploadRejected
+}
+
+// UploadMaxSize is the largest size any purpose allows
+func UploadMaxSize() int64 {
+	var max int64
+	for _, purpose := range UploadPurposes {
+		if purpose.MaxSize > max {
+			max = purpose.MaxSize
+		}
+	}
+	return max
+}
+
+// UploadService implements resumable uploads following the tus 1.0 protocol.
+// Every chunk is stored encrypted as a separate part on the default disk, so
+// an upload can be continued on any replica; the parts are joined when the
+// last byte has arrived.
+type UploadService struct{}
+
+// Create starts an upload of length bytes. An empty upload is complete right
+// away.
+func (*UploadService) Create(userId uint, purpose models.UploadPurpose, length int64, metadata map[string]string) (models.Upload, error) {
+	config, ok := UploadPurposes[purpose]
+	if !ok {
+		return models.Upload{}, ErrUploadUnknownPurpose
+	}
+	if length > config.MaxSize {
+		return models.Upload{}, ErrUploadTooLarge
+	}
+
+	upload := models.Upload{
+		Reference: helpers.GenerateReference("UPL"),
+		UserID:    userId,
+		Purpose:   purpose,
+		Length:    length,
+		Metadata:  metadata,
+		ExpiresAt: time.Now().Add(time.Duration(helpers.GetEnvInt("UPLOAD_EXPIRE_HOURS", 24)) * time.Hour),
+	}
+	return upload, facades.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&upload).Error; err != nil {
+			return err
+		}
+		if length == 0 {
+			return completeUpload(tx, &upload)
+		}
+		return nil
+	})
+}
+
+// Find returns an upload of the user that has not expired yet
+func (*UploadService) Find(userId uint, reference string) (models.Upload, error) {
+	return findUpload(facades.DB, userId, reference)
+}
+
+// WriteChunk appends the chunk read from r at offset, which must be the
+// number of bytes received so far. When r fails halfway, the bytes received
+// until then are kept so the client can resume after them.
+func (*UploadService) WriteChunk(userId uint, reference string, offset int64, r io.Reader) (models.Upload, error) {
+	upload, err := findUpload(facades.DB, userId, reference)
+	if err != nil {
+		return upload, err
+	}
+	if offset != upload.Offset {
+		return upload, ErrUploadOffsetMismatch
+	}
+
+	chunk, readErr := io.ReadAll(io.LimitReader(r, upload.Length-upload.Offset+1))
+	if int64(len(chunk)) > upload.Length-upload.Offset {
+		return upload, ErrUploadTooLarge
+	}
+	if len(chunk) == 0 {
+		if readErr != nil {
+			return upload, readErr
+		}
+		return upload, nil
+	}
+
+	// parts get a unique name so that concurrent requests for the same
+	// offset cannot overwrite each other; only one of them is recorded
+	part := fmt.Sprintf("%s/%s/%020d-%s", UploadDirectory, upload.Reference, offset, uuid.NewString())
+	if err := writeEncryptedFile(part, bytes.NewReader(chunk)); err != nil {
+		return upload, err
+	}
+
+	var parts []string
+	err = facades.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ?", upload.ID).First(&upload).Error; err != nil {
+			return err
+		}
+		if upload.Offset != offset || upload.Completed() {
+			return ErrUploadOffsetMismatch
+		}
+
+		upload.Parts = append(upload.Parts, part)
+		upload.Offset += int64(len(chunk))
+		if err := tx.Select("Parts", "Offset").Updates(&upload).Error; err != nil {
+			return err
+		}
+		if upload.Offset == upload.Length {
+			parts = upload.Parts
+			return completeUpload(tx, &upload)
+		}
+		return nil
+	})
+	if err != nil {
+		FileService{}.Delete(part)
+		if upload.FileName != "" {
+			FileService{}.Delete(upload.Key + "/" + upload.FileName)
+		}
+		return upload, err
+	}
+	removeStoredFiles(parts)
+	return upload, readErr
+}
+
+// Terminate deletes an upload and everything stored for it
+func (*UploadService) Terminate(userId uint, reference string) error {
+	upload, err := findUpload(facades.DB, userId, reference)
+	if err != nil {
+		return err
+	}
+	files, err := deleteUploads(facades.DB, facades.DB.Where("id = ?", upload.ID))
+	if err != nil {
+		return err
+	}
+	removeStoredFiles(files)
+	return nil
+}
+
+// Input returns a complete upload made for purpose, to be passed through
+// Process like any other file received from a client
+func (*UploadService) Input(userId uint, reference string, purpose models.UploadPurpose) (UploadInput, error) {
+	upload, err := findUpload(facades.DB, userId, reference)
+	if err != nil {
+		return UploadInput{}, err
+	}
+	if upload.Purpose != purpose {
+		return UploadInput{}, ErrUploadWrongPurpose
+	}
+	if !upload.Completed() {
+		return UploadInput{}, ErrUploadIncomplete
+	}
+
+	file, _, err := FileService{}.Open(upload.Key + "/" + upload.FileName)
+	if err != nil {
+		return UploadInput{}, err
+	}
+	defer file.Close()
+	content, err := io.ReadAll(file)
+	if err != nil {
+		return UploadInput{}, err
+	}
+	return UploadInput{Content: content, Name: upload.Metadata["filename"], DeclaredType: upload.Metadata["filetype"]}, nil
+}
+
+// Process runs a file through the upload pipeline before it is stored: it
+// enforces the size limit of the purpose, sniffs the real content type and
+// checks it against the declared type, the file name and the allowlist of
+// the purpose, scans it for malware and strips metadata from images. Refused
+// files are quarantined and reported with an *UploadRejectedError. When the
+// scanner is unavailable the file is refused without being quarantined.
+func (*UploadService) Process(userId uint, purpose models.UploadPurpose, input UploadInput) (ProcessedUpload, error) {
+	config, ok := UploadPurposes[purpose]
+	if !ok {
+		return ProcessedUpload{}, ErrUploadUnknownPurpose
+	}
+	content := input.Content
+	detected := sniffContentType(content)
+
+	reject := func(reason models.QuarantineReason, detail string) (ProcessedUpload, error) {
+		if err := quarantine(userId, purpose, input, detected, reason, detail); err != nil {
+			return ProcessedUpload{}, err
+		}
+		return ProcessedUpload{}, &UploadRejectedError{Reason: reason, Detail: detail}
+	}
+
+	if int64(len(content)) > config.MaxSize {
+		return reject(models.QuarantineTooLarge, fmt.Sprintf("larger than %d bytes", config.MaxSize))
+	}
+	if declared := normalizeContentType(input.DeclaredType); declared != "" && declared != "application/octet-stream" && declared != detected {
+		return reject(models.QuarantineTypeMismatch, fmt.Sprintf("declared %s, detected %s", declared, detected))
+	}
+	if byName := normalizeContentType(mime.TypeByExtension(strings.ToLower(filepath.Ext(input.Name)))); byName != "" && byName != detected {
+		return reject(models.QuarantineTypeMismatch, fmt.Sprintf("file name %q, detected %s", filepath.Base(input.Name), detected))
+	}
+	if !slices.Contains(config.AllowedTypes, detected) {
+		return reject(models.QuarantineTypeNotAllowed, detected)
+	}
+
+	scanner, err := filescan.Default()
+	if err != nil {
+		return ProcessedUpload{}, err
+	}
+	result, err := scanner.Scan(bytes.NewReader(content))
+	if err != nil {
+		return ProcessedUpload{}, err
+	}
+	if result.Infected {
+		return reject(models.QuarantineInfected, result.Signature)
+	}
+
+	stripped, err := imagemeta.Strip(content, detected)
+	if err != nil {
+		return reject(models.QuarantineMalformed, err.Error())
+	}
+	return ProcessedUpload{Content: stripped, MimeType: detected}, nil
+}
+
+// DeleteExpired deletes the uploads that expired before now, complete or
+// not, and returns how many files were removed from storage
+func (*UploadService) DeleteExpired(now time.Time) (int, error) {
+	files, err := deleteUploads(facades.DB, facades.DB.Where("expires_at < ?", now))
+	if err != nil {
+		return 0, err
+	}
+	return removeStoredFiles(files), nil
+}
+
+func findUpload(db *gorm.DB, userId uint, reference string) (models.Upload, error) {
+	var upload models.Upload
+	err := db.Where("reference = ? AND user_id = ?", reference, userId).First(&upload).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return upload, ErrUploadNotFound
+	}
+	if err != nil {
+		return upload, err
+	}
+	if upload.ExpiresAt.Before(time.Now()) {
+		return upload, ErrUploadExpired
+	}
+	return upload, nil
+}
+
+// completeUpload joins the parts of a fully received upload into a single
+// file. The parts are forgotten but left for the caller to remove once the
+// transaction has committed.
+func completeUpload(tx *gorm.DB, upload *models.Upload) error {
+	readers := make([]io.Reader, 0, len(upload.Parts))
+	for _, part := range upload.Parts {
+		file, _, err := FileService{}.Open(part)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		readers = append(readers, file)
+	}
+
+	content := bufio.NewReader(io.MultiReader(readers...))
+	head, _ := content.Peek(512)
+	contentType := sniffContentType(head)
+
+	now := time.Now()
+	upload.Key = UploadDirectory
+	upload.FileName = upload.Reference + helpers.ExtensionByContent(head)
+	upload.MimeType = contentType
+	upload.CompletedAt = &now
+	if err := writeEncryptedFile(upload.Key+"/"+upload.FileName, content); err != nil {
+		return err
+	}
+	upload.Parts = nil
+	return tx.Select("Parts", "Key", "FileName", "MimeType", "CompletedAt").Updates(upload).Error
+}
+
+// deleteUploads deletes the upload rows matched by query and returns the
+// paths of their files
+func deleteUploads(tx *gorm.DB, query *gorm.DB) ([]string, error) {
+	var uploads []models.Upload
+	if err := query.Find(&uploads).Error; err != nil {
+		return nil, err
+	}
+	if len(uploads) == 0 {
+		return nil, nil
+	}
+
+	var files []string
+	ids := make([]uint, len(uploads))
+	for i, upload := range uploads {
+		ids[i] = upload.ID
+		files = append(files, upload.Parts...)
+		if upload.FileName != "" {
+			files = append(files, upload.Key+"/"+upload.FileName)
+		}
+	}
+	if err := tx.Delete(&models.Upload{}, ids).Error; err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+// eraseQuarantinedFiles deletes the quarantined files of a user and returns
+// the stored files to delete. Infected files are kept as evidence but no
+// longer point to the user.
+func eraseQuarantinedFiles(tx *gorm.DB, userId uint) ([]string, error) {
+	var quarantined []models.QuarantinedFile
+	if err := tx.Where("user_id = ? AND reason <> ?", userId, models.QuarantineInfected).Find(&quarantined).Error; err != nil {
+		return nil, err
+	}
+
+	var files []string
+	ids := make([]uint, len(quarantined))
+	for i, file := range quarantined {
+		ids[i] = file.ID
+		if file.FileName != "" {
+			files = append(files, file.Key+"/"+file.FileName)
+		}
+	}
+	if len(ids) > 0 {
+		if err := tx.Delete(&models.QuarantinedFile{}, ids).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	err := tx.Model(&models.QuarantinedFile{}).Where("user_id = ?", userId).
+		Updates(map[string]interface{}{"user_id": nil, "original_name": ""}).Error
+	return files, err
+}
+
+// quarantine records a refused file and keeps its content encrypted, unless
+// it was refused for its size
+func quarantine(userId uint, purpose models.UploadPurpose, input UploadInput, detected string, reason models.QuarantineReason, detail string) error {
+	sum := sha256.Sum256(input.Content)
+	name := ""
+	if input.Name != "" {
+		name = filepath.Base(input.Name)
+	}
+	record := models.QuarantinedFile{
+		Reference:    helpers.GenerateReference("QRN"),
+		Purpose:      purpose,
+		OriginalName: truncate(name, 255),
+		DeclaredType: truncate(input.DeclaredType, 100),
+		DetectedType: detected,
+		Size:         int64(len(input.Content)),
+		SHA256:       hex.EncodeToString(sum[:]),
+		Reason:       reason,
+		Detail:       truncate(detail, 255),
+	}
+	if userId != 0 {
+		record.UserID = &userId
+	}
+
+	if reason != models.QuarantineTooLarge {
+		record.Key = QuarantineDirectory
+		record.FileName = record.Reference + helpers.ExtensionByContent(input.Content)
+		if err := writeEncryptedFile(record.Key+"/"+record.FileName, bytes.NewReader(input.Content)); err != nil {
+			return err
+		}
+	}
+	if err := facades.DB.Create(&record).Error; err != nil {
+		if record.FileName != "" {
+			FileService{}.Delete(record.Key + "/" + record.FileName)
+		}
+		return err
+	}
+	return nil
+}
+
+// sniffContentType detects the content type from the content itself
+func sniffContentType(content []byte) string {
+	return normalizeContentType(http.DetectContentType(content))
+}
+
+// normalizeContentType strips parameters from a content type and folds
+// common aliases
+func normalizeContentType(contentType string) string {
+	contentType = strings.ToLower(strings.TrimSpace(strings.SplitN(contentType, ";", 2)[0]))
+	if contentType == "image/jpg" || contentType == "image/pjpeg" {
+		return "image/jpeg"
+	}
+	return contentType
+}
+
+func truncate(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+	return s[:max]
+}
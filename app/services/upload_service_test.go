@@ -0,0 +1,203 @@
+package services_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"time"
+
+	"golang_starter_kit_2025/app/models"
+	"golang_starter_kit_2025/app/services"
+	"golang_starter_kit_2025/app/storage"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"gorm.io/gorm"
+)
+
+// failingReader returns its content, then fails as a dropped connection would
+type failingReader struct {
+	content io.Reader
+}
+
+func (r *failingReader) Read(p []byte) (int, error) {
+	n, err := r.content.Read(p)
+	if err == io.EOF {
+		return n, errors.New("connection reset")
+	}
+	return n, err
+}
+
+// racingReader returns its content and, once it is read, lets another
+// request record a chunk at the same offset
+type racingReader struct {
+	content io.Reader
+	race    func()
+}
+
+func (r *racingReader) Read(p []byte) (int, error) {
+	n, err := r.content.Read(p)
+	if err == io.EOF && r.race != nil {
+		r.race()
+		r.race = nil
+	}
+	return n, err
+}
+
+var _ = Describe("UploadService", func() {
+	const user uint = 7
+	var (
+		db      *gorm.DB
+		disk    *storage.Memory
+		service *services.UploadService
+	)
+
+	BeforeEach(func() {
+		db = useSQLite(&models.Upload{})
+		disk = useMemoryDisk()
+		service = &services.UploadService{}
+	})
+
+	create := func(length int64) models.Upload {
+		upload, err := service.Create(user, models.UploadPurposeKycDocument, length, map[string]string{"filename": "ktp.txt"})
+		Expect(err).NotTo(HaveOccurred())
+		return upload
+	}
+
+	stored := func(upload models.Upload) []byte {
+		input, err := service.Input(user, upload.Reference, models.UploadPurposeKycDocument)
+		Expect(err).NotTo(HaveOccurred())
+		return input.Content
+	}
+
+	It("should refuse uploads larger than their purpose allows", func() {
+		_, err := service.Create(user, models.UploadPurposeKycDocument, services.UploadPurposes[models.UploadPurposeKycDocument].MaxSize+1, nil)
+		Expect(err).To(MatchError(services.ErrUploadTooLarge))
+	})
+
+	It("should complete an empty upload right away", func() {
+		upload := create(0)
+		Expect(upload.Completed()).To(BeTrue())
+		Expect(stored(upload)).To(BeEmpty())
+	})
+
+	It("should join the parts once the last byte has arrived", func() {
+		upload := create(11)
+
+		upload, err := service.WriteChunk(user, upload.Reference, 0, strings.NewReader("hello "))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(upload.Offset).To(Equal(int64(6)))
+		Expect(upload.Completed()).To(BeFalse())
+		Expect(paths(disk, "uploads/"+upload.Reference+"/")).To(HaveLen(1))
+
+		_, err = service.Input(user, upload.Reference, models.UploadPurposeKycDocument)
+		Expect(err).To(MatchError(services.ErrUploadIncomplete))
+
+		upload, err = service.WriteChunk(user, upload.Reference, 6, strings.NewReader("world"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(upload.Completed()).To(BeTrue())
+		Expect(upload.MimeType).To(Equal("text/plain"))
+		Expect(stored(upload)).To(Equal([]byte("hello world")))
+
+		// the parts are removed, only the joined file is left
+		Expect(paths(disk, "uploads/")).To(ConsistOf("uploads/" + upload.FileName))
+		var record models.Upload
+		Expect(db.First(&record, upload.ID).Error).To(Succeed())
+		Expect(record.Parts).To(BeEmpty())
+	})
+
+	It("should refuse a chunk at another offset than the bytes received", func() {
+		upload := create(10)
+		_, err := service.WriteChunk(user, upload.Reference, 0, strings.NewReader("01234"))
+		Expect(err).NotTo(HaveOccurred())
+
+		for _, offset := range []int64{0, 3, 6} {
+			_, err = service.WriteChunk(user, upload.Reference, offset, strings.NewReader("56789"))
+			Expect(err).To(MatchError(services.ErrUploadOffsetMismatch))
+		}
+		Expect(paths(disk, "uploads/"+upload.Reference+"/")).To(HaveLen(1))
+	})
+
+	It("should refuse a chunk that runs past the length of the upload", func() {
+		upload := create(5)
+
+		upload, err := service.WriteChunk(user, upload.Reference, 0, strings.NewReader("0123456"))
+		Expect(err).To(MatchError(services.ErrUploadTooLarge))
+		Expect(upload.Offset).To(BeZero())
+		Expect(paths(disk, "uploads/")).To(BeEmpty())
+
+		// the client can still send the right number of bytes
+		upload, err = service.WriteChunk(user, upload.Reference, 0, strings.NewReader("01234"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(upload.Completed()).To(BeTrue())
+	})
+
+	It("should keep the bytes received before a chunk failed halfway", func() {
+		upload := create(10)
+
+		upload, err := service.WriteChunk(user, upload.Reference, 0, &failingReader{content: strings.NewReader("0123")})
+		Expect(err).To(MatchError("connection reset"))
+		Expect(upload.Offset).To(Equal(int64(4)))
+
+		upload, err = service.WriteChunk(user, upload.Reference, 4, strings.NewReader("456789"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(stored(upload)).To(Equal([]byte("0123456789")))
+	})
+
+	It("should delete its part when another request recorded the same offset first", func() {
+		upload := create(10)
+
+		race := func() {
+			_, err := service.WriteChunk(user, upload.Reference, 0, strings.NewReader("abcde"))
+			Expect(err).NotTo(HaveOccurred())
+		}
+		_, err := service.WriteChunk(user, upload.Reference, 0, &racingReader{content: strings.NewReader("01234"), race: race})
+		Expect(err).To(MatchError(services.ErrUploadOffsetMismatch))
+
+		// only the part of the request that won is left
+		Expect(paths(disk, "uploads/"+upload.Reference+"/")).To(HaveLen(1))
+		upload, err = service.WriteChunk(user, upload.Reference, 5, strings.NewReader("fghij"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(stored(upload)).To(Equal([]byte("abcdefghij")))
+	})
+
+	It("should refuse uploads of other users and expired uploads", func() {
+		upload := create(5)
+
+		_, err := service.WriteChunk(user+1, upload.Reference, 0, strings.NewReader("01234"))
+		Expect(err).To(MatchError(services.ErrUploadNotFound))
+
+		Expect(db.Model(&upload).Update("expires_at", time.Now().Add(-time.Minute)).Error).To(Succeed())
+		_, err = service.WriteChunk(user, upload.Reference, 0, strings.NewReader("01234"))
+		Expect(err).To(MatchError(services.ErrUploadExpired))
+		_, err = service.Find(user, upload.Reference)
+		Expect(err).To(MatchError(services.ErrUploadExpired))
+	})
+
+	It("should delete expired uploads with their parts and joined files", func() {
+		partial := create(10)
+		_, err := service.WriteChunk(user, partial.Reference, 0, strings.NewReader("01234"))
+		Expect(err).NotTo(HaveOccurred())
+		complete := create(3)
+		complete, err = service.WriteChunk(user, complete.Reference, 0, bytes.NewReader([]byte("abc")))
+		Expect(err).NotTo(HaveOccurred())
+		kept := create(3)
+		_, err = service.WriteChunk(user, kept.Reference, 0, strings.NewReader("x"))
+		Expect(err).NotTo(HaveOccurred())
+
+		now := time.Now()
+		Expect(db.Model(&models.Upload{}).Where("id IN ?", []uint{partial.ID, complete.ID}).
+			Update("expires_at", now.Add(-time.Hour)).Error).To(Succeed())
+
+		removed, err := service.DeleteExpired(now)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(removed).To(Equal(2))
+
+		var left []models.Upload
+		Expect(db.Find(&left).Error).To(Succeed())
+		Expect(left).To(HaveLen(1))
+		Expect(left[0].Reference).To(Equal(kept.Reference))
+		Expect(paths(disk, "uploads/")).To(ConsistOf(HavePrefix("uploads/" + kept.Reference + "/")))
+	})
+})
@@ -1,12 +1,24 @@
 package services
 
 import (
+	"errors"
+	"fmt"
+	"time"
+
 	"golang_starter_kit_2025/app/models"
+	"golang_starter_kit_2025/app/models/scopes"
+	"golang_starter_kit_2025/app/responses"
 	"golang_starter_kit_2025/facades"
 
+	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
 )
 
+var (
+	ErrInvalidStatusTransition = errors.New("account status transition is not allowed")
+	ErrCannotChangeOwnStatus   = errors.New("you cannot change the status of your own account")
+)
+
 type UserService struct{}
 
 func (*UserService) GetAllUsers() ([]models.User, error) {
@@ -29,7 +41,7 @@ func (*UserService) Put(user models.User) (models.User, error) {
 
 	if err := facades.DB.Clauses(clause.OnConflict{
 		Columns:   []clause.Column{{Name: "id"}},
-		DoUpdates: clause.AssignmentColumns([]string{"username", "email", "password", "fcm_token", "updated_at"}),
+		DoUpdates: clause.AssignmentColumns([]string{"username", "email", "email_bidx", "email_canonical_bidx", "password", "fcm_token", "updated_at"}),
 	}).Create(&user).Error; err != nil {
 		return user, err
 	}
@@ -45,36 +57,235 @@ func (*UserService) Delete(id string) error {
 	return facades.DB.Delete(&user).Error
 }
 
+// AssignRolesToUser replaces the roles of a user with the given set
 func (*UserService) AssignRolesToUser(userId string, roles []uint) error {
+	return facades.DB.Transaction(func(tx *gorm.DB) error {
+		user, err := lockUser(tx, userId)
+		if err != nil {
+			return err
+		}
+
+		roles = uniqueIds(roles)
+		if err := validateIds(tx, &models.Role{}, roles, ErrInvalidRoleIds); err != nil {
+			return err
+		}
+
+		current, err := userRoleIdList(tx, user.ID)
+		if err != nil {
+			return err
+		}
+		add, remove := diffIds(current, roles)
+		return applyUserRoles(tx, user.ID, add, remove)
+	})
+}
+
+// UpdateUserRoles grants and revokes individual roles of a user, leaving
+// every other role untouched. When startsAt or expiresAt is set the added
+// roles are only in effect within that window.
+func (*UserService) UpdateUserRoles(userId string, add, remove []uint, startsAt, expiresAt *time.Time) error {
+	return facades.DB.Transaction(func(tx *gorm.DB) error {
+		user, err := lockUser(tx, userId)
+		if err != nil {
+			return err
+		}
+
+		if overlaps(add, remove) {
+			return ErrConflictingIds
+		}
+		if err := validateIds(tx, &models.Role{}, append(append([]uint{}, add...), remove...), ErrInvalidRoleIds); err != nil {
+			return err
+		}
+
+		if len(remove) > 0 {
+			if err := applyUserRoles(tx, user.ID, nil, uniqueIds(remove)); err != nil {
+				return err
+			}
+		}
+		for _, roleId := range uniqueIds(add) {
+			if err := setUserRoleGrant(tx, user.ID, roleId, startsAt, expiresAt); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// PruneExpiredRoleGrants deletes role grants whose expiry has passed and
+// returns how many were removed
+func (*UserService) PruneExpiredRoleGrants(now time.Time) (int64, error) {
+	result := facades.DB.Where("expires_at IS NOT NULL AND expires_at <= ?", now).Delete(&models.UserHasRole{})
+	return result.RowsAffected, result.Error
+}
+
+// GetRolesByUserId lists the roles of a user, marking whether each role is
+// assigned directly and which groups it is inherited from
+func (*UserService) GetRolesByUserId(userId string) ([]responses.UserRole, error) {
 	var user models.User
 	if err := facades.DB.First(&user, userId).Error; err != nil {
-		return err
+		return nil, err
+	}
+
+	var grants []models.UserHasRole
+	if err := facades.DB.Scopes(scopes.ActiveRoleGrant(time.Now())).Where("user_id = ?", user.ID).Find(&grants).Error; err != nil {
+		return nil, err
+	}
+	grantByRoleId := make(map[uint]models.UserHasRole, len(grants))
+	for _, grant := range grants {
+		grantByRoleId[grant.RoleID] = grant
 	}
 
-	// Clear existing roles for the user
-	facades.DB.Where("user_id = ?", user.ID).Delete(&models.UserHasRole{})
+	var direct []models.Role
+	if err := facades.DB.Where("id IN (?)", userRoleIds(facades.DB, user.ID)).Find(&direct).Error; err != nil {
+		return nil, err
+	}
+
+	var inherited []struct {
+		RoleID    uint
+		GroupID   uint
+		GroupName string
+	}
+	if err := facades.DB.Model(&models.UserGroupHasRole{}).
+		Select("user_groups_has_roles.role_id, user_groups.id AS group_id, user_groups.name AS group_name").
+		Joins("join user_groups on user_groups.id = user_groups_has_roles.user_group_id").
+		Joins("join user_groups_has_users on user_groups.id = user_groups_has_users.user_group_id").
+		Where("user_groups_has_users.user_id = ?", user.ID).
+		Order("user_groups.name").
+		Scan(&inherited).Error; err != nil {
+		return nil, err
+	}
 
-	// Assign new roles
-	for _, roleId := range roles {
-		userRole := models.UserHasRole{
-			UserID: user.ID,
-			RoleID: roleId,
+	var inheritedRoles []models.Role
+	if err := facades.DB.Where("id IN (?)", groupRoleIds(facades.DB, user.ID)).Find(&inheritedRoles).Error; err != nil {
+		return nil, err
+	}
+	inheritedById := make(map[uint]models.Role, len(inheritedRoles))
+	for _, role := range inheritedRoles {
+		inheritedById[role.ID] = role
+	}
+
+	roles := []responses.UserRole{}
+	index := make(map[uint]int)
+	entry := func(role models.Role) *responses.UserRole {
+		if i, ok := index[role.ID]; ok {
+			return &roles[i]
 		}
-		if err := facades.DB.Create(&userRole).Error; err != nil {
+		index[role.ID] = len(roles)
+		roles = append(roles, responses.UserRole{ID: role.ID, Name: role.Name, Group: role.Group, InheritedFrom: []responses.GroupRef{}})
+		return &roles[len(roles)-1]
+	}
+
+	for _, role := range direct {
+		r := entry(role)
+		r.Direct = true
+		r.StartsAt = grantByRoleId[role.ID].StartsAt
+		r.ExpiresAt = grantByRoleId[role.ID].ExpiresAt
+	}
+	for _, row := range inherited {
+		role := entry(inheritedById[row.RoleID])
+		role.InheritedFrom = append(role.InheritedFrom, responses.GroupRef{ID: row.GroupID, Name: row.GroupName})
+	}
+
+	return roles, nil
+}
+
+func lockUser(tx *gorm.DB, userId string) (models.User, error) {
+	var user models.User
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&user, userId).Error
+	return user, err
+}
+
+// userRoleIdList returns the roles of every grant row of a user, including
+// grants that are not yet or no longer in effect
+func userRoleIdList(tx *gorm.DB, userId uint) ([]uint, error) {
+	var ids []uint
+	err := tx.Model(&models.UserHasRole{}).Where("user_id = ?", userId).Pluck("role_id", &ids).Error
+	return ids, err
+}
+
+// setUserRoleGrant creates the grant of a role to a user or replaces the
+// time window of an existing grant
+func setUserRoleGrant(tx *gorm.DB, userId, roleId uint, startsAt, expiresAt *time.Time) error {
+	var grant models.UserHasRole
+	err := tx.Where("user_id = ? AND role_id = ?", userId, roleId).First(&grant).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return tx.Create(&models.UserHasRole{UserID: userId, RoleID: roleId, StartsAt: startsAt, ExpiresAt: expiresAt}).Error
+	}
+	if err != nil {
+		return err
+	}
+	return tx.Model(&grant).Select("starts_at", "expires_at").Updates(models.UserHasRole{StartsAt: startsAt, ExpiresAt: expiresAt}).Error
+}
+
+func applyUserRoles(tx *gorm.DB, userId uint, add, remove []uint) error {
+	if len(remove) > 0 {
+		if err := tx.Where("user_id = ? AND role_id IN ?", userId, remove).Delete(&models.UserHasRole{}).Error; err != nil {
 			return err
 		}
 	}
+	if len(add) == 0 {
+		return nil
+	}
 
-	return nil
+	rows := make([]models.UserHasRole, 0, len(add))
+	for _, roleId := range add {
+		rows = append(rows, models.UserHasRole{UserID: userId, RoleID: roleId})
+	}
+	return tx.Create(&rows).Error
+}
+
+// ChangeStatus moves a user's account to a new status, records the change in
+// the status history and revokes the stored token when the account can no
+// longer be used
+func (*UserService) ChangeStatus(userId string, status models.UserStatus, reason string, changedBy uint) (models.User, error) {
+	var user models.User
+	err := facades.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if user, err = lockUser(tx, userId); err != nil {
+			return err
+		}
+		if user.ID == changedBy {
+			return ErrCannotChangeOwnStatus
+		}
+
+		from := user.Status
+		if from == "" {
+			from = models.UserStatusActive
+		}
+		if !from.CanTransitionTo(status) {
+			return fmt.Errorf("%w: %s -> %s", ErrInvalidStatusTransition, from, status)
+		}
+
+		now := time.Now()
+		updates := map[string]interface{}{
+			"status":            status,
+			"status_reason":     reason,
+			"status_changed_at": now,
+		}
+		if status != models.UserStatusActive {
+			updates["jwt_token"] = ""
+		}
+		if err := tx.Model(&user).Updates(updates).Error; err != nil {
+			return err
+		}
+		if err := tx.First(&user, user.ID).Error; err != nil {
+			return err
+		}
+
+		return tx.Create(&models.UserStatusHistory{
+			UserID:     user.ID,
+			FromStatus: from,
+			ToStatus:   status,
+			Reason:     reason,
+			ChangedBy:  &changedBy,
+		}).Error
+	})
+	return user, err
 }
-func (*UserService) GetRolesByUserId(userId string) ([]models.Role, error) {
-	var roles []models.Role
-	if err := facades.DB.Table("roles").
-		Select("roles.*").
-		Joins("join user_has_roles on roles.id = user_has_roles.role_id").
-		Where("user_has_roles.user_id = ?", userId).
-		Find(&roles).Error; err != nil {
+
+func (*UserService) GetStatusHistory(userId string) ([]models.UserStatusHistory, error) {
+	var history []models.UserStatusHistory
+	if err := facades.DB.Where("user_id = ?", userId).Order("id DESC").Find(&history).Error; err != nil {
 		return nil, err
 	}
-	return roles, nil
+	return history, nil
 }
@@ -0,0 +1,212 @@
+// Package signedurl grants temporary access to a single route of this
+// application. A signed URL carries an HMAC-SHA256 signature over the
+// method, path and query it was issued for, its expiry and optionally the
+// client IP and user it was issued to, so it cannot be reused for another
+// file or route.
+package signedurl
+
+import (
+	"crypto/hkdf"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang_starter_kit_2025/app/helpers"
+)
+
+const (
+	ParamExpires   = "expires"
+	ParamBind      = "bind"
+	ParamSignature = "signature"
+
+	bindIP   = "ip"
+	bindUser = "user"
+)
+
+var (
+	ErrMissingSignature = errors.New("signed url: signature is missing")
+	ErrInvalidSignature = errors.New("signed url: signature is invalid")
+	ErrExpired          = errors.New("signed url: url has expired")
+	ErrNotConfigured    = errors.New("signed url: SIGNED_URL_KEY or APP_KEY must be set to a secret value")
+)
+
+// placeholderKey is the APP_KEY of example configurations, which anyone
+// could sign URLs with
+const placeholderKey = "your_secret_key"
+
+// Options restrict what a signed URL grants
+type Options struct {
+	// Method the URL may be requested with, GET when empty
+	Method string
+	// Expires is how long the URL stays valid
+	Expires time.Duration
+	// ClientIP binds the URL to the client it was issued to
+	ClientIP string
+	// UserID binds the URL to an authenticated user. The route must then
+	// authenticate the request as well.
+	UserID uint
+}
+
+// Request is what a signed URL is verified against
+type Request struct {
+	Method   string
+	URL      *url.URL
+	ClientIP string
+	UserID   uint
+}
+
+// Signer issues and verifies signed URLs
+type Signer struct {
+	key     []byte
+	baseURL string
+	now     func() time.Time
+}
+
+// New creates a signer whose URLs point at baseURL. The signing key is
+// derived from secret, so it is never used for anything else.
+func New(secret []byte, baseURL string) (*Signer, error) {
+	key, err := hkdf.Key(sha256.New, secret, nil, "signed url", sha256.Size)
+	if err != nil {
+		return nil, err
+	}
+	return &Signer{key: key, baseURL: strings.TrimSuffix(baseURL, "/"), now: time.Now}, nil
+}
+
+// Sign returns an absolute URL for path, which may carry its own query
+func (s *Signer) Sign(path string, opts Options) (string, error) {
+	if opts.Expires <= 0 {
+		return "", fmt.Errorf("signed url: expiry must be positive")
+	}
+	u, err := url.Parse(path)
+	if err != nil {
+		return "", err
+	}
+	if u.IsAbs() || u.Host != "" || !strings.HasPrefix(u.Path, "/") {
+		return "", fmt.Errorf("signed url: %q is not an absolute path", path)
+	}
+
+	query := u.Query()
+	for _, param := range []string{ParamExpires, ParamBind, ParamSignature} {
+		query.Del(param)
+	}
+	query.Set(ParamExpires, strconv.FormatInt(s.now().Add(opts.Expires).Unix(), 10))
+	var bind []string
+	if opts.ClientIP != "" {
+		bind = append(bind, bindIP)
+	}
+	if opts.UserID != 0 {
+		bind = append(bind, bindUser)
+	}
+	if len(bind) > 0 {
+		query.Set(ParamBind, strings.Join(bind, ","))
+	}
+
+	method := opts.Method
+	if method == "" {
+		method = http.MethodGet
+	}
+	query.Set(ParamSignature, s.signature(method, u.EscapedPath(), query, opts.ClientIP, opts.UserID))
+	u.RawQuery = query.Encode()
+	return s.baseURL + u.String(), nil
+}
+
+// Verify checks that req is what its URL was signed for and that the URL
+// has not expired
+func (s *Signer) Verify(req Request) error {
+	query := req.URL.Query()
+	signature := query.Get(ParamSignature)
+	if signature == "" {
+		return ErrMissingSignature
+	}
+
+	var clientIP string
+	var userID uint
+	for _, bind := range strings.Split(query.Get(ParamBind), ",") {
+		switch bind {
+		case bindIP:
+			clientIP = req.ClientIP
+		case bindUser:
+			if req.UserID == 0 {
+				return ErrInvalidSignature
+			}
+			userID = req.UserID
+		}
+	}
+
+	expected := s.signature(req.Method, req.URL.EscapedPath(), query, clientIP, userID)
+	if !hmac.Equal([]byte(signature), []byte(expected)) {
+		return ErrInvalidSignature
+	}
+
+	// the expiry is only trusted once the signature is known to cover it
+	expires, err := strconv.ParseInt(query.Get(ParamExpires), 10, 64)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+	if s.now().Unix() > expires {
+		return ErrExpired
+	}
+	return nil
+}
+
+// signature signs everything the URL grants access to. The query includes
+// the expiry and the list of bindings; the bound values themselves are not
+// part of the URL.
+func (s *Signer) signature(method, path string, query url.Values, clientIP string, userID uint) string {
+	signed := url.Values{}
+	for key, values := range query {
+		if key != ParamSignature {
+			signed[key] = values
+		}
+	}
+
+	mac := hmac.New(sha256.New, s.key)
+	fmt.Fprintf(mac, "%s\n%s\n%s\n%s\n%d", method, path, signed.Encode(), clientIP, userID)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+var (
+	defaultMu     sync.Mutex
+	defaultSigner *Signer
+)
+
+// Default returns the signer configured by SIGNED_URL_KEY, or APP_KEY when
+// it is not set, and APP_URL. Signed URLs open files regardless of who asks,
+// so there is no fallback key.
+func Default() (*Signer, error) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+
+	if defaultSigner == nil {
+		signer, err := Load()
+		if err != nil {
+			return nil, err
+		}
+		defaultSigner = signer
+	}
+	return defaultSigner, nil
+}
+
+// Load creates a signer from the environment, see Default
+func Load() (*Signer, error) {
+	secret := helpers.GetEnv("SIGNED_URL_KEY", helpers.GetEnv("APP_KEY", ""))
+	if secret == "" || secret == placeholderKey {
+		return nil, ErrNotConfigured
+	}
+	return New([]byte(secret), helpers.GetEnv("APP_URL", "http://localhost:8080"))
+}
+
+// SetDefault replaces the default signer, e.g. in tests
+func SetDefault(signer *Signer) {
+	defaultMu.Lock()
+	defaultSigner = signer
+	defaultMu.Unlock()
+}
@@ -0,0 +1,13 @@
+package signedurl_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestSignedURLSuite(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Signed URL Test Suite")
+}
@@ -0,0 +1,165 @@
+package signedurl_test
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"golang_starter_kit_2025/app/signedurl"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Signer", func() {
+	var (
+		signer *signedurl.Signer
+		now    time.Time
+	)
+
+	sign := func(path string, opts signedurl.Options) *url.URL {
+		signed, err := signer.Sign(path, opts)
+		Expect(err).NotTo(HaveOccurred())
+		u, err := url.Parse(signed)
+		Expect(err).NotTo(HaveOccurred())
+		return u
+	}
+
+	BeforeEach(func() {
+		var err error
+		signer, err = signedurl.New([]byte("secret"), "https://api.example.com/")
+		Expect(err).NotTo(HaveOccurred())
+		now = time.Unix(1_800_000_000, 0)
+		signer.SetClock(func() time.Time { return now })
+	})
+
+	It("should accept the URL it was issued for until it expires", func() {
+		u := sign("/file/kyc/a.jpg", signedurl.Options{Expires: time.Minute})
+		Expect(u.String()).To(HavePrefix("https://api.example.com/file/kyc/a.jpg?expires="))
+
+		Expect(signer.Verify(signedurl.Request{Method: http.MethodGet, URL: u})).To(Succeed())
+
+		now = now.Add(time.Minute + time.Second)
+		Expect(signer.Verify(signedurl.Request{Method: http.MethodGet, URL: u})).To(MatchError(signedurl.ErrExpired))
+	})
+
+	It("should bind the signature to the path", func() {
+		u := sign("/file/kyc/a.jpg", signedurl.Options{Expires: time.Minute})
+		u.Path = "/file/kyc/b.jpg"
+
+		Expect(signer.Verify(signedurl.Request{Method: http.MethodGet, URL: u})).To(MatchError(signedurl.ErrInvalidSignature))
+	})
+
+	It("should bind the signature to the method", func() {
+		u := sign("/kyc/export", signedurl.Options{Method: http.MethodPost, Expires: time.Minute})
+
+		Expect(signer.Verify(signedurl.Request{Method: http.MethodPost, URL: u})).To(Succeed())
+		Expect(signer.Verify(signedurl.Request{Method: http.MethodGet, URL: u})).To(MatchError(signedurl.ErrInvalidSignature))
+	})
+
+	It("should bind the signature to the query", func() {
+		u := sign("/reports?month=2026-10", signedurl.Options{Expires: time.Minute})
+		Expect(u.Query().Get("month")).To(Equal("2026-10"))
+		Expect(signer.Verify(signedurl.Request{Method: http.MethodGet, URL: u})).To(Succeed())
+
+		query := u.Query()
+		query.Set("month", "2026-11")
+		u.RawQuery = query.Encode()
+		Expect(signer.Verify(signedurl.Request{Method: http.MethodGet, URL: u})).To(MatchError(signedurl.ErrInvalidSignature))
+	})
+
+	It("should not let the expiry be extended", func() {
+		u := sign("/file/kyc/a.jpg", signedurl.Options{Expires: time.Minute})
+		query := u.Query()
+		query.Set(signedurl.ParamExpires, "9999999999")
+		u.RawQuery = query.Encode()
+
+		Expect(signer.Verify(signedurl.Request{Method: http.MethodGet, URL: u})).To(MatchError(signedurl.ErrInvalidSignature))
+	})
+
+	It("should bind the URL to the client IP", func() {
+		u := sign("/file/kyc/a.jpg", signedurl.Options{Expires: time.Minute, ClientIP: "10.0.0.1"})
+		Expect(u.RawQuery).NotTo(ContainSubstring("10.0.0.1"))
+
+		Expect(signer.Verify(signedurl.Request{Method: http.MethodGet, URL: u, ClientIP: "10.0.0.1"})).To(Succeed())
+		Expect(signer.Verify(signedurl.Request{Method: http.MethodGet, URL: u, ClientIP: "10.0.0.2"})).To(MatchError(signedurl.ErrInvalidSignature))
+
+		query := u.Query()
+		query.Del(signedurl.ParamBind)
+		u.RawQuery = query.Encode()
+		Expect(signer.Verify(signedurl.Request{Method: http.MethodGet, URL: u, ClientIP: "10.0.0.2"})).To(MatchError(signedurl.ErrInvalidSignature))
+	})
+
+	It("should bind the URL to the user", func() {
+		u := sign("/file/kyc/a.jpg", signedurl.Options{Expires: time.Minute, UserID: 7})
+
+		Expect(signer.Verify(signedurl.Request{Method: http.MethodGet, URL: u, UserID: 7})).To(Succeed())
+		Expect(signer.Verify(signedurl.Request{Method: http.MethodGet, URL: u, UserID: 8})).To(MatchError(signedurl.ErrInvalidSignature))
+		Expect(signer.Verify(signedurl.Request{Method: http.MethodGet, URL: u})).To(MatchError(signedurl.ErrInvalidSignature))
+	})
+
+	It("should reject URLs of another key and unsigned URLs", func() {
+		other, err := signedurl.New([]byte("other secret"), "https://api.example.com")
+		Expect(err).NotTo(HaveOccurred())
+		signed, err := other.Sign("/file/kyc/a.jpg", signedurl.Options{Expires: time.Minute})
+		Expect(err).NotTo(HaveOccurred())
+		u, _ := url.Parse(signed)
+
+		Expect(signer.Verify(signedurl.Request{Method: http.MethodGet, URL: u})).To(MatchError(signedurl.ErrInvalidSignature))
+
+		u, _ = url.Parse("/file/kyc/a.jpg")
+		Expect(signer.Verify(signedurl.Request{Method: http.MethodGet, URL: u})).To(MatchError(signedurl.ErrMissingSignature))
+	})
+
+	It("should sign paths that need escaping", func() {
+		u := sign("/file/kyc/foto ktp.jpg", signedurl.Options{Expires: time.Minute})
+		Expect(u.EscapedPath()).To(Equal("/file/kyc/foto%20ktp.jpg"))
+
+		// what the server parses from the request line
+		received, err := url.ParseRequestURI(strings.TrimPrefix(u.String(), "https://api.example.com"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(signer.Verify(signedurl.Request{Method: http.MethodGet, URL: received})).To(Succeed())
+	})
+
+	It("should refuse to sign without expiry or for another host", func() {
+		_, err := signer.Sign("/file/kyc/a.jpg", signedurl.Options{})
+		Expect(err).To(HaveOccurred())
+		_, err = signer.Sign("https://evil.example.com/file", signedurl.Options{Expires: time.Minute})
+		Expect(err).To(HaveOccurred())
+	})
+})
+
+var _ = Describe("Load", func() {
+	BeforeEach(func() {
+		GinkgoT().Setenv("SIGNED_URL_KEY", "")
+		GinkgoT().Setenv("APP_KEY", "")
+	})
+
+	It("should refuse to sign without a key", func() {
+		_, err := signedurl.Load()
+		Expect(err).To(MatchError(signedurl.ErrNotConfigured))
+	})
+
+	It("should refuse the example APP_KEY", func() {
+		GinkgoT().Setenv("APP_KEY", "your_secret_key")
+		_, err := signedurl.Load()
+		Expect(err).To(MatchError(signedurl.ErrNotConfigured))
+	})
+
+	It("should prefer SIGNED_URL_KEY over APP_KEY", func() {
+		GinkgoT().Setenv("APP_KEY", "app key")
+		GinkgoT().Setenv("SIGNED_URL_KEY", "signing key")
+		signer, err := signedurl.Load()
+		Expect(err).NotTo(HaveOccurred())
+		signed, err := signer.Sign("/file/kyc/a.jpg", signedurl.Options{Expires: time.Minute})
+		Expect(err).NotTo(HaveOccurred())
+
+		other, err := signedurl.New([]byte("app key"), "http://localhost:8080")
+		Expect(err).NotTo(HaveOccurred())
+		u, err := url.Parse(signed)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(signer.Verify(signedurl.Request{Method: http.MethodGet, URL: u})).To(Succeed())
+		Expect(other.Verify(signedurl.Request{Method: http.MethodGet, URL: u})).To(MatchError(signedurl.ErrInvalidSignature))
+	})
+})
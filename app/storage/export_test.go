@@ -0,0 +1,8 @@
+package storage
+
+import "time"
+
+// SetClock fixes the time requests are signed at
+func (s *S3) SetClock(now func() time.Time) {
+	s.now = now
+}
@@ -0,0 +1,127 @@
+package storage
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Local stores files in a directory of the local filesystem
+type Local struct {
+	root string
+}
+
+func NewLocal(root string) *Local {
+	return &Local{root: root}
+}
+
+func (l *Local) file(p string) (string, error) {
+	p, err := cleanPath(p)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(l.root, filepath.FromSlash(p)), nil
+}
+
+// Put writes r to a temporary file next to path and renames it over path
+// once complete
+func (l *Local) Put(p string, r io.Reader) error {
+	file, err := l.file(p)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(file), os.ModePerm); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(file), ".put-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), file)
+}
+
+func (l *Local) Get(p string) (io.ReadCloser, error) {
+	file, err := l.file(p)
+	if err != nil {
+		return nil, err
+	}
+	return os.Open(file)
+}
+
+func (l *Local) Delete(p string) error {
+	file, err := l.file(p)
+	if err != nil {
+		return err
+	}
+	return os.Remove(file)
+}
+
+func (l *Local) Stat(p string) (FileInfo, error) {
+	file, err := l.file(p)
+	if err != nil {
+		return FileInfo{}, err
+	}
+	info, err := os.Stat(file)
+	if err != nil {
+		return FileInfo{}, err
+	}
+	if info.IsDir() {
+		return FileInfo{}, &fs.PathError{Op: "stat", Path: p, Err: ErrNotExist}
+	}
+	p, _ = cleanPath(p)
+	return FileInfo{Path: p, Size: info.Size(), ModTime: info.ModTime()}, nil
+}
+
+// List walks the directory tree, skipping hidden files such as unfinished
+// writes
+func (l *Local) List(prefix string) ([]FileInfo, error) {
+	var files []FileInfo
+	err := filepath.WalkDir(l.root, func(file string, entry fs.DirEntry, err error) error {
+		if errors.Is(err, fs.ErrNotExist) && file == l.root {
+			return filepath.SkipDir
+		}
+		if err != nil || entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			return err
+		}
+
+		rel, err := filepath.Rel(l.root, file)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if !strings.HasPrefix(rel, prefix) {
+			return nil
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		files = append(files, FileInfo{Path: rel, Size: info.Size(), ModTime: info.ModTime()})
+		return nil
+	})
+	sort.Slice(files, func(i, j int) bool { return files[i].Path < files[j].Path })
+	return files, err
+}
+
+func (l *Local) SignedURL(p string, expires time.Duration) (string, error) {
+	return appURL(p, expires)
+}
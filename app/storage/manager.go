@@ -0,0 +1,73 @@
+package storage
+
+import (
+	"fmt"
+	"sync"
+
+	"golang_starter_kit_2025/config"
+)
+
+var (
+	disksMu sync.Mutex
+	disks   = make(map[string]Storage)
+)
+
+// Disk returns the named disk, creating it from the storage configuration on
+// first use
+func Disk(name string) (Storage, error) {
+	disksMu.Lock()
+	defer disksMu.Unlock()
+
+	if disk, ok := disks[name]; ok {
+		return disk, nil
+	}
+
+	cfg, ok := config.GetStorageConfigs().Disks[name]
+	if !ok {
+		return nil, fmt.Errorf("storage disk %q is not configured", name)
+	}
+	disk, err := New(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("storage disk %q: %w", name, err)
+	}
+	disks[name] = disk
+	return disk, nil
+}
+
+// DefaultDisk returns the disk named by FILESYSTEM_DISK
+func DefaultDisk() (Storage, error) {
+	return Disk(config.GetStorageConfigs().Default)
+}
+
+// Default returns the disk named by FILESYSTEM_DISK. The application resolves
+// it with DefaultDisk at startup and refuses to start when it is
+// misconfigured, so Default only panics when that check was skipped.
+func Default() Storage {
+	disk, err := DefaultDisk()
+	if err != nil {
+		panic(err)
+	}
+	return disk
+}
+
+// SetDisk replaces the named disk, e.g. with a Memory disk in tests
+func SetDisk(name string, disk Storage) {
+	disksMu.Lock()
+	defer disksMu.Unlock()
+	disks[name] = disk
+}
+
+// New creates a disk from its configuration
+func New(cfg *config.DiskConfig) (Storage, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	switch cfg.Driver {
+	case config.LocalStorage:
+		return NewLocal(cfg.Root), nil
+	case config.MemoryStorage:
+		return NewMemory(), nil
+	default:
+		return NewS3(cfg.Endpoint, cfg.Region, cfg.Bucket, cfg.AccessKey, cfg.SecretKey, cfg.PathStyle)
+	}
+}
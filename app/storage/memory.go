@@ -0,0 +1,105 @@
+package storage
+
+import (
+	"bytes"
+	"io"
+	"io/fs"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Memory keeps files in memory. It is meant for tests: the content is lost
+// when the process exits and is not shared between processes.
+type Memory struct {
+	mu    sync.RWMutex
+	files map[string]memoryFile
+}
+
+type memoryFile struct {
+	content []byte
+	modTime time.Time
+}
+
+func NewMemory() *Memory {
+	return &Memory{files: make(map[string]memoryFile)}
+}
+
+func (m *Memory) Put(p string, r io.Reader) error {
+	p, err := cleanPath(p)
+	if err != nil {
+		return err
+	}
+	content, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	m.files[p] = memoryFile{content: content, modTime: time.Now()}
+	m.mu.Unlock()
+	return nil
+}
+
+func (m *Memory) Get(p string) (io.ReadCloser, error) {
+	file, err := m.get(p, "open")
+	if err != nil {
+		return nil, err
+	}
+	return io.NopCloser(bytes.NewReader(file.content)), nil
+}
+
+func (m *Memory) Delete(p string) error {
+	if _, err := m.get(p, "remove"); err != nil {
+		return err
+	}
+	p, _ = cleanPath(p)
+
+	m.mu.Lock()
+	delete(m.files, p)
+	m.mu.Unlock()
+	return nil
+}
+
+func (m *Memory) Stat(p string) (FileInfo, error) {
+	file, err := m.get(p, "stat")
+	if err != nil {
+		return FileInfo{}, err
+	}
+	p, _ = cleanPath(p)
+	return FileInfo{Path: p, Size: int64(len(file.content)), ModTime: file.modTime}, nil
+}
+
+func (m *Memory) List(prefix string) ([]FileInfo, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var files []FileInfo
+	for p, file := range m.files {
+		if strings.HasPrefix(p, prefix) {
+			files = append(files, FileInfo{Path: p, Size: int64(len(file.content)), ModTime: file.modTime})
+		}
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Path < files[j].Path })
+	return files, nil
+}
+
+func (m *Memory) SignedURL(p string, expires time.Duration) (string, error) {
+	return appURL(p, expires)
+}
+
+func (m *Memory) get(p string, op string) (memoryFile, error) {
+	cleaned, err := cleanPath(p)
+	if err != nil {
+		return memoryFile{}, err
+	}
+
+	m.mu.RLock()
+	file, ok := m.files[cleaned]
+	m.mu.RUnlock()
+	if !ok {
+		return file, &fs.PathError{Op: op, Path: p, Err: ErrNotExist}
+	}
+	return file, nil
+}
@@ -0,0 +1,374 @@
+package storage
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	s3Algorithm       = "AWS4-HMAC-SHA256"
+	s3UnsignedPayload = "UNSIGNED-PAYLOAD"
+	s3TimeFormat      = "20060102T150405Z"
+)
+
+// S3 stores files as objects of a bucket of an S3-compatible object store
+// such as AWS S3 or MinIO. Requests are signed with AWS Signature Version 4.
+type S3 struct {
+	endpoint  *url.URL
+	region    string
+	bucket    string
+	accessKey string
+	secretKey string
+	pathStyle bool
+
+	client *http.Client
+	now    func() time.Time
+}
+
+// NewS3 creates an S3 disk. With pathStyle the bucket is addressed as
+// endpoint/bucket/key, which MinIO needs; otherwise as bucket.endpoint/key.
+func NewS3(endpoint, region, bucket, accessKey, secretKey string, pathStyle bool) (*S3, error) {
+	u, err := url.Parse(strings.TrimSuffix(endpoint, "/"))
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" || u.Host == "" {
+		return nil, fmt.Errorf("invalid s3 endpoint %q", endpoint)
+	}
+	return &S3{
+		endpoint:  u,
+		region:    region,
+		bucket:    bucket,
+		accessKey: accessKey,
+		secretKey: secretKey,
+		pathStyle: pathStyle,
+		client:    &http.Client{Timeout: 5 * time.Minute},
+		now:       time.Now,
+	}, nil
+}
+
+// Put uploads r as a single object. S3 needs the length of the object up
+// front, so a reader of unknown length is first spooled to a temporary file.
+func (s *S3) Put(p string, r io.Reader) error {
+	key, err := cleanPath(p)
+	if err != nil {
+		return err
+	}
+
+	var length int64
+	if sized, ok := r.(interface{ Len() int }); ok {
+		length = int64(sized.Len())
+	} else {
+		spool, err := os.CreateTemp("", "s3-put-*")
+		if err != nil {
+			return err
+		}
+		defer os.Remove(spool.Name())
+		defer spool.Close()
+
+		if length, err = io.Copy(spool, r); err != nil {
+			return err
+		}
+		if _, err := spool.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+		r = spool
+	}
+
+	req, err := s.request(http.MethodPut, key, nil, r)
+	if err != nil {
+		return err
+	}
+	req.ContentLength = length
+	if length == 0 {
+		req.Body = http.NoBody
+	}
+
+	res, err := s.do(req)
+	if err != nil {
+		return err
+	}
+	res.Body.Close()
+	return nil
+}
+
+func (s *S3) Get(p string) (io.ReadCloser, error) {
+	key, err := cleanPath(p)
+	if err != nil {
+		return nil, err
+	}
+	req, err := s.request(http.MethodGet, key, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	res, err := s.do(req)
+	if err != nil {
+		return nil, err
+	}
+	return res.Body, nil
+}
+
+// Delete removes the object. S3 reports success for a missing object, so
+// its existence is checked first to behave like the other disks.
+func (s *S3) Delete(p string) error {
+	if _, err := s.Stat(p); err != nil {
+		return err
+	}
+	key, _ := cleanPath(p)
+	req, err := s.request(http.MethodDelete, key, nil, nil)
+	if err != nil {
+		return err
+	}
+	res, err := s.do(req)
+	if err != nil {
+		return err
+	}
+	res.Body.Close()
+	return nil
+}
+
+func (s *S3) Stat(p string) (FileInfo, error) {
+	key, err := cleanPath(p)
+	if err != nil {
+		return FileInfo{}, err
+	}
+	req, err := s.request(http.MethodHead, key, nil, nil)
+	if err != nil {
+		return FileInfo{}, err
+	}
+	res, err := s.do(req)
+	if err != nil {
+		return FileInfo{}, err
+	}
+	res.Body.Close()
+
+	modTime, _ := http.ParseTime(res.Header.Get("Last-Modified"))
+	return FileInfo{Path: key, Size: res.ContentLength, ModTime: modTime}, nil
+}
+
+type s3ListResult struct {
+	Contents []struct {
+		Key          string    `xml:"Key"`
+		Size         int64     `xml:"Size"`
+		LastModified time.Time `xml:"LastModified"`
+	} `xml:"Contents"`
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+}
+
+// List pages through ListObjectsV2, which returns keys in lexical order
+func (s *S3) List(prefix string) ([]FileInfo, error) {
+	var files []FileInfo
+	token := ""
+	for {
+		query := url.Values{"list-type": {"2"}, "prefix": {prefix}}
+		if token != "" {
+			query.Set("continuation-token", token)
+		}
+		req, err := s.request(http.MethodGet, "", query, nil)
+		if err != nil {
+			return nil, err
+		}
+		res, err := s.do(req)
+		if err != nil {
+			return nil, err
+		}
+
+		var result s3ListResult
+		err = xml.NewDecoder(res.Body).Decode(&result)
+		res.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("storage: invalid s3 list response: %w", err)
+		}
+		for _, object := range result.Contents {
+			files = append(files, FileInfo{Path: object.Key, Size: object.Size, ModTime: object.LastModified})
+		}
+		if !result.IsTruncated || result.NextContinuationToken == "" {
+			return files, nil
+		}
+		token = result.NextContinuationToken
+	}
+}
+
+// SignedURL presigns a GET of the object, so the client downloads it
+// straight from the object store. Files encrypted by FileService must be
+// served through the application instead.
+func (s *S3) SignedURL(p string, expires time.Duration) (string, error) {
+	key, err := cleanPath(p)
+	if err != nil {
+		return "", err
+	}
+	u := s.objectURL(key)
+	now := s.now().UTC()
+	scope := s.scope(now)
+
+	query := url.Values{
+		"X-Amz-Algorithm":     {s3Algorithm},
+		"X-Amz-Credential":    {s.accessKey + "/" + scope},
+		"X-Amz-Date":          {now.Format(s3TimeFormat)},
+		"X-Amz-Expires":       {strconv.Itoa(int(expires.Seconds()))},
+		"X-Amz-SignedHeaders": {"host"},
+	}
+	canonical := strings.Join([]string{
+		http.MethodGet,
+		u.EscapedPath(),
+		canonicalQuery(query),
+		"host:" + u.Host + "\n",
+		"host",
+		s3UnsignedPayload,
+	}, "\n")
+	query.Set("X-Amz-Signature", s.signature(now, canonical))
+	u.RawQuery = canonicalQuery(query)
+	return u.String(), nil
+}
+
+// objectURL addresses key in the bucket, or the bucket itself for an empty
+// key
+func (s *S3) objectURL(key string) *url.URL {
+	u := *s.endpoint
+	prefix := u.Path
+	if s.pathStyle {
+		prefix += "/" + s.bucket
+	} else {
+		u.Host = s.bucket + "." + u.Host
+	}
+	u.Path = prefix + "/" + key
+	u.RawPath = uriEncode(prefix, false) + "/" + uriEncode(key, false)
+	return &u
+}
+
+// request builds a request signed with the Authorization header. The payload
+// is not signed, so bodies can be streamed; they are protected by TLS.
+func (s *S3) request(method, key string, query url.Values, body io.Reader) (*http.Request, error) {
+	u := s.objectURL(key)
+	u.RawQuery = canonicalQuery(query)
+	req, err := http.NewRequest(method, u.String(), body)
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now().UTC()
+	req.Header.Set("X-Amz-Date", now.Format(s3TimeFormat))
+	req.Header.Set("X-Amz-Content-Sha256", s3UnsignedPayload)
+
+	signedHeaders := "host;x-amz-content-sha256;x-amz-date"
+	canonical := strings.Join([]string{
+		method,
+		u.EscapedPath(),
+		u.RawQuery,
+		"host:" + u.Host + "\n" +
+			"x-amz-content-sha256:" + s3UnsignedPayload + "\n" +
+			"x-amz-date:" + now.Format(s3TimeFormat) + "\n",
+		signedHeaders,
+		s3UnsignedPayload,
+	}, "\n")
+	req.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s3Algorithm, s.accessKey, s.scope(now), signedHeaders, s.signature(now, canonical)))
+	return req, nil
+}
+
+type s3Error struct {
+	Code    string `xml:"Code"`
+	Message string `xml:"Message"`
+}
+
+// do sends req and turns an unsuccessful response into an error. A missing
+// object becomes ErrNotExist.
+func (s *S3) do(req *http.Request) (*http.Response, error) {
+	res, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode >= 200 && res.StatusCode < 300 {
+		return res, nil
+	}
+	defer res.Body.Close()
+
+	key := strings.TrimPrefix(req.URL.Path, s.objectURL("").Path)
+	if res.StatusCode == http.StatusNotFound && key != "" {
+		return nil, &fs.PathError{Op: strings.ToLower(req.Method), Path: key, Err: ErrNotExist}
+	}
+
+	var apiErr s3Error
+	xml.NewDecoder(io.LimitReader(res.Body, 64<<10)).Decode(&apiErr)
+	if apiErr.Code == "" {
+		apiErr.Code = res.Status
+	}
+	return nil, fmt.Errorf("storage: s3 %s %q failed: %s %s", req.Method, key, apiErr.Code, apiErr.Message)
+}
+
+func (s *S3) scope(now time.Time) string {
+	return now.Format("20060102") + "/" + s.region + "/s3/aws4_request"
+}
+
+// signature signs a canonical request, see
+// https://docs.aws.amazon.com/IAM/latest/UserGuide/create-signed-request.html
+func (s *S3) signature(now time.Time, canonical string) string {
+	hash := sha256.Sum256([]byte(canonical))
+	stringToSign := strings.Join([]string{
+		s3Algorithm,
+		now.Format(s3TimeFormat),
+		s.scope(now),
+		hex.EncodeToString(hash[:]),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+s.secretKey), now.Format("20060102"))
+	key = hmacSHA256(key, s.region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	return hex.EncodeToString(hmacSHA256(key, stringToSign))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// canonicalQuery encodes query sorted by key the way SigV4 expects
+func canonicalQuery(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var pairs []string
+	for _, key := range keys {
+		values := append([]string(nil), query[key]...)
+		sort.Strings(values)
+		for _, value := range values {
+			pairs = append(pairs, uriEncode(key, true)+"="+uriEncode(value, true))
+		}
+	}
+	return strings.Join(pairs, "&")
+}
+
+// uriEncode percent-encodes everything except unreserved characters, and
+// slashes unless encodeSlash is set
+func uriEncode(s string, encodeSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9',
+			c == '-', c == '.', c == '_', c == '~', c == '/' && !encodeSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
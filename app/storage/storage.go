@@ -0,0 +1,96 @@
+// Package storage stores files on named disks backed by the local
+// filesystem, memory or an S3-compatible object store, so the application
+// does not depend on where its files live.
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"strings"
+	"time"
+
+	"golang_starter_kit_2025/app/signedurl"
+)
+
+var (
+	// ErrNotExist is returned for a path that holds no file. It is
+	// fs.ErrNotExist, so errors.Is(err, fs.ErrNotExist) keeps working.
+	ErrNotExist    = fs.ErrNotExist
+	ErrInvalidPath = errors.New("storage: invalid path")
+)
+
+// FileInfo describes a stored file
+type FileInfo struct {
+	Path    string
+	Size    int64
+	ModTime time.Time
+}
+
+// Storage is a disk. Paths are relative, slash separated and may not leave
+// the disk with "..".
+type Storage interface {
+	// Put stores the content of r at path, replacing any previous file.
+	// Readers never see a partially written file.
+	Put(path string, r io.Reader) error
+	// Get opens the file at path for reading
+	Get(path string) (io.ReadCloser, error)
+	// Delete removes the file at path, returning ErrNotExist if there is none
+	Delete(path string) error
+	// Stat describes the file at path
+	Stat(path string) (FileInfo, error)
+	// List describes every file whose path starts with prefix, in
+	// lexical order
+	List(prefix string) ([]FileInfo, error)
+	// SignedURL returns a URL the file can be downloaded from until expires
+	// has passed
+	SignedURL(path string, expires time.Duration) (string, error)
+}
+
+// cleanPath validates a disk path and returns it in canonical form
+func cleanPath(p string) (string, error) {
+	cleaned := path.Clean("/" + strings.ReplaceAll(p, "\\", "/"))[1:]
+	if cleaned == "" || cleaned == "." || strings.Contains(p, "\x00") {
+		return "", fmt.Errorf("%w: %q", ErrInvalidPath, p)
+	}
+	for _, segment := range strings.Split(strings.ReplaceAll(p, "\\", "/"), "/") {
+		if segment == ".." {
+			return "", fmt.Errorf("%w: %q", ErrInvalidPath, p)
+		}
+	}
+	return cleaned, nil
+}
+
+// JoinPath joins path segments taken from a request into a path of a disk.
+// Each segment must be a single name: empty segments, "." and "..", slashes,
+// backslashes and NUL bytes are refused, so the result cannot leave the disk
+// or point at another directory than the one asked for.
+func JoinPath(segments ...string) (string, error) {
+	for _, segment := range segments {
+		if segment == "" || segment == "." || segment == ".." || strings.ContainsAny(segment, "/\\\x00") {
+			return "", fmt.Errorf("%w: %q", ErrInvalidPath, segment)
+		}
+	}
+	return strings.Join(segments, "/"), nil
+}
+
+// appURL signs a URL of the file route of this application, which serves
+// files of the default disk decrypted. The route takes exactly a directory
+// and a file name.
+func appURL(p string, expires time.Duration) (string, error) {
+	p, err := cleanPath(p)
+	if err != nil {
+		return "", err
+	}
+	dir, _ := path.Split(p)
+	if dir == "" || strings.Count(dir, "/") != 1 {
+		return "", fmt.Errorf("%w: %q cannot be served by the file route", ErrInvalidPath, p)
+	}
+	signer, err := signedurl.Default()
+	if err != nil {
+		return "", err
+	}
+	return signer.Sign("/file/"+p, signedurl.Options{Expires: expires})
+}
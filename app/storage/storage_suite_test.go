@@ -0,0 +1,21 @@
+package storage_test
+
+import (
+	"testing"
+
+	"golang_starter_kit_2025/app/signedurl"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestStorageSuite(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Storage Test Suite")
+}
+
+var _ = BeforeSuite(func() {
+	signer, err := signedurl.New([]byte("storage test key"), "http://localhost:8080")
+	Expect(err).NotTo(HaveOccurred())
+	signedurl.SetDefault(signer)
+})
@@ -0,0 +1,239 @@
+package storage_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"golang_starter_kit_2025/app/signedurl"
+	"golang_starter_kit_2025/app/storage"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// onlyReader hides Len and Seek, like a pipe does
+type onlyReader struct{ io.Reader }
+
+func read(disk storage.Storage, path string) string {
+	reader, err := disk.Get(path)
+	Expect(err).NotTo(HaveOccurred())
+	defer reader.Close()
+	content, err := io.ReadAll(reader)
+	Expect(err).NotTo(HaveOccurred())
+	return string(content)
+}
+
+func paths(files []storage.FileInfo) []string {
+	var result []string
+	for _, file := range files {
+		result = append(result, file.Path)
+	}
+	return result
+}
+
+// describeDisk checks the behaviour every driver must share
+func describeDisk(name string, newDisk func() storage.Storage) {
+	Describe(name, func() {
+		var disk storage.Storage
+
+		BeforeEach(func() {
+			disk = newDisk()
+		})
+
+		It("should store, describe and read back a file", func() {
+			Expect(disk.Put("kyc/a.jpg", strings.NewReader("hello"))).To(Succeed())
+
+			info, err := disk.Stat("kyc/a.jpg")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(info.Path).To(Equal("kyc/a.jpg"))
+			Expect(info.Size).To(Equal(int64(5)))
+			Expect(read(disk, "kyc/a.jpg")).To(Equal("hello"))
+		})
+
+		It("should store readers of unknown length and empty files", func() {
+			Expect(disk.Put("kyc/stream.bin", onlyReader{strings.NewReader("streamed")})).To(Succeed())
+			Expect(disk.Put("kyc/empty.bin", bytes.NewReader(nil))).To(Succeed())
+
+			Expect(read(disk, "kyc/stream.bin")).To(Equal("streamed"))
+			Expect(read(disk, "kyc/empty.bin")).To(BeEmpty())
+		})
+
+		It("should replace an existing file", func() {
+			Expect(disk.Put("kyc/a.jpg", strings.NewReader("old content"))).To(Succeed())
+			Expect(disk.Put("kyc/a.jpg", strings.NewReader("new"))).To(Succeed())
+
+			Expect(read(disk, "kyc/a.jpg")).To(Equal("new"))
+		})
+
+		It("should report missing files as not existing", func() {
+			_, err := disk.Get("kyc/missing.jpg")
+			Expect(errors.Is(err, fs.ErrNotExist)).To(BeTrue())
+			_, err = disk.Stat("kyc/missing.jpg")
+			Expect(errors.Is(err, storage.ErrNotExist)).To(BeTrue())
+			Expect(errors.Is(disk.Delete("kyc/missing.jpg"), fs.ErrNotExist)).To(BeTrue())
+		})
+
+		It("should delete a file", func() {
+			Expect(disk.Put("kyc/a.jpg", strings.NewReader("hello"))).To(Succeed())
+			Expect(disk.Delete("kyc/a.jpg")).To(Succeed())
+
+			_, err := disk.Stat("kyc/a.jpg")
+			Expect(errors.Is(err, fs.ErrNotExist)).To(BeTrue())
+		})
+
+		It("should list files by prefix in lexical order", func() {
+			for _, path := range []string{"kyc/b.jpg", "kyc/a.jpg", "kyb/c.pdf", "kyc/sub/d.png"} {
+				Expect(disk.Put(path, strings.NewReader(path))).To(Succeed())
+			}
+
+			files, err := disk.List("kyc/")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(paths(files)).To(Equal([]string{"kyc/a.jpg", "kyc/b.jpg", "kyc/sub/d.png"}))
+			Expect(files[0].Size).To(Equal(int64(len("kyc/a.jpg"))))
+
+			files, err = disk.List("")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(files).To(HaveLen(4))
+		})
+
+		It("should refuse paths leaving the disk", func() {
+			for _, path := range []string{"../secret", "kyc/../../secret", "", "."} {
+				Expect(errors.Is(disk.Put(path, strings.NewReader("x")), storage.ErrInvalidPath)).To(BeTrue(), path)
+				_, err := disk.Get(path)
+				Expect(errors.Is(err, storage.ErrInvalidPath)).To(BeTrue(), path)
+			}
+		})
+
+		It("should sign download URLs", func() {
+			Expect(disk.Put("kyc/a.jpg", strings.NewReader("hello"))).To(Succeed())
+
+			url, err := disk.SignedURL("kyc/a.jpg", time.Minute)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(url).To(ContainSubstring("a.jpg"))
+		})
+	})
+}
+
+var _ = Describe("Storage", func() {
+	describeDisk("Memory", func() storage.Storage {
+		return storage.NewMemory()
+	})
+
+	describeDisk("Local", func() storage.Storage {
+		return storage.NewLocal(GinkgoT().TempDir())
+	})
+
+	describeDisk("S3", func() storage.Storage {
+		server := newFakeS3()
+		DeferCleanup(server.Close)
+		disk, err := storage.NewS3(server.URL, "us-east-1", fakeBucket, fakeAccessKey, fakeSecretKey, true)
+		Expect(err).NotTo(HaveOccurred())
+		return disk
+	})
+
+	Describe("Local", func() {
+		It("should not leave temporary files behind nor list them", func() {
+			root := GinkgoT().TempDir()
+			disk := storage.NewLocal(root)
+			Expect(disk.Put("kyc/a.jpg", strings.NewReader("hello"))).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(root, "kyc", ".put-123"), []byte("partial"), 0o600)).To(Succeed())
+
+			entries, err := os.ReadDir(filepath.Join(root, "kyc"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(entries).To(HaveLen(2))
+
+			files, err := disk.List("")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(paths(files)).To(Equal([]string{"kyc/a.jpg"}))
+		})
+
+		It("should list an empty disk whose root does not exist yet", func() {
+			disk := storage.NewLocal(filepath.Join(GinkgoT().TempDir(), "missing"))
+			files, err := disk.List("")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(files).To(BeEmpty())
+		})
+
+		It("should only sign URLs the file route can serve", func() {
+			signer, err := signedurl.Default()
+			Expect(err).NotTo(HaveOccurred())
+
+			disk := storage.NewLocal(GinkgoT().TempDir())
+			signed, err := disk.SignedURL("kyc/a.jpg", time.Minute)
+			Expect(err).NotTo(HaveOccurred())
+			u, err := url.Parse(signed)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(u.Path).To(Equal("/file/kyc/a.jpg"))
+			Expect(signer.Verify(signedurl.Request{Method: http.MethodGet, URL: u})).To(Succeed())
+
+			_, err = disk.SignedURL("kyc/sub/a.jpg", time.Minute)
+			Expect(errors.Is(err, storage.ErrInvalidPath)).To(BeTrue())
+		})
+	})
+})
+
+var _ = Describe("Disk", func() {
+	It("should create each named disk with the driver configured for it", func() {
+		server := newFakeS3()
+		DeferCleanup(server.Close)
+		root := GinkgoT().TempDir()
+		GinkgoT().Setenv("STORAGE_DISKS", "public, kyc")
+		GinkgoT().Setenv("STORAGE_PUBLIC_DRIVER", "local")
+		GinkgoT().Setenv("STORAGE_PUBLIC_ROOT", root)
+		GinkgoT().Setenv("STORAGE_KYC_DRIVER", "s3")
+		GinkgoT().Setenv("STORAGE_KYC_ENDPOINT", server.URL)
+		GinkgoT().Setenv("STORAGE_KYC_BUCKET", fakeBucket)
+		GinkgoT().Setenv("STORAGE_KYC_ACCESS_KEY_ID", fakeAccessKey)
+		GinkgoT().Setenv("STORAGE_KYC_SECRET_ACCESS_KEY", fakeSecretKey)
+
+		public, err := storage.Disk("public")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(public.Put("a.txt", strings.NewReader("public"))).To(Succeed())
+		Expect(filepath.Join(root, "a.txt")).To(BeAnExistingFile())
+
+		kyc, err := storage.Disk("kyc")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(kyc.Put("a.txt", strings.NewReader("kyc"))).To(Succeed())
+		info, err := kyc.Stat("a.txt")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(info.Size).To(BeEquivalentTo(3))
+	})
+
+	It("should refuse disks that are not listed or not complete", func() {
+		GinkgoT().Setenv("STORAGE_DISKS", "archive")
+		_, err := storage.Disk("unlisted")
+		Expect(err).To(HaveOccurred())
+
+		_, err = storage.Disk("archive")
+		Expect(err).To(MatchError(ContainSubstring("unknown storage driver")))
+	})
+})
+
+var _ = Describe("JoinPath", func() {
+	It("joins names into a path", func() {
+		p, err := storage.JoinPath("kyc", "KYC-1.jpg")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(p).To(Equal("kyc/KYC-1.jpg"))
+	})
+
+	DescribeTable("refuses segments that are not a single name",
+		func(segments ...string) {
+			_, err := storage.JoinPath(segments...)
+			Expect(errors.Is(err, storage.ErrInvalidPath)).To(BeTrue())
+		},
+		Entry("parent directory", "..", "etc"),
+		Entry("current directory", ".", "a.jpg"),
+		Entry("empty", "kyc", ""),
+		Entry("slash", "kyc", "../../etc/passwd"),
+		Entry("backslash", "kyc", "..\\secret"),
+		Entry("NUL", "kyc", "a.jpg\x00.png"),
+	)
+})
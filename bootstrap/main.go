@@ -5,7 +5,13 @@ import (
 	"log"
 	"os"
 
+	"golang_starter_kit_2025/app/encryption"
+	"golang_starter_kit_2025/app/filescan"
 	"golang_starter_kit_2025/app/helpers"
+	"golang_starter_kit_2025/app/nik"
+	"golang_starter_kit_2025/app/services"
+	"golang_starter_kit_2025/app/signedurl"
+	"golang_starter_kit_2025/app/storage"
 	"golang_starter_kit_2025/cmd"
 	"golang_starter_kit_2025/docs"
 	"golang_starter_kit_2025/facades"
@@ -28,6 +34,29 @@ func Init() {
 	facades.ConnectDB()
 	defer facades.CloseDB()
 
+	if _, err := encryption.Default(); err != nil {
+		log.Fatalf("Konfigurasi kunci enkripsi tidak valid: %v", err)
+	}
+	if _, err := signedurl.Default(); err != nil {
+		log.Fatalf("Konfigurasi URL bertanda tangan tidak valid: %v", err)
+	}
+	if _, err := storage.DefaultDisk(); err != nil {
+		log.Fatalf("Konfigurasi disk penyimpanan default tidak valid: %v", err)
+	}
+	if _, err := filescan.Default(); err != nil {
+		log.Fatalf("Konfigurasi pemindai file tidak valid: %v", err)
+	}
+
+	if path := helpers.GetEnv("NIK_DISTRICT_FILE", ""); path != "" {
+		if err := nik.LoadDistrictFile(path); err != nil {
+			log.Printf("Gagal memuat daftar kecamatan NIK: %v", err)
+		}
+	}
+
+	if _, err := services.LoadRiskRules(); err != nil {
+		log.Printf("Gagal memuat aturan risiko KYC: %v", err)
+	}
+
 	app := &cli.App{
 		Name:  "Golang Starter Kit",
 		Usage: "CLI tool for managing migrations",
@@ -42,6 +71,18 @@ func Init() {
 			cmd.MakeSeederCommand,
 			cmd.DBSeedCommand,
 			cmd.RollbackSeederCommand,
+			cmd.PermissionSyncCommand,
+			cmd.RolePruneExpiredCommand,
+			cmd.CryptoRotateCommand,
+			cmd.FileEncryptCommand,
+			cmd.FileIndexCommand,
+			cmd.FileGCCommand,
+			cmd.WatchlistImportCommand,
+			cmd.ScreeningRescreenCommand,
+			cmd.KycRiskRescoreCommand,
+			cmd.PrivacyEraseCommand,
+			cmd.PrivacyEnforceRetentionCommand,
+			cmd.UploadCleanupCommand,
 		},
 	}
 
@@ -68,8 +109,13 @@ func Router() *gin.Engine {
 
 	route.Use(cors.New(cors.Config{
 		AllowOrigins: []string{"*"},
-		AllowMethods: []string{"GET", "PUT", "DELETE"},
+		AllowMethods: []string{"GET", "HEAD", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
 		AllowHeaders: []string{"*"},
+		// tus clients in the browser read these to resume uploads
+		ExposeHeaders: []string{
+			"Location", "Upload-Offset", "Upload-Length", "Upload-Expires", "Upload-Metadata",
+			"Tus-Resumable", "Tus-Version", "Tus-Extension", "Tus-Max-Size",
+		},
 	}))
 
 	routes.RegisterRoutes(route)
@@ -0,0 +1,41 @@
+package cmd
+
+import (
+	"fmt"
+
+	"golang_starter_kit_2025/app/services"
+
+	"github.com/urfave/cli/v2"
+)
+
+var CryptoRotateCommand = &cli.Command{
+	Name:  "crypto:rotate",
+	Usage: "Re-encrypt PII columns under the active key version and backfill blind indexes",
+	Flags: []cli.Flag{
+		&cli.IntFlag{
+			Name:  "batch",
+			Usage: "Rows per batch",
+			Value: 500,
+		},
+		&cli.BoolFlag{
+			Name:  "reindex",
+			Usage: "Rewrite every row, also those whose blind indexes are already filled",
+		},
+	},
+	Action: func(c *cli.Context) error {
+		service := services.CryptoService{}
+
+		fmt.Println("🔄 Rotating encrypted columns...")
+		reports, err := service.Rotate(c.Int("batch"), c.Bool("reindex"), func(report services.CryptoRotateReport) {
+			fmt.Printf("   %s: %d scanned, %d re-encrypted\n", report.Table, report.Scanned, report.Rotated)
+		})
+		if err != nil {
+			return fmt.Errorf("gagal merotasi enkripsi: %w", err)
+		}
+
+		for _, report := range reports {
+			fmt.Printf("✅ %s: %d row(s) re-encrypted\n", report.Table, report.Rotated)
+		}
+		return nil
+	},
+}
@@ -0,0 +1,27 @@
+package cmd
+
+import (
+	"fmt"
+
+	"golang_starter_kit_2025/app/services"
+
+	"github.com/urfave/cli/v2"
+)
+
+var KycRiskRescoreCommand = &cli.Command{
+	Name:  "kyc:risk-rescore",
+	Usage: "Reload the KYC risk rules file and score every submission awaiting a decision again",
+	Action: func(c *cli.Context) error {
+		fmt.Println("🔄 Rescoring KYC submissions...")
+		report, err := (&services.KycRiskService{}).Rescore()
+		if err != nil {
+			return fmt.Errorf("gagal menilai ulang risiko KYC: %w", err)
+		}
+
+		fmt.Printf("✅ %d submission(s) scored with rules %s (%s)\n", report.Scored, report.RulesVersion, report.RulesChecksum)
+		for tier, count := range report.Tiers {
+			fmt.Printf("   %s: %d\n", tier, count)
+		}
+		return nil
+	},
+}
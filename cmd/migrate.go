@@ -3,10 +3,12 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
 	"time"
 
 	"golang_starter_kit_2025/app/database"
+	"golang_starter_kit_2025/config"
 	"golang_starter_kit_2025/facades"
 
 	"github.com/urfave/cli/v2"
@@ -45,11 +47,19 @@ var RollbackCommand = &cli.Command{
 var MakeMigrationCommand = &cli.Command{
 	Name:  "make:migration",
 	Usage: "Create new migration template",
+	Flags: []cli.Flag{
+		&cli.StringFlag{Name: "connection", Value: "mysql", Usage: "Database connection the migration is for (mysql, postgres, mysql_secondary)"},
+		&cli.StringFlag{Name: "variant", Usage: "Existing migration to add a variant for the dialect of the connection to"},
+	},
 	Action: func(c *cli.Context) error {
+		connection := c.String("connection")
+		if variant := c.String("variant"); variant != "" {
+			return CreateMigrationVariant(variant, connection)
+		}
 		if c.Args().Len() < 1 {
 			return fmt.Errorf("nama migration dibutuhkan")
 		}
-		return CreateMigration(c.Args().First())
+		return CreateMigration(c.Args().First(), connection)
 	},
 }
 
@@ -161,29 +171,103 @@ var DBStatusCommand = &cli.Command{
 	},
 }
 
-func CreateMigration(name string) error {
+// CreateMigration creates a migration in the migrations directory of a
+// connection, with a template in the SQL dialect of the connection
+func CreateMigration(name, connection string) error {
+	cfg, dir, err := migrationTarget(connection)
+	if err != nil {
+		return err
+	}
 	ts := time.Now().Format("20060102150405")
-	fname := fmt.Sprintf("%s_%s.sql", ts, name)
-	dir, _ := os.Getwd()
-	path := fmt.Sprintf("%s/app/database/migrations/%s", dir, fname)
-	up, down := getMigrationTemplate(name)
+	path := filepath.Join(dir, fmt.Sprintf("%s_%s.sql", ts, name))
+	return writeMigration(path, name, cfg.Type)
+}
+
+// CreateMigrationVariant creates <migration>.<dialect>.sql next to an
+// existing migration, which is run instead of it on connections of that
+// dialect
+func CreateMigrationVariant(migration, connection string) error {
+	cfg, dir, err := migrationTarget(connection)
+	if err != nil {
+		return err
+	}
+	migration = strings.TrimSuffix(migration, ".sql")
+	if _, err := os.Stat(filepath.Join(dir, migration+".sql")); err != nil {
+		return fmt.Errorf("migration %s tidak ada di %s", migration, dir)
+	}
+
+	path := filepath.Join(dir, fmt.Sprintf("%s.%s.sql", migration, cfg.Type))
+	if _, err := os.Stat(path); err == nil {
+		return fmt.Errorf("varian %s sudah ada", path)
+	}
+	// the template follows the name without its timestamp
+	name := migration
+	if i := strings.Index(migration, "_"); i >= 0 {
+		name = migration[i+1:]
+	}
+	return writeMigration(path, name, cfg.Type)
+}
+
+// migrationTarget returns the configuration and migrations directory of a
+// connection
+func migrationTarget(connection string) (*config.DatabaseConfig, string, error) {
+	cfg, ok := config.GetDatabaseConfigs().Connections[connection]
+	if !ok {
+		return nil, "", fmt.Errorf("koneksi %s tidak ditemukan", connection)
+	}
+	dir := cfg.MigrationsPath
+	if dir == "" {
+		dir = filepath.Join("app", "database", "migrations")
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, "", fmt.Errorf("gagal membuat direktori migrasi: %w", err)
+	}
+	return cfg, dir, nil
+}
+
+func writeMigration(path, name string, dialect config.DatabaseType) error {
+	up, down := getMigrationTemplate(name, dialect)
 	content := fmt.Sprintf("%s\n%s\n%s\n%s", upMarker, up, downMarker, down)
-	return os.WriteFile(path, []byte(content), 0644)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		return err
+	}
+	fmt.Printf("Created: %s\n", path)
+	return nil
 }
 
 var upMarker = "-- +++ UP Migration"
 var downMarker = "-- --- DOWN Migration"
 
-func getMigrationTemplate(name string) (string, string) {
+// createTableColumns are the columns a new table starts with in each dialect
+var createTableColumns = map[config.DatabaseType]string{
+	config.MySQL: `	id BIGINT AUTO_INCREMENT PRIMARY KEY,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+	deleted_at TIMESTAMP NULL DEFAULT NULL`,
+	// updated_at is kept by GORM, PostgreSQL has no ON UPDATE
+	config.PostgreSQL: `	id BIGSERIAL PRIMARY KEY,
+	created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+	deleted_at TIMESTAMPTZ NULL DEFAULT NULL`,
+	config.SQLite: `	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	deleted_at DATETIME NULL DEFAULT NULL`,
+	config.SQLServer: `	id BIGINT IDENTITY(1,1) PRIMARY KEY,
+	created_at DATETIME2 DEFAULT SYSDATETIME(),
+	updated_at DATETIME2 DEFAULT SYSDATETIME(),
+	deleted_at DATETIME2 NULL DEFAULT NULL`,
+}
+
+func getMigrationTemplate(name string, dialect config.DatabaseType) (string, string) {
 	if strings.HasPrefix(name, "create_") {
 		tbl := strings.TrimPrefix(name, "create_")
 		tbl = strings.TrimSuffix(tbl, "_table")
-		up := fmt.Sprintf(`CREATE TABLE %s (
-	id BIGINT AUTO_INCREMENT PRIMARY KEY,
-	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-	updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
-	deleted_at TIMESTAMP NULL DEFAULT NULL
-);`, tbl)
+		columns, ok := createTableColumns[dialect]
+		if !ok {
+			columns = createTableColumns[config.MySQL]
+		}
+		up := fmt.Sprintf("CREATE TABLE %s (\n%s\n);", tbl, columns)
 		down := fmt.Sprintf("DROP TABLE IF EXISTS %s;", tbl)
 		return up, down
 	}
@@ -191,9 +275,14 @@ func getMigrationTemplate(name string) (string, string) {
 	if strings.HasPrefix(name, "alter_") {
 		tbl := strings.TrimPrefix(name, "alter_")
 		tbl = strings.TrimSuffix(tbl, "_table")
+		// SQL Server adds columns without the COLUMN keyword
+		add := "ADD COLUMN"
+		if dialect == config.SQLServer {
+			add = "ADD"
+		}
 		up := fmt.Sprintf(`ALTER TABLE %s 
--- ADD COLUMN new_column_name DATA_TYPE;
-`, tbl)
+-- %s new_column_name DATA_TYPE;
+`, tbl, add)
 		down := fmt.Sprintf(`ALTER TABLE %s 
 -- DROP COLUMN new_column_name;
 `, tbl)
@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"fmt"
+
+	"golang_starter_kit_2025/app/services"
+
+	"github.com/urfave/cli/v2"
+)
+
+var PermissionSyncCommand = &cli.Command{
+	Name:  "permissions:sync",
+	Usage: "Sync permissions and default role grants declared in code to the database",
+	Flags: []cli.Flag{
+		&cli.BoolFlag{Name: "prune", Usage: "Delete database permissions that are no longer declared in code"},
+	},
+	Action: func(c *cli.Context) error {
+		service := services.PermissionService{}
+		prune := c.Bool("prune")
+
+		fmt.Println("🔄 Syncing permissions...")
+		report, err := service.Sync(prune)
+		if err != nil {
+			return fmt.Errorf("gagal sync permission: %w", err)
+		}
+
+		for _, name := range report.Created {
+			fmt.Printf("  + created: %s\n", name)
+		}
+		for _, name := range report.Updated {
+			fmt.Printf("  ~ updated: %s\n", name)
+		}
+		for _, grant := range report.Granted {
+			fmt.Printf("  + granted: %s\n", grant)
+		}
+		for _, name := range report.Orphaned {
+			if report.Pruned {
+				fmt.Printf("  - pruned: %s\n", name)
+			} else {
+				fmt.Printf("  ! orphaned: %s (not declared in code, use --prune to delete)\n", name)
+			}
+		}
+
+		fmt.Printf("✅ Permissions synced: %d created, %d updated, %d granted, %d orphaned\n",
+			len(report.Created), len(report.Updated), len(report.Granted), len(report.Orphaned))
+		return nil
+	},
+}
@@ -0,0 +1,58 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"golang_starter_kit_2025/app/services"
+
+	"github.com/urfave/cli/v2"
+)
+
+var PrivacyEraseCommand = &cli.Command{
+	Name:  "privacy:erase",
+	Usage: "Anonymise a user and their KYC data and delete their stored files, keeping only a tombstone",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:     "user",
+			Usage:    "ID of the user to erase",
+			Required: true,
+		},
+		&cli.StringFlag{
+			Name:     "reason",
+			Usage:    "Why the data is erased, e.g. the ticket of the erasure request",
+			Required: true,
+		},
+	},
+	Action: func(c *cli.Context) error {
+		service := services.PrivacyService{}
+
+		record, err := service.Erase(c.String("user"), nil, c.String("reason"))
+		if err != nil {
+			return fmt.Errorf("gagal menghapus data user: %w", err)
+		}
+
+		fmt.Printf("✅ User %s erased: %d KYC submission(s) anonymised, %d file(s) deleted\n", record.UserReference, len(record.Submissions), record.FilesDeleted)
+		return nil
+	},
+}
+
+var PrivacyEnforceRetentionCommand = &cli.Command{
+	Name:  "privacy:enforce-retention",
+	Usage: "Delete or anonymise data kept longer than its retention policy (schedule it, e.g. daily via cron)",
+	Action: func(c *cli.Context) error {
+		service := services.RetentionService{}
+
+		_, err := service.Enforce(time.Now(), func(result services.RetentionResult) {
+			if result.Days == 0 {
+				fmt.Printf("⏭️  %s: kept forever\n", result.Category)
+				return
+			}
+			fmt.Printf("✅ %s (%d days): %d affected, %d file(s) deleted\n", result.Category, result.Days, result.Affected, result.FilesDeleted)
+		})
+		if err != nil {
+			return fmt.Errorf("gagal menerapkan kebijakan retensi: %w", err)
+		}
+		return nil
+	},
+}
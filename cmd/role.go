@@ -0,0 +1,26 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"golang_starter_kit_2025/app/services"
+
+	"github.com/urfave/cli/v2"
+)
+
+var RolePruneExpiredCommand = &cli.Command{
+	Name:  "roles:prune-expired",
+	Usage: "Delete time-bound role grants that have expired (schedule it, e.g. every 5 minutes via cron)",
+	Action: func(c *cli.Context) error {
+		service := services.UserService{}
+
+		count, err := service.PruneExpiredRoleGrants(time.Now())
+		if err != nil {
+			return fmt.Errorf("gagal menghapus role yang kadaluarsa: %w", err)
+		}
+
+		fmt.Printf("✅ %d expired role grant(s) pruned\n", count)
+		return nil
+	},
+}
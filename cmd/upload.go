@@ -0,0 +1,26 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"golang_starter_kit_2025/app/services"
+
+	"github.com/urfave/cli/v2"
+)
+
+var UploadCleanupCommand = &cli.Command{
+	Name:  "uploads:cleanup",
+	Usage: "Delete expired resumable uploads and their stored chunks (schedule it, e.g. hourly via cron)",
+	Action: func(c *cli.Context) error {
+		service := services.UploadService{}
+
+		removed, err := service.DeleteExpired(time.Now())
+		if err != nil {
+			return fmt.Errorf("gagal menghapus upload kadaluarsa: %w", err)
+		}
+
+		fmt.Printf("✅ Expired uploads deleted, %d file(s) removed\n", removed)
+		return nil
+	},
+}
@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"fmt"
+
+	"golang_starter_kit_2025/app/models"
+	"golang_starter_kit_2025/app/services"
+
+	"github.com/urfave/cli/v2"
+)
+
+var WatchlistImportCommand = &cli.Command{
+	Name:  "watchlist:import",
+	Usage: "Import a sanctions or PEP list file (CSV or JSON) as a new list version and re-screen users",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:     "name",
+			Usage:    "List name, e.g. dttot or un-sanctions",
+			Required: true,
+		},
+		&cli.StringFlag{
+			Name:  "category",
+			Usage: "List category (sanctions, pep)",
+			Value: string(models.WatchlistSanctions),
+		},
+		&cli.StringFlag{
+			Name:     "file",
+			Usage:    "Path to the .csv or .json file",
+			Required: true,
+		},
+		&cli.BoolFlag{
+			Name:  "no-rescreen",
+			Usage: "Do not re-screen users after loading a new version",
+		},
+	},
+	Action: func(c *cli.Context) error {
+		category := models.WatchlistCategory(c.String("category"))
+		if category != models.WatchlistSanctions && category != models.WatchlistPEP {
+			return fmt.Errorf("kategori tidak valid: %s", category)
+		}
+
+		service := services.ScreeningService{}
+		version, created, err := service.Import(c.String("name"), category, c.String("file"))
+		if err != nil {
+			return fmt.Errorf("gagal mengimpor watchlist: %w", err)
+		}
+		if !created {
+			fmt.Printf("ℹ️  %s version %d is identical, nothing to import\n", c.String("name"), version.Version)
+			return nil
+		}
+		fmt.Printf("✅ %s version %d imported with %d entries\n", c.String("name"), version.Version, version.EntryCount)
+
+		if c.Bool("no-rescreen") {
+			return nil
+		}
+		return rescreen(service)
+	},
+}
+
+var ScreeningRescreenCommand = &cli.Command{
+	Name:  "screening:rescreen",
+	Usage: "Screen every user's latest KYC data against the active watchlist versions",
+	Action: func(c *cli.Context) error {
+		return rescreen(services.ScreeningService{})
+	},
+}
+
+func rescreen(service services.ScreeningService) error {
+	fmt.Println("🔄 Re-screening users...")
+	report, err := service.Rescreen(func(report services.ScreeningRescreenReport) {
+		if report.Screened%100 == 0 {
+			fmt.Printf("   %d screened, %d potential match(es)\n", report.Screened, report.PotentialMatches)
+		}
+	})
+	if err != nil {
+		return fmt.Errorf("gagal melakukan screening ulang: %w", err)
+	}
+
+	fmt.Printf("✅ %d user(s) screened, %d potential match(es)\n", report.Screened, report.PotentialMatches)
+	return nil
+}
@@ -32,6 +32,9 @@ type DatabaseConfig struct {
 	MaxOpenConns    int           `json:"max_open_conns"`
 	ConnMaxLifetime time.Duration `json:"conn_max_lifetime"`
 	ConnMaxIdleTime time.Duration `json:"conn_max_idle_time"`
+	// MigrationsPath is the directory holding the migrations of the
+	// connection
+	MigrationsPath string `json:"migrations_path"`
 }
 
 // DatabaseConfigs holds multiple database configurations
@@ -61,6 +64,7 @@ func GetDatabaseConfigs() *DatabaseConfigs {
 		MaxOpenConns:    getEnvAsInt("MYSQL_MAX_OPEN_CONNS", 200),
 		ConnMaxLifetime: getEnvAsDuration("MYSQL_CONN_MAX_LIFETIME", 15*time.Minute),
 		ConnMaxIdleTime: getEnvAsDuration("MYSQL_CONN_MAX_IDLE_TIME", 5*time.Minute),
+		MigrationsPath:  getEnv("MYSQL_MIGRATIONS_PATH", "app/database/migrations"),
 	}
 
 	// PostgreSQL Configuration
@@ -77,6 +81,7 @@ func GetDatabaseConfigs() *DatabaseConfigs {
 		MaxOpenConns:    getEnvAsInt("POSTGRES_MAX_OPEN_CONNS", 200),
 		ConnMaxLifetime: getEnvAsDuration("POSTGRES_CONN_MAX_LIFETIME", 15*time.Minute),
 		ConnMaxIdleTime: getEnvAsDuration("POSTGRES_CONN_MAX_IDLE_TIME", 5*time.Minute),
+		MigrationsPath:  getEnv("POSTGRES_MIGRATIONS_PATH", "app/database/migrations/postgres"),
 	}
 
 	// MySQL Secondary Configuration (for multiple MySQL instances)
@@ -93,6 +98,7 @@ func GetDatabaseConfigs() *DatabaseConfigs {
 		MaxOpenConns:    getEnvAsInt("MYSQL_SECONDARY_MAX_OPEN_CONNS", 200),
 		ConnMaxLifetime: getEnvAsDuration("MYSQL_SECONDARY_CONN_MAX_LIFETIME", 15*time.Minute),
 		ConnMaxIdleTime: getEnvAsDuration("MYSQL_SECONDARY_CONN_MAX_IDLE_TIME", 5*time.Minute),
+		MigrationsPath:  getEnv("MYSQL_SECONDARY_MIGRATIONS_PATH", "app/database/migrations"),
 	}
 
 	return configs
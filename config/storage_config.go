@@ -0,0 +1,129 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// StorageDriver is the kind of backend a disk stores its files in
+type StorageDriver string
+
+const (
+	LocalStorage  StorageDriver = "local"
+	MemoryStorage StorageDriver = "memory"
+	S3Storage     StorageDriver = "s3"
+)
+
+// DiskConfig holds the configuration of a named storage disk
+type DiskConfig struct {
+	Driver StorageDriver `json:"driver"`
+
+	// Root is the directory of a local disk
+	Root string `json:"root"`
+
+	// S3-compatible object storage (AWS S3, MinIO, ...)
+	Endpoint  string `json:"endpoint"`
+	Region    string `json:"region"`
+	Bucket    string `json:"bucket"`
+	AccessKey string `json:"access_key"`
+	SecretKey string `json:"secret_key"`
+	PathStyle bool   `json:"path_style"`
+}
+
+// StorageConfigs holds the configuration of every named disk
+type StorageConfigs struct {
+	Default string                 `json:"default"`
+	Disks   map[string]*DiskConfig `json:"disks"`
+}
+
+// GetStorageConfigs loads storage disk configurations from environment
+// variables. STORAGE_DISKS lists the disk names; each disk is configured by
+// STORAGE_<NAME>_DRIVER, STORAGE_<NAME>_ROOT, STORAGE_<NAME>_BUCKET and so
+// on, so for example a kyc disk on S3 can sit next to a public disk on
+// local. A disk named after a driver uses that driver by default, and the
+// s3 disk falls back to the S3_* variables.
+func GetStorageConfigs() *StorageConfigs {
+	configs := &StorageConfigs{
+		Default: getEnv("FILESYSTEM_DISK", "local"),
+		Disks:   make(map[string]*DiskConfig),
+	}
+
+	for _, name := range strings.Split(getEnv("STORAGE_DISKS", "local,memory,s3"), ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		configs.Disks[name] = diskConfig(name)
+	}
+	return configs
+}
+
+// diskConfig reads the configuration of the named disk
+func diskConfig(name string) *DiskConfig {
+	prefix := "STORAGE_" + strings.ToUpper(name) + "_"
+	// the s3 disk predates named disks and keeps reading S3_*
+	legacy := func(key, fallback string) string {
+		if name == string(S3Storage) {
+			return getEnv("S3_"+key, fallback)
+		}
+		return fallback
+	}
+
+	cfg := &DiskConfig{
+		Driver: StorageDriver(getEnv(prefix+"DRIVER", name)),
+		Root:   getEnv(prefix+"ROOT", ""),
+	}
+	switch cfg.Driver {
+	case LocalStorage:
+		if cfg.Root == "" && name == string(LocalStorage) {
+			cfg.Root = "storage/"
+		}
+	case S3Storage:
+		cfg.Endpoint = getEnv(prefix+"ENDPOINT", legacy("ENDPOINT", "https://s3.amazonaws.com"))
+		cfg.Region = getEnv(prefix+"REGION", legacy("REGION", "us-east-1"))
+		cfg.Bucket = getEnv(prefix+"BUCKET", legacy("BUCKET", ""))
+		cfg.AccessKey = getEnv(prefix+"ACCESS_KEY_ID", legacy("ACCESS_KEY_ID", ""))
+		cfg.SecretKey = getEnv(prefix+"SECRET_ACCESS_KEY", legacy("SECRET_ACCESS_KEY", ""))
+		pathStyle := true
+		if name == string(S3Storage) {
+			pathStyle = getEnvAsBool("S3_USE_PATH_STYLE", true)
+		}
+		cfg.PathStyle = getEnvAsBool(prefix+"USE_PATH_STYLE", pathStyle)
+	}
+	return cfg
+}
+
+// Validate checks if the disk configuration is valid
+func (cfg *DiskConfig) Validate() error {
+	switch cfg.Driver {
+	case LocalStorage:
+		if cfg.Root == "" {
+			return fmt.Errorf("root directory is required for the local driver")
+		}
+	case MemoryStorage:
+	case S3Storage:
+		if cfg.Endpoint == "" {
+			return fmt.Errorf("endpoint is required for the s3 driver")
+		}
+		if cfg.Bucket == "" {
+			return fmt.Errorf("bucket is required for the s3 driver")
+		}
+		if cfg.AccessKey == "" || cfg.SecretKey == "" {
+			return fmt.Errorf("access key and secret key are required for the s3 driver")
+		}
+	default:
+		return fmt.Errorf("unknown storage driver %q", cfg.Driver)
+	}
+	return nil
+}
+
+func getEnvAsBool(key string, fallback bool) bool {
+	if value := os.Getenv(key); value != "" {
+		if boolVal, err := strconv.ParseBool(value); err == nil {
+			return boolVal
+		}
+	}
+	return fallback
+}
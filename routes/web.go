@@ -5,6 +5,7 @@ import (
 
 	"golang_starter_kit_2025/app/controllers"
 	"golang_starter_kit_2025/app/middleware"
+	"golang_starter_kit_2025/app/permissions"
 	"golang_starter_kit_2025/app/services"
 	"golang_starter_kit_2025/facades"
 
@@ -45,12 +46,121 @@ func RegisterRoutes(route *gin.Engine) {
 	userController := controllers.NewUserController(userService)
 	userRoutes := route.Group("/users", middleware.AuthMiddleware()) // Protect user routes
 	{
-		userRoutes.GET("", userController.List)
-		userRoutes.GET("/:id", userController.Get)
-		userRoutes.PUT("", userController.Put)
-		userRoutes.DELETE("/:id", userController.Delete)
-		userRoutes.POST("/:id/roles", userController.AssignRoles)
-		userRoutes.GET("/:id/roles", userController.GetRoles)
+		userRoutes.GET("", middleware.PermissionMiddleware(permissions.UserView), userController.List)
+		userRoutes.GET("/:id", middleware.PermissionMiddleware(permissions.UserView), userController.Get)
+		userRoutes.PUT("", middleware.PermissionMiddleware(permissions.UserManage), userController.Put)
+		userRoutes.DELETE("/:id", middleware.PermissionMiddleware(permissions.UserManage), userController.Delete)
+		userRoutes.POST("/:id/roles", middleware.PermissionMiddleware(permissions.UserManage), userController.AssignRoles)
+		userRoutes.PATCH("/:id/roles", middleware.PermissionMiddleware(permissions.UserManage), userController.UpdateRoles)
+		userRoutes.GET("/:id/roles", middleware.PermissionMiddleware(permissions.UserView), userController.GetRoles)
+		userRoutes.POST("/:id/suspend", middleware.PermissionMiddleware(permissions.UserStatus), userController.Suspend)
+		userRoutes.POST("/:id/lock", middleware.PermissionMiddleware(permissions.UserStatus), userController.Lock)
+		userRoutes.POST("/:id/reactivate", middleware.PermissionMiddleware(permissions.UserStatus), userController.Reactivate)
+		userRoutes.POST("/:id/close", middleware.PermissionMiddleware(permissions.UserStatus), userController.Close)
+		userRoutes.GET("/:id/status-history", middleware.PermissionMiddleware(permissions.UserView), userController.StatusHistory)
+	}
+
+	// Routes untuk permintaan akses role sementara (protected by AuthMiddleware)
+	accessRequestService := services.AccessRequestService{}
+	accessRequestController := controllers.NewAccessRequestController(accessRequestService)
+	accessRequestRoutes := route.Group("/access-requests", middleware.AuthMiddleware())
+	{
+		accessRequestRoutes.POST("", accessRequestController.Create)
+		accessRequestRoutes.GET("/mine", accessRequestController.Mine)
+		accessRequestRoutes.GET("", middleware.PermissionMiddleware(permissions.AccessRequestApprove), accessRequestController.List)
+		accessRequestRoutes.POST("/:id/approve", middleware.PermissionMiddleware(permissions.AccessRequestApprove), accessRequestController.Approve)
+		accessRequestRoutes.POST("/:id/deny", middleware.PermissionMiddleware(permissions.AccessRequestApprove), accessRequestController.Deny)
+	}
+
+	// Routes untuk NIK (protected by AuthMiddleware)
+	nikService := services.NIKService{}
+	nikController := controllers.NewNIKController(nikService)
+	nikRoutes := route.Group("/nik", middleware.AuthMiddleware())
+	{
+		nikRoutes.POST("/check", middleware.PermissionMiddleware(permissions.NIKCheck), nikController.Check)
+	}
+
+	// Routes untuk KYC (protected by AuthMiddleware)
+	kycService := services.KycService{}
+	kycController := controllers.NewKycController(kycService)
+	kycFlagService := services.KycFlagService{}
+	kycFlagController := controllers.NewKycFlagController(kycFlagService)
+	kycRiskService := services.KycRiskService{}
+	kycRiskController := controllers.NewKycRiskController(kycRiskService)
+	kycRoutes := route.Group("/kyc", middleware.AuthMiddleware())
+	{
+		kycRoutes.POST("", kycController.Create)
+		kycRoutes.GET("/mine", kycController.Mine)
+		kycRoutes.PUT("/mine", kycController.UpdateMine)
+		kycRoutes.POST("/mine/documents", kycController.UploadDocument)
+		kycRoutes.POST("/mine/submit", kycController.Submit)
+		kycRoutes.GET("", middleware.PermissionMiddleware(permissions.KycView, permissions.KycReview), kycController.List)
+		kycRoutes.GET("/duplicates", middleware.PermissionMiddleware(permissions.KycView, permissions.KycReview), kycFlagController.Clusters)
+		kycRoutes.POST("/flags/:id/resolve", middleware.PermissionMiddleware(permissions.KycReview), kycFlagController.Resolve)
+		kycRoutes.GET("/risk/rules", middleware.PermissionMiddleware(permissions.KycRisk, permissions.KycReview), kycRiskController.Rules)
+		kycRoutes.POST("/risk/rescore", middleware.PermissionMiddleware(permissions.KycRisk), kycRiskController.Rescore)
+		kycRoutes.GET("/:id", middleware.PermissionMiddleware(permissions.KycView, permissions.KycReview), kycController.Get)
+		kycRoutes.POST("/:id/review", middleware.PermissionMiddleware(permissions.KycReview), kycController.StartReview)
+		kycRoutes.POST("/:id/approve", middleware.PermissionMiddleware(permissions.KycReview), kycController.Approve)
+		kycRoutes.POST("/:id/reject", middleware.PermissionMiddleware(permissions.KycReview), kycController.Reject)
+		kycRoutes.POST("/:id/request-resubmission", middleware.PermissionMiddleware(permissions.KycReview), kycController.RequestResubmission)
+	}
+
+	// Routes untuk toko dan verifikasi usaha/KYB (protected by AuthMiddleware)
+	storeService := services.StoreService{}
+	storeController := controllers.NewStoreController(storeService)
+	storeRoutes := route.Group("/stores", middleware.AuthMiddleware())
+	{
+		storeRoutes.POST("", storeController.Create)
+		storeRoutes.GET("/mine", storeController.Mine)
+		storeRoutes.GET("/mine/:id", storeController.GetMine)
+		storeRoutes.PUT("/mine/:id", storeController.UpdateMine)
+		storeRoutes.DELETE("/mine/:id", storeController.DeleteMine)
+		storeRoutes.POST("/mine/:id/documents", storeController.UploadDocument)
+		storeRoutes.POST("/mine/:id/submit", storeController.Submit)
+		storeRoutes.GET("", middleware.PermissionMiddleware(permissions.StoreView, permissions.StoreReview), storeController.List)
+		storeRoutes.GET("/:id", middleware.PermissionMiddleware(permissions.StoreView, permissions.StoreReview), storeController.Get)
+		storeRoutes.POST("/:id/review", middleware.PermissionMiddleware(permissions.StoreReview), storeController.StartReview)
+		storeRoutes.POST("/:id/approve", middleware.PermissionMiddleware(permissions.StoreReview), storeController.Approve)
+		storeRoutes.POST("/:id/reject", middleware.PermissionMiddleware(permissions.StoreReview), storeController.Reject)
+		storeRoutes.POST("/:id/request-resubmission", middleware.PermissionMiddleware(permissions.StoreReview), storeController.RequestResubmission)
+	}
+
+	// Routes untuk upload resumable (protokol tus)
+	uploadService := services.UploadService{}
+	uploadController := controllers.NewUploadController(uploadService)
+	route.OPTIONS("/uploads", uploadController.Options)
+	uploadRoutes := route.Group("/uploads", middleware.AuthMiddleware())
+	{
+		uploadRoutes.POST("", uploadController.Create)
+		uploadRoutes.HEAD("/:id", uploadController.Head)
+		uploadRoutes.PATCH("/:id", uploadController.Patch)
+		uploadRoutes.GET("/:id", uploadController.Get)
+		uploadRoutes.DELETE("/:id", uploadController.Terminate)
+	}
+
+	// Routes untuk screening sanksi/PEP (protected by AuthMiddleware)
+	screeningService := services.ScreeningService{}
+	screeningController := controllers.NewScreeningController(screeningService)
+	screeningRoutes := route.Group("/screening", middleware.AuthMiddleware())
+	{
+		screeningRoutes.GET("/watchlists", middleware.PermissionMiddleware(permissions.ScreeningView, permissions.ScreeningReview), screeningController.Watchlists)
+		screeningRoutes.GET("/hits", middleware.PermissionMiddleware(permissions.ScreeningView, permissions.ScreeningReview), screeningController.Hits)
+		screeningRoutes.GET("/users/:id", middleware.PermissionMiddleware(permissions.ScreeningView, permissions.ScreeningReview), screeningController.UserResults)
+		screeningRoutes.POST("/hits/:id/resolve", middleware.PermissionMiddleware(permissions.ScreeningReview), screeningController.ResolveHit)
+	}
+
+	// Routes untuk ekspor dan penghapusan data pribadi (protected by AuthMiddleware)
+	privacyService := services.PrivacyService{}
+	retentionService := services.RetentionService{}
+	privacyController := controllers.NewPrivacyController(privacyService, retentionService)
+	privacyRoutes := route.Group("/privacy", middleware.AuthMiddleware())
+	{
+		privacyRoutes.GET("/export", privacyController.ExportMine)
+		privacyRoutes.GET("/users/:id/export", middleware.PermissionMiddleware(permissions.PrivacyExport), privacyController.ExportUser)
+		privacyRoutes.POST("/users/:id/erase", middleware.PermissionMiddleware(permissions.PrivacyErase), privacyController.Erase)
+		privacyRoutes.GET("/erasures", middleware.PermissionMiddleware(permissions.PrivacyExport, permissions.PrivacyErase), privacyController.Erasures)
+		privacyRoutes.GET("/retention", middleware.PermissionMiddleware(permissions.PrivacyExport, permissions.PrivacyErase), privacyController.Retention)
 	}
 
 	// Routes untuk roles (protected by AuthMiddleware)
@@ -58,11 +168,30 @@ func RegisterRoutes(route *gin.Engine) {
 	roleController := controllers.NewRoleController(roleService)
 	roleRoutes := route.Group("/roles", middleware.AuthMiddleware()) // Protect role routes
 	{
-		roleRoutes.GET("", roleController.List)                               // List roles
-		roleRoutes.PUT("", roleController.Put)                                // Create/Update role
-		roleRoutes.DELETE("/:id", roleController.Delete)                      // Delete role by ID
-		roleRoutes.POST("/:id/permissions", roleController.AssignPermissions) // Assign permissions to role
-		roleRoutes.GET("/:id/permissions", roleController.GetPermissions)     // Get permissions for role
+		roleRoutes.GET("", middleware.PermissionMiddleware(permissions.RoleView), roleController.List)                                  // List roles
+		roleRoutes.PUT("", middleware.PermissionMiddleware(permissions.RoleManage), roleController.Put)                                 // Create/Update role
+		roleRoutes.DELETE("/:id", middleware.PermissionMiddleware(permissions.RoleManage), roleController.Delete)                       // Delete role by ID
+		roleRoutes.POST("/:id/permissions", middleware.PermissionMiddleware(permissions.RoleManage), roleController.AssignPermissions)  // Assign permissions to role
+		roleRoutes.PATCH("/:id/permissions", middleware.PermissionMiddleware(permissions.RoleManage), roleController.UpdatePermissions) // Add/remove permissions of role
+		roleRoutes.GET("/:id/permissions", middleware.PermissionMiddleware(permissions.RoleView), roleController.GetPermissions)        // Get permissions for role
+		roleRoutes.GET("/matrix", middleware.PermissionMiddleware(permissions.RoleView), roleController.GetMatrix)                      // Role x permission matrix
+		roleRoutes.PUT("/matrix", middleware.PermissionMiddleware(permissions.RoleManage), roleController.PutMatrix)                    // Replace grants of several roles
+	}
+
+	// Routes untuk groups (protected by AuthMiddleware)
+	groupService := services.GroupService{}
+	groupController := controllers.NewGroupController(groupService)
+	groupRoutes := route.Group("/groups", middleware.AuthMiddleware())
+	{
+		groupRoutes.GET("", middleware.PermissionMiddleware(permissions.GroupView), groupController.List)
+		groupRoutes.GET("/:id", middleware.PermissionMiddleware(permissions.GroupView), groupController.Get)
+		groupRoutes.PUT("", middleware.PermissionMiddleware(permissions.GroupManage), groupController.Put)
+		groupRoutes.DELETE("/:id", middleware.PermissionMiddleware(permissions.GroupManage), groupController.Delete)
+		groupRoutes.GET("/:id/members", middleware.PermissionMiddleware(permissions.GroupView), groupController.GetMembers)
+		groupRoutes.PATCH("/:id/members", middleware.PermissionMiddleware(permissions.GroupManage), groupController.UpdateMembers)
+		groupRoutes.GET("/:id/roles", middleware.PermissionMiddleware(permissions.GroupView), groupController.GetRoles)
+		groupRoutes.POST("/:id/roles", middleware.PermissionMiddleware(permissions.GroupManage), groupController.AssignRoles)
+		groupRoutes.PATCH("/:id/roles", middleware.PermissionMiddleware(permissions.GroupManage), groupController.UpdateRoles)
 	}
 
 	// Routes untuk permissions (protected by AuthMiddleware)
@@ -70,15 +199,16 @@ func RegisterRoutes(route *gin.Engine) {
 	permissionController := controllers.NewPermissionController(permissionService)
 	permissionRoutes := route.Group("/permissions", middleware.AuthMiddleware()) // Protect permission routes
 	{
-		permissionRoutes.GET("", permissionController.List)          // List all permissions
-		permissionRoutes.PUT("", permissionController.Put)           // Create/Update permission
-		permissionRoutes.DELETE("/:id", permissionController.Delete) // Delete permission by ID
+		permissionRoutes.GET("", middleware.PermissionMiddleware(permissions.PermissionView), permissionController.List)            // List all permissions
+		permissionRoutes.PUT("", middleware.PermissionMiddleware(permissions.PermissionManage), permissionController.Put)           // Create/Update permission
+		permissionRoutes.DELETE("/:id", middleware.PermissionMiddleware(permissions.PermissionManage), permissionController.Delete) // Delete permission by ID
 	}
 
 	fileController := controllers.NewFileController()
 	fileRoutes := route.Group("/file")
 	{
-		fileRoutes.GET("/:key/:filename", fileController.ServeFile)
+		fileRoutes.GET("/public/:key/:filename", fileController.ServePublicFile)
+		fileRoutes.GET("/:key/:filename", middleware.OptionalAuthMiddleware(), middleware.OptionalSignedURLMiddleware(), fileController.ServeFile)
 	}
 
 	// Database management routes (protected by AuthMiddleware)